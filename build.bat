@@ -8,8 +8,41 @@ echo Generating coverage report...
 go tool cover -html=coverage.out -o coverage.html
 
 
+rem Build information reported by --version
+set VERSION=(devel)
+for /f %%i in ('git describe --tags --always --dirty 2^>nul') do set VERSION=%%i
+set COMMIT=unknown
+for /f %%i in ('git rev-parse HEAD 2^>nul') do set COMMIT=%%i
+for /f %%i in ('powershell -NoProfile -Command "(Get-Date).ToUniversalTime().ToString('yyyy-MM-ddTHH:mm:ssZ')"') do set BUILD_DATE=%%i
+set VERSION_PKG=github.com/bwinhwang/githookkit/cmd/internal/version
+set LDFLAGS=-X %VERSION_PKG%.Version=%VERSION% -X %VERSION_PKG%.Commit=%COMMIT% -X %VERSION_PKG%.BuildDate=%BUILD_DATE%
+
+echo Building commit-received application...
+go build -ldflags "%LDFLAGS%" -o bin/commit-received.exe ./cmd/commit-received
+
 echo Building ref-update application...
-go build -o bin/ref-update.exe ./cmd/ref-update
+go build -ldflags "%LDFLAGS%" -o bin/ref-update.exe ./cmd/ref-update
+
+echo Building pre-receive application...
+go build -ldflags "%LDFLAGS%" -o bin/pre-receive.exe ./cmd/pre-receive
+
+echo Building update application...
+go build -ldflags "%LDFLAGS%" -o bin/update.exe ./cmd/update
+
+echo Building post-receive application...
+go build -ldflags "%LDFLAGS%" -o bin/post-receive.exe ./cmd/post-receive
+
+echo Building change-merged application...
+go build -ldflags "%LDFLAGS%" -o bin/change-merged.exe ./cmd/change-merged
+
+echo Building patchset-created application...
+go build -ldflags "%LDFLAGS%" -o bin/patchset-created.exe ./cmd/patchset-created
+
+echo Building scan application...
+go build -ldflags "%LDFLAGS%" -o bin/scan.exe ./cmd/scan
+
+echo Building githookkit application...
+go build -ldflags "%LDFLAGS%" -o bin/githookkit.exe ./cmd/githookkit
 
 echo Build completed successfully!
 echo Coverage report available at: coverage.html
@@ -12,8 +12,39 @@ go tool cover -html=coverage.out -o coverage.html
 
 mkdir -p bin
 
+# Build information reported by --version
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo "(devel)")
+COMMIT=$(git rev-parse HEAD 2>/dev/null || echo "unknown")
+BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+VERSION_PKG=github.com/bwinhwang/githookkit/cmd/internal/version
+LDFLAGS="-X ${VERSION_PKG}.Version=${VERSION} -X ${VERSION_PKG}.Commit=${COMMIT} -X ${VERSION_PKG}.BuildDate=${BUILD_DATE}"
+
+echo "Building commit-received application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/commit-received ./cmd/commit-received
+
 echo "Building ref-update application..."
-CGO_ENABLED=0 go build -o bin/ref-update ./cmd/ref-update
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/ref-update ./cmd/ref-update
+
+echo "Building pre-receive application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/pre-receive ./cmd/pre-receive
+
+echo "Building update application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/update ./cmd/update
+
+echo "Building post-receive application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/post-receive ./cmd/post-receive
+
+echo "Building change-merged application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/change-merged ./cmd/change-merged
+
+echo "Building patchset-created application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/patchset-created ./cmd/patchset-created
+
+echo "Building scan application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/scan ./cmd/scan
+
+echo "Building githookkit application..."
+CGO_ENABLED=0 go build -ldflags "$LDFLAGS" -o bin/githookkit ./cmd/githookkit
 
 echo "Build completed successfully!"
 echo "Coverage report available at: coverage.html"
@@ -21,4 +52,11 @@ echo "Executables available at: bin/"
 
 # Make the binary executable
 chmod +x bin/commit-received
-chmod +x bin/ref-update  
\ No newline at end of file
+chmod +x bin/ref-update
+chmod +x bin/pre-receive
+chmod +x bin/update
+chmod +x bin/post-receive
+chmod +x bin/change-merged
+chmod +x bin/patchset-created
+chmod +x bin/scan
+chmod +x bin/githookkit  
\ No newline at end of file
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// change-merged is the Gerrit hook called after a change has been submitted, it records
+// the repository growth caused by the merged commit in the audit log. The change is already
+// merged so errors are only logged.
+func main() {
+	// Define command line parameters
+	change := flag.String("change", "", "Change ID")
+	changeURL := flag.String("change-url", "", "Change URL")
+	flag.String("change-owner", "", "Change owner")
+	flag.String("change-owner-username", "", "Change owner username")
+	project := flag.String("project", "", "Project name")
+	branch := flag.String("branch", "", "Target branch")
+	flag.String("topic", "", "Topic")
+	flag.String("submitter", "", "Submitter information")
+	submitterUsername := flag.String("submitter-username", "", "Submitter username")
+	commit := flag.String("commit", "", "Merged commit hash")
+	newRev := flag.String("newrev", "", "New branch tip, the merge commit when one was created")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters, parameters added by newer Gerrit versions are ignored
+	unknownArgs, _ := config.ParseArgs(flag.CommandLine, os.Args[1:])
+
+	if *showVersion {
+		fmt.Println(version.String("change-merged"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
+	}
+	defer logger.Close()
+
+	if err := check.SetOutput("change-merged", *format); err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+	// The push is already accepted, the document is written however the hook ends
+	defer check.WriteOutput(githookkit.ExitPass, "")
+
+	if cfgErr != nil {
+		logger.Errorf("Load config failed: %v", cfgErr)
+		return
+	}
+
+	if len(unknownArgs) > 0 {
+		logger.Warnf("Ignoring unknown parameters: %q", unknownArgs)
+	}
+
+	logger.Debugf("project=%s, branch=%s, change=%s", *project, *branch, *change)
+	logger.Debugf("commit=%s, newRev=%s", *commit, *newRev)
+
+	// The branch tip includes the merge commit, if any, so it describes what actually landed
+	rev := *newRev
+	if rev == "" {
+		rev = *commit
+	}
+	if rev == "" {
+		logger.Errorf("no --newrev or --commit given")
+		return
+	}
+
+	newFiles, err := check.NewFiles(rev+"^", rev, nil)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+
+	check.AddOutput(check.Result{
+		Update:   check.Update{Project: *project, RefName: *branch, OldRev: rev + "^", NewRev: rev, UploaderUsername: *submitterUsername},
+		NewFiles: newFiles,
+	})
+
+	record := audit.Record{
+		Event:    audit.EventMerge,
+		Hook:     "change-merged",
+		Project:  *project,
+		RefName:  *branch,
+		OldRev:   rev + "^",
+		NewRev:   rev,
+		User:     *submitterUsername,
+		Change:   *changeURL,
+		NewFiles: len(newFiles),
+	}
+	if record.Change == "" {
+		record.Change = *change
+	}
+
+	// Files above the size limit can only land through warn mode or an exemption, list them for admins
+	sizeLimit := config.GetRefSizeLimit(cfg, *project, *branch)
+	var largest githookkit.FileInfo
+	for _, file := range newFiles {
+		record.TotalSize += file.Size
+		if file.Size > largest.Size {
+			largest = file
+		}
+		if file.Size > sizeLimit {
+			record.LargeFiles = append(record.LargeFiles, file.Path)
+		}
+	}
+	record.Largest = largest.Path
+
+	logger.Infof("%s: %d new files, %s in total, %d above the size limit", *branch, record.NewFiles, githookkit.FormatSize(record.TotalSize), len(record.LargeFiles))
+
+	if path := config.GetAuditLogPath(cfg); path != "" {
+		if err := audit.Write(path, record); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+
+	check.RunChainedHooks(logger, cfg, "change-merged", os.Args[1:], os.Stdin)
+}
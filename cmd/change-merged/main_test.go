@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestMain(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "change-merged")
+
+	dir := testutil.InitRepo(t)
+	testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096, "small.txt": 100})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf("audit_log: %s\n", auditPath)
+
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, []string{"GITHOOK_FILE_SIZE_MAX=1024"}, "",
+		"--change", "I0123456789abcdef", "--change-url", "https://review.example.com/123", "--change-owner", "Alice <alice@example.com>",
+		"--change-owner-username", "alice", "--project", "app", "--branch", "refs/heads/master", "--topic", "",
+		"--submitter", "Bob <bob@example.com>", "--submitter-username", "bob", "--commit", newRev, "--newrev", newRev)
+	if err != nil {
+		t.Fatalf("change-merged failed: %v\n%s", err, output)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v\n%s", err, output)
+	}
+	var record audit.Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("Failed to decode audit log %q: %v", data, err)
+	}
+	if record.Event != audit.EventMerge || record.Project != "app" || record.User != "bob" || record.Change != "https://review.example.com/123" {
+		t.Errorf("audit record is incorrect: %+v", record)
+	}
+	if record.NewFiles != 2 || record.TotalSize != 4196 || record.Largest != "large.bin" {
+		t.Errorf("audit record statistics are incorrect: %+v", record)
+	}
+	if len(record.LargeFiles) != 1 || record.LargeFiles[0] != "large.bin" {
+		t.Errorf("LargeFiles = %v, expected [large.bin]", record.LargeFiles)
+	}
+}
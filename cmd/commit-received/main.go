@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// commit-received is the Gerrit hook called for commits pushed for review (refs/for/*),
+// it runs the same checks as ref-update so that large files are rejected before the change is created
+func main() {
+	// Define command line parameters
+	project := flag.String("project", "", "Project name")
+	refName := flag.String("refname", "", "Target branch of the change")
+	uploader := flag.String("uploader", "", "Uploader information")
+	uploaderUsername := flag.String("uploader-username", "", "Uploader username")
+	oldRev := flag.String("oldrev", "", "Old commit hash")
+	newRev := flag.String("newrev", "", "Received commit hash")
+	cmdRef := flag.String("cmdref", "", "Ref the commit was pushed to, e.g. refs/for/master")
+	var pushOptions config.StringList
+	flag.Var(&pushOptions, "push-option", "Push option given by the uploader, can be repeated")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters, parameters added by newer Gerrit versions are ignored
+	unknownArgs, _ := config.ParseArgs(flag.CommandLine, os.Args[1:])
+
+	if *showVersion {
+		fmt.Println(version.String("commit-received"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
+	}
+
+	if err := check.SetOutput("commit-received", *format); err != nil {
+		check.ConfigError(logger, cfg, "%v", err)
+	}
+
+	if cfgErr != nil {
+		check.ConfigError(logger, cfg, "Load config failed: %v", cfgErr)
+	}
+
+	if len(unknownArgs) > 0 {
+		logger.Warnf("Ignoring unknown parameters: %q", unknownArgs)
+	}
+
+	logger.Debugf("project=%s, ref=%s, cmdref=%s", *project, *refName, *cmdRef)
+	logger.Debugf("uploader=%s, username=%s", *uploader, *uploaderUsername)
+	logger.Debugf("oldRev=%s, newRev=%s", *oldRev, *newRev)
+
+	if config.IsProjectWhitelisted(cfg, *project) {
+		logger.Infof("%s", locale.T("Project %s is in the whitelist, exiting", *project))
+		check.RunChainedHooks(logger, cfg, "commit-received", os.Args[1:], os.Stdin)
+		check.Exit(githookkit.ExitPass, "project is in the whitelist")
+	}
+
+	updates := []check.Update{{
+		Project:          *project,
+		Uploader:         *uploader,
+		UploaderUsername: *uploaderUsername,
+		OldRev:           *oldRev,
+		NewRev:           *newRev,
+		RefName:          *refName,
+		PushOptions:      pushOptions,
+	}}
+
+	if check.EmergencyBypass(logger, cfg, "commit-received", updates) {
+		check.RunChainedHooks(logger, cfg, "commit-received", os.Args[1:], os.Stdin)
+		check.Exit(githookkit.ExitPass, "emergency bypass")
+	}
+
+	if check.AdminBypass(logger, cfg, "commit-received", updates) {
+		check.RunChainedHooks(logger, cfg, "commit-received", os.Args[1:], os.Stdin)
+		check.Exit(githookkit.ExitPass, "admin bypass")
+	}
+
+	if check.RateLimited(logger, cfg, updates) {
+		check.Exit(githookkit.ExitRejected, "rate limit exceeded")
+	}
+
+	results, err := daemon.CheckUpdates(cfg, logger, updates)
+	if err != nil {
+		check.Fail(logger, cfg, err)
+	}
+
+	if check.Report(logger, cfg, results) {
+		check.Exit(githookkit.ExitRejected, "")
+	}
+	check.AuditOverrides(logger, cfg, "commit-received", results)
+	check.AuditPushes(logger, cfg, "commit-received", results)
+	check.RecordRefs(logger, cfg, results)
+
+	check.RunChainedHooks(logger, cfg, "commit-received", os.Args[1:], os.Stdin)
+	check.WriteOutput(githookkit.ExitPass, "")
+}
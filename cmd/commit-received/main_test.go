@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestMain(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "commit-received")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+
+	configYAML := "projects_whitelist:\n  - trusted\nprojects:\n  app:\n    size_limit: 4096\n"
+
+	tests := []struct {
+		name     string
+		project  string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:     "project size limit is enforced",
+			project:  "app",
+			wantErr:  true,
+			expected: "REJECTED: one or more files exceed maximum size of 4.00 KB",
+		},
+		{
+			name:    "whitelisted project",
+			project: "trusted",
+			wantErr: false,
+		},
+		{
+			name:    "default size limit",
+			project: "other",
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := testutil.RunBinary(t, execPath, dir, configYAML, nil, "",
+				"--project", tt.project, "--refname", "refs/heads/master", "--uploader", "Alice <alice@example.com>",
+				"--uploader-username", "alice", "--oldrev", oldRev, "--newrev", newRev, "--cmdref", "refs/for/master")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+// On Gerrit the growth of a project is recorded by the hooks accepting the uploads, post-receive never runs
+func TestMainGrowthBudget(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "commit-received")
+	mergedPath := testutil.BuildBinary(t, filepath.Join(wd, "..", "change-merged"), "change-merged")
+
+	dir := testutil.InitRepo(t)
+	baseRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf("audit_log: %s\ngrowth_budget:\n  limit: 5000\n", auditPath)
+	env := []string{"GITHOOK_FILE_SIZE_MAX="}
+
+	upload := func(oldRev, newRev string) (string, error) {
+		return testutil.RunBinary(t, execPath, dir, configYAML, env, "",
+			"--project", "app", "--refname", "refs/heads/master", "--uploader", "Alice <alice@example.com>",
+			"--uploader-username", "alice", "--oldrev", oldRev, "--newrev", newRev, "--cmdref", "refs/for/master")
+	}
+
+	firstRev := testutil.CommitFiles(t, dir, map[string]int{"first.bin": 3000})
+	if output, err := upload(baseRev, firstRev); err != nil {
+		t.Fatalf("first upload should be accepted: %v\n%s", err, output)
+	}
+
+	// The merge of the change adds nothing the upload didn't already count
+	output, err := testutil.RunBinary(t, mergedPath, dir, configYAML, env, "",
+		"--change", "I0123456789abcdef", "--project", "app", "--branch", "refs/heads/master",
+		"--submitter-username", "bob", "--commit", firstRev, "--newrev", firstRev)
+	if err != nil {
+		t.Fatalf("change-merged failed: %v\n%s", err, output)
+	}
+
+	secondRev := testutil.CommitFiles(t, dir, map[string]int{"second.bin": 1500})
+	if output, err := upload(firstRev, secondRev); err != nil {
+		t.Fatalf("second upload within the budget should be accepted: %v\n%s", err, output)
+	}
+
+	thirdRev := testutil.CommitFiles(t, dir, map[string]int{"third.bin": 1000})
+	output, err = upload(secondRev, thirdRev)
+	if err == nil {
+		t.Fatalf("upload above the growth budget should be rejected\n%s", output)
+	}
+	if !strings.Contains(output, "exceeding its growth budget") {
+		t.Errorf("output does not contain the growth budget violation:\n%s", output)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// minGitVersion is the oldest git supported by the hooks, rev-parse --absolute-git-dir needs 2.13
+var minGitVersion = []int{2, 13}
+
+// gerritHooks are the hooks githookkit provides for the Gerrit hooks plugin
+var gerritHooks = []string{"ref-update", "commit-received", "change-merged", "patchset-created"}
+
+// doctor checks a deployment and prints a pass/fail line per check
+// It exits with 0 when every check passed and 1 otherwise
+func doctor(args []string) int {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	site := flags.String("site", "", "Gerrit site directory, its hooks and git directories are checked")
+	repo := flags.String("repo", "", "Repository to check access to, defaults to the git directory of -site")
+	configPath := flags.String("config", "", "Config file to check, defaults to the deployed config")
+	flags.Parse(args)
+
+	failed := 0
+	report := func(name, detail string, err error) {
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %-12s %v\n", name, err)
+			return
+		}
+		fmt.Printf("PASS  %-12s %s\n", name, detail)
+	}
+	skip := func(name, reason string) {
+		fmt.Printf("SKIP  %-12s %s\n", name, reason)
+	}
+
+	detail, err := checkGit()
+	report("git", detail, err)
+
+	path := *configPath
+	if path == "" {
+		path = config.ConfigPath()
+	}
+	cfg, detail, err := checkConfig(path, *configPath != "")
+	report("config", detail, err)
+
+	output := os.Getenv("GITHOOK_LOG_OUTPUT")
+	if output == "" {
+		output = cfg.LogConfig.Output
+	}
+	if output != "" {
+		report("log", output+" is writable", checkWritable(output, false))
+	} else {
+		skip("log", "logging to stderr")
+	}
+
+	if auditLog := config.GetAuditLogPath(cfg); auditLog != "" {
+		report("audit log", auditLog+" is writable", checkWritable(auditLog, true))
+	} else {
+		skip("audit log", "audit_log is not configured")
+	}
+
+	if *site != "" {
+		detail, err = checkHooks(filepath.Join(*site, "hooks"))
+		report("hooks", detail, err)
+	} else {
+		skip("hooks", "no -site given")
+	}
+
+	switch {
+	case *repo != "":
+		detail, err = checkRepository(*repo)
+		report("repository", detail, err)
+	case *site != "":
+		detail, err = checkRepositories(filepath.Join(*site, "git"))
+		report("repository", detail, err)
+	default:
+		skip("repository", "no -site or -repo given")
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d checks failed\n", failed)
+		return 1
+	}
+	fmt.Printf("\nAll checks passed\n")
+	return 0
+}
+
+// checkGit checks that git is on the PATH and recent enough
+func checkGit() (string, error) {
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("git not found: %w", err)
+	}
+
+	output, err := exec.Command(path, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git version: %w", err)
+	}
+
+	version := parseGitVersion(string(output))
+	if version == nil {
+		return "", fmt.Errorf("unrecognized git version %q", strings.TrimSpace(string(output)))
+	}
+	if version[0] < minGitVersion[0] || version[0] == minGitVersion[0] && version[1] < minGitVersion[1] {
+		return "", fmt.Errorf("%s is %s, at least %s is required", path, formatVersion(version), formatVersion(minGitVersion))
+	}
+	return fmt.Sprintf("%s is %s", path, formatVersion(version)), nil
+}
+
+// parseGitVersion extracts major and minor from "git version 2.39.5" and its vendor variants, nil when there is no version
+func parseGitVersion(output string) []int {
+	matches := regexp.MustCompile(`(\d+)\.(\d+)`).FindStringSubmatch(output)
+	if matches == nil {
+		return nil
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	return []int{major, minor}
+}
+
+func formatVersion(version []int) string {
+	return fmt.Sprintf("%d.%d", version[0], version[1])
+}
+
+// checkConfig parses the config at path, a missing deployed config is fine because the hooks fall back to the defaults
+func checkConfig(path string, required bool) (config.Config, string, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) && !required {
+		return config.Config{}, path + " not found, the built-in defaults apply", nil
+	}
+
+	cfg, err := config.ParseConfigFile(path)
+	if err != nil {
+		return cfg, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, fmt.Sprintf("%s is valid, schema version %d", path, cfg.Version), nil
+}
+
+// checkWritable checks that the hooks can append to the file at path, without creating it
+// A missing file is fine when its directory is writable, or its nearest existing parent when the writer creates directories
+func checkWritable(path string, createsDirs bool) error {
+	if _, err := os.Stat(path); err == nil {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	}
+
+	dir := filepath.Dir(path)
+	for createsDirs {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	file, err := os.CreateTemp(dir, ".githookkit-doctor-*")
+	if err != nil {
+		return fmt.Errorf("can't create %s: %w", path, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// checkHooks checks that the githookkit hooks in the hooks directory of a Gerrit site are executable and run
+func checkHooks(dir string) (string, error) {
+	var installed []string
+	for _, name := range gerritHooks {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("%s is not a regular file", path)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+			return "", fmt.Errorf("%s is not executable (mode %s)", path, info.Mode().Perm())
+		}
+		output, err := exec.Command(path, "-version").Output()
+		if err != nil {
+			return "", fmt.Errorf("%s -version failed: %w", path, err)
+		}
+		installed = append(installed, strings.TrimSpace(string(output)))
+	}
+
+	if len(installed) == 0 {
+		return "", fmt.Errorf("none of %s is installed in %s", strings.Join(gerritHooks, ", "), dir)
+	}
+	return strings.Join(installed, "; "), nil
+}
+
+// checkRepository checks that git can read the repository in dir
+func checkRepository(dir string) (string, error) {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a readable git repository: %w", dir, err)
+	}
+	gitDir := strings.TrimSpace(string(output))
+
+	if err := exec.Command("git", "-C", dir, "for-each-ref", "--count=1").Run(); err != nil {
+		return "", fmt.Errorf("failed to read refs of %s: %w", gitDir, err)
+	}
+	return gitDir + " is readable", nil
+}
+
+// checkRepositories checks that the git base path of a Gerrit site can be listed
+func checkRepositories(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), ".git") {
+			count++
+		}
+	}
+	return fmt.Sprintf("%s is readable, %d repositories at the top level", dir, count), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestDoctor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake hooks are shell scripts")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "githookkit")
+
+	// Gerrit site with a working ref-update hook and one repository
+	site := t.TempDir()
+	hooks := filepath.Join(site, "hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatalf("Failed to create hooks directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks, "ref-update"), []byte("#!/bin/sh\necho ref-update test\n"), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(site, "git", "app.git"), 0755); err != nil {
+		t.Fatalf("Failed to create git directory: %v", err)
+	}
+	repo := testutil.InitRepo(t)
+
+	logPath := filepath.Join(t.TempDir(), "githook.log")
+	validConfig := "log_config:\n  output: " + logPath + "\naudit_log: " + filepath.Join(t.TempDir(), "audit", "audit.jsonl") + "\n"
+
+	tests := []struct {
+		name     string
+		config   string
+		args     []string
+		wantErr  bool
+		expected []string
+	}{
+		{"Site", validConfig, []string{"-site", site}, false, []string{"PASS  git", "PASS  config", "PASS  log", "PASS  audit log", "PASS  hooks        ref-update test", "1 repositories", "All checks passed"}},
+		{"Repository", "", []string{"-repo", repo}, false, []string{"not found, the built-in defaults apply", "SKIP  hooks", "PASS  repository"}},
+		{"Invalid config", "mode: strict\n", nil, true, []string{"FAIL  config", `unknown mode "strict"`, "1 checks failed"}},
+		{"Log directory missing", "log_config:\n  output: " + filepath.Join(t.TempDir(), "logs", "githook.log") + "\n", nil, true, []string{"FAIL  log"}},
+		{"No hooks", "", []string{"-site", t.TempDir()}, true, []string{"FAIL  hooks", "none of ref-update, commit-received, change-merged, patchset-created is installed"}},
+		{"Not a repository", "", []string{"-repo", t.TempDir()}, true, []string{"FAIL  repository", "is not a readable git repository"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := testutil.RunBinary(t, execPath, repo, test.config, nil, "", append([]string{"doctor"}, test.args...)...)
+			if (err != nil) != test.wantErr {
+				t.Errorf("doctor error = %v, wantErr %v\n%s", err, test.wantErr, output)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("output does not contain %q:\n%s", expected, output)
+				}
+			}
+		})
+	}
+
+	if err := os.Chmod(filepath.Join(hooks, "ref-update"), 0644); err != nil {
+		t.Fatalf("Failed to change hook mode: %v", err)
+	}
+	output, err := testutil.RunBinary(t, execPath, repo, "", nil, "", "doctor", "-site", site)
+	if err == nil || !strings.Contains(output, "is not executable") {
+		t.Errorf("a hook without execute permission should fail, got %v:\n%s", err, output)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// command is a githookkit subcommand, it returns the exit code of the process
+type command struct {
+	run         func(args []string) int
+	description string
+}
+
+var commands = map[string]command{
+	"doctor":   {doctor, "Check the deployment: git, config, log paths, Gerrit hooks and repository access"},
+	"serve":    {serve, "Run the check daemon the hooks forward to"},
+	"simulate": {simulate, "Evaluate the rules against a commit range and print the decision"},
+	"version":  {printVersion, "Print version information"},
+}
+
+// githookkit is the administration tool, the hooks themselves are separate binaries
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "-version" || name == "--version" {
+		name = "version"
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range []string{"doctor", "serve", "simulate", "version"} {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+}
+
+func printVersion(args []string) int {
+	fmt.Println(version.String("githookkit"))
+	return 0
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/rpc"
+	"google.golang.org/grpc"
+)
+
+// serve runs the check daemon the hooks forward to when "daemon" is configured,
+// and optionally the gRPC policy service for other infrastructure
+// The config is kept in memory and reloaded when the file changes or on SIGHUP
+func serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := flags.String("listen", "", `Address to listen on, "unix:<path>" or host:port, defaults to the configured daemon address`)
+	grpcAddress := flags.String("grpc", "", `Address of the gRPC policy service, "unix:<path>" or host:port, disabled when empty`)
+	basePath := flags.String("base-path", "", "Directory of the repositories checked by the gRPC policy service, defaults to gerrit.base_path")
+	flags.Parse(args)
+
+	cache := config.NewCache(config.ConfigPath())
+	stop := make(chan struct{})
+	defer close(stop)
+	cache.ReloadOnSignal(stop)
+
+	cfg := *cache.Get()
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v", err)
+		return githookkit.ExitConfigError
+	}
+	defer logger.Close()
+
+	address := *listen
+	if address == "" {
+		address = config.GetDaemonAddress(cfg)
+	}
+	if address == "" && *grpcAddress == "" {
+		logger.Errorf("no -listen or -grpc address given and no daemon address configured")
+		return githookkit.ExitConfigError
+	}
+
+	// The gRPC callers name a repository, only the repositories under the base path can be checked
+	if *basePath == "" {
+		*basePath = cfg.Gerrit.BasePath
+	}
+	if *grpcAddress != "" && *basePath == "" {
+		logger.Errorf("no -base-path given and no gerrit.base_path configured for the gRPC policy service")
+		return githookkit.ExitConfigError
+	}
+	repositories, err := filepath.Abs(*basePath)
+	if err != nil {
+		logger.Errorf("Invalid base path %s: %v", *basePath, err)
+		return githookkit.ExitConfigError
+	}
+
+	checker := daemon.NewServer(cache, logger)
+	defer checker.Close()
+	httpServer := &http.Server{Handler: checker}
+	grpcServer := grpc.NewServer()
+	rpc.RegisterPolicyServiceServer(grpcServer, rpc.NewServer(checker, repositories))
+
+	errs := make(chan error, 2)
+	if address != "" {
+		listener, err := daemon.Listen(address)
+		if err != nil {
+			logger.Errorf("Listen on %s failed: %v", address, err)
+			return githookkit.ExitInternalError
+		}
+		logger.Infof("Serving checks on %s", address)
+		go func() {
+			if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+	if *grpcAddress != "" {
+		listener, err := daemon.Listen(*grpcAddress)
+		if err != nil {
+			logger.Errorf("Listen on %s failed: %v", *grpcAddress, err)
+			return githookkit.ExitInternalError
+		}
+		logger.Infof("Serving the gRPC policy service on %s", *grpcAddress)
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	// Shut down cleanly so that the unix sockets are removed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-signals:
+		httpServer.Shutdown(context.Background())
+		grpcServer.GracefulStop()
+		return githookkit.ExitPass
+	case err := <-errs:
+		logger.Errorf("Serve failed: %v", err)
+		return githookkit.ExitInternalError
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// simulate evaluates the full rule set against a range of an existing repository without being invoked by the server
+// It exits with the codes of the hooks: 0 when the push would be accepted, 1 when it would be rejected,
+// 2 for an invalid config or invocation and 3 when the check failed
+func simulate(args []string) int {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	project := flags.String("project", "", "Project name, defaults to the repository directory name")
+	uploaderUsername := flags.String("uploader-username", "", "Uploader username")
+	oldRev := flags.String("oldrev", check.ZeroRev, "Old commit hash")
+	newRev := flags.String("newrev", "", "New commit hash")
+	refName := flags.String("refname", "refs/heads/master", "Reference name")
+	configPath := flags.String("config", "", "Config file to evaluate, defaults to the deployed config")
+	format := flags.String("format", check.OutputText, "Output format: text, or junit for a JUnit XML report on stdout with the decision on stderr")
+	flags.Parse(args)
+
+	// The decision is kept out of a JUnit report
+	decision := io.Writer(os.Stdout)
+	switch *format {
+	case check.OutputText:
+	case check.OutputJUnit:
+		decision = os.Stderr
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output format %q, expected %s or %s\n", *format, check.OutputText, check.OutputJUnit)
+		return githookkit.ExitConfigError
+	}
+
+	var cfg config.Config
+	var cfgErr error
+	if *configPath != "" {
+		cfg, cfgErr = config.ParseConfigFile(*configPath)
+	} else {
+		cfg, cfgErr = config.LoadConfig()
+	}
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v", err)
+		return githookkit.ExitConfigError
+	}
+	defer logger.Close()
+
+	if cfgErr != nil {
+		logger.Errorf("Load config failed: %v", cfgErr)
+		return githookkit.ExitConfigError
+	}
+
+	if *newRev == "" {
+		logger.Errorf("-newrev is required")
+		return githookkit.ExitConfigError
+	}
+
+	if *project == "" {
+		*project, err = check.RepositoryProject()
+		if err != nil {
+			logger.Errorf("%v", err)
+			return githookkit.ExitInternalError
+		}
+	}
+
+	if config.IsProjectWhitelisted(cfg, *project) {
+		fmt.Fprintf(decision, "Decision: ACCEPTED, project %s is in the whitelist\n", *project)
+		if *format == check.OutputJUnit {
+			if err := check.WriteJUnit(os.Stdout, "simulate", nil); err != nil {
+				logger.Errorf("%v", err)
+				return githookkit.ExitInternalError
+			}
+		}
+		return githookkit.ExitPass
+	}
+
+	result, err := check.Check(cfg, logger, check.Update{
+		Project:          *project,
+		UploaderUsername: *uploaderUsername,
+		OldRev:           *oldRev,
+		NewRev:           *newRev,
+		RefName:          *refName,
+	})
+	if err != nil {
+		logger.Errorf("Check failed: %v", err)
+		return githookkit.ExitInternalError
+	}
+
+	// simulate is the full report the hooks point to when they summarize their output
+	cfg.MaxOutputLines, cfg.MaxOutputBytes = -1, -1
+	check.Report(logger, cfg, []check.Result{result})
+	if *format == check.OutputJUnit {
+		if err := check.WriteJUnit(os.Stdout, "simulate", []check.Result{result}); err != nil {
+			logger.Errorf("%v", err)
+			return githookkit.ExitInternalError
+		}
+	}
+
+	if err := result.Err(); err != nil {
+		fmt.Fprintf(decision, "Decision: REJECTED\n")
+		return githookkit.ExitCode(err)
+	}
+	fmt.Fprintf(decision, "Decision: ACCEPTED\n")
+	return githookkit.ExitPass
+}
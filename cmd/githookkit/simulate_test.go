@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestSimulate(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "githookkit")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096})
+
+	newConfig := filepath.Join(t.TempDir(), "new_config.yaml")
+	if err := os.WriteFile(newConfig, []byte("max_push_size: 1024\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		args     []string
+		exitCode int
+		expected string
+	}{
+		{"Accepted", "", []string{"-project", "app", "-oldrev", oldRev, "-newrev", newRev}, 0, "Decision: ACCEPTED"},
+		{"Rejected by deployed config", "max_push_size: 1024\n", []string{"-project", "app", "-oldrev", oldRev, "-newrev", newRev}, 1, "Decision: REJECTED"},
+		{"Rejected by new config", "", []string{"-project", "app", "-oldrev", oldRev, "-newrev", newRev, "-config", newConfig}, 1, "Decision: REJECTED"},
+		{"Whitelisted", "projects_whitelist: [app]\nmax_push_size: 1024\n", []string{"-project", "app", "-oldrev", oldRev, "-newrev", newRev}, 0, "project app is in the whitelist"},
+		{"JUnit report", "max_push_size: 1024\n", []string{"-project", "app", "-oldrev", oldRev, "-newrev", newRev, "-format", "junit"}, 1, `type="push_size">push adds 4.00 KB`},
+		{"Unknown format", "", []string{"-project", "app", "-newrev", newRev, "-format", "xml"}, 2, `unknown output format "xml"`},
+		{"Missing newrev", "", []string{"-project", "app"}, 2, "-newrev is required"},
+		{"Missing config file", "", []string{"-project", "app", "-newrev", newRev, "-config", filepath.Join(dir, "missing.yaml")}, 2, "Load config failed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := testutil.RunBinary(t, execPath, dir, test.config, nil, "", append([]string{"simulate"}, test.args...)...)
+			exitCode := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("githookkit failed: %v", err)
+			}
+			if exitCode != test.exitCode {
+				t.Errorf("exit code = %d, expected %d\n%s", exitCode, test.exitCode, output)
+			}
+			if !strings.Contains(output, test.expected) {
+				t.Errorf("output does not contain %q:\n%s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "githookkit")
+
+	output, err := testutil.RunBinary(t, execPath, t.TempDir(), "", nil, "", "unknown")
+	if err == nil || !strings.Contains(output, `Unknown command "unknown"`) {
+		t.Errorf("githookkit unknown should fail with usage, got %v:\n%s", err, output)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "githookkit")
+
+	for _, arg := range []string{"version", "--version"} {
+		output, err := testutil.RunBinary(t, execPath, t.TempDir(), "", nil, "", arg)
+		if err != nil {
+			t.Fatalf("githookkit %s failed: %v\n%s", arg, err, output)
+		}
+		if !strings.HasPrefix(output, "githookkit ") || !strings.Contains(output, "config schema version 2") {
+			t.Errorf("githookkit %s output is incorrect: %s", arg, output)
+		}
+	}
+}
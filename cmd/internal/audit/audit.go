@@ -0,0 +1,121 @@
+// Package audit records hook events as JSON lines in a local append-only log
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Event types
+const (
+	EventPush     = "push"             // Accepted push, with size statistics
+	EventMerge    = "merge"            // Merged Gerrit change, with the growth it caused
+	EventOverride = "override"         // Rules bypassed for a push with an override push option
+	EventBypass   = "emergency_bypass" // All rules skipped with GITHOOK_EMERGENCY_BYPASS
+	EventAdmin    = "admin_bypass"     // All rules skipped for an uploader holding a capability or group of admin_bypass
+)
+
+// Record is a single audit log entry
+type Record struct {
+	Time          time.Time                `json:"time"`
+	Event         string                   `json:"event"`
+	Hook          string                   `json:"hook,omitempty"`
+	Project       string                   `json:"project,omitempty"`
+	RefName       string                   `json:"ref,omitempty"`
+	OldRev        string                   `json:"oldrev,omitempty"`
+	NewRev        string                   `json:"newrev,omitempty"`
+	User          string                   `json:"user,omitempty"`
+	Change        string                   `json:"change,omitempty"`
+	Reason        string                   `json:"reason,omitempty"` // Reason given for an emergency bypass
+	NewFiles      int                      `json:"new_files,omitempty"`
+	TotalSize     int64                    `json:"total_size,omitempty"`
+	Largest       string                   `json:"largest,omitempty"`
+	LargeFiles    []string                 `json:"large_files,omitempty"`    // Files above the size limit that landed anyway
+	Duplicates    int                      `json:"duplicates,omitempty"`     // Added files duplicating content at other paths
+	DuplicateSize int64                    `json:"duplicate_size,omitempty"` // Total size of the duplicates
+	Warnings      []string                 `json:"warnings,omitempty"`
+	Violations    []githookkit.Violation   `json:"violations,omitempty"` // The rules the push broke, with the files and commits
+	Overrides     []config.AppliedOverride `json:"overrides,omitempty"`
+}
+
+// Write appends a record to the audit log at path, the time is set when it is empty
+// Each record is written with a single write call so concurrent hooks don't interleave lines
+func Write(path string, record Record) error {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// maxLineSize bounds a line read back from the audit log, records listing many warnings are long
+const maxLineSize = 16 * 1024 * 1024
+
+// Growth sums the size of the new files of the pushes to project recorded in the audit log at path since the given time
+// Pushes are recorded by post-receive, or on Gerrit by ref-update and commit-received once they accept them.
+// Merges are not counted, their files entered the repository with the push of the change
+func Growth(path, project string, since time.Time) (int64, error) {
+	return sumPushes(path, since, func(record Record) bool { return record.Project == project })
+}
+
+// Uploaded sums the size of the new files user pushed to any project since the given time, as recorded in the audit log at path
+// Like for Growth, merges are not counted, only the pushes recorded by post-receive, ref-update and commit-received
+func Uploaded(path, user string, since time.Time) (int64, error) {
+	return sumPushes(path, since, func(record Record) bool { return record.User == user })
+}
+
+// sumPushes sums the size of the new files of the pushes recorded since the given time that match
+// A missing log is no growth, lines that aren't records, e.g. cut off by a crash, are skipped
+func sumPushes(path string, since time.Time, match func(Record) bool) (int64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var sum int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxLineSize)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if record.Event == EventPush && !record.Time.Before(since) && match(record) {
+			sum += record.TotalSize
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return sum, nil
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+
+	records := []Record{
+		{Event: EventPush, Project: "project1", RefName: "refs/heads/master", NewFiles: 3, TotalSize: 1024},
+		{Event: EventPush, Project: "project2", Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+	for _, record := range records {
+		if err := Write(path, record); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var read []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to decode audit line %q: %v", scanner.Text(), err)
+		}
+		read = append(read, record)
+	}
+
+	if len(read) != 2 {
+		t.Fatalf("audit log has %d records, expected 2", len(read))
+	}
+	if read[0].Project != "project1" || read[0].NewFiles != 3 || read[0].TotalSize != 1024 {
+		t.Errorf("first record is incorrect: %+v", read[0])
+	}
+	if read[0].Time.IsZero() {
+		t.Errorf("Write() should set the time of records without one")
+	}
+	if !read[1].Time.Equal(records[1].Time) {
+		t.Errorf("Write() should keep the time of records, got %v", read[1].Time)
+	}
+}
+
+func TestGrowth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	now := time.Now()
+
+	growth, err := Growth(path, "project1", now.Add(-time.Hour))
+	if err != nil || growth != 0 {
+		t.Fatalf("Growth() of a missing log = %d, %v, expected 0", growth, err)
+	}
+
+	records := []Record{
+		{Event: EventPush, Project: "project1", User: "alice", TotalSize: 1000, Time: now.Add(-2 * time.Hour)},
+		{Event: EventPush, Project: "project1", User: "bob", TotalSize: 200, Time: now.Add(-time.Minute)},
+		{Event: EventMerge, Project: "project1", User: "alice", TotalSize: 200, Time: now.Add(-time.Minute)},
+		{Event: EventPush, Project: "project2", User: "alice", TotalSize: 400, Time: now.Add(-time.Minute)},
+		{Event: EventPush, Project: "project1", User: "alice", TotalSize: 30, Time: now},
+	}
+	for _, record := range records {
+		if err := Write(path, record); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	file.WriteString(`{"event":"push","project":"proj`)
+	file.Close()
+
+	growth, err = Growth(path, "project1", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Growth() returned error: %v", err)
+	}
+	if growth != 230 {
+		t.Errorf("Growth() = %d, expected 230", growth)
+	}
+
+	uploaded, err := Uploaded(path, "alice", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Uploaded() returned error: %v", err)
+	}
+	if uploaded != 430 {
+		t.Errorf("Uploaded() = %d, expected 430", uploaded)
+	}
+}
@@ -0,0 +1,46 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// HookError is returned when a chained hook exits with a non-zero code
+type HookError struct {
+	Command  string
+	ExitCode int
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("chained hook %s exited with code %d", e.Command, e.ExitCode)
+}
+
+// Run execs the chained hooks configured for hookName in order with the given arguments
+// It stops at the first hook that fails, returning a *HookError when the hook exited with a non-zero code
+func Run(hooks []config.ChainedHook, hookName string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	for _, hook := range hooks {
+		if hook.Hook != "" && hook.Hook != hookName {
+			continue
+		}
+
+		cmd := exec.Command(hook.Command, args...)
+		cmd.Env = os.Environ()
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return &HookError{Command: hook.Command, ExitCode: exitErr.ExitCode()}
+			}
+			return fmt.Errorf("failed to run chained hook %s: %w", hook.Command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package chain
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// writeScript creates an executable shell script in dir
+func writeScript(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+content+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write script %s: %v", name, err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	echo := writeScript(t, dir, "echo.sh", `echo "echo $@"`)
+	other := writeScript(t, dir, "other.sh", `echo "other hook"`)
+	fail := writeScript(t, dir, "fail.sh", `echo "fail"; exit 3`)
+	never := writeScript(t, dir, "never.sh", `echo "never"`)
+
+	t.Run("Hooks run in order with the same arguments", func(t *testing.T) {
+		hooks := []config.ChainedHook{
+			{Hook: "ref-update", Command: echo},
+			{Hook: "commit-received", Command: other},
+			{Command: echo},
+		}
+
+		var stdout bytes.Buffer
+		err := Run(hooks, "ref-update", []string{"--project", "p"}, nil, &stdout, &stdout)
+		if err != nil {
+			t.Fatalf("Run() returned error: %v", err)
+		}
+		if stdout.String() != "echo --project p\necho --project p\n" {
+			t.Errorf("unexpected output: %q", stdout.String())
+		}
+	})
+
+	t.Run("Failing hook stops the chain", func(t *testing.T) {
+		hooks := []config.ChainedHook{
+			{Command: fail},
+			{Command: never},
+		}
+
+		var stdout bytes.Buffer
+		err := Run(hooks, "ref-update", nil, nil, &stdout, &stdout)
+
+		var hookErr *HookError
+		if !errors.As(err, &hookErr) {
+			t.Fatalf("Run() error = %v, expected *HookError", err)
+		}
+		if hookErr.ExitCode != 3 {
+			t.Errorf("ExitCode = %d, expected 3", hookErr.ExitCode)
+		}
+		if strings.Contains(stdout.String(), "never") {
+			t.Errorf("hooks after the failing one should not run")
+		}
+	})
+
+	t.Run("Missing command", func(t *testing.T) {
+		hooks := []config.ChainedHook{{Command: filepath.Join(dir, "missing.sh")}}
+
+		err := Run(hooks, "ref-update", nil, nil, nil, nil)
+		var hookErr *HookError
+		if err == nil || errors.As(err, &hookErr) {
+			t.Errorf("Run() error = %v, expected a non hook error", err)
+		}
+	})
+}
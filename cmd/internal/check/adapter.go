@@ -0,0 +1,86 @@
+package check
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+)
+
+// Adapter names, selecting how the native hooks find the project and the pusher
+const (
+	AdapterGit       = "git"       // Plain git server, the pusher comes from REMOTE_USER
+	AdapterBitbucket = "bitbucket" // Bitbucket Data Center external hooks, BB_* environment
+	AdapterGitea     = "gitea"     // Gitea and Forgejo hooks, GITEA_* environment
+)
+
+// Environment is what the hosting server tells a native hook about the push
+type Environment struct {
+	Adapter  string
+	Project  string // Empty when the server doesn't name the project
+	Username string
+}
+
+// DetectEnvironment reads the hook environment of the named adapter, an empty name selects the adapter
+// from the environment variables that are present
+func DetectEnvironment(adapter string) (Environment, error) {
+	if adapter == "" {
+		adapter = AdapterGit
+		switch {
+		case os.Getenv("BB_REPO_SLUG") != "":
+			adapter = AdapterBitbucket
+		case os.Getenv("GITEA_REPO_NAME") != "":
+			adapter = AdapterGitea
+		}
+	}
+
+	switch adapter {
+	case AdapterGit:
+		return Environment{Adapter: adapter, Username: os.Getenv("REMOTE_USER")}, nil
+	case AdapterBitbucket:
+		// Repository slugs are only unique within a Bitbucket project, so both make up the project name
+		env := Environment{Adapter: adapter, Username: os.Getenv("BB_USER_NAME")}
+		if slug := os.Getenv("BB_REPO_SLUG"); slug != "" {
+			env.Project = slug
+			if key := os.Getenv("BB_PROJECT_KEY"); key != "" {
+				env.Project = key + "/" + slug
+			}
+		}
+		return env, nil
+	case AdapterGitea:
+		// Repository names are only unique per owner, the project is named like the repository URL
+		env := Environment{Adapter: adapter, Username: os.Getenv("GITEA_PUSHER_NAME")}
+		if name := os.Getenv("GITEA_REPO_NAME"); name != "" {
+			env.Project = name
+			if owner := os.Getenv("GITEA_REPO_USER_NAME"); owner != "" {
+				env.Project = owner + "/" + name
+			}
+		}
+		return env, nil
+	default:
+		return Environment{}, &githookkit.ConfigError{Err: fmt.Errorf("unknown adapter %q", adapter)}
+	}
+}
+
+// HookIdentity returns the project and the pusher of a native hook call, the flags take precedence over
+// the adapter's environment and the repository directory name is the last resort for the project
+func HookIdentity(adapter, project, username string) (string, string, error) {
+	env, err := DetectEnvironment(adapter)
+	if err != nil {
+		return "", "", err
+	}
+
+	if project == "" {
+		project = env.Project
+	}
+	if username == "" {
+		username = env.Username
+	}
+	if project == "" {
+		project, err = RepositoryProject()
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return project, username, nil
+}
@@ -0,0 +1,63 @@
+package check
+
+import (
+	"testing"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		adapter  string
+		env      map[string]string
+		expected Environment
+		wantErr  bool
+	}{
+		{
+			name:     "Plain git server",
+			env:      map[string]string{"REMOTE_USER": "alice"},
+			expected: Environment{Adapter: AdapterGit, Username: "alice"},
+		},
+		{
+			name:     "Bitbucket detected",
+			env:      map[string]string{"BB_PROJECT_KEY": "APP", "BB_REPO_SLUG": "web", "BB_USER_NAME": "bob", "REMOTE_USER": "alice"},
+			expected: Environment{Adapter: AdapterBitbucket, Project: "APP/web", Username: "bob"},
+		},
+		{
+			name:     "Bitbucket without project key",
+			env:      map[string]string{"BB_REPO_SLUG": "web"},
+			expected: Environment{Adapter: AdapterBitbucket, Project: "web"},
+		},
+		{
+			name:     "Gitea detected",
+			env:      map[string]string{"GITEA_REPO_USER_NAME": "org", "GITEA_REPO_NAME": "web", "GITEA_PUSHER_NAME": "carol"},
+			expected: Environment{Adapter: AdapterGitea, Project: "org/web", Username: "carol"},
+		},
+		{
+			name:     "Adapter forced",
+			adapter:  AdapterGit,
+			env:      map[string]string{"BB_REPO_SLUG": "web", "REMOTE_USER": "alice"},
+			expected: Environment{Adapter: AdapterGit, Username: "alice"},
+		},
+		{
+			name:    "Unknown adapter",
+			adapter: "svn",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"REMOTE_USER", "BB_PROJECT_KEY", "BB_REPO_SLUG", "BB_USER_NAME", "GITEA_REPO_USER_NAME", "GITEA_REPO_NAME", "GITEA_PUSHER_NAME"} {
+				t.Setenv(name, test.env[name])
+			}
+
+			env, err := DetectEnvironment(test.adapter)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DetectEnvironment() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if env != test.expected {
+				t.Errorf("DetectEnvironment() = %+v, expected %+v", env, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package check
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Archive formats recognized by their first bytes
+const (
+	archiveZip     = "zip"
+	archiveTar     = "tar"
+	archiveTarGzip = "tar.gz"
+)
+
+const (
+	archiveSniffSize = 512     // Bytes needed to recognize an archive, the tar magic is at offset 257
+	maxTarGzipStream = 1 << 30 // Decompressed bytes read from a gzip compressed tar archive before giving up
+)
+
+// errArchiveTooLarge stops reading an archive once its entries exceed the limit
+var errArchiveTooLarge = errors.New("archive too large")
+
+// archiveMismatchError stops reading a zip archive with an entry whose content doesn't match its header,
+// e.g. declaring a smaller size than it decompresses to
+type archiveMismatchError struct {
+	name string
+}
+
+func (e *archiveMismatchError) Error() string {
+	return fmt.Sprintf("%s doesn't match the size declared in its header", e.name)
+}
+
+// archiveRule rejects updates adding archives whose content exceeds the uncompressed size limit or contains denied entries
+func archiveRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetArchivePolicy(cfg, update.Project)
+	if policy.MaxUncompressedSize == 0 && len(policy.DeniedEntries) == 0 {
+		return nil, nil
+	}
+
+	readLimit := policy.ReadLimit
+	if readLimit == 0 {
+		readLimit = config.DefaultArchiveReadLimit
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		if file.Size > readLimit {
+			logger.Debugf("Not inspecting %s, %s exceeds the archive read limit", file.Path, githookkit.FormatSize(file.Size))
+			return true
+		}
+		return false
+	})
+
+	// Only the start of each blob is read to find the archives, then the archives are read as a whole
+	formats := map[string]string{}
+	var archives []string
+	read := func(object string, content []byte) {
+		if format := archiveFormat(content); format != "" {
+			formats[object] = format
+			archives = append(archives, object)
+		}
+	}
+
+	report := func() error {
+		var files []githookkit.FileInfo
+		var problems []string
+		err := update.Repository.ReadBlobs(archives, 0, func(object string, content []byte) error {
+			problem, err := archiveProblem(formats[object], content, policy)
+			if err != nil {
+				logger.Debugf("Not inspecting %s, the %s archive can't be read: %v", paths[object][0].Path, formats[object], err)
+				return nil
+			}
+			if problem != "" {
+				for _, file := range paths[object] {
+					files = append(files, file)
+					problems = append(problems, problem)
+				}
+			}
+			return nil
+		})
+		if err != nil || len(files) == 0 {
+			return err
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d rejected archives:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+		if policy.MaxUncompressedSize > 0 {
+			data.Limit = githookkit.FormatSize(policy.MaxUncompressedSize)
+		}
+		result.violate(config.RuleArchive, config.RenderMessage(cfg, config.RuleArchive, data,
+			fmt.Sprintf("push adds %d rejected archives, the first one is %s: %s, use an artifact repository!", len(files), files[0].Path, problems[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: archiveSniffSize, read: read, report: report}, nil
+}
+
+// archiveFormat recognizes zip and tar archives, and gzip compressed tar archives, by their first bytes
+func archiveFormat(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, []byte("PK\x03\x04")) || bytes.HasPrefix(sample, []byte("PK\x05\x06")):
+		return archiveZip
+	case len(sample) >= 262 && string(sample[257:262]) == "ustar":
+		return archiveTar
+	case bytes.HasPrefix(sample, []byte{0x1f, 0x8b}):
+		// Only gzip streams starting with a tar header are archives, other gzip files are single files
+		reader, err := gzip.NewReader(bytes.NewReader(sample))
+		if err != nil {
+			return ""
+		}
+		header := make([]byte, 262)
+		if n, _ := io.ReadFull(reader, header); n == len(header) && string(header[257:262]) == "ustar" {
+			return archiveTarGzip
+		}
+	}
+	return ""
+}
+
+// archiveProblem returns why an archive is rejected, or "" when its content is allowed
+// The sizes are taken from the entry headers; with a size limit the zip entries are decompressed as well to check them
+func archiveProblem(format string, content []byte, policy config.ArchivePolicy) (string, error) {
+	var total int64
+	var denied string
+	visit := func(name string, size int64) error {
+		// Archive bombs may declare sizes adding up beyond the int64 range
+		if size > math.MaxInt64-total {
+			total = math.MaxInt64
+		} else {
+			total += size
+		}
+		if denied == "" && matchAny(policy.DeniedEntries, name) {
+			denied = name
+		}
+		if policy.MaxUncompressedSize > 0 && total > policy.MaxUncompressedSize {
+			return errArchiveTooLarge
+		}
+		return nil
+	}
+
+	var err error
+	switch format {
+	case archiveZip:
+		err = visitZip(content, policy.MaxUncompressedSize, visit)
+	case archiveTar:
+		err = visitTar(bytes.NewReader(content), visit)
+	case archiveTarGzip:
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(bytes.NewReader(content)); err == nil {
+			err = visitTar(io.LimitReader(reader, maxTarGzipStream), visit)
+		}
+	}
+
+	switch {
+	case denied != "":
+		return fmt.Sprintf("contains %s", denied), nil
+	case errors.Is(err, errArchiveTooLarge):
+		return fmt.Sprintf("uncompressed content exceeds %s", githookkit.FormatSize(policy.MaxUncompressedSize)), nil
+	}
+	var mismatch *archiveMismatchError
+	if errors.As(err, &mismatch) {
+		return mismatch.Error(), nil
+	}
+	return "", err
+}
+
+// visitZip calls visit for each file of a zip archive with the size declared in its header. With a limit the files
+// are decompressed too, at most limit bytes in total, so that under-declared sizes can't take an archive past it.
+func visitZip(content []byte, limit int64, visit func(name string, size int64) error) error {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, file := range reader.File {
+		if err := visit(file.Name, int64(min(file.UncompressedSize64, math.MaxInt64))); err != nil {
+			return err
+		}
+		if limit == 0 {
+			continue
+		}
+
+		size, err := zipFileSize(file, limit-total+1)
+		if errors.Is(err, zip.ErrAlgorithm) {
+			// Content compressed with a method Go can't decompress is only known by its declared size
+			continue
+		}
+		total += size
+		switch {
+		case total > limit:
+			return errArchiveTooLarge
+		// The reader fails on more content than declared, on less, and on a checksum not matching the content
+		case errors.Is(err, zip.ErrFormat) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, zip.ErrChecksum):
+			return &archiveMismatchError{name: file.Name}
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
+
+// zipFileSize decompresses up to limit bytes of a file of a zip archive and returns how many there were
+func zipFileSize(file *zip.File, limit int64) (int64, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	size, err := io.CopyN(io.Discard, reader, limit)
+	if err == io.EOF {
+		err = nil
+	}
+	return size, err
+}
+
+// visitTar calls visit for each entry of a tar archive, the content of entries is skipped
+func visitTar(r io.Reader, visit func(name string, size int64) error) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := visit(header.Name, header.Size); err != nil {
+			return err
+		}
+	}
+}
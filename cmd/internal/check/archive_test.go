@@ -0,0 +1,191 @@
+package check
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// zipArchive returns a zip archive of files, keyed by name
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s to zip: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// tarArchive returns a tar archive of files, keyed by name, gzip compressed if compress is set
+func tarArchive(t *testing.T, files map[string]string, compress bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	writer := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		writer = tar.NewWriter(gz)
+	}
+	for name, content := range files {
+		if err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Format: tar.FormatUSTAR}); err != nil {
+			t.Fatalf("Failed to add %s to tar: %v", name, err)
+		}
+		writer.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write tar: %v", err)
+	}
+	if gz != nil {
+		gz.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveFormat(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(strings.Repeat("plain text ", 100)))
+	gz.Close()
+
+	tests := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{"zip", zipArchive(t, map[string]string{"a.txt": "a"}), archiveZip},
+		{"empty zip", zipArchive(t, nil), archiveZip},
+		{"tar", tarArchive(t, map[string]string{"a.txt": "a"}, false), archiveTar},
+		{"tar.gz", tarArchive(t, map[string]string{"a.txt": "a"}, true), archiveTarGzip},
+		{"gzip", gzipped.Bytes(), ""},
+		{"text", []byte("PK is not enough"), ""},
+	}
+
+	for _, test := range tests {
+		if format := archiveFormat(test.content[:min(len(test.content), archiveSniffSize)]); format != test.expected {
+			t.Errorf("archiveFormat(%s) = %q, expected %q", test.name, format, test.expected)
+		}
+	}
+}
+
+// falsifiedZipArchive returns a zip archive with a single deflated file whose header declares size instead of the
+// real size of content
+func falsifiedZipArchive(t *testing.T, name, content string, size uint64) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	deflater, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		t.Fatalf("Failed to create deflater: %v", err)
+	}
+	deflater.Write([]byte(content))
+	deflater.Close()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	header := &zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE([]byte(content)),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: size,
+	}
+	file, err := writer.CreateRaw(header)
+	if err != nil {
+		t.Fatalf("Failed to add %s to zip: %v", name, err)
+	}
+	file.Write(compressed.Bytes())
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveProblem(t *testing.T) {
+	files := map[string]string{"lib/tool.exe": strings.Repeat("x", 3000), "README": "r"}
+	policy := config.ArchivePolicy{MaxUncompressedSize: 2000, DeniedEntries: []string{"*.dll"}}
+
+	tests := []struct {
+		name     string
+		format   string
+		content  []byte
+		policy   config.ArchivePolicy
+		expected string
+	}{
+		{"zip too large", archiveZip, zipArchive(t, files), policy, "uncompressed content exceeds 1.95 KB"},
+		{"tar too large", archiveTar, tarArchive(t, files, false), policy, "uncompressed content exceeds 1.95 KB"},
+		{"tar.gz too large", archiveTarGzip, tarArchive(t, files, true), policy, "uncompressed content exceeds 1.95 KB"},
+		{"denied entry", archiveZip, zipArchive(t, files), config.ArchivePolicy{DeniedEntries: []string{"*.exe"}}, "contains lib/tool.exe"},
+		{"tar.gz denied entry", archiveTarGzip, tarArchive(t, files, true), config.ArchivePolicy{DeniedEntries: []string{"lib/*"}}, "contains lib/tool.exe"},
+		{"zip under-declaring its size", archiveZip, falsifiedZipArchive(t, "bomb.bin", strings.Repeat("\x00", 100000), 10), policy,
+			"bomb.bin doesn't match the size declared in its header"},
+		{"zip over-declaring its size", archiveZip, falsifiedZipArchive(t, "small.bin", "small", 1000), policy,
+			"small.bin doesn't match the size declared in its header"},
+		{"zip declaring a size above the limit", archiveZip, falsifiedZipArchive(t, "bomb.bin", strings.Repeat("\x00", 100000), 1900),
+			config.ArchivePolicy{MaxUncompressedSize: 1000}, "uncompressed content exceeds 1000 B"},
+		{"zip only checked for entries", archiveZip, falsifiedZipArchive(t, "bomb.bin", strings.Repeat("\x00", 100000), 10),
+			config.ArchivePolicy{DeniedEntries: []string{"*.exe"}}, ""},
+		{"zip allowed", archiveZip, zipArchive(t, files), config.ArchivePolicy{MaxUncompressedSize: 4000}, ""},
+		{"allowed", archiveTar, tarArchive(t, files, false), config.ArchivePolicy{MaxUncompressedSize: 4000, DeniedEntries: []string{"*.dll"}}, ""},
+	}
+
+	for _, test := range tests {
+		problem, err := archiveProblem(test.format, test.content, test.policy)
+		if err != nil {
+			t.Fatalf("%s: archiveProblem() returned error: %v", test.name, err)
+		}
+		if problem != test.expected {
+			t.Errorf("%s: archiveProblem() = %q, expected %q", test.name, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckArchives(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	// Renaming an archive doesn't hide it
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"notes.txt":     string(zipArchive(t, map[string]string{"payload.bin": strings.Repeat("\x00", 10000)})),
+		"docs/site.zip": string(zipArchive(t, map[string]string{"index.html": "<html>"})),
+	}, "Add archives")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		Archives: config.ArchivePolicy{MaxUncompressedSize: 1000},
+		Projects: map[string]config.ProjectPolicy{"assets": {Archives: &config.ArchivePolicy{MaxUncompressedSize: 1000, ReadLimit: 100}}},
+	}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "push adds 1 rejected archives, the first one is notes.txt: uncompressed content exceeds 1000 B") {
+		t.Errorf("Check() returned violations %v, expected only notes.txt", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "assets", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none for archives above the read limit", result.Violations)
+	}
+}
@@ -0,0 +1,107 @@
+package check
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// CheckBatch checks the ref updates of one push, the results are in the order of updates
+// The objects of all updates are enumerated before any of them is checked, updates of the same range share
+// one enumeration and the sizes of objects shared between refs (e.g. a branch and a tag on the same commit) are read once
+func CheckBatch(cfg config.Config, logger *config.Logger, updates []Update) ([]Result, error) {
+	files, err := batchNewFiles(cfg, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(updates))
+	for i, update := range updates {
+		logger.Debugf("Checking %s %s..%s", update.RefName, update.OldRev, update.NewRev)
+		start := time.Now()
+		result, err := check(cfg, logger, update, func() ([]githookkit.FileInfo, error) {
+			return files[i], nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", update.RefName, err)
+		}
+		result.Duration = time.Since(start)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// batchNewFiles returns the blobs introduced by each update, looking up the details of all objects in one pass
+func batchNewFiles(cfg config.Config, updates []Update) ([][]githookkit.FileInfo, error) {
+	objects := make([][]string, len(updates))
+	firstOfRange := map[[2]string]int{}
+	seen := map[string]bool{}
+	var unique []string
+	var repo githookkit.Repository // The updates of a push share their repository
+
+	for i, update := range updates {
+		if update.NewRev == ZeroRev || config.IsRefSkipped(cfg, update.RefName) {
+			continue
+		}
+
+		updateRange := [2]string{update.OldRev, update.NewRev}
+		if first, exists := firstOfRange[updateRange]; exists {
+			objects[i] = objects[first]
+			continue
+		}
+		firstOfRange[updateRange] = i
+
+		repo = update.Repository
+		objectChan, err := objectList(repo, update.OldRev, update.NewRev)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", update.RefName, err)
+		}
+		for line := range objectChan {
+			objects[i] = append(objects[i], line)
+			if !seen[line] {
+				seen[line] = true
+				unique = append(unique, line)
+			}
+		}
+	}
+
+	objectChan := make(chan string)
+	go func() {
+		defer close(objectChan)
+		for _, line := range unique {
+			objectChan <- line
+		}
+	}()
+
+	fileInfoChan, err := repo.GetObjectDetails(objectChan, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object details: %w", err)
+	}
+
+	var found []githookkit.FileInfo
+	for fileInfo := range fileInfoChan {
+		found = append(found, fileInfo)
+	}
+	found, err = withoutExistingBlobs(repo, found)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines of the object list are "<object> <path>", the same blob under another path is another file
+	details := map[string]githookkit.FileInfo{}
+	for _, fileInfo := range found {
+		details[fileInfo.Hash+" "+fileInfo.Path] = fileInfo
+	}
+
+	files := make([][]githookkit.FileInfo, len(updates))
+	for i := range updates {
+		for _, line := range objects[i] {
+			if fileInfo, exists := details[line]; exists {
+				files[i] = append(files[i], fileInfo)
+			}
+		}
+	}
+	return files, nil
+}
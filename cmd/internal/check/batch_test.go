@@ -0,0 +1,79 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckBatch(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 2048, "small.txt": 100})
+	otherRev := testutil.CommitFiles(t, dir, map[string]int{"copy.bin": 2048})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	sizeLimit := int64(1024)
+	cfg := config.Config{Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &sizeLimit}}}
+
+	updates := []Update{
+		{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+		{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/release"},
+		{Project: "p", OldRev: oldRev, NewRev: otherRev, RefName: "refs/heads/topic"},
+		{Project: "p", OldRev: oldRev, NewRev: ZeroRev, RefName: "refs/heads/old"},
+		{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/changes/01/1/1"},
+	}
+
+	results, err := CheckBatch(cfg, logger, updates)
+	if err != nil {
+		t.Fatalf("CheckBatch() returned error: %v", err)
+	}
+	if len(results) != len(updates) {
+		t.Fatalf("CheckBatch() returned %d results, expected %d", len(results), len(updates))
+	}
+
+	// The results must not depend on the updates being checked together
+	for i, update := range updates {
+		expected, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		// Only the time spent may differ
+		expected.Duration = results[i].Duration
+		if !reflect.DeepEqual(results[i], expected) {
+			t.Errorf("CheckBatch() result for %s = %+v, expected %+v", update.RefName, results[i], expected)
+		}
+	}
+
+	if len(results[2].NewFiles) != 3 || len(results[2].Violations) != 1 {
+		t.Errorf("topic has new files %v and violations %v, expected 3 files and the size violation", results[2].NewFiles, results[2].Violations)
+	}
+}
+
+func TestCheckBatchSkipsExistingBlobs(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	testutil.CommitContents(t, dir, map[string]string{"restored.txt": "restored content"}, "add restored.txt")
+	testutil.Git(t, dir, "rm", "-q", "restored.txt")
+	testutil.Git(t, dir, "commit", "-q", "-m", "remove restored.txt")
+	oldRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+
+	// receive-pack writes the pushed objects into a quarantine, with the object store of the repository as alternate
+	quarantine := t.TempDir()
+	t.Setenv("GIT_OBJECT_DIRECTORY", quarantine)
+	t.Setenv("GIT_ALTERNATE_OBJECT_DIRECTORIES", dir+"/.git/objects")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"restored.txt": "restored content", "new.txt": "new content"}, "restore restored.txt")
+	t.Setenv("GIT_QUARANTINE_PATH", quarantine)
+	chdir(t, dir)
+
+	updates := []Update{{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}}
+	results, err := CheckBatch(config.Config{}, newTestLogger(t), updates)
+	if err != nil {
+		t.Fatalf("CheckBatch() returned error: %v", err)
+	}
+	if files := results[0].NewFiles; len(files) != 1 || files[0].Path != "new.txt" {
+		t.Errorf("CheckBatch() returned new files %v, expected only new.txt", files)
+	}
+}
@@ -0,0 +1,71 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// isBinary classifies the start of a file like git does: a NUL byte makes it binary
+// Text without NUL bytes that is mostly control characters, e.g. UTF-16 or compressed data, is binary as well
+func isBinary(sample []byte) bool {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	control := 0
+	for _, b := range sample {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\b' && b != 0x1b) || b == 0x7f {
+			control++
+		}
+	}
+	return control*10 > len(sample)*3
+}
+
+// binaryRule rejects updates adding binary files outside of the allowed paths
+func binaryRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetBinaryPolicy(cfg, update.Project)
+	if !policy.Block {
+		return nil, nil
+	}
+
+	sampleSize := policy.SampleSize
+	if sampleSize == 0 {
+		sampleSize = config.DefaultBinarySampleSize
+	}
+
+	objects, files := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		return matchAny(policy.AllowedPaths, file.Path)
+	})
+
+	var binaries []githookkit.FileInfo
+	read := func(object string, content []byte) {
+		if isBinary(content) {
+			binaries = append(binaries, files[object]...)
+		}
+	}
+
+	report := func() error {
+		if len(binaries) == 0 {
+			return nil
+		}
+		var largest githookkit.FileInfo
+		for _, file := range binaries {
+			if file.Size > largest.Size {
+				largest = file
+			}
+		}
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(policy.AllowedPaths, ", "), Actual: binaries[0].Path, Count: len(binaries), Files: binaries, Largest: largest}
+		message := fmt.Sprintf("push adds %d binary files, the first one is %s, use git lfs or an artifact repository!", len(binaries), binaries[0].Path)
+		if len(policy.AllowedPaths) > 0 {
+			message = fmt.Sprintf("push adds %d binary files outside of %s, the first one is %s, use git lfs or an artifact repository!", len(binaries), data.Limit, binaries[0].Path)
+		}
+		result.violate(config.RuleBinary, config.RenderMessage(cfg, config.RuleBinary, data, message), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: sampleSize, read: read, report: report}, nil
+}
@@ -0,0 +1,71 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := map[string]bool{
+		"":                           false,
+		"plain text\n":               false,
+		"tabs\tand\r\nCRLF\f":        false,
+		"UTF-8 text: grüße, 日本語\n":   false,
+		"\x1b[31mcolored\x1b[0m\n":   false,
+		"PK\x03\x04\x00\x00archive":  true,
+		"\x01\x02\x03\x04\x05abc":    true,
+		"mostly text with one \x01.": false,
+	}
+	for sample, expected := range tests {
+		if result := isBinary([]byte(sample)); result != expected {
+			t.Errorf("isBinary(%q) = %v, expected %v", sample, result, expected)
+		}
+	}
+}
+
+func TestCheckBinaries(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"lib/tool.so":     "\x7fELF\x02\x01\x01\x00\x00\x00",
+		"assets/logo.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"src/main.go":     "package main\n",
+	}, "Add binaries")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+
+	tests := []struct {
+		name      string
+		cfg       config.Config
+		wantCount int
+		expected  string
+	}{
+		{"Not blocked", config.Config{}, 0, ""},
+		{"Blocked", config.Config{BinaryFiles: config.BinaryPolicy{Block: true}}, 1, "push adds 2 binary files"},
+		{"Allowed paths", config.Config{BinaryFiles: config.BinaryPolicy{Block: true, AllowedPaths: []string{"assets/*"}}}, 1, "push adds 1 binary files outside of assets/*, the first one is lib/tool.so"},
+		{"Project policy", config.Config{
+			BinaryFiles: config.BinaryPolicy{Block: true},
+			Projects:    map[string]config.ProjectPolicy{"p": {BinaryFiles: &config.BinaryPolicy{}}},
+		}, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Check(test.cfg, logger, update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != test.wantCount {
+				t.Fatalf("Check() returned violations %v, expected %d", result.Violations, test.wantCount)
+			}
+			if test.wantCount > 0 && !strings.Contains(result.Violations[0], test.expected) {
+				t.Errorf("violation %q does not contain %q", result.Violations[0], test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+)
+
+// checkPathBudgets rejects updates adding more bytes under the paths of a budget than it allows
+// Each new file counts against the first budget matching its path only, so that a catch-all entry can follow specific ones
+func checkPathBudgets(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) {
+	budgets := config.GetPathBudgets(cfg, update.Project)
+	if len(budgets) == 0 {
+		return
+	}
+
+	files := make([][]githookkit.FileInfo, len(budgets))
+	totals := make([]int64, len(budgets))
+	for _, file := range newFiles {
+		for i, budget := range budgets {
+			if matchAny(budget.Paths, file.Path) {
+				files[i] = append(files[i], file)
+				totals[i] += file.Size
+				break
+			}
+		}
+	}
+
+	for i, budget := range budgets {
+		if totals[i] <= budget.Max {
+			continue
+		}
+
+		paths := strings.Join(budget.Paths, ", ")
+		sortBySize(files[i])
+		largest := files[i][0]
+		logOffenders(logger, cfg, fmt.Sprintf("Found %d files under %s, %s in total:", len(files[i]), paths, githookkit.FormatSize(totals[i])), files[i])
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: githookkit.FormatSize(budget.Max), Actual: githookkit.FormatSize(totals[i]), Count: len(files[i]), Files: files[i], Largest: largest}
+		result.violate(config.RulePathBudget, config.RenderMessage(cfg, config.RulePathBudget, data,
+			locale.T("push adds %s under %s, exceeding its budget of %s, the largest file is %s (%s), split the push or use git lfs!",
+				githookkit.FormatSize(totals[i]), paths, githookkit.FormatSize(budget.Max), largest.Path, githookkit.FormatSize(largest.Size))), data.Files...)
+	}
+}
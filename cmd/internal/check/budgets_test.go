@@ -0,0 +1,49 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckPathBudgets(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"docs/manual.pdf": 3000, "docs/logo.png": 1000, "src/main.go": 1500})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		budgets  []config.PathBudget
+		expected []string
+	}{
+		{"Within budgets", []config.PathBudget{{Paths: []string{"docs/*"}, Max: 5000}, {Paths: []string{"*"}, Max: 2000}}, nil},
+		{"Directory over budget", []config.PathBudget{{Paths: []string{"docs/*"}, Max: 3500}, {Paths: []string{"*"}, Max: 2000}}, []string{
+			"push adds 3.91 KB under docs/*, exceeding its budget of 3.42 KB, the largest file is docs/manual.pdf (2.93 KB)",
+		}},
+		{"Catch-all over budget", []config.PathBudget{{Paths: []string{"docs/*"}, Max: 5000}, {Paths: []string{"*"}, Max: 1000}}, []string{
+			"push adds 1.46 KB under *, exceeding its budget of 1000 B, the largest file is src/main.go",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Config{Projects: map[string]config.ProjectPolicy{"p": {PathBudgets: test.budgets}}}
+			result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != len(test.expected) {
+				t.Fatalf("Check() returned violations %v, expected %v", result.Violations, test.expected)
+			}
+			for i, expected := range test.expected {
+				if !strings.HasPrefix(result.Violations[i], expected) {
+					t.Errorf("violation %q doesn't start with %q", result.Violations[i], expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package check
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+)
+
+// ZeroRev is the object name git uses for a missing side of a ref update (creation or deletion)
+const ZeroRev = "0000000000000000000000000000000000000000"
+
+// Update describes a single ref update to check
+type Update struct {
+	Project          string
+	Uploader         string
+	UploaderUsername string
+	OldRev           string
+	NewRev           string
+	RefName          string
+	PushOptions      []string // Options given with git push -o
+
+	Repository githookkit.Repository `json:"-"` // Repository of the update, the zero value is the working directory
+}
+
+// Result is the outcome of checking a ref update
+type Result struct {
+	Update     Update
+	Mode       string                // Effective enforcement mode for the update
+	Violations []string              // Human-readable violation messages
+	NewFiles   []githookkit.FileInfo // Blobs introduced by the update
+	Overrides  []config.AppliedOverride
+	Bypassed   []string               // Violations bypassed by Overrides
+	Warnings   []string               // Reported but never reject the push, whatever the mode
+	Duplicates []githookkit.FileInfo  // Added files whose content is present at other paths, see duplicate_content
+	Findings   []githookkit.Violation // Violations and Bypassed, one per offending file or commit
+	Duration   time.Duration          // Time spent checking the update
+
+	disabled []string // Rules not checked for the project, see disabled_rules
+}
+
+// Rejected reports whether the result blocks the push
+func (r Result) Rejected() bool {
+	return len(r.Violations) > 0 && r.Mode != config.ModeWarn
+}
+
+// Err returns the *githookkit.PolicyError of a result blocking the push, nil otherwise
+func (r Result) Err() error {
+	if !r.Rejected() {
+		return nil
+	}
+	return &githookkit.PolicyError{Violations: r.Violations}
+}
+
+// violate records a violation of rule by files, unless an override granted for the push bypasses the rule
+// Violations of disabled rules are dropped
+func (r *Result) violate(rule, message string, files ...githookkit.FileInfo) {
+	if slices.Contains(r.disabled, rule) {
+		return
+	}
+	violation := r.record(rule, message)
+	if len(files) == 0 {
+		r.Findings = append(r.Findings, violation)
+	}
+	for _, file := range files {
+		violation.Path, violation.Size = file.Path, file.Size
+		r.Findings = append(r.Findings, violation)
+	}
+}
+
+// violateCommit records a violation of rule by a commit, unless an override granted for the push bypasses the rule
+func (r *Result) violateCommit(rule, message, commit string) {
+	if slices.Contains(r.disabled, rule) {
+		return
+	}
+	violation := r.record(rule, message)
+	violation.Commit = commit
+	r.Findings = append(r.Findings, violation)
+}
+
+// record adds message to Violations or, when an override covers rule, to Bypassed and returns the finding
+func (r *Result) record(rule, message string) githookkit.Violation {
+	violation := githookkit.Violation{RuleID: rule, Severity: githookkit.SeverityError, Message: message}
+	if r.Mode == config.ModeWarn {
+		violation.Severity = githookkit.SeverityWarning
+	}
+	for _, override := range r.Overrides {
+		if override.Covers(rule) {
+			r.Bypassed = append(r.Bypassed, message)
+			violation.Severity, violation.Bypassed = githookkit.SeverityWarning, true
+			return violation
+		}
+	}
+	r.Violations = append(r.Violations, message)
+	return violation
+}
+
+// Check runs all checks for a ref update
+// An error means the check itself failed and the on_internal_error policy applies
+func Check(cfg config.Config, logger *config.Logger, update Update) (Result, error) {
+	start := time.Now()
+	result, err := check(cfg, logger, update, func() ([]githookkit.FileInfo, error) {
+		return newFiles(update.Repository, update.OldRev, update.NewRev, nil)
+	})
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// check runs all checks for a ref update, listFiles returns the blobs introduced by the update
+func check(cfg config.Config, logger *config.Logger, update Update, listFiles func() ([]githookkit.FileInfo, error)) (Result, error) {
+	cfg, err := withProjectConfig(cfg, update.Repository, update.Project)
+	if err != nil {
+		return Result{Update: update, Mode: config.GetMode(cfg, update.Project, update.RefName)}, fmt.Errorf("read project.config failed: %w", err)
+	}
+
+	sizeLimit := config.GetRefSizeLimit(cfg, update.Project, update.RefName)
+	sizeWarning := config.GetSizeWarning(cfg, update.Project, update.RefName)
+	pushSizeLimit := config.GetPushSizeLimit(cfg, update.Project)
+	newFileLimit := config.GetNewFileLimit(cfg, update.Project)
+	commitLimit := config.GetCommitLimit(cfg, update.RefName)
+
+	// Violations are collected so that warn mode can report all of them without rejecting
+	result := Result{
+		Update: update,
+		Mode:   config.GetMode(cfg, update.Project, update.RefName),
+
+		disabled: config.GetDisabledRules(cfg, update.Project),
+	}
+
+	// Internal refs are skipped before enumerating any objects
+	if config.IsRefSkipped(cfg, update.RefName) {
+		logger.Debugf("Ref %s is in skip_refs, not checking it", update.RefName)
+		return result, nil
+	}
+
+	var denied []string
+	result.Overrides, denied = config.GetOverrides(cfg, update.UploaderUsername, update.PushOptions)
+	for _, option := range denied {
+		logger.Warnf("User %q is not allowed to use override %s, ignoring it", update.UploaderUsername, option)
+	}
+
+	// Check the commit count first, it is cheap compared to enumerating objects
+	if commitLimit > 0 && update.NewRev != ZeroRev {
+		count, err := update.Repository.CountCommits(update.NewRev, update.OldRev)
+		if err != nil {
+			return result, fmt.Errorf("count commits failed: %w", err)
+		}
+		logger.Debugf("Push contains %d commits, limit is %d", count, commitLimit)
+		if count > commitLimit {
+			data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strconv.Itoa(commitLimit), Actual: strconv.Itoa(count), Count: count}
+			result.violate(config.RuleCommitCount, config.RenderMessage(cfg, config.RuleCommitCount, data,
+				fmt.Sprintf("push contains %d commits for %s, exceeding the maximum of %d, push in smaller batches or ask an administrator to import the history!", count, update.RefName, commitLimit)))
+		}
+	}
+
+	if err := checkTag(cfg, update, &result); err != nil {
+		return result, fmt.Errorf("check tag failed: %w", err)
+	}
+
+	checkBranchName(cfg, update, &result)
+	checkDeletion(cfg, update, &result)
+
+	if err := checkRefQuota(cfg, update, &result); err != nil {
+		return result, fmt.Errorf("check ref quota failed: %w", err)
+	}
+
+	if err := checkForcePush(cfg, update, &result); err != nil {
+		return result, fmt.Errorf("check force push failed: %w", err)
+	}
+
+	if err := checkCommits(cfg, update, &result); err != nil {
+		return result, fmt.Errorf("check commits failed: %w", err)
+	}
+
+	if err := checkPathOwners(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check path owners failed: %w", err)
+	}
+
+	if err := checkOwnersFiles(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check ownership files failed: %w", err)
+	}
+
+	if err := checkPaths(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check paths failed: %w", err)
+	}
+
+	if err := checkDirectoryEntries(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check directory entries failed: %w", err)
+	}
+
+	if err := checkSymlinks(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check symlinks failed: %w", err)
+	}
+
+	if err := checkExecutables(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check executable files failed: %w", err)
+	}
+
+	if err := checkSubmodules(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check submodules failed: %w", err)
+	}
+
+	if err := checkSubmoduleChanges(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check submodule changes failed: %w", err)
+	}
+
+	if err := checkDuplicates(cfg, logger, update, &result); err != nil {
+		return result, fmt.Errorf("check duplicate content failed: %w", err)
+	}
+
+	// Collect every new blob so that both the per-file and the aggregate limits can be checked
+	newFiles, err := listFiles()
+	if err != nil {
+		return result, fmt.Errorf("run failed: %w", err)
+	}
+	result.NewFiles = newFiles
+
+	var largeFiles, warnFiles []githookkit.FileInfo
+	var totalSize int64 = 0
+	for _, file := range newFiles {
+		totalSize += file.Size
+		if file.Size > sizeLimit {
+			largeFiles = append(largeFiles, file)
+		} else if sizeWarning > 0 && file.Size > sizeWarning {
+			warnFiles = append(warnFiles, file)
+		}
+	}
+
+	var largestFile githookkit.FileInfo
+	if len(largeFiles) > 0 {
+		sortBySize(largeFiles)
+		largestFile = largeFiles[0]
+		logOffenders(logger, cfg, fmt.Sprintf("Found %d large files:", len(largeFiles)), largeFiles)
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: githookkit.FormatSize(sizeLimit), Actual: githookkit.FormatSize(largestFile.Size), Count: len(largeFiles), Files: largeFiles, Largest: largestFile}
+		result.violate(config.RuleFileSize, config.RenderMessage(cfg, config.RuleFileSize, data,
+			locale.T("one or more files exceed maximum size of %s, the largest one is %s, use git lfs!", githookkit.FormatSize(sizeLimit), githookkit.FormatSize(largestFile.Size))), data.Files...)
+	}
+
+	// Files between the two thresholds are accepted, the warning lets users move them to LFS before they hit the limit
+	if len(warnFiles) > 0 {
+		sortBySize(warnFiles)
+		largest := warnFiles[0]
+		logOffenders(logger, cfg, fmt.Sprintf("Found %d files close to the size limit:", len(warnFiles)), warnFiles)
+		result.Warnings = append(result.Warnings, locale.T("%d files are larger than %s, the largest one is %s (%s), files above %s are rejected, consider git lfs",
+			len(warnFiles), githookkit.FormatSize(sizeWarning), largest.Path, githookkit.FormatSize(largest.Size), githookkit.FormatSize(sizeLimit)))
+	}
+
+	logger.Debugf("Push adds %d files, %s in total", len(newFiles), githookkit.FormatSize(totalSize))
+	if pushSizeLimit > 0 && totalSize > pushSizeLimit {
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: githookkit.FormatSize(pushSizeLimit), Actual: githookkit.FormatSize(totalSize), Count: len(newFiles)}
+		result.violate(config.RulePushSize, config.RenderMessage(cfg, config.RulePushSize, data,
+			locale.T("push adds %s in %d files, exceeding the maximum push size of %s, split the push or use git lfs!", githookkit.FormatSize(totalSize), len(newFiles), githookkit.FormatSize(pushSizeLimit))))
+	}
+
+	checkPathBudgets(cfg, logger, update, newFiles, &result)
+
+	if newFileLimit > 0 && len(newFiles) > newFileLimit {
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strconv.Itoa(newFileLimit), Actual: strconv.Itoa(len(newFiles)), Count: len(newFiles)}
+		result.violate(config.RuleNewFiles, config.RenderMessage(cfg, config.RuleNewFiles, data,
+			locale.T("push adds %d new files, exceeding the maximum of %d, are vendored dependencies or generated files included by mistake?", len(newFiles), newFileLimit)))
+	}
+
+	if err := checkGrowthBudget(cfg, logger, update, newFiles, &result); err != nil {
+		return result, fmt.Errorf("check growth budget failed: %w", err)
+	}
+
+	if err := checkUploadQuota(cfg, logger, update, newFiles, &result); err != nil {
+		return result, fmt.Errorf("check upload quota failed: %w", err)
+	}
+
+	// The checks of the file contents share a single read of the new blobs
+	err = checkContent(cfg, logger, update, newFiles, &result, []namedContentCheck{
+		{"scan secrets", secretRule},
+		{"scan viruses", virusRule},
+		{"check binary files", binaryRule},
+		{"check content types", contentTypeRule},
+		{"check archives", archiveRule},
+		{"check images", imageRule},
+		{"check line endings", lineEndingRule},
+		{"check text encoding", encodingRule},
+		{"check text limits", textLimitRule},
+		{"check syntax", syntaxRule},
+		{"check lfs pointers", lfsPointerRule},
+		{"check license headers", licenseHeaderRule},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if err := checkLFSAttributes(cfg, update, &result); err != nil {
+		return result, fmt.Errorf("check .gitattributes failed: %w", err)
+	}
+
+	if err := checkExpressions(cfg, logger, update, newFiles, &result); err != nil {
+		return result, fmt.Errorf("check expression rules failed: %w", err)
+	}
+
+	if err := checkPlugins(cfg, logger, update, newFiles, &result); err != nil {
+		return result, fmt.Errorf("check plugins failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// NewFiles returns the blobs introduced between startCommit and endCommit in the repository of the working directory
+// sizeChecker is an optional function that returns true if the blob should be included based on its size
+func NewFiles(startCommit, endCommit string, sizeChecker func(int64) bool) ([]githookkit.FileInfo, error) {
+	return newFiles(githookkit.Repository{}, startCommit, endCommit, sizeChecker)
+}
+
+// newFiles returns the blobs introduced between startCommit and endCommit in repo, see NewFiles
+func newFiles(repo githookkit.Repository, startCommit, endCommit string, sizeChecker func(int64) bool) ([]githookkit.FileInfo, error) {
+	// Get all objects
+	// Collect all matching file information
+	var results []githookkit.FileInfo
+
+	// branch deletion, return
+	if endCommit == ZeroRev {
+		return results, nil
+	}
+
+	objectChan, err := objectList(repo, startCommit, endCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use GetObjectDetails and size checker to filter objects
+	fileInfoChan, err := repo.GetObjectDetails(objectChan, sizeChecker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object details: %w", err)
+	}
+
+	for fileInfo := range fileInfoChan {
+		// Ensure object has path and size information
+		if fileInfo.Path != "" {
+			results = append(results, fileInfo)
+		}
+	}
+
+	return withoutExistingBlobs(repo, results)
+}
+
+// withoutExistingBlobs removes the files whose blob the repository already had, e.g. brought back by a revert or
+// a cherry-pick, they are not new
+func withoutExistingBlobs(repo githookkit.Repository, files []githookkit.FileInfo) ([]githookkit.FileInfo, error) {
+	objects := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.Hash != "" {
+			objects = append(objects, file.Hash)
+		}
+	}
+	existing, err := repo.ExistingObjects(objects)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		files = slices.DeleteFunc(files, func(file githookkit.FileInfo) bool { return existing[file.Hash] })
+	}
+	return files, nil
+}
+
+// objectList returns the objects between startCommit and endCommit in repo as "<object> <path>" lines
+func objectList(repo githookkit.Repository, startCommit, endCommit string) (<-chan string, error) {
+	count, err := repo.CountCommits(endCommit, startCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get count: %w", err)
+	}
+	assuredStartCommit := fmt.Sprintf("%s~%d", endCommit, count)
+
+	var objectChan <-chan string
+	isOk := repo.VerifyCommit(assuredStartCommit)
+
+	if isOk {
+		objectChan, err = repo.GetSpanObjectList(assuredStartCommit, endCommit, true)
+
+	} else {
+		objectChan, err = repo.GetSingleCommitObjectList(endCommit, true)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object list: %w", err)
+	}
+	return objectChan, nil
+}
+
+// sortBySize sorts files biggest first, files of the same size by path, so that reports don't depend on the order git listed them
+func sortBySize(files []githookkit.FileInfo) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Size != files[j].Size {
+			return files[i].Size > files[j].Size
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// logOffenders logs the heading and the first max_reported_files files, sort them with sortBySize to show the biggest ones
+func logOffenders(logger *config.Logger, cfg config.Config, heading string, files []githookkit.FileInfo) {
+	logFindings(logger, cfg, heading, len(files), func(i int) string {
+		return fmt.Sprintf("Path: %s, Size: %d bytes", files[i].Path, files[i].Size)
+	})
+}
+
+// logFindings logs the heading and the lines of the first max_reported_files of count findings
+func logFindings(logger *config.Logger, cfg config.Config, heading string, count int, line func(i int) string) {
+	reportLimit := config.GetReportLimit(cfg)
+	logger.Infof("%s", heading)
+	for i := 0; i < count; i++ {
+		if reportLimit >= 0 && i >= reportLimit {
+			logger.Infof("  ...and %d more", count-reportLimit)
+			break
+		}
+		logger.Infof("  %s", line(i))
+	}
+}
@@ -0,0 +1,242 @@
+package check
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// newTestLogger returns a logger writing to stderr
+func newTestLogger(t *testing.T) *config.Logger {
+	t.Helper()
+
+	logger, err := config.InitLogger(config.Config{})
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	return logger
+}
+
+// chdir changes into dir for the duration of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change to test repository directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+}
+
+func TestCheck(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096, "small.txt": 100})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	warn := config.ModeWarn
+	limit := int64(1024)
+	overrides := map[string]config.Override{"large-files-ok": {Rules: []string{config.RuleFileSize}, Users: []string{"alice"}}}
+
+	tests := []struct {
+		name         string
+		cfg          config.Config
+		update       Update
+		wantCount    int
+		wantRejected bool
+		wantMessage  string
+	}{
+		{
+			name:   "Within limits",
+			cfg:    config.Config{},
+			update: Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+		},
+		{
+			name:         "File size limit",
+			cfg:          config.Config{Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &limit}}},
+			update:       Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+			wantCount:    1,
+			wantRejected: true,
+			wantMessage:  "one or more files exceed maximum size of 1.00 KB",
+		},
+		{
+			name:        "Warn mode",
+			cfg:         config.Config{Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &limit, Mode: &warn}}},
+			update:      Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+			wantCount:   1,
+			wantMessage: "one or more files exceed maximum size of 1.00 KB",
+		},
+		{
+			name:         "Push size and new file limits",
+			cfg:          config.Config{MaxPushSize: 2048, MaxNewFiles: 1},
+			update:       Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+			wantCount:    2,
+			wantRejected: true,
+			wantMessage:  "push adds 2 new files, exceeding the maximum of 1",
+		},
+		{
+			name:   "Override bypasses the rule",
+			cfg:    config.Config{Overrides: overrides, Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &limit}}},
+			update: Update{Project: "p", UploaderUsername: "alice", PushOptions: []string{"large-files-ok=JIRA-1"}, OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+		},
+		{
+			name:         "Override only bypasses its rules",
+			cfg:          config.Config{Overrides: overrides, MaxNewFiles: 1, Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &limit}}},
+			update:       Update{Project: "p", UploaderUsername: "alice", PushOptions: []string{"large-files-ok=JIRA-1"}, OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+			wantCount:    1,
+			wantRejected: true,
+			wantMessage:  "push adds 2 new files",
+		},
+		{
+			name:         "Override denied to other users",
+			cfg:          config.Config{Overrides: overrides, Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &limit}}},
+			update:       Update{Project: "p", UploaderUsername: "bob", PushOptions: []string{"large-files-ok=JIRA-1"}, OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+			wantCount:    1,
+			wantRejected: true,
+			wantMessage:  "one or more files exceed maximum size of 1.00 KB",
+		},
+		{
+			name:   "Skipped ref",
+			cfg:    config.Config{MaxNewFiles: 1},
+			update: Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/changes/01/1/1"},
+		},
+		{
+			name:   "Branch deletion",
+			cfg:    config.Config{MaxNewFiles: 1},
+			update: Update{Project: "p", OldRev: newRev, NewRev: ZeroRev, RefName: "refs/heads/master"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(tt.cfg, logger, tt.update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != tt.wantCount {
+				t.Errorf("Check() returned %d violations, expected %d: %v", len(result.Violations), tt.wantCount, result.Violations)
+			}
+			if result.Rejected() != tt.wantRejected {
+				t.Errorf("Rejected() = %v, expected %v", result.Rejected(), tt.wantRejected)
+			}
+			if tt.wantMessage != "" && !strings.Contains(strings.Join(result.Violations, "\n"), tt.wantMessage) {
+				t.Errorf("violations %v do not contain %q", result.Violations, tt.wantMessage)
+			}
+		})
+	}
+
+	t.Run("Size warning", func(t *testing.T) {
+		warning := int64(1024)
+		cfg := config.Config{Projects: map[string]config.ProjectPolicy{"p": {
+			SizeWarning:   &warning,
+			RefSizeLimits: []config.RefSizeLimit{{Ref: "refs/heads/release/*", Warn: 50, Max: 1024}},
+		}}}
+
+		result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		expected := "1 files are larger than 1.00 KB, the largest one is large.bin (4.00 KB), files above 5.00 MB are rejected"
+		if len(result.Violations) != 0 || len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], expected) {
+			t.Errorf("Check() returned violations %v and warnings %v, expected the warning %q", result.Violations, result.Warnings, expected)
+		}
+
+		result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/release/1.0"})
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		expected = "1 files are larger than 50 B, the largest one is small.txt (100 B), files above 1.00 KB are rejected"
+		if len(result.Violations) != 1 || len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], expected) {
+			t.Errorf("Check() returned violations %v and warnings %v, expected the large file and the warning %q", result.Violations, result.Warnings, expected)
+		}
+	})
+
+	t.Run("Findings", func(t *testing.T) {
+		small := int64(50)
+		cfg := config.Config{
+			Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &small}},
+			DCO:      config.DCOPolicy{Refs: []string{"refs/heads/*"}},
+		}
+
+		result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		// A violation of several files or commits gives one finding per file or commit
+		found := map[string]githookkit.Violation{}
+		for _, violation := range result.Findings {
+			found[violation.RuleID+" "+violation.Path+violation.Commit] = violation
+		}
+		if violation := found[config.RuleFileSize+" large.bin"]; violation.Size != 4096 || violation.Severity != githookkit.SeverityError {
+			t.Errorf("Findings %+v, expected large.bin with 4096 bytes as an error", result.Findings)
+		}
+		if _, ok := found[config.RuleFileSize+" small.txt"]; !ok {
+			t.Errorf("Findings %+v, expected small.txt", result.Findings)
+		}
+		if _, ok := found[config.RuleDCO+" "+newRev]; !ok {
+			t.Errorf("Findings %+v, expected commit %s without sign-off", result.Findings, newRev)
+		}
+	})
+
+	t.Run("Invalid revision", func(t *testing.T) {
+		_, err := Check(config.Config{}, logger, Update{OldRev: "invalid-hash", NewRev: newRev, RefName: "refs/heads/master"})
+		if err == nil {
+			t.Errorf("Check() should return error for an invalid revision")
+		}
+	})
+}
+
+func TestSortBySize(t *testing.T) {
+	files := []githookkit.FileInfo{{Path: "b.bin", Size: 10}, {Path: "small.txt", Size: 1}, {Path: "huge.iso", Size: 100}, {Path: "a.bin", Size: 10}}
+	sortBySize(files)
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	if strings.Join(paths, " ") != "huge.iso a.bin b.bin small.txt" {
+		t.Errorf("sortBySize() = %v, expected biggest first and ties by path", paths)
+	}
+}
+
+func TestNewFilesSkipsExistingBlobs(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	testutil.CommitContents(t, dir, map[string]string{"restored.txt": "restored content"}, "add restored.txt")
+	testutil.Git(t, dir, "rm", "-q", "restored.txt")
+	testutil.Git(t, dir, "commit", "-q", "-m", "remove restored.txt")
+	oldRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+
+	// receive-pack writes the pushed objects into a quarantine, with the object store of the repository as alternate
+	quarantine := t.TempDir()
+	t.Setenv("GIT_OBJECT_DIRECTORY", quarantine)
+	t.Setenv("GIT_ALTERNATE_OBJECT_DIRECTORIES", dir+"/.git/objects")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"restored.txt": "restored content", "new.txt": "new content"}, "restore restored.txt")
+	chdir(t, dir)
+
+	files, err := NewFiles(oldRev, newRev, nil)
+	if err != nil {
+		t.Fatalf("NewFiles() returned error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("NewFiles() outside of a quarantine returned %v, expected both files", files)
+	}
+
+	t.Setenv("GIT_QUARANTINE_PATH", quarantine)
+	files, err = NewFiles(oldRev, newRev, nil)
+	if err != nil {
+		t.Fatalf("NewFiles() returned error: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "new.txt" {
+		t.Errorf("NewFiles() returned %v, expected only new.txt", files)
+	}
+}
@@ -0,0 +1,235 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+)
+
+// commitRule checks one aspect of the pushed commits
+type commitRule struct {
+	id    string                                  // Rule ID
+	what  string                                  // Describes the offending commits, e.g. "with invalid messages"
+	check func(commit githookkit.Commit) []string // Returns what is wrong with a commit
+}
+
+// checkCommits applies the rules on the individual commits of an update
+// The commits are only listed when one of the rules applies to the ref
+func checkCommits(cfg config.Config, update Update, result *Result) error {
+	if update.NewRev == ZeroRev {
+		return nil
+	}
+
+	rules, err := commitRules(cfg, update, result)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	commits, err := update.Repository.GetCommits(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	reportLimit := config.GetReportLimit(cfg)
+	for _, rule := range rules {
+		invalid := 0
+		for _, commit := range commits {
+			problems := rule.check(commit)
+			if len(problems) == 0 {
+				continue
+			}
+
+			invalid++
+			if reportLimit >= 0 && invalid > reportLimit {
+				continue
+			}
+			data := config.MessageData{Project: update.Project, RefName: update.RefName, Commit: shortHash(commit.Hash), Actual: strings.Join(problems, ", ")}
+			result.violateCommit(rule.id, config.RenderMessage(cfg, rule.id, data,
+				fmt.Sprintf("commit %s (%s): %s!", data.Commit, subject(commit.Message), data.Actual)), commit.Hash)
+		}
+
+		if reportLimit >= 0 && invalid > reportLimit {
+			result.violate(rule.id, fmt.Sprintf("...and %d more commits %s, fix them with git rebase -i!", invalid-reportLimit, rule.what))
+		}
+	}
+	return nil
+}
+
+// commitRules returns the commit rules that apply to the ref of an update, rules may add warnings to result
+func commitRules(cfg config.Config, update Update, result *Result) ([]commitRule, error) {
+	var rules []commitRule
+
+	if messageRule, ok := config.GetCommitMessageRule(cfg, update.RefName); ok {
+		subjectPattern, err := regexp.Compile(messageRule.SubjectPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject_pattern: %w", err)
+		}
+		rules = append(rules, commitRule{config.RuleCommitMessage, "with invalid messages", func(commit githookkit.Commit) []string {
+			return commitMessageProblems(messageRule, subjectPattern, commit.Message)
+		}})
+	}
+
+	if trailers := config.GetRequiredTrailers(cfg, update.RefName); len(trailers) > 0 {
+		rules = append(rules, commitRule{config.RuleTrailer, "with missing trailers", func(commit githookkit.Commit) []string {
+			return missingTrailers(trailers, commit.Message)
+		}})
+	}
+
+	if issueRule, ok := config.GetIssueReferenceRule(cfg, update.RefName); ok {
+		checker, err := newIssueChecker(cfg, issueRule, result)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, commitRule{config.RuleIssue, "without issue reference", func(commit githookkit.Commit) []string {
+			return checker.problems(commit.Message)
+		}})
+	}
+
+	if config.IsDCORequired(cfg, update.RefName) {
+		var uploaderEmails []string
+		if cfg.DCO.UploaderEmails && update.UploaderUsername != "" {
+			emails, err := gerrit.AccountEmails(cfg.Gerrit, update.UploaderUsername)
+			if err != nil {
+				return nil, err
+			}
+			uploaderEmails = emails
+		}
+		rules = append(rules, commitRule{config.RuleDCO, "without sign-off", func(commit githookkit.Commit) []string {
+			return dcoProblems(commit, uploaderEmails)
+		}})
+	}
+
+	if cfg.MaxParents > 0 {
+		rules = append(rules, commitRule{config.RuleParents, "with too many parents", func(commit githookkit.Commit) []string {
+			return parentProblems(commit, cfg.MaxParents)
+		}})
+	}
+
+	if config.IsLinearHistoryRequired(cfg, update.RefName) {
+		rules = append(rules, commitRule{config.RuleLinearHistory, "merging other branches", mergeProblems})
+	}
+
+	if config.IsEmptyCommitDenied(cfg, update.RefName) {
+		empty, err := update.Repository.GetEmptyCommits(update.NewRev, update.OldRev)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, commitRule{config.RuleEmptyCommit, "without changes", func(commit githookkit.Commit) []string {
+			return emptyCommitProblems(commit, empty)
+		}})
+	}
+
+	if config.IsRootCommitDenied(cfg, update.RefName) {
+		// The first push to an empty repository has to start the history
+		refs, err := update.Repository.GetRefs()
+		if err != nil {
+			return nil, err
+		}
+		if len(refs) > 0 {
+			rules = append(rules, commitRule{config.RuleRootCommit, "without parents", rootCommitProblems})
+		}
+	}
+
+	if dates := cfg.CommitDates; dates.MaxFuture > 0 || dates.MaxAge > 0 {
+		now := time.Now()
+		rules = append(rules, commitRule{config.RuleCommitDate, "with implausible dates", func(commit githookkit.Commit) []string {
+			return commitDateProblems(commit, dates, now)
+		}})
+	}
+
+	if config.IsSignatureRequired(cfg, update.RefName) {
+		keys := githookkit.SignatureKeys{GPGHome: cfg.CommitSignatures.GPGHome, AllowedSigners: cfg.CommitSignatures.AllowedSigners}
+		signatures, err := update.Repository.GetSignatures(update.NewRev, update.OldRev, keys)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, commitRule{config.RuleSignature, "without trusted signature", func(commit githookkit.Commit) []string {
+			return signatureProblems(signatures[commit.Hash])
+		}})
+	}
+
+	if config.IsUploaderIdentityRequired(cfg, update.RefName) {
+		emails, err := uploaderEmails(cfg, update)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, commitRule{config.RuleIdentity, "of other users", func(commit githookkit.Commit) []string {
+			return identityProblems(commit, cfg.UploaderIdentity.Match, emails)
+		}})
+	}
+
+	if len(cfg.BlockedIdentities) > 0 {
+		rules = append(rules, commitRule{config.RuleBlockedIdentity, "of blocked identities", func(commit githookkit.Commit) []string {
+			return blockedIdentityProblems(commit, cfg.BlockedIdentities)
+		}})
+	}
+
+	if domains := config.GetEmailDomains(cfg, update.Project); len(domains) > 0 {
+		rules = append(rules, commitRule{config.RuleEmailDomain, "with disallowed emails", func(commit githookkit.Commit) []string {
+			return emailDomainProblems(commit, domains)
+		}})
+	}
+
+	return rules, nil
+}
+
+// commitMessageProblems returns what is wrong with a commit message according to rule
+func commitMessageProblems(rule config.CommitMessageRule, subjectPattern *regexp.Regexp, message string) []string {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	subject := lines[0]
+
+	var problems []string
+	if length := utf8.RuneCountInString(subject); rule.SubjectMaxLength > 0 && length > rule.SubjectMaxLength {
+		problems = append(problems, fmt.Sprintf("subject is %d characters long, the maximum is %d", length, rule.SubjectMaxLength))
+	}
+	if rule.SubjectPattern != "" && !subjectPattern.MatchString(subject) {
+		problems = append(problems, fmt.Sprintf("subject doesn't match %s", rule.SubjectPattern))
+	}
+	if rule.DenyAutosquash {
+		for _, prefix := range []string{"fixup! ", "squash! ", "amend! "} {
+			if strings.HasPrefix(subject, prefix) {
+				problems = append(problems, fmt.Sprintf("%scommits must be squashed before pushing", prefix))
+			}
+		}
+	}
+
+	body := lines[1:]
+	if len(body) > 0 && strings.TrimSpace(body[0]) != "" {
+		if rule.BlankLineAfterSubject {
+			problems = append(problems, "subject is not followed by a blank line")
+		}
+	} else if len(body) > 0 {
+		body = body[1:]
+	}
+
+	if rule.BodyMaxLineLength > 0 {
+		offset := len(lines) - len(body)
+		for i, line := range body {
+			if length := utf8.RuneCountInString(line); length > rule.BodyMaxLineLength && strings.ContainsAny(line, " \t") {
+				problems = append(problems, fmt.Sprintf("line %d is %d characters long, the maximum is %d", offset+i+1, length, rule.BodyMaxLineLength))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// subject returns the first line of a commit message
+func subject(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
+
+// shortHash abbreviates a commit hash for messages
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
@@ -0,0 +1,89 @@
+package check
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCommitMessageProblems(t *testing.T) {
+	rule := config.CommitMessageRule{
+		SubjectMaxLength:      30,
+		SubjectPattern:        `^[A-Z][a-z]+ `,
+		BodyMaxLineLength:     40,
+		BlankLineAfterSubject: true,
+		DenyAutosquash:        true,
+	}
+	subjectPattern := regexp.MustCompile(rule.SubjectPattern)
+
+	tests := []struct {
+		name     string
+		message  string
+		expected []string
+	}{
+		{"Valid", "Add parser\n\nThe parser reads the config.\n", nil},
+		{"Subject only", "Add parser\n", nil},
+		{"Long subject", "Add a parser for the configuration file\n", []string{"subject is 39 characters long, the maximum is 30"}},
+		{"Pattern", "added parser\n", []string{"subject doesn't match ^[A-Z][a-z]+ "}},
+		{"Autosquash", "fixup! Add parser\n", []string{"subject doesn't match ^[A-Z][a-z]+ ", "fixup! commits must be squashed before pushing"}},
+		{"No blank line", "Add parser\nThe parser reads the config.\n", []string{"subject is not followed by a blank line"}},
+		{"Long body line", "Add parser\n\nShort line\nThis line of the body is longer than forty characters.\n", []string{"line 4 is 54 characters long, the maximum is 40"}},
+		{"Long URL", "Add parser\n\nSee https://example.com/a/very/long/link/that/can/not/be/wrapped\n", []string{"line 3 is 64 characters long, the maximum is 40"}},
+		{"URL only", "Add parser\n\nhttps://example.com/a/very/long/link/that/can/not/be/wrapped\n", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := commitMessageProblems(rule, subjectPattern, test.message)
+			if strings.Join(problems, "|") != strings.Join(test.expected, "|") {
+				t.Errorf("commitMessageProblems() = %q, expected %q", problems, test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckCommitMessages(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "fixup! Add a")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"c.txt": "c"}, "Add c with a subject that is far too long to be read")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{CommitMessages: []config.CommitMessageRule{
+		{Ref: "refs/heads/master", SubjectMaxLength: 50, DenyAutosquash: true},
+		{Ref: "refs/heads/*", SubjectMaxLength: 50},
+	}}
+
+	tests := []struct {
+		refName  string
+		expected []string
+	}{
+		{"refs/heads/master", []string{"(fixup! Add a): fixup! commits must be squashed before pushing!", "(Add c with a subject that is far too long to be read): subject is 52 characters long, the maximum is 50!"}},
+		{"refs/heads/topic", []string{"subject is 52 characters long"}},
+		{"refs/tags/v1.0", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.refName, func(t *testing.T) {
+			result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: test.refName})
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != len(test.expected) {
+				t.Fatalf("Check() returned violations %v, expected %d", result.Violations, len(test.expected))
+			}
+			// Commits are reported newest first
+			for i, expected := range test.expected {
+				j := len(test.expected) - 1 - i
+				if !strings.Contains(result.Violations[j], expected) {
+					t.Errorf("violation %q does not contain %q", result.Violations[j], expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// contentRule is a check of the content of the new blobs, the blobs of all content rules are read in a single pass
+type contentRule struct {
+	objects []string                            // Blobs read by the rule
+	limit   int64                               // Bytes of each blob passed to read, 0 = the whole content
+	read    func(object string, content []byte) // Called for each blob of objects, in order
+	report  func() error                        // Called once all blobs are read
+	done    func()                              // Optional, called once the reading stops, also when it failed
+}
+
+// contentCheck returns the content rule of a check, nil when the check is disabled for the update
+type contentCheck func(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error)
+
+// namedContentCheck is a content check with the name used in its errors
+type namedContentCheck struct {
+	name  string
+	check contentCheck
+}
+
+// checkContent runs checks reading each new blob once for all of them, the results are reported in the order of checks
+func checkContent(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result, checks []namedContentCheck) error {
+	var rules []*contentRule
+	var names []string
+	for _, check := range checks {
+		rule, err := check.check(cfg, logger, update, newFiles, result)
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", check.name, err)
+		}
+		if rule != nil {
+			rules = append(rules, rule)
+			names = append(names, check.name)
+		}
+	}
+
+	// A blob read by several rules is read up to the largest limit, each rule gets its own part of it
+	var objects []string
+	readers := map[string][]*contentRule{}
+	limits := map[string]int64{}
+	for _, rule := range rules {
+		for _, object := range rule.objects {
+			limit, exists := limits[object]
+			if !exists {
+				objects = append(objects, object)
+			}
+			if !exists || (limit > 0 && (rule.limit == 0 || rule.limit > limit)) {
+				limits[object] = rule.limit
+			}
+			readers[object] = append(readers[object], rule)
+		}
+	}
+
+	err := update.Repository.ReadBlobSamples(objects, func(object string) int64 { return limits[object] }, func(object string, content []byte) error {
+		for _, rule := range readers[object] {
+			if rule.limit > 0 && int64(len(content)) > rule.limit {
+				rule.read(object, content[:rule.limit])
+			} else {
+				rule.read(object, content)
+			}
+		}
+		return nil
+	})
+	for _, rule := range rules {
+		if rule.done != nil {
+			rule.done()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("read new files failed: %w", err)
+	}
+
+	for i, rule := range rules {
+		if err := rule.report(); err != nil {
+			return fmt.Errorf("%s failed: %w", names[i], err)
+		}
+	}
+	return nil
+}
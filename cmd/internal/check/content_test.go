@@ -0,0 +1,64 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckContent(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "readme\n"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"large.txt": strings.Repeat("x", 1000),
+		"small.txt": "small\n",
+	}, "Add files")
+	chdir(t, dir)
+
+	newFiles, err := NewFiles(oldRev, newRev, nil)
+	if err != nil {
+		t.Fatalf("NewFiles() returned error: %v", err)
+	}
+
+	// Each rule records the paths and sizes it was given, and the order of the reports
+	var events []string
+	rule := func(name string, limit int64, skip func(githookkit.FileInfo) bool) contentCheck {
+		return func(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+			objects, paths := githookkit.GroupBlobs(newFiles, skip)
+			read := func(object string, content []byte) {
+				for _, file := range paths[object] {
+					events = append(events, name+" read "+file.Path+" "+githookkit.FormatSize(int64(len(content))))
+				}
+			}
+			report := func() error {
+				events = append(events, name+" report")
+				return nil
+			}
+			return &contentRule{objects: objects, limit: limit, read: read, report: report}, nil
+		}
+	}
+	disabled := func(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+		return nil, nil
+	}
+
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+	var result Result
+	err = checkContent(config.Config{}, newTestLogger(t), update, newFiles, &result, []namedContentCheck{
+		{"sample", rule("sample", 10, nil)},
+		{"disabled", disabled},
+		{"whole", rule("whole", 0, func(file githookkit.FileInfo) bool { return file.Path == "small.txt" })},
+	})
+	if err != nil {
+		t.Fatalf("checkContent() returned error: %v", err)
+	}
+
+	// large.txt is read once for both rules, each getting its own part of it
+	expected := []string{"sample read large.txt 10 B", "whole read large.txt 1000 B", "sample read small.txt 6 B", "sample report", "whole report"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("checkContent() made events %q, expected %q", events, expected)
+	}
+}
@@ -0,0 +1,90 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// contentTypeSniffSize is the number of bytes http.DetectContentType looks at
+const contentTypeSniffSize = 512
+
+// magicTypes are signatures http.DetectContentType doesn't know, mostly executables and archives
+var magicTypes = []struct {
+	magic    string
+	mimeType string
+}{
+	{"\x7fELF", "application/x-executable"},
+	{"\xfe\xed\xfa\xce", "application/x-mach-binary"},
+	{"\xfe\xed\xfa\xcf", "application/x-mach-binary"},
+	{"\xce\xfa\xed\xfe", "application/x-mach-binary"},
+	{"\xcf\xfa\xed\xfe", "application/x-mach-binary"},
+	{"\xca\xfe\xba\xbe", "application/java-vm"}, // Also universal Mach-O binaries
+	{"7z\xbc\xaf\x27\x1c", "application/x-7z-compressed"},
+	{"\xfd7zXZ\x00", "application/x-xz"},
+	{"BZh", "application/x-bzip2"},
+	{"\x28\xb5\x2f\xfd", "application/zstd"},
+	{"SQLite format 3\x00", "application/vnd.sqlite3"},
+}
+
+// contentTypeRule rejects updates adding files of denied content types outside of the allowed paths
+func contentTypeRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetContentTypePolicy(cfg, update.Project)
+	if len(policy.Deny) == 0 {
+		return nil, nil
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		return matchAny(policy.AllowedPaths, file.Path)
+	})
+
+	var files []githookkit.FileInfo
+	var types []string
+	read := func(object string, content []byte) {
+		if mimeType := detectContentType(content); matchAny(policy.Deny, mimeType) {
+			for _, file := range paths[object] {
+				files = append(files, file)
+				types = append(types, mimeType)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d files of denied content types:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s, Type: %s", files[i].Path, types[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(policy.Deny, ", "), Actual: types[0], Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleContentType, config.RenderMessage(cfg, config.RuleContentType, data,
+			fmt.Sprintf("push adds %d files of denied content types, the first one is %s (%s), use an artifact repository!", len(files), files[0].Path, types[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: contentTypeSniffSize, read: read, report: report}, nil
+}
+
+// detectContentType returns the MIME type of a file from its first bytes, without parameters like charset
+func detectContentType(sample []byte) string {
+	for _, magic := range magicTypes {
+		if bytes.HasPrefix(sample, []byte(magic.magic)) {
+			return magic.mimeType
+		}
+	}
+	// Windows executables only start with MZ, text starting with MZ isn't one
+	if bytes.HasPrefix(sample, []byte("MZ")) && isBinary(sample) {
+		return "application/vnd.microsoft.portable-executable"
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(sample))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mimeType
+}
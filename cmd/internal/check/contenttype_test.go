@@ -0,0 +1,76 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// mp4Header is the start of an MP4 video, an ftyp box with the isom brand
+const mp4Header = "\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2avc1mp41"
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		sample   string
+		expected string
+	}{
+		{"text", "hello world\n", "text/plain"},
+		{"elf", "\x7fELF\x02\x01\x01\x00", "application/x-executable"},
+		{"windows executable", "MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00", "application/vnd.microsoft.portable-executable"},
+		{"text starting with MZ", "MZ is a text file", "text/plain"},
+		{"mp4", mp4Header, "video/mp4"},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR", "image/png"},
+		{"sqlite", "SQLite format 3\x00\x10\x00", "application/vnd.sqlite3"},
+		{"binary", "\x00\x01\x02\x03", "application/octet-stream"},
+	}
+
+	for _, test := range tests {
+		if mimeType := detectContentType([]byte(test.sample)); mimeType != test.expected {
+			t.Errorf("detectContentType(%s) = %q, expected %q", test.name, mimeType, test.expected)
+		}
+	}
+}
+
+func TestCheckContentTypes(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	// Renaming a video doesn't hide it
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"docs/big.txt":     mp4Header + strings.Repeat("\x00", 100),
+		"media/intro.mp4":  mp4Header,
+		"tools/helper.txt": "\x7fELF\x02\x01\x01\x00",
+		"docs/guide.md":    "# Guide",
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		policy   config.ContentTypePolicy
+		expected string
+	}{
+		{"no policy", config.ContentTypePolicy{}, ""},
+		{"videos", config.ContentTypePolicy{Deny: []string{"video/*"}, AllowedPaths: []string{"media/*"}}, "push adds 1 files of denied content types, the first one is docs/big.txt (video/mp4)"},
+		{"executables", config.ContentTypePolicy{Deny: []string{"application/x-executable", "video/*"}}, "push adds 3 files of denied content types"},
+		{"text", config.ContentTypePolicy{Deny: []string{"image/*"}}, ""},
+	}
+
+	for _, test := range tests {
+		result, err := Check(config.Config{ContentTypes: test.policy}, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		if test.expected == "" {
+			if len(result.Violations) != 0 {
+				t.Errorf("%s: Check() returned violations %v, expected none", test.name, result.Violations)
+			}
+			continue
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.expected) {
+			t.Errorf("%s: Check() returned violations %v, expected %q", test.name, result.Violations, test.expected)
+		}
+	}
+}
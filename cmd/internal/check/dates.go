@@ -0,0 +1,29 @@
+package check
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// commitDateProblems returns a problem for each author or committer date outside of the plausible range around now
+func commitDateProblems(commit githookkit.Commit, dates config.CommitDates, now time.Time) []string {
+	var problems []string
+	for _, date := range []struct {
+		role string
+		time time.Time
+	}{
+		{config.IdentityAuthor, commit.AuthorDate},
+		{config.IdentityCommitter, commit.CommitterDate},
+	} {
+		switch {
+		case dates.MaxFuture > 0 && date.time.After(now.Add(dates.MaxFuture)):
+			problems = append(problems, fmt.Sprintf("%s date %s is in the future, check the clock", date.role, date.time.UTC().Format(time.RFC3339)))
+		case dates.MaxAge > 0 && date.time.Before(now.Add(-dates.MaxAge)):
+			problems = append(problems, fmt.Sprintf("%s date %s is older than %s", date.role, date.time.UTC().Format(time.RFC3339), dates.MaxAge))
+		}
+	}
+	return problems
+}
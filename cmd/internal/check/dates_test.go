@@ -0,0 +1,60 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCommitDateProblems(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dates := config.CommitDates{MaxFuture: time.Hour, MaxAge: 365 * 24 * time.Hour}
+
+	tests := []struct {
+		name      string
+		author    time.Time
+		committer time.Time
+		expected  []string
+	}{
+		{"Plausible", now.Add(-48 * time.Hour), now, nil},
+		{"Small clock skew", now.Add(30 * time.Minute), now.Add(30 * time.Minute), nil},
+		{"Future committer", now, now.Add(48 * time.Hour), []string{"committer date 2024-06-03T12:00:00Z is in the future, check the clock"}},
+		{"Old author", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), now, []string{"author date 1970-01-01T00:00:00Z is older than 8760h0m0s"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commit := githookkit.Commit{AuthorDate: test.author, CommitterDate: test.committer}
+			if problems := commitDateProblems(commit, dates, now); !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("commitDateProblems() = %v, expected %v", problems, test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckCommitDates(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	future := time.Now().Add(72 * time.Hour).Format(time.RFC3339)
+	t.Setenv("GIT_AUTHOR_DATE", future)
+	t.Setenv("GIT_COMMITTER_DATE", future)
+	newRev := testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "Add b")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{CommitDates: config.CommitDates{MaxFuture: time.Hour}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add b): author date") || !strings.Contains(result.Violations[0], "committer date") {
+		t.Errorf("Check() returned violations %v, expected the future dates of Add b", result.Violations)
+	}
+}
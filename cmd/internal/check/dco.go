@@ -0,0 +1,41 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+)
+
+// signOffEmails returns the email addresses of the Signed-off-by trailers of a commit message
+func signOffEmails(message string) []string {
+	var emails []string
+	for _, trailer := range parseTrailers(message) {
+		if !strings.EqualFold(trailer.Key, "Signed-off-by") {
+			continue
+		}
+		if email := parseEmail(trailer.Value); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// dcoProblems returns a problem when the commit isn't signed off by its author
+// Sign-offs with one of uploaderEmails are accepted too, for uploaders pushing commits they didn't author
+func dcoProblems(commit githookkit.Commit, uploaderEmails []string) []string {
+	signOffs := signOffEmails(commit.Message)
+	accepted := append([]string{commit.AuthorEmail}, uploaderEmails...)
+	for _, email := range signOffs {
+		for _, candidate := range accepted {
+			if strings.EqualFold(email, candidate) {
+				return nil
+			}
+		}
+	}
+
+	if len(signOffs) == 0 {
+		return []string{"missing Signed-off-by trailer, add it with git commit --amend -s"}
+	}
+	return []string{fmt.Sprintf("no Signed-off-by matches the author %s", commit.AuthorEmail)}
+}
@@ -0,0 +1,101 @@
+package check
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestDCOProblems(t *testing.T) {
+	tests := []struct {
+		name           string
+		message        string
+		uploaderEmails []string
+		expected       []string
+	}{
+		{"Signed off by the author", "Add a\n\nSigned-off-by: Alice <ALICE@example.com>\n", nil, nil},
+		{"Not signed off", "Add a\n", nil, []string{"missing Signed-off-by trailer, add it with git commit --amend -s"}},
+		{"Signed off by someone else", "Add a\n\nSigned-off-by: Bob <bob@example.com>\n", nil, []string{"no Signed-off-by matches the author alice@example.com"}},
+		{"Signed off by the uploader", "Add a\n\nSigned-off-by: Bob <bob@example.com>\n", []string{"bob@example.com"}, nil},
+		{"Sign-off in the body", "Add a\n\nSigned-off-by: Alice <alice@example.com>\n\nMore text.\n", nil, []string{"missing Signed-off-by trailer, add it with git commit --amend -s"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commit := githookkit.Commit{AuthorEmail: "alice@example.com", Message: test.message}
+			if problems := dcoProblems(commit, test.uploaderEmails); !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("dcoProblems() = %v, expected %v", problems, test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckDCO(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/a/accounts/uploader/emails" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(")]}'\n[{\"email\":\"uploader@example.com\"}]"))
+	}))
+	defer server.Close()
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a\n\nSigned-off-by: Test User <test@example.com>")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "Add b\n\nSigned-off-by: Uploader <uploader@example.com>")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	update := Update{Project: "p", UploaderUsername: "uploader", OldRev: oldRev, NewRev: newRev, RefName: "refs/for/master"}
+
+	t.Run("Author sign-off required", func(t *testing.T) {
+		cfg := config.Config{DCO: config.DCOPolicy{Refs: []string{"refs/for/*"}}}
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add b): no Signed-off-by matches the author test@example.com!") {
+			t.Errorf("Check() returned violations %v, expected the missing sign-off of Add b", result.Violations)
+		}
+	})
+
+	t.Run("Uploader sign-off accepted", func(t *testing.T) {
+		cfg := config.Config{DCO: config.DCOPolicy{Refs: []string{"refs/for/*"}, UploaderEmails: true}, Gerrit: config.GerritConfig{URL: server.URL}}
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("Check() returned violations %v, expected none", result.Violations)
+		}
+	})
+
+	t.Run("Unknown uploader", func(t *testing.T) {
+		cfg := config.Config{DCO: config.DCOPolicy{Refs: []string{"refs/for/*"}, UploaderEmails: true}, Gerrit: config.GerritConfig{URL: server.URL}}
+		unknown := update
+		unknown.UploaderUsername = "nobody"
+		if _, err := Check(cfg, logger, unknown); err == nil {
+			t.Errorf("Check() should return error when the uploader emails can't be read")
+		}
+	})
+
+	t.Run("Other refs", func(t *testing.T) {
+		cfg := config.Config{DCO: config.DCOPolicy{Refs: []string{"refs/for/*"}}}
+		heads := update
+		heads.RefName = "refs/heads/master"
+		result, err := Check(cfg, logger, heads)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("Check() returned violations %v, expected none", result.Violations)
+		}
+	})
+}
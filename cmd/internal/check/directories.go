@@ -0,0 +1,117 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkDirectoryEntries rejects updates adding entries to directories that end up with too many of them
+// Directories that were already too large are only reported when the push adds to them
+func checkDirectoryEntries(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	limit := config.GetPathPolicy(cfg, update.Project).MaxDirectoryEntries
+	if limit == 0 || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	added, err := update.Repository.GetAddedPaths(update.NewRev, update.OldRev)
+	if err != nil || len(added) == 0 {
+		return err
+	}
+	paths, err := update.Repository.ListTree(update.NewRev)
+	if err != nil {
+		return err
+	}
+	base, err := basePrefixes(update)
+	if err != nil {
+		return err
+	}
+
+	// Every distinct prefix of a path is an entry of its parent directory, "." is the top level
+	entries := map[string]int{}
+	seen := map[string]bool{}
+	for _, file := range paths {
+		for end := 0; end < len(file); end++ {
+			end = nextSeparator(file, end)
+			if prefix := file[:end]; !seen[prefix] {
+				seen[prefix] = true
+				entries[path.Dir(prefix)]++
+			}
+		}
+	}
+
+	// A directory grew when an added path brought a new entry into it
+	grown := map[string]bool{}
+	for _, file := range added {
+		for end := 0; end < len(file); end++ {
+			end = nextSeparator(file, end)
+			if prefix := file[:end]; seen[prefix] && !base[prefix] {
+				grown[path.Dir(prefix)] = true
+			}
+		}
+	}
+
+	var directories []string
+	for directory := range grown {
+		if entries[directory] > limit {
+			directories = append(directories, directory)
+		}
+	}
+	if len(directories) == 0 {
+		return nil
+	}
+	sort.Strings(directories)
+	var files []githookkit.FileInfo
+	var problems []string
+	for _, directory := range directories {
+		name := directory + "/"
+		if directory == "." {
+			name = "/"
+		}
+		files = append(files, githookkit.FileInfo{Path: name})
+		problems = append(problems, fmt.Sprintf("%d entries", entries[directory]))
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d directories with more than %d entries:", len(files), limit), len(files), func(i int) string {
+		return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: fmt.Sprint(limit), Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+	result.violate(config.RuleDirectoryEntries, config.RenderMessage(cfg, config.RuleDirectoryEntries, data,
+		fmt.Sprintf("push adds to %d directories with more than %d entries, the first one is %s (%s), is generated output committed by mistake?", len(files), limit, files[0].Path, problems[0])), data.Files...)
+	return nil
+}
+
+// basePrefixes returns the prefixes of the paths the update builds on: the old revision, or for new refs and
+// changes for review the target branch when it exists
+func basePrefixes(update Update) (map[string]bool, error) {
+	base := update.OldRev
+	if base == ZeroRev {
+		target := update.RefName
+		if branch, ok := strings.CutPrefix(target, "refs/for/"); ok {
+			target = "refs/heads/" + branch
+		}
+		refs, err := update.Repository.GetRefs(target)
+		if err != nil || !config.Contains(refs, target) {
+			return map[string]bool{}, err
+		}
+		base = target
+	}
+
+	paths, err := update.Repository.ListTree(base)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := map[string]bool{}
+	for _, file := range paths {
+		for end := 0; end < len(file); end++ {
+			end = nextSeparator(file, end)
+			prefixes[file[:end]] = true
+		}
+	}
+	return prefixes, nil
+}
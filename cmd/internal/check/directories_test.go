@@ -0,0 +1,52 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckDirectoryEntries(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{
+		"legacy/file0.txt": "0", "legacy/file1.txt": "1", "legacy/file2.txt": "2", "legacy/file3.txt": "3", "legacy/file4.txt": "4", "legacy/file5.txt": "5",
+		"legacy/sub/a.txt": "a",
+	}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"gen/file0.txt": "0", "gen/file1.txt": "1", "gen/file2.txt": "2", "gen/file3.txt": "3", "gen/file4.txt": "4", "gen/file5.txt": "5",
+		"legacy/sub/b.txt": "b",
+		"src/main.go":      "package main\n",
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{Paths: config.PathPolicy{MaxDirectoryEntries: 5}}
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	// legacy/ has 7 entries already, adding to legacy/sub/ doesn't grow it
+	expected := "push adds to 1 directories with more than 5 entries, the first one is gen/ (6 entries)"
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+		t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+	}
+
+	t.Run("Top level of a change for review", func(t *testing.T) {
+		testutil.Git(t, dir, "checkout", "-q", "--detach", oldRev)
+		review := testutil.CommitContents(t, dir, map[string]string{"a": "a", "b": "b", "c": "c", "d": "d", "e": "e"}, "Top level files")
+		testutil.Git(t, dir, "checkout", "-q", testutil.DefaultBranch)
+		testutil.Git(t, dir, "reset", "-q", "--hard", oldRev)
+
+		cfg := config.Config{Paths: config.PathPolicy{MaxDirectoryEntries: 5}}
+		result, err := Check(cfg, logger, Update{Project: "p", OldRev: ZeroRev, NewRev: review, RefName: "refs/for/master"})
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		expected := "push adds to 1 directories with more than 5 entries, the first one is / (6 entries)"
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+			t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkDuplicates warns about added files whose content is byte-identical to many files at other paths
+// It is advisory: the files are recorded in the result and reported as a warning, the push is never rejected
+func checkDuplicates(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	policy := config.GetDuplicatePolicy(cfg, update.Project)
+	if policy.MinCopies == 0 || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	changes, err := update.Repository.GetChanges(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+	added := map[string]bool{}
+	for _, change := range changes {
+		if change.Status == "A" && (change.NewMode == githookkit.ModeFile || change.NewMode == githookkit.ModeExecutable) {
+			added[change.Path] = true
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	entries, err := update.Repository.ListTreeEntries(update.NewRev)
+	if err != nil {
+		return err
+	}
+	paths := map[string][]string{}
+	for _, entry := range entries {
+		if entry.Mode == githookkit.ModeFile || entry.Mode == githookkit.ModeExecutable {
+			paths[entry.Hash] = append(paths[entry.Hash], entry.Path)
+		}
+	}
+
+	// Added files still in the tree whose content is at enough other paths, sized with git cat-file
+	candidates := make(chan string)
+	go func() {
+		defer close(candidates)
+		for _, entry := range entries {
+			if added[entry.Path] && len(paths[entry.Hash])-1 >= policy.MinCopies {
+				candidates <- entry.Hash + " " + entry.Path
+			}
+		}
+	}()
+	details, err := update.Repository.GetObjectDetails(candidates, func(size int64) bool { return size >= policy.MinSize })
+	if err != nil {
+		return err
+	}
+	var wasted int64
+	for file := range details {
+		result.Duplicates = append(result.Duplicates, file)
+		wasted += file.Size
+	}
+	if len(result.Duplicates) == 0 {
+		return nil
+	}
+
+	files := result.Duplicates
+	copyOf := func(file githookkit.FileInfo) string {
+		for _, path := range paths[file.Hash] {
+			if path != file.Path {
+				return path
+			}
+		}
+		return ""
+	}
+	logFindings(logger, cfg, fmt.Sprintf("Found %d added files duplicating existing content:", len(files)), len(files), func(i int) string {
+		return fmt.Sprintf("Path: %s (%s, %d copies, e.g. %s)", files[i].Path, githookkit.FormatSize(files[i].Size), len(paths[files[i].Hash])-1, copyOf(files[i]))
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: fmt.Sprint(policy.MinCopies), Actual: githookkit.FormatSize(wasted), Count: len(files), Files: files, Largest: files[0]}
+	result.Warnings = append(result.Warnings, config.RenderMessage(cfg, config.RuleDuplicateContent, data,
+		fmt.Sprintf("push adds %d files duplicating content present at %d or more other paths (%s in total), the first one is %s, same as %s, consider reusing the existing file",
+			len(files), policy.MinCopies, githookkit.FormatSize(wasted), files[0].Path, copyOf(files[0]))))
+	return nil
+}
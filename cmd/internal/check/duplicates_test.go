@@ -0,0 +1,54 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckDuplicates(t *testing.T) {
+	logo := strings.Repeat("logo", 1000)
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{
+		"app/a/logo.png":    logo,
+		"app/b/logo.png":    logo,
+		"pkg/a/__init__.py": "",
+		"pkg/b/__init__.py": "",
+	}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"app/c/logo.png":    logo,
+		"app/d/icon.png":    strings.Repeat("icon", 1000),
+		"pkg/c/__init__.py": "",
+	}, "Add copies")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{DuplicateContent: config.DuplicatePolicy{MinCopies: 2, MinSize: 1}}
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, duplicates are advisory", result.Violations)
+	}
+	if len(result.Duplicates) != 1 || result.Duplicates[0].Path != "app/c/logo.png" || result.Duplicates[0].Size != 4000 {
+		t.Errorf("Check() returned duplicates %v, expected app/c/logo.png", result.Duplicates)
+	}
+	expected := "push adds 1 files duplicating content present at 2 or more other paths (3.91 KB in total), the first one is app/c/logo.png, same as app/a/logo.png"
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], expected) {
+		t.Errorf("Check() returned warnings %v, expected %q", result.Warnings, expected)
+	}
+
+	t.Run("Below the number of copies", func(t *testing.T) {
+		cfg := config.Config{DuplicateContent: config.DuplicatePolicy{MinCopies: 3}}
+		result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Duplicates) != 0 || len(result.Warnings) != 0 {
+			t.Errorf("Check() returned duplicates %v and warnings %v, expected none", result.Duplicates, result.Warnings)
+		}
+	})
+}
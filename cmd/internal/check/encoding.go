@@ -0,0 +1,90 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// encodingSampleSize is the number of bytes of each new file validated as UTF-8
+const encodingSampleSize = 1024 * 1024
+
+// Byte order marks
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// encodingRule rejects updates adding text files that are not valid UTF-8
+func encodingRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetTextEncodingPolicy(cfg, update.Project)
+	if !policy.Enabled {
+		return nil, nil
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		return len(policy.Paths) > 0 && !matchAny(policy.Paths, file.Path)
+	})
+
+	var files []githookkit.FileInfo
+	var problems []string
+	read := func(object string, content []byte) {
+		if problem := encodingProblem(content, len(content) == encodingSampleSize, policy); problem != "" {
+			for _, file := range paths[object] {
+				files = append(files, file)
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d files with invalid encodings:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleEncoding, config.RenderMessage(cfg, config.RuleEncoding, data,
+			fmt.Sprintf("push adds %d files that are not UTF-8, the first one is %s (%s), convert them with iconv!", len(files), files[0].Path, problems[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: encodingSampleSize, read: read, report: report}, nil
+}
+
+// encodingProblem returns why a text file violates the policy, or "" when it is valid or binary
+// truncated means content is the start of a longer file, an incomplete character at its end is fine
+func encodingProblem(content []byte, truncated bool, policy config.TextEncodingPolicy) string {
+	if bytes.HasPrefix(content, bomUTF16LE) || bytes.HasPrefix(content, bomUTF16BE) {
+		if policy.DenyUTF16 {
+			return "UTF-16"
+		}
+		return ""
+	}
+	if isBinary(content[:min(len(content), config.DefaultBinarySampleSize)]) {
+		return ""
+	}
+	if bytes.HasPrefix(content, bomUTF8) && policy.DenyBOM {
+		return "UTF-8 with byte order mark"
+	}
+	if utf8.Valid(content) {
+		return ""
+	}
+
+	for offset := 0; offset < len(content); {
+		r, size := utf8.DecodeRune(content[offset:])
+		if r == utf8.RuneError && size == 1 {
+			if truncated && !utf8.FullRune(content[offset:]) {
+				return ""
+			}
+			return fmt.Sprintf("invalid UTF-8 at line %d", bytes.Count(content[:offset], []byte("\n"))+1)
+		}
+		offset += size
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestEncodingProblem(t *testing.T) {
+	strict := config.TextEncodingPolicy{Enabled: true, DenyBOM: true, DenyUTF16: true}
+	lenient := config.TextEncodingPolicy{Enabled: true}
+
+	tests := []struct {
+		name      string
+		content   string
+		truncated bool
+		policy    config.TextEncodingPolicy
+		expected  string
+	}{
+		{"ascii", "hello\n", false, strict, ""},
+		{"utf-8", "grüße\n", false, strict, ""},
+		{"latin-1", "line 1\ngr\xfc\xdfe\n", false, strict, "invalid UTF-8 at line 2"},
+		{"bom", "\xef\xbb\xbfhello", false, strict, "UTF-8 with byte order mark"},
+		{"bom allowed", "\xef\xbb\xbfhello", false, lenient, ""},
+		{"utf-16", "\xff\xfeh\x00i\x00", false, strict, "UTF-16"},
+		{"utf-16 skipped", "\xff\xfeh\x00i\x00", false, lenient, ""},
+		{"binary", "\x00\x01\xff", false, strict, ""},
+		{"truncated character", "gr\xc3", true, strict, ""},
+		{"incomplete character", "gr\xc3", false, strict, "invalid UTF-8 at line 1"},
+	}
+
+	for _, test := range tests {
+		if problem := encodingProblem([]byte(test.content), test.truncated, test.policy); problem != test.expected {
+			t.Errorf("%s: encodingProblem() = %q, expected %q", test.name, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckEncoding(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"src/main.c":     "/* gr\xfc\xdfe */\n",
+		"docs/notes.txt": "caf\xe9\n",
+		"src/util.c":     "/* grüße */\n",
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		policy   config.TextEncodingPolicy
+		expected string
+	}{
+		{"disabled", config.TextEncodingPolicy{}, ""},
+		{"all text files", config.TextEncodingPolicy{Enabled: true}, "push adds 2 files that are not UTF-8, the first one is docs/notes.txt (invalid UTF-8 at line 1)"},
+		{"scoped", config.TextEncodingPolicy{Enabled: true, Paths: []string{"*.c"}}, "push adds 1 files that are not UTF-8, the first one is src/main.c"},
+	}
+
+	for _, test := range tests {
+		result, err := Check(config.Config{TextEncoding: test.policy}, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		if test.expected == "" {
+			if len(result.Violations) != 0 {
+				t.Errorf("%s: Check() returned violations %v, expected none", test.name, result.Violations)
+			}
+			continue
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.expected) {
+			t.Errorf("%s: Check() returned violations %v, expected %q", test.name, result.Violations, test.expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkExecutables rejects updates adding executable files, or setting the executable bit of files, outside of the allowed paths
+func checkExecutables(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	policy := config.GetExecutablePolicy(cfg, update.Project)
+	if !policy.Restrict || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	changes, err := update.Repository.GetChanges(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	var files []githookkit.FileInfo
+	seen := map[string]bool{}
+	for _, change := range changes {
+		if change.NewMode != githookkit.ModeExecutable || change.OldMode == githookkit.ModeExecutable {
+			continue
+		}
+		if seen[change.Path] || matchAny(policy.AllowedPaths, change.Path) {
+			continue
+		}
+		seen[change.Path] = true
+		files = append(files, githookkit.FileInfo{Path: change.Path, Hash: change.NewHash})
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d executable files outside of the allowed paths:", len(files)), len(files), func(i int) string {
+		return fmt.Sprintf("Path: %s", files[i].Path)
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(policy.AllowedPaths, ", "), Actual: files[0].Path, Count: len(files), Files: files, Largest: files[0]}
+	message := fmt.Sprintf("push makes %d files executable, the first one is %s, run git update-index --chmod=-x on them!", len(files), files[0].Path)
+	if len(policy.AllowedPaths) > 0 {
+		message = fmt.Sprintf("push makes %d files executable outside of %s, the first one is %s, run git update-index --chmod=-x on them!", len(files), data.Limit, files[0].Path)
+	}
+	result.violate(config.RuleExecutable, config.RenderMessage(cfg, config.RuleExecutable, data, message), data.Files...)
+	return nil
+}
@@ -0,0 +1,51 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckExecutables(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	testutil.CommitContents(t, dir, map[string]string{"src/main.c": "m", "src/tool.sh": "t", "build.sh": "b"}, "Initial")
+	testutil.Git(t, dir, "update-index", "--chmod=+x", "build.sh")
+	testutil.Git(t, dir, "commit", "-q", "-m", "Make build.sh executable")
+	oldRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+
+	testutil.CommitContents(t, dir, map[string]string{"scripts/release.sh": "r", "src/gen.py": "g"}, "Add scripts")
+	testutil.Git(t, dir, "update-index", "--chmod=+x", "scripts/release.sh", "src/gen.py", "src/main.c")
+	testutil.Git(t, dir, "commit", "-q", "-m", "Make files executable")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"build.sh": "changed"}, "Change build.sh")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		policy   config.ExecutablePolicy
+		expected string
+	}{
+		{"not restricted", config.ExecutablePolicy{AllowedPaths: []string{"scripts/*"}}, ""},
+		{"restricted", config.ExecutablePolicy{Restrict: true}, "push makes 3 files executable, the first one is scripts/release.sh"},
+		{"allowed paths", config.ExecutablePolicy{Restrict: true, AllowedPaths: []string{"scripts/*"}}, "push makes 2 files executable outside of scripts/*, the first one is src/gen.py"},
+		{"all allowed", config.ExecutablePolicy{Restrict: true, AllowedPaths: []string{"scripts/*", "src/*"}}, ""},
+	}
+
+	for _, test := range tests {
+		result, err := Check(config.Config{ExecutableFiles: test.policy}, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		if test.expected == "" {
+			if len(result.Violations) != 0 {
+				t.Errorf("%s: Check() returned violations %v, expected none", test.name, result.Violations)
+			}
+			continue
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.expected) {
+			t.Errorf("%s: Check() returned violations %v, expected %q", test.name, result.Violations, test.expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkExpressions rejects updates for which an expression rule is true, for any of their new files
+// when the expression references file
+func checkExpressions(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) error {
+	for _, rule := range config.GetExpressionRules(cfg, update.Project) {
+		expression, err := config.CompileExpression(rule.Reject)
+		if err != nil {
+			return fmt.Errorf("expression rule %s: %w", rule.Name, err)
+		}
+		input := config.ExpressionInput{
+			Project:     update.Project,
+			RefName:     update.RefName,
+			OldRev:      update.OldRev,
+			NewRev:      update.NewRev,
+			Uploader:    update.Uploader,
+			Username:    update.UploaderUsername,
+			PushOptions: update.PushOptions,
+		}
+
+		if !expression.PerFile {
+			rejected, err := expression.Eval(input)
+			if err != nil {
+				return fmt.Errorf("expression rule %s: %w", rule.Name, err)
+			}
+			if rejected {
+				data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: rule.Name}
+				result.violate(config.RuleExpression, config.RenderMessage(cfg, config.RuleExpression, data,
+					fmt.Sprintf("%s: %s", rule.Name, rule.Message)))
+			}
+			continue
+		}
+
+		var files []githookkit.FileInfo
+		for _, file := range newFiles {
+			input.Path, input.Size, input.Hash = file.Path, file.Size, file.Hash
+			rejected, err := expression.Eval(input)
+			if err != nil {
+				return fmt.Errorf("expression rule %s on %s: %w", rule.Name, file.Path, err)
+			}
+			if rejected {
+				files = append(files, file)
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		logFindings(logger, cfg, fmt.Sprintf("Found %d files rejected by %s:", len(files), rule.Name), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, githookkit.FormatSize(files[i].Size))
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: rule.Name, Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleExpression, config.RenderMessage(cfg, config.RuleExpression, data,
+			fmt.Sprintf("%s: %s, push adds %d such files, the first one is %s!", rule.Name, rule.Message, len(files), files[0].Path)), data.Files...)
+	}
+	return nil
+}
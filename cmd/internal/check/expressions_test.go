@@ -0,0 +1,44 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckExpressions(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"assets/video.mp4":  strings.Repeat("v", 3000),
+		"third_party/lib.a": strings.Repeat("l", 3000),
+		"src/main.go":       "package main\n",
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{ExpressionRules: []config.ExpressionRule{
+		{Name: "release-assets", Reject: `ref.startsWith("refs/heads/release/") && file.size > 2*KB && !file.path.matches("^third_party/")`, Message: "release branches take no files above 2 KB"},
+		{Name: "tmp-branches", Reject: `ref.startsWith("refs/heads/tmp/") && username != "ci"`, Message: "only CI pushes to tmp branches"},
+	}}
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"refs/heads/release/1.0", "release-assets: release branches take no files above 2 KB, push adds 1 such files, the first one is assets/video.mp4!"},
+		{"refs/heads/tmp/x", "tmp-branches: only CI pushes to tmp branches"},
+		{"refs/heads/master", ""},
+	}
+	for _, test := range tests {
+		result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: test.ref, UploaderUsername: "alice"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.ref, err)
+		}
+		if test.expected == "" && len(result.Violations) != 0 || test.expected != "" && (len(result.Violations) != 1 || result.Violations[0] != test.expected) {
+			t.Errorf("%s: Check() returned violations %v, expected %q", test.ref, result.Violations, test.expected)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package check
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+)
+
+// maxGroups is the number of extensions and directories listed in the summary of the offending files
+const maxGroups = 3
+
+// fileGroup is the offending files sharing an extension or a top-level directory
+type fileGroup struct {
+	name  string
+	files int
+	size  int64
+}
+
+func (g fileGroup) String() string {
+	return locale.T("%s %s (%d files)", g.name, githookkit.FormatSize(g.size), g.files)
+}
+
+// offendingSummary returns the lines summarizing the offending files of the result by extension and top-level directory,
+// a long file list is hard to act on while "assets/ 900 MB" tells where to look
+// It returns nil for less than two offending files
+func offendingSummary(result Result) []string {
+	sizes := map[string]int64{}
+	for _, violation := range result.Findings {
+		if violation.Path != "" && !violation.Bypassed {
+			sizes[violation.Path] = violation.Size
+		}
+	}
+	if len(sizes) < 2 {
+		return nil
+	}
+
+	var total int64
+	extensions := map[string]*fileGroup{}
+	directories := map[string]*fileGroup{}
+	for p, size := range sizes {
+		total += size
+		addToGroup(extensions, fileExtension(p), size)
+		addToGroup(directories, topDirectory(p), size)
+	}
+	byExtension := sortedGroups(extensions)
+	byDirectory := sortedGroups(directories)
+
+	return []string{
+		locale.T("%d offending files, %s in total; worst: %s", len(sizes), githookkit.FormatSize(total), byDirectory[0]),
+		locale.T("by extension: %s", joinGroups(byExtension)),
+		locale.T("by directory: %s", joinGroups(byDirectory)),
+	}
+}
+
+func addToGroup(groups map[string]*fileGroup, name string, size int64) {
+	if groups[name] == nil {
+		groups[name] = &fileGroup{name: name}
+	}
+	groups[name].files++
+	groups[name].size += size
+}
+
+// sortedGroups returns the groups biggest first
+func sortedGroups(groups map[string]*fileGroup) []fileGroup {
+	var result []fileGroup
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].size != result[j].size {
+			return result[i].size > result[j].size
+		}
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+// joinGroups lists the first maxGroups groups and counts the others
+func joinGroups(groups []fileGroup) string {
+	var parts []string
+	for i, g := range groups {
+		if i == maxGroups {
+			parts = append(parts, locale.T("%d more", len(groups)-maxGroups))
+			break
+		}
+		parts = append(parts, g.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fileExtension returns the lower-cased extension of a path, "(none)" when it has none
+func fileExtension(p string) string {
+	if ext := strings.ToLower(path.Ext(p)); ext != "" {
+		return ext
+	}
+	return "(none)"
+}
+
+// topDirectory returns the top-level directory of a path, "." for files at the root
+func topDirectory(p string) string {
+	if dir, _, found := strings.Cut(p, "/"); found {
+		return dir + "/"
+	}
+	return "."
+}
@@ -0,0 +1,43 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestOffendingSummary(t *testing.T) {
+	result := Result{Mode: config.ModeEnforce}
+	result.violate(config.RuleFileSize, "files too large",
+		githookkit.FileInfo{Path: "assets/video/intro.mp4", Size: 900 << 20},
+		githookkit.FileInfo{Path: "assets/video/outro.MP4", Size: 100 << 20},
+		githookkit.FileInfo{Path: "docs/design.psd", Size: 50 << 20},
+		githookkit.FileInfo{Path: "build.zip", Size: 10 << 20},
+		githookkit.FileInfo{Path: "tools/setup", Size: 1 << 20},
+	)
+	// A file breaking several rules is counted once
+	result.violate(config.RuleBinary, "binary files", githookkit.FileInfo{Path: "docs/design.psd", Size: 50 << 20})
+
+	expected := []string{
+		"5 offending files, 1.04 GB in total; worst: assets/ 1000.00 MB (2 files)",
+		"by extension: .mp4 1000.00 MB (2 files), .psd 50.00 MB (1 files), .zip 10.00 MB (1 files), 1 more",
+		"by directory: assets/ 1000.00 MB (2 files), docs/ 50.00 MB (1 files), . 10.00 MB (1 files), 1 more",
+	}
+	if summary := offendingSummary(result); !reflect.DeepEqual(summary, expected) {
+		t.Errorf("offendingSummary() = %q, expected %q", summary, expected)
+	}
+
+	single := Result{}
+	single.violate(config.RuleFileSize, "file too large", githookkit.FileInfo{Path: "large.bin", Size: 4096})
+	if summary := offendingSummary(single); summary != nil {
+		t.Errorf("offendingSummary() of one file = %q, expected nil", summary)
+	}
+
+	bypassed := Result{Overrides: []config.AppliedOverride{{Option: "large-files-ok", Rules: []string{config.RuleFileSize}}}}
+	bypassed.violate(config.RuleFileSize, "files too large", githookkit.FileInfo{Path: "a.bin", Size: 1}, githookkit.FileInfo{Path: "b.bin", Size: 2})
+	if summary := offendingSummary(bypassed); summary != nil {
+		t.Errorf("offendingSummary() of bypassed files = %q, expected nil", summary)
+	}
+}
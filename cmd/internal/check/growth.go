@@ -0,0 +1,102 @@
+package check
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkGrowthBudget adds the new files of the update to the growth of its project within the budget window
+// and warns or rejects when the sum exceeds the budget, pushes adding nothing always pass
+func checkGrowthBudget(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) error {
+	budget := config.GetGrowthBudget(cfg, update.Project)
+	if budget.Warn == 0 && budget.Limit == 0 {
+		return nil
+	}
+
+	var size int64
+	for _, file := range newFiles {
+		size += file.Size
+	}
+	if size == 0 {
+		return nil
+	}
+
+	path := config.GetAuditLogPath(cfg)
+	if path == "" {
+		logger.Warnf("Growth budget of project %s needs an audit log, not checking it", update.Project)
+		return nil
+	}
+	window := budget.Window
+	if window == 0 {
+		window = config.DefaultGrowthWindow
+	}
+	growth, err := audit.Growth(path, update.Project, time.Now().Add(-window))
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Project %s grew by %s in the last %s, push adds %s", update.Project, githookkit.FormatSize(growth), formatWindow(window), githookkit.FormatSize(size))
+
+	total := growth + size
+	switch {
+	case budget.Limit > 0 && total > budget.Limit:
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: githookkit.FormatSize(budget.Limit), Actual: githookkit.FormatSize(total), Count: len(newFiles)}
+		result.violate(config.RuleGrowthBudget, config.RenderMessage(cfg, config.RuleGrowthBudget, data,
+			fmt.Sprintf("project %s grew by %s in the last %s, this push adds %s, exceeding its growth budget of %s, ask an administrator for more space!",
+				update.Project, githookkit.FormatSize(growth), formatWindow(window), githookkit.FormatSize(size), githookkit.FormatSize(budget.Limit))))
+	case budget.Warn > 0 && total > budget.Warn:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("project %s grew by %s in the last %s including this push, above its growth budget warning of %s",
+			update.Project, githookkit.FormatSize(total), formatWindow(window), githookkit.FormatSize(budget.Warn)))
+	}
+	return nil
+}
+
+// uploadQuotaWindow is the window of the upload quota
+const uploadQuotaWindow = 24 * time.Hour
+
+// checkUploadQuota adds the new files of the update to what the uploader pushed to any project within the last
+// 24 hours and rejects the update when the sum exceeds the daily upload quota
+func checkUploadQuota(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) error {
+	quota := cfg.UploadQuota.Daily
+	if quota == 0 || update.UploaderUsername == "" || config.IsUploadQuotaExempt(cfg, update.UploaderUsername) {
+		return nil
+	}
+
+	var size int64
+	for _, file := range newFiles {
+		size += file.Size
+	}
+	if size == 0 {
+		return nil
+	}
+
+	path := config.GetAuditLogPath(cfg)
+	if path == "" {
+		logger.Warnf("Upload quota needs an audit log, not checking it")
+		return nil
+	}
+	uploaded, err := audit.Uploaded(path, update.UploaderUsername, time.Now().Add(-uploadQuotaWindow))
+	if err != nil {
+		return err
+	}
+	logger.Debugf("User %s uploaded %s in the last 24 hours, push adds %s", update.UploaderUsername, githookkit.FormatSize(uploaded), githookkit.FormatSize(size))
+
+	if uploaded+size > quota {
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: githookkit.FormatSize(quota), Actual: githookkit.FormatSize(uploaded + size), Count: len(newFiles)}
+		result.violate(config.RuleUploadQuota, config.RenderMessage(cfg, config.RuleUploadQuota, data,
+			fmt.Sprintf("user %s uploaded %s in the last 24 hours, this push adds %s, exceeding the daily upload quota of %s, try again later!",
+				update.UploaderUsername, githookkit.FormatSize(uploaded), githookkit.FormatSize(size), githookkit.FormatSize(quota))))
+	}
+	return nil
+}
+
+// formatWindow formats a window in days when it is a whole number of them
+func formatWindow(window time.Duration) string {
+	if window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", window/(24*time.Hour))
+	}
+	return window.String()
+}
@@ -0,0 +1,114 @@
+package check
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckGrowthBudget(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README.md": "readme"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"data.bin": strings.Repeat("x", 1000)}, "Add data")
+	chdir(t, dir)
+
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	records := []audit.Record{
+		{Event: audit.EventPush, Project: "p", TotalSize: 5000, Time: time.Now().Add(-60 * 24 * time.Hour)},
+		{Event: audit.EventPush, Project: "p", TotalSize: 1500, Time: time.Now().Add(-24 * time.Hour)},
+		{Event: audit.EventPush, Project: "other", TotalSize: 5000, Time: time.Now()},
+	}
+	for _, record := range records {
+		if err := audit.Write(auditLog, record); err != nil {
+			t.Fatalf("audit.Write() returned error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		budget     config.GrowthBudget
+		violations int
+		warnings   int
+	}{
+		{"Within budget", config.GrowthBudget{Warn: 3000, Limit: 4000}, 0, 0},
+		{"Above warning", config.GrowthBudget{Warn: 2000, Limit: 4000}, 0, 1},
+		{"Above limit", config.GrowthBudget{Warn: 1000, Limit: 2000}, 1, 0},
+		{"Old pushes in window", config.GrowthBudget{Limit: 4000, Window: 90 * 24 * time.Hour}, 1, 0},
+	}
+
+	logger := newTestLogger(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Config{AuditLog: auditLog, GrowthBudget: test.budget}
+			result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != test.violations || len(result.Warnings) != test.warnings {
+				t.Errorf("Check() returned violations %v and warnings %v, expected %d and %d", result.Violations, result.Warnings, test.violations, test.warnings)
+			}
+		})
+	}
+
+	expected := "project p grew by 1.46 KB in the last 30 days, this push adds 1000 B, exceeding its growth budget of 1.95 KB"
+	cfg := config.Config{AuditLog: auditLog, GrowthBudget: config.GrowthBudget{Limit: 2000}}
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.HasPrefix(result.Violations[0], expected) {
+		t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+	}
+}
+
+func TestCheckUploadQuota(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README.md": "readme"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"data.bin": strings.Repeat("x", 1000)}, "Add data")
+	chdir(t, dir)
+
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	records := []audit.Record{
+		{Event: audit.EventPush, Project: "a", User: "bot", TotalSize: 1500, Time: time.Now().Add(-time.Hour)},
+		{Event: audit.EventPush, Project: "b", User: "bot", TotalSize: 1500, Time: time.Now().Add(-2 * time.Hour)},
+		{Event: audit.EventPush, Project: "a", User: "bot", TotalSize: 9000, Time: time.Now().Add(-25 * time.Hour)},
+		{Event: audit.EventPush, Project: "a", User: "alice", TotalSize: 9000, Time: time.Now()},
+	}
+	for _, record := range records {
+		if err := audit.Write(auditLog, record); err != nil {
+			t.Fatalf("audit.Write() returned error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		username   string
+		quota      config.UploadQuota
+		violations int
+	}{
+		{"Within quota", "bot", config.UploadQuota{Daily: 5000}, 0},
+		{"Above quota across projects", "bot", config.UploadQuota{Daily: 3500}, 1},
+		{"Other uploader", "carol", config.UploadQuota{Daily: 3500}, 0},
+		{"Exempt uploader", "bot", config.UploadQuota{Daily: 3500, ExemptUsers: []string{"bot"}}, 0},
+	}
+
+	logger := newTestLogger(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Config{AuditLog: auditLog, UploadQuota: test.quota}
+			update := Update{Project: "c", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master", UploaderUsername: test.username}
+			result, err := Check(cfg, logger, update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != test.violations {
+				t.Errorf("Check() returned violations %v, expected %d", result.Violations, test.violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bwinhwang/githookkit"
+)
+
+// parentProblems returns a problem when a commit has more than maxParents parents
+func parentProblems(commit githookkit.Commit, maxParents int) []string {
+	if len(commit.Parents) <= maxParents {
+		return nil
+	}
+	return []string{fmt.Sprintf("commit has %d parents, the maximum is %d", len(commit.Parents), maxParents)}
+}
+
+// mergeProblems returns a problem when a commit is a merge, which breaks a linear history
+func mergeProblems(commit githookkit.Commit) []string {
+	if len(commit.Parents) <= 1 {
+		return nil
+	}
+	return []string{"merge commits are not allowed on this branch, rebase instead of merging"}
+}
+
+// emptyCommitProblems returns a problem when a commit is one of the empty commits
+func emptyCommitProblems(commit githookkit.Commit, empty map[string]bool) []string {
+	if !empty[commit.Hash] {
+		return nil
+	}
+	return []string{"commit doesn't change any file, drop it or squash it"}
+}
+
+// rootCommitProblems returns a problem when a commit has no parents, its history has nothing in common with the existing refs
+func rootCommitProblems(commit githookkit.Commit) []string {
+	if len(commit.Parents) > 0 {
+		return nil
+	}
+	return []string{"commit has no parents, it starts a history unrelated to the project, rebase it onto an existing branch"}
+}
@@ -0,0 +1,173 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckParents(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.Git(t, dir, "branch", "-q", "main")
+	for _, branch := range []string{"a", "b"} {
+		testutil.Git(t, dir, "checkout", "-q", "-b", branch, "main")
+		testutil.CommitContents(t, dir, map[string]string{branch + ".txt": branch}, "Add "+branch)
+	}
+	testutil.Git(t, dir, "checkout", "-q", "main")
+	testutil.Git(t, dir, "merge", "-q", "--no-ff", "-m", "Merge a", "a")
+	mergeRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	testutil.Git(t, dir, "checkout", "-q", "-b", "octopus", oldRev)
+	testutil.Git(t, dir, "merge", "-q", "--no-ff", "-m", "Merge a and b", "a", "b")
+	octopusRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{MaxParents: 2}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: mergeRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none for a regular merge", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: octopusRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Merge a and b): commit has 3 parents, the maximum is 2!") {
+		t.Errorf("Check() returned violations %v, expected the octopus merge", result.Violations)
+	}
+}
+
+func TestCheckLinearHistory(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.Git(t, dir, "checkout", "-q", "-b", "side")
+	testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "Add b")
+	testutil.Git(t, dir, "checkout", "-q", testutil.DefaultBranch)
+	linearRev := testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	testutil.Git(t, dir, "merge", "-q", "--no-ff", "-m", "Merge side", "side")
+	mergeRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{LinearHistory: []string{"refs/heads/master"}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: linearRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none for linear history", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: mergeRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Merge side): merge commits are not allowed") {
+		t.Errorf("Check() returned violations %v, expected the merge", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: mergeRev, RefName: "refs/heads/integration"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none on other refs", result.Violations)
+	}
+}
+
+func TestCheckEmptyCommits(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.Git(t, dir, "checkout", "-q", "-b", "side")
+	testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "Add b")
+	testutil.Git(t, dir, "checkout", "-q", "-")
+	testutil.CommitContents(t, dir, map[string]string{}, "Empty")
+	testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	testutil.Git(t, dir, "merge", "-q", "--no-ff", "-s", "ours", "-m", "Merge side", "side")
+	newRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{DenyEmptyCommits: []string{"refs/heads/*"}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Empty): commit doesn't change any file") {
+		t.Errorf("Check() returned violations %v, expected only the empty commit", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/for/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none on other refs", result.Violations)
+	}
+}
+
+func TestCheckRootCommits(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.Git(t, dir, "checkout", "-q", "--orphan", "orphan")
+	testutil.Git(t, dir, "rm", "-q", "-r", "--cached", ".")
+	orphanRev := testutil.CommitContents(t, dir, map[string]string{"other.txt": "other"}, "Unrelated")
+	testutil.Git(t, dir, "checkout", "-q", "-f", testutil.DefaultBranch)
+	testutil.Git(t, dir, "branch", "-q", "-D", "orphan")
+	testutil.Git(t, dir, "merge", "-q", "--allow-unrelated-histories", "-m", "Merge unrelated", orphanRev)
+	mergeRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	testutil.Git(t, dir, "reset", "-q", "--hard", oldRev)
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{DenyRootCommits: []string{"refs/heads/*"}}
+
+	tests := []struct {
+		name     string
+		update   Update
+		expected string
+	}{
+		{"Orphan branch", Update{Project: "p", OldRev: ZeroRev, NewRev: orphanRev, RefName: "refs/heads/orphan"}, "(Unrelated): commit has no parents"},
+		{"Merge of unrelated history", Update{Project: "p", OldRev: oldRev, NewRev: mergeRev, RefName: "refs/heads/master"}, "(Unrelated): commit has no parents"},
+		{"New branch from existing history", Update{Project: "p", OldRev: ZeroRev, NewRev: oldRev, RefName: "refs/heads/topic"}, ""},
+		{"Other ref", Update{Project: "p", OldRev: ZeroRev, NewRev: orphanRev, RefName: "refs/meta/config"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Check(cfg, logger, test.update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if test.expected == "" && len(result.Violations) != 0 {
+				t.Errorf("Check() returned violations %v, expected none", result.Violations)
+			}
+			if test.expected != "" && (len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.expected)) {
+				t.Errorf("Check() returned violations %v, expected %q", result.Violations, test.expected)
+			}
+		})
+	}
+
+	t.Run("Empty repository", func(t *testing.T) {
+		emptyDir := testutil.InitRepo(t)
+		rev := testutil.CommitFiles(t, emptyDir, map[string]int{"README": 10})
+		testutil.Git(t, emptyDir, "update-ref", "-d", "refs/heads/master")
+		chdir(t, emptyDir)
+
+		result, err := Check(cfg, logger, Update{Project: "p", OldRev: ZeroRev, NewRev: rev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("Check() returned violations %v, the first push starts the history", result.Violations)
+		}
+	})
+}
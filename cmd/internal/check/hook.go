@@ -0,0 +1,344 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/chain"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/notify"
+)
+
+// Report prints the violations of the results and reports whether the push is rejected, see reportLines
+// The results are added to the decision document
+func Report(logger *config.Logger, cfg config.Config, results []Result) bool {
+	AddOutput(results...)
+	lines, rejected := reportLines(cfg, results)
+	for _, line := range lines {
+		if line.isError {
+			logger.Errorf("%s", line.text)
+		} else {
+			logger.Warnf("%s", line.text)
+		}
+	}
+	return rejected
+}
+
+// reportLine is a line of the report of results, written as an error or a warning
+type reportLine struct {
+	text    string
+	isError bool
+}
+
+// reportLines returns the report of the violations of the results and whether they reject the push
+// Results in warn mode are reported as warnings, the ref name is shown when several refs were checked
+// Lines beyond max_output_lines or max_output_bytes are summarized per rule with a pointer to the full report
+// The offending files of a ref are summarized by extension and top-level directory, this summary is never cut
+// Rejections by size and LFS rules are followed by instructions moving the files to git lfs, see remediation
+func reportLines(cfg config.Config, results []Result) ([]reportLine, bool) {
+	var lines []reportLine
+	add := func(isError bool, text string) {
+		lines = append(lines, reportLine{text: text, isError: isError})
+	}
+
+	limit := newOutputCap(cfg)
+	rejected := false
+	for _, result := range results {
+		prefix := ""
+		if len(results) > 1 {
+			prefix = result.Update.RefName + ": "
+		}
+
+		for _, warning := range result.Warnings {
+			if line, ok := limit.allow(result.Update, "warning", locale.T("WARNING: %s", prefix+warning)); ok {
+				add(false, line)
+			}
+		}
+		for _, violation := range result.Bypassed {
+			if line, ok := limit.allow(result.Update, result.ruleOf(violation), locale.T("OVERRIDDEN: %s", prefix+violation)); ok {
+				add(false, line)
+			}
+		}
+		for _, override := range result.Overrides {
+			add(false, locale.T("Override %s granted to %s for %s", override, result.Update.UploaderUsername, result.Update.RefName))
+		}
+
+		if len(result.Violations) == 0 {
+			continue
+		}
+
+		// In warn mode the pusher sees the violations but the push is allowed
+		if result.Rejected() {
+			rejected = true
+			for _, violation := range result.Violations {
+				if line, ok := limit.allow(result.Update, result.ruleOf(violation), locale.T("REJECTED: %s", prefix+violation)); ok {
+					add(true, line)
+				}
+			}
+			for _, line := range offendingSummary(result) {
+				add(true, locale.T("REJECTED: %s", prefix+line))
+			}
+			for _, line := range remediation(cfg, result) {
+				add(true, locale.T("HINT: %s", prefix+line))
+			}
+			continue
+		}
+
+		for _, violation := range result.Violations {
+			if line, ok := limit.allow(result.Update, result.ruleOf(violation), locale.T("WARNING: %s", prefix+violation)); ok {
+				add(false, line)
+			}
+		}
+		for _, line := range offendingSummary(result) {
+			add(false, locale.T("WARNING: %s", prefix+line))
+		}
+		add(false, locale.T("Project %s is in warn mode for %s, push allowed", result.Update.Project, result.Update.RefName))
+	}
+
+	if summary := limit.summary(cfg); summary != "" {
+		add(rejected, summary)
+	}
+	return lines, rejected
+}
+
+// AuditPushes records the accepted updates adding files in the audit log, if one is configured, for the hooks that
+// accept pushes without a post-receive running after them, e.g. ref-update and commit-received on Gerrit.
+// The growth budget and upload quota are summed from these records.
+func AuditPushes(logger *config.Logger, cfg config.Config, hookName string, results []Result) {
+	path := config.GetAuditLogPath(cfg)
+	if path == "" {
+		return
+	}
+
+	for _, result := range results {
+		if result.Rejected() || len(result.NewFiles) == 0 {
+			continue
+		}
+		record := audit.Record{
+			Event:    audit.EventPush,
+			Hook:     hookName,
+			Project:  result.Update.Project,
+			RefName:  result.Update.RefName,
+			OldRev:   result.Update.OldRev,
+			NewRev:   result.Update.NewRev,
+			User:     result.Update.UploaderUsername,
+			NewFiles: len(result.NewFiles),
+		}
+		var largest githookkit.FileInfo
+		for _, file := range result.NewFiles {
+			record.TotalSize += file.Size
+			if file.Size > largest.Size {
+				largest = file
+			}
+		}
+		record.Largest = largest.Path
+		if err := audit.Write(path, record); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+}
+
+// AuditOverrides records the overrides granted for the results in the audit log, if one is configured
+func AuditOverrides(logger *config.Logger, cfg config.Config, hookName string, results []Result) {
+	path := config.GetAuditLogPath(cfg)
+	if path == "" {
+		return
+	}
+
+	for _, result := range results {
+		if len(result.Overrides) == 0 {
+			continue
+		}
+		record := audit.Record{
+			Event:     audit.EventOverride,
+			Hook:      hookName,
+			Project:   result.Update.Project,
+			RefName:   result.Update.RefName,
+			OldRev:    result.Update.OldRev,
+			NewRev:    result.Update.NewRev,
+			User:      result.Update.UploaderUsername,
+			Warnings:  result.Bypassed,
+			Overrides: result.Overrides,
+		}
+		for _, violation := range result.Findings {
+			if violation.Bypassed {
+				record.Violations = append(record.Violations, violation)
+			}
+		}
+		if err := audit.Write(path, record); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+}
+
+// EmergencyBypass reports whether the updates skip all rules because an admin set GITHOOK_EMERGENCY_BYPASS=<reason>
+// The bypass is only granted once it is recorded in the audit log, without an audit log the updates are checked as usual
+func EmergencyBypass(logger *config.Logger, cfg config.Config, hookName string, updates []Update) bool {
+	reason := os.Getenv("GITHOOK_EMERGENCY_BYPASS")
+	if reason == "" || len(updates) == 0 {
+		return false
+	}
+
+	username := updates[0].UploaderUsername
+	if !config.IsEmergencyBypassAllowed(cfg, username) {
+		logger.Warnf("%s", locale.T("User %q is not allowed to use the emergency bypass, checking the push", username))
+		return false
+	}
+
+	path := config.GetAuditLogPath(cfg)
+	if path == "" {
+		logger.Errorf("%s", locale.T("Emergency bypass requires an audit log, checking the push"))
+		return false
+	}
+
+	for _, update := range updates {
+		record := audit.Record{
+			Event:   audit.EventBypass,
+			Hook:    hookName,
+			Project: update.Project,
+			RefName: update.RefName,
+			OldRev:  update.OldRev,
+			NewRev:  update.NewRev,
+			User:    username,
+			Reason:  reason,
+		}
+		if err := audit.Write(path, record); err != nil {
+			logger.Errorf("%s", locale.T("%v, emergency bypass refused, checking the push", err))
+			return false
+		}
+		if err := notify.Send(cfg.Notify, record); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+
+	logger.Warnf("%s", locale.T("EMERGENCY BYPASS by %s: %s, all rules skipped for %d ref updates", username, reason, len(updates)))
+	return true
+}
+
+// AdminBypass reports whether the updates skip all rules because Gerrit grants the uploader one of the capabilities or
+// groups of admin_bypass. Like the emergency bypass it is only granted once recorded in the audit log; when Gerrit can't
+// be asked the updates are checked as usual
+func AdminBypass(logger *config.Logger, cfg config.Config, hookName string, updates []Update) bool {
+	if len(cfg.AdminBypass.Capabilities) == 0 && len(cfg.AdminBypass.Groups) == 0 || len(updates) == 0 {
+		return false
+	}
+	username := updates[0].UploaderUsername
+	if username == "" {
+		return false
+	}
+
+	reason, err := adminBypassReason(cfg, username)
+	if err != nil {
+		logger.Errorf("%s", locale.T("%v, admin bypass not granted, checking the push", err))
+		return false
+	}
+	if reason == "" {
+		return false
+	}
+
+	path := config.GetAuditLogPath(cfg)
+	if path == "" {
+		logger.Errorf("%s", locale.T("Admin bypass requires an audit log, checking the push"))
+		return false
+	}
+
+	for _, update := range updates {
+		record := audit.Record{
+			Event:   audit.EventAdmin,
+			Hook:    hookName,
+			Project: update.Project,
+			RefName: update.RefName,
+			OldRev:  update.OldRev,
+			NewRev:  update.NewRev,
+			User:    username,
+			Reason:  reason,
+		}
+		if err := audit.Write(path, record); err != nil {
+			logger.Errorf("%s", locale.T("%v, admin bypass not granted, checking the push", err))
+			return false
+		}
+	}
+
+	logger.Warnf("%s", locale.T("ADMIN BYPASS by %s: %s, all rules skipped for %d ref updates", username, reason, len(updates)))
+	return true
+}
+
+// adminBypassReason returns the capability or group of admin_bypass Gerrit grants to username, empty when there is none
+func adminBypassReason(cfg config.Config, username string) (string, error) {
+	if len(cfg.AdminBypass.Capabilities) > 0 {
+		capabilities, err := gerrit.AccountCapabilities(cfg.Gerrit, username, cfg.AdminBypass.Capabilities)
+		if err != nil {
+			return "", err
+		}
+		if len(capabilities) > 0 {
+			return "gerrit capability " + capabilities[0], nil
+		}
+	}
+
+	if len(cfg.AdminBypass.Groups) > 0 {
+		groups, err := gerrit.AccountGroups(cfg.Gerrit, username)
+		if err != nil {
+			return "", err
+		}
+		for _, group := range cfg.AdminBypass.Groups {
+			if config.Contains(groups, group) {
+				return "gerrit group " + group, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// RunChainedHooks execs the site-local hooks chained after hookName, exiting with the code of the first one that fails
+func RunChainedHooks(logger *config.Logger, cfg config.Config, hookName string, args []string, stdin io.Reader) {
+	err := chain.Run(cfg.ChainedHooks, hookName, args, stdin, hookStdout(), os.Stderr)
+
+	var hookErr *chain.HookError
+	if errors.As(err, &hookErr) {
+		logger.Errorf("%s", locale.T("REJECTED: %s", hookErr))
+		Exit(hookErr.ExitCode, hookErr.Error())
+	}
+	if err != nil {
+		InternalError(logger, cfg, "%v", err)
+	}
+}
+
+// InternalError ends the hook after an infrastructure failure, accepting or rejecting the push per on_internal_error
+func InternalError(logger *config.Logger, cfg config.Config, format string, args ...interface{}) {
+	Fail(logger, cfg, &githookkit.InternalError{Err: fmt.Errorf(format, args...)})
+}
+
+// ConfigError ends the hook after finding its configuration or invocation invalid, accepting or rejecting the push
+// per on_internal_error
+func ConfigError(logger *config.Logger, cfg config.Config, format string, args ...interface{}) {
+	Fail(logger, cfg, &githookkit.ConfigError{Err: fmt.Errorf(format, args...)})
+}
+
+// Fail ends the hook with the exit code of err, errors of the hook itself accept the push when on_internal_error allows it
+func Fail(logger *config.Logger, cfg config.Config, err error) {
+	code := githookkit.ExitCode(err)
+	kind := "INTERNAL ERROR"
+	switch code {
+	case githookkit.ExitRejected:
+		logger.Errorf("%s", locale.T("REJECTED: %s", err))
+		Exit(code, "")
+	case githookkit.ExitConfigError:
+		kind = "CONFIGURATION ERROR"
+	}
+	reason := strings.ToLower(kind) + ": " + err.Error()
+
+	if config.GetOnInternalError(cfg) == config.OnInternalErrorAllow {
+		logger.Errorf("%s", locale.T("%s: %v, on_internal_error is %s, push accepted without checks", locale.T(kind), err, config.OnInternalErrorAllow))
+		Exit(githookkit.ExitPass, reason)
+	}
+
+	logger.Errorf("%s", locale.T("%s: %v, on_internal_error is %s, push rejected", locale.T(kind), err, config.OnInternalErrorReject))
+	Exit(code, reason)
+}
@@ -0,0 +1,123 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+)
+
+// parseEmail returns the email of an identity "Name <email>", empty when it has none
+func parseEmail(identity string) string {
+	start, end := strings.LastIndex(identity, "<"), strings.LastIndex(identity, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return strings.TrimSpace(identity[start+1 : end])
+}
+
+// uploaderEmails returns the emails identifying the pusher of an update
+func uploaderEmails(cfg config.Config, update Update) ([]string, error) {
+	var emails []string
+	if email := parseEmail(update.Uploader); email != "" {
+		emails = append(emails, email)
+	}
+	if cfg.UploaderIdentity.AccountEmails && update.UploaderUsername != "" {
+		accountEmails, err := gerrit.AccountEmails(cfg.Gerrit, update.UploaderUsername)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, accountEmails...)
+	}
+
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("email of uploader %q is unknown, uploader_identity can't be checked", update.UploaderUsername)
+	}
+	return emails, nil
+}
+
+// identityProblems returns a problem when the committer, or the author when match is author, isn't the uploader
+func identityProblems(commit githookkit.Commit, match string, emails []string) []string {
+	who, email := config.IdentityCommitter, commit.CommitterEmail
+	if match == config.IdentityAuthor {
+		who, email = config.IdentityAuthor, commit.AuthorEmail
+	}
+
+	for _, uploader := range emails {
+		if strings.EqualFold(email, uploader) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s email %s doesn't match the uploader %s", who, email, emails[0])}
+}
+
+// emailDomainProblems returns a problem for the author and the committer email when its domain matches none of domains
+func emailDomainProblems(commit githookkit.Commit, domains []string) []string {
+	var problems []string
+	if !isEmailDomainAllowed(commit.AuthorEmail, domains) {
+		problems = append(problems, fmt.Sprintf("author email %s is not in an allowed domain", commit.AuthorEmail))
+	}
+	if commit.CommitterEmail != commit.AuthorEmail && !isEmailDomainAllowed(commit.CommitterEmail, domains) {
+		problems = append(problems, fmt.Sprintf("committer email %s is not in an allowed domain", commit.CommitterEmail))
+	}
+	return problems
+}
+
+// isEmailDomainAllowed checks if the domain of email matches one of the domain patterns
+// A leading @ of the patterns is ignored
+func isEmailDomainAllowed(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+
+	for _, pattern := range domains {
+		if matchFold(strings.TrimPrefix(pattern, "@"), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedIdentityProblems returns a problem for the author and the committer when they are blocked
+func blockedIdentityProblems(commit githookkit.Commit, blocked []config.BlockedIdentity) []string {
+	var problems []string
+	for _, who := range []struct{ role, name, email string }{
+		{config.IdentityAuthor, commit.AuthorName, commit.AuthorEmail},
+		{config.IdentityCommitter, commit.CommitterName, commit.CommitterEmail},
+	} {
+		if who.role == config.IdentityCommitter && who.name == commit.AuthorName && who.email == commit.AuthorEmail {
+			break
+		}
+		for _, identity := range blocked {
+			if isBlocked(identity, who.name, who.email) {
+				problem := fmt.Sprintf("%s %s <%s> is blocked", who.role, who.name, who.email)
+				if identity.Reason != "" {
+					problem += ": " + identity.Reason
+				}
+				problems = append(problems, problem)
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// isBlocked checks if an identity matches a blocked identity entry
+func isBlocked(identity config.BlockedIdentity, name, email string) bool {
+	if identity.Name != "" && identity.Name != name {
+		return false
+	}
+	return identity.Email == "" || matchFold(identity.Email, email)
+}
+
+// matchFold matches value against a ref pattern ignoring case, regular expressions are used as they are
+func matchFold(pattern, value string) bool {
+	if strings.HasPrefix(pattern, "^") {
+		return config.MatchRef(pattern, value)
+	}
+	return config.MatchRef(strings.ToLower(pattern), strings.ToLower(value))
+}
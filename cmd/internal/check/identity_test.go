@@ -0,0 +1,197 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestIsEmailDomainAllowed(t *testing.T) {
+	tests := []struct {
+		email    string
+		domains  []string
+		expected bool
+	}{
+		{"alice@example.com", []string{"example.com"}, true},
+		{"alice@Example.COM", []string{"@example.com"}, true},
+		{"alice@eng.example.com", []string{"example.com"}, false},
+		{"alice@eng.example.com", []string{"*.example.com"}, true},
+		{"alice@example.com.evil.org", []string{"example.com"}, false},
+		{"alice@example.org", []string{"example.com", "^example\\.(org|net)$"}, true},
+		{"alice", []string{"*"}, false},
+	}
+
+	for _, test := range tests {
+		if result := isEmailDomainAllowed(test.email, test.domains); result != test.expected {
+			t.Errorf("isEmailDomainAllowed(%s, %v) = %v, expected %v", test.email, test.domains, result, test.expected)
+		}
+	}
+}
+
+func TestEmailDomainProblems(t *testing.T) {
+	commit := githookkit.Commit{AuthorEmail: "alice@gmail.com", CommitterEmail: "bob@example.com"}
+	expected := []string{"author email alice@gmail.com is not in an allowed domain"}
+	if problems := emailDomainProblems(commit, []string{"example.com"}); !reflect.DeepEqual(problems, expected) {
+		t.Errorf("emailDomainProblems() = %v, expected %v", problems, expected)
+	}
+
+	commit.CommitterEmail = "bob@gmail.org"
+	if problems := emailDomainProblems(commit, []string{"example.com"}); len(problems) != 2 {
+		t.Errorf("emailDomainProblems() = %v, expected author and committer problems", problems)
+	}
+}
+
+func TestCheckEmailDomains(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		EmailDomains: []string{"corp.example"},
+		Projects:     map[string]config.ProjectPolicy{"oss/": {EmailDomains: []string{}}},
+	}
+
+	result, err := Check(cfg, logger, Update{Project: "internal", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add a): author email test@example.com is not in an allowed domain!") {
+		t.Errorf("Check() returned violations %v, expected the author email of Add a", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "oss/tool", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none for the exempted project", result.Violations)
+	}
+}
+
+func TestIdentityProblems(t *testing.T) {
+	commit := githookkit.Commit{AuthorEmail: "alice@example.com", CommitterEmail: "bob@example.com"}
+	tests := []struct {
+		name     string
+		match    string
+		emails   []string
+		expected []string
+	}{
+		{"Committer matches", "", []string{"Bob@example.com"}, nil},
+		{"Committer doesn't match", config.IdentityCommitter, []string{"alice@example.com"}, []string{"committer email bob@example.com doesn't match the uploader alice@example.com"}},
+		{"Author matches", config.IdentityAuthor, []string{"alice@example.com"}, nil},
+		{"Author matches an account email", config.IdentityAuthor, []string{"alice@example.org", "alice@example.com"}, nil},
+		{"Author doesn't match", config.IdentityAuthor, []string{"bob@example.com"}, []string{"author email alice@example.com doesn't match the uploader bob@example.com"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if problems := identityProblems(commit, test.match, test.emails); !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("identityProblems() = %v, expected %v", problems, test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckUploaderIdentity(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{UploaderIdentity: config.UploaderIdentity{Refs: []string{"refs/heads/*"}}}
+	update := Update{Project: "p", UploaderUsername: "test", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+
+	t.Run("Own commits", func(t *testing.T) {
+		update := update
+		update.Uploader = "Test User <test@example.com>"
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("Check() returned violations %v, expected none", result.Violations)
+		}
+	})
+
+	t.Run("Commits of another user", func(t *testing.T) {
+		update := update
+		update.Uploader = "Bob <bob@example.com>"
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add a): committer email test@example.com doesn't match the uploader bob@example.com!") {
+			t.Errorf("Check() returned violations %v, expected the committer of Add a", result.Violations)
+		}
+	})
+
+	t.Run("Unknown uploader email", func(t *testing.T) {
+		if _, err := Check(cfg, logger, update); err == nil {
+			t.Errorf("Check() should return error without uploader email")
+		}
+	})
+
+	t.Run("Review refs", func(t *testing.T) {
+		update := update
+		update.RefName = "refs/for/master"
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("Check() returned violations %v, expected none", result.Violations)
+		}
+	})
+}
+
+func TestBlockedIdentityProblems(t *testing.T) {
+	blocked := []config.BlockedIdentity{
+		{Email: "*@contractor.example", Reason: "contract ended"},
+		{Name: "Build Bot", Email: "bot@example.com"},
+	}
+	tests := []struct {
+		name     string
+		commit   githookkit.Commit
+		expected []string
+	}{
+		{"Allowed", githookkit.Commit{AuthorName: "Alice", AuthorEmail: "alice@example.com", CommitterName: "Alice", CommitterEmail: "alice@example.com"}, nil},
+		{"Blocked author", githookkit.Commit{AuthorName: "Carl", AuthorEmail: "Carl@Contractor.example", CommitterName: "Carl", CommitterEmail: "Carl@Contractor.example"},
+			[]string{"author Carl <Carl@Contractor.example> is blocked: contract ended"}},
+		{"Blocked committer", githookkit.Commit{AuthorName: "Alice", AuthorEmail: "alice@example.com", CommitterName: "Build Bot", CommitterEmail: "bot@example.com"},
+			[]string{"committer Build Bot <bot@example.com> is blocked"}},
+		{"Name and email must both match", githookkit.Commit{AuthorName: "Bob", AuthorEmail: "bot@example.com", CommitterName: "Bob", CommitterEmail: "bot@example.com"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if problems := blockedIdentityProblems(test.commit, blocked); !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("blockedIdentityProblems() = %v, expected %v", problems, test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckBlockedIdentities(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{BlockedIdentities: []config.BlockedIdentity{{Email: "test@example.com", Reason: "account was retired, ask #infra"}}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add a): author Test User <test@example.com> is blocked: account was retired, ask #infra!") {
+		t.Errorf("Check() returned violations %v, expected the blocked author of Add a", result.Violations)
+	}
+}
@@ -0,0 +1,78 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Registers the JPEG header decoder
+	_ "image/png"  // Registers the PNG header decoder
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// imageSampleSize is the number of bytes read to find the dimensions, JPEG files can have large metadata before them
+const imageSampleSize = 256 * 1024
+
+// imageBytesPerPixel estimates the size of decoded images, as RGBA
+const imageBytesPerPixel = 4
+
+// imageRule rejects updates adding images larger than the configured dimensions outside of the allowed paths
+func imageRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetImagePolicy(cfg, update.Project)
+	if policy.MaxWidth == 0 && policy.MaxHeight == 0 && policy.MaxDecodedSize == 0 {
+		return nil, nil
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		return matchAny(policy.AllowedPaths, file.Path)
+	})
+
+	var files []githookkit.FileInfo
+	var problems []string
+	read := func(object string, content []byte) {
+		header, format, err := image.DecodeConfig(bytes.NewReader(content))
+		if err == image.ErrFormat {
+			return
+		}
+		if err != nil {
+			logger.Debugf("Not checking %s, the %s header can't be read: %v", paths[object][0].Path, format, err)
+			return
+		}
+		if problem := imageProblem(header.Width, header.Height, policy); problem != "" {
+			for _, file := range paths[object] {
+				files = append(files, file)
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d oversized images:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleImage, config.RenderMessage(cfg, config.RuleImage, data,
+			fmt.Sprintf("push adds %d oversized images, the first one is %s: %s, scale them down!", len(files), files[0].Path, problems[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: imageSampleSize, read: read, report: report}, nil
+}
+
+// imageProblem returns the limit an image of width x height pixels exceeds, or ""
+func imageProblem(width, height int, policy config.ImagePolicy) string {
+	decodedSize := int64(width) * int64(height) * imageBytesPerPixel
+	switch {
+	case policy.MaxWidth > 0 && width > policy.MaxWidth:
+		return fmt.Sprintf("%dx%d pixels, the width exceeds %d", width, height, policy.MaxWidth)
+	case policy.MaxHeight > 0 && height > policy.MaxHeight:
+		return fmt.Sprintf("%dx%d pixels, the height exceeds %d", width, height, policy.MaxHeight)
+	case policy.MaxDecodedSize > 0 && decodedSize > policy.MaxDecodedSize:
+		return fmt.Sprintf("%dx%d pixels decode to %s, exceeding %s", width, height, githookkit.FormatSize(decodedSize), githookkit.FormatSize(policy.MaxDecodedSize))
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package check
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestImageProblem(t *testing.T) {
+	tests := []struct {
+		width    int
+		height   int
+		policy   config.ImagePolicy
+		expected string
+	}{
+		{100, 100, config.ImagePolicy{MaxWidth: 100, MaxHeight: 100}, ""},
+		{101, 50, config.ImagePolicy{MaxWidth: 100, MaxHeight: 100}, "101x50 pixels, the width exceeds 100"},
+		{50, 2000, config.ImagePolicy{MaxHeight: 1000}, "50x2000 pixels, the height exceeds 1000"},
+		{1000, 1000, config.ImagePolicy{MaxDecodedSize: 1024 * 1024}, "1000x1000 pixels decode to 3.81 MB, exceeding 1.00 MB"},
+		{500, 500, config.ImagePolicy{MaxDecodedSize: 1024 * 1024}, ""},
+	}
+
+	for _, test := range tests {
+		if problem := imageProblem(test.width, test.height, test.policy); problem != test.expected {
+			t.Errorf("imageProblem(%d, %d, %+v) = %q, expected %q", test.width, test.height, test.policy, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckImages(t *testing.T) {
+	var pngImage, jpegImage bytes.Buffer
+	if err := png.Encode(&pngImage, image.NewGray(image.Rect(0, 0, 300, 20))); err != nil {
+		t.Fatalf("Failed to encode png: %v", err)
+	}
+	if err := jpeg.Encode(&jpegImage, image.NewGray(image.Rect(0, 0, 40, 500)), nil); err != nil {
+		t.Fatalf("Failed to encode jpeg: %v", err)
+	}
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"docs/banner.png": pngImage.String(),
+		"docs/photo.jpg":  jpegImage.String(),
+		"docs/icon.png":   "not really a png",
+	}, "Add images")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		policy   config.ImagePolicy
+		expected string
+	}{
+		{"no policy", config.ImagePolicy{}, ""},
+		{"width", config.ImagePolicy{MaxWidth: 200}, "push adds 1 oversized images, the first one is docs/banner.png: 300x20 pixels, the width exceeds 200"},
+		{"height", config.ImagePolicy{MaxWidth: 400, MaxHeight: 400}, "push adds 1 oversized images, the first one is docs/photo.jpg: 40x500 pixels, the height exceeds 400"},
+		{"allowed paths", config.ImagePolicy{MaxWidth: 10, AllowedPaths: []string{"docs/*"}}, ""},
+	}
+
+	for _, test := range tests {
+		result, err := Check(config.Config{Images: test.policy}, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		if test.expected == "" {
+			if len(result.Violations) != 0 {
+				t.Errorf("%s: Check() returned violations %v, expected none", test.name, result.Violations)
+			}
+			continue
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.expected) {
+			t.Errorf("%s: Check() returned violations %v, expected %q", test.name, result.Violations, test.expected)
+		}
+	}
+}
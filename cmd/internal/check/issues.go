@@ -0,0 +1,93 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/tracker"
+)
+
+// issueChecker checks the issue references of the commit messages of one update
+type issueChecker struct {
+	pattern *regexp.Regexp
+	tracker *tracker.Client // nil when the issues aren't validated
+	onError string
+	result  *Result // Receives the warning when the tracker can't be reached
+	err     error   // First tracker failure, the tracker isn't asked again for the rest of the update
+}
+
+// newIssueChecker returns the checker of an issue reference rule
+func newIssueChecker(cfg config.Config, rule config.IssueReferenceRule, result *Result) (*issueChecker, error) {
+	pattern, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issue pattern: %w", err)
+	}
+
+	checker := &issueChecker{pattern: pattern, onError: cfg.IssueTracker.OnError, result: result}
+	if rule.Validate {
+		checker.tracker = tracker.New(cfg.IssueTracker)
+	}
+	return checker, nil
+}
+
+// problems returns what is wrong with the issue references of a message
+// One existing open issue is enough, messages often mention related issues that are closed already
+func (c *issueChecker) problems(message string) []string {
+	keys := issueKeys(c.pattern, message)
+	if len(keys) == 0 {
+		return []string{fmt.Sprintf("message doesn't reference an issue matching %s", c.pattern)}
+	}
+	if c.tracker == nil {
+		return nil
+	}
+
+	var problems []string
+	for _, key := range keys {
+		state, err := c.state(key)
+		switch {
+		case err != nil && c.onError == config.OnInternalErrorReject:
+			problems = append(problems, fmt.Sprintf("issue %s can't be validated: %v", key, err))
+		case err != nil:
+			return nil
+		case state == tracker.StateOpen:
+			return nil
+		case state == tracker.StateClosed:
+			problems = append(problems, fmt.Sprintf("issue %s is closed", key))
+		default:
+			problems = append(problems, fmt.Sprintf("issue %s doesn't exist", key))
+		}
+	}
+	return problems
+}
+
+// state looks up an issue, after the first failure the tracker is considered down and a warning is added once
+func (c *issueChecker) state(key string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+
+	state, err := c.tracker.State(key)
+	if err != nil {
+		c.err = err
+		if c.onError != config.OnInternalErrorReject {
+			c.result.Warnings = append(c.result.Warnings, fmt.Sprintf("issue tracker is unavailable, issue references were not validated: %v", err))
+		}
+	}
+	return state, err
+}
+
+// issueKeys returns the distinct issue keys of a message in order, the first capture group is the key when the pattern has groups
+func issueKeys(pattern *regexp.Regexp, message string) []string {
+	var keys []string
+	for _, match := range pattern.FindAllStringSubmatch(message, -1) {
+		key := match[0]
+		if len(match) > 1 && match[1] != "" {
+			key = match[1]
+		}
+		if !config.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,108 @@
+package check
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestIssueKeys(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		message  string
+		expected []string
+	}{
+		{`\b[A-Z]+-[0-9]+\b`, "PROJ-1: Fix crash\n\nSee also PROJ-2 and PROJ-1.\n", []string{"PROJ-1", "PROJ-2"}},
+		{`(?:refs|fixes) #([0-9]+)`, "Fix crash\n\nfixes #12\n", []string{"12"}},
+		{`\b[A-Z]+-[0-9]+\b`, "Fix crash\n", nil},
+	}
+
+	for _, test := range tests {
+		if keys := issueKeys(regexp.MustCompile(test.pattern), test.message); !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("issueKeys(%s) = %v, expected %v", test.pattern, keys, test.expected)
+		}
+	}
+}
+
+func TestIssueCheckerProblems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/issue/PROJ-1":
+			w.Write([]byte(`{"fields":{"status":{"name":"Open","statusCategory":{"key":"new"}}}}`))
+		case "/rest/api/2/issue/PROJ-2":
+			w.Write([]byte(`{"fields":{"status":{"name":"Done","statusCategory":{"key":"done"}}}}`))
+		case "/rest/api/2/issue/DOWN-1":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	rule := config.IssueReferenceRule{Ref: "*", Pattern: `\b[A-Z]+-[0-9]+\b`, Validate: true}
+	tests := []struct {
+		name     string
+		onError  string
+		message  string
+		expected []string
+		warnings int
+	}{
+		{"Open issue", "", "PROJ-1: Fix crash", nil, 0},
+		{"Open and closed issue", "", "PROJ-2: Fix crash\n\nReopened as PROJ-1", nil, 0},
+		{"Closed issue", "", "PROJ-2: Fix crash", []string{"issue PROJ-2 is closed"}, 0},
+		{"Missing issue", "", "PROJ-9: Fix crash", []string{"issue PROJ-9 doesn't exist"}, 0},
+		{"No issue", "", "Fix crash", []string{`message doesn't reference an issue matching \b[A-Z]+-[0-9]+\b`}, 0},
+		{"Tracker down", "", "DOWN-1: Fix crash", nil, 1},
+		{"Tracker down with on_error reject", config.OnInternalErrorReject, "DOWN-1: Fix crash", []string{"issue DOWN-1 can't be validated: issue tracker returned 503 Service Unavailable"}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Config{IssueTracker: config.IssueTracker{Type: config.TrackerJira, URL: server.URL, OnError: test.onError}}
+			var result Result
+			checker, err := newIssueChecker(cfg, rule, &result)
+			if err != nil {
+				t.Fatalf("newIssueChecker() returned error: %v", err)
+			}
+			if problems := checker.problems(test.message); !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("problems() = %v, expected %v", problems, test.expected)
+			}
+			if len(result.Warnings) != test.warnings {
+				t.Errorf("problems() added warnings %v, expected %d", result.Warnings, test.warnings)
+			}
+		})
+	}
+}
+
+func TestCheckIssueReferences(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "PROJ-1: Add a")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "Add b")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{IssueReferences: []config.IssueReferenceRule{{Ref: "refs/heads/release-*", Pattern: `\bPROJ-[0-9]+\b`}}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/release-1"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add b): message doesn't reference an issue") {
+		t.Errorf("Check() returned violations %v, expected the missing reference of Add b", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none", result.Violations)
+	}
+}
@@ -0,0 +1,122 @@
+package check
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// OutputJUnit is the format of scan and simulate for CI dashboards, a JUnit XML report with a test per rule
+const OutputJUnit = "junit"
+
+// JUnitReport is a JUnit XML report with a test suite per checked ref
+type JUnitReport struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the tests of the rules for one ref
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a rule, it fails when a violation of the rule rejects the push
+type JUnitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"` // Violations reported without rejecting the push
+}
+
+// JUnitFailure lists the violations of a rule
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnit returns the JUnit report of results, name is the binary that checked them
+// Every rule is a test of every ref so that dashboards keep the history of each rule
+func NewJUnit(name string, results []Result) JUnitReport {
+	report := JUnitReport{Name: name}
+	var total float64
+	for _, result := range results {
+		suite := JUnitTestSuite{
+			Name: strings.TrimSpace(result.Update.Project + " " + result.Update.RefName),
+			Time: fmt.Sprintf("%.3f", result.Duration.Seconds()),
+		}
+		total += result.Duration.Seconds()
+
+		violations := map[string][]githookkit.Violation{}
+		for _, violation := range result.Findings {
+			violations[violation.RuleID] = append(violations[violation.RuleID], violation)
+		}
+
+		for _, rule := range config.Rules {
+			testCase := JUnitTestCase{ClassName: suite.Name, Name: rule}
+			var failures, reported []string
+			message, last := "", ""
+			for _, violation := range violations[rule] {
+				// Violations of several files share their message, it is listed once followed by the files
+				text := ""
+				if violation.Message != last {
+					text = violation.Message
+					last = violation.Message
+				}
+				if where := violation.Path + violation.Commit; where != "" {
+					text = strings.TrimPrefix(text+"\n  "+where, "\n")
+				}
+				switch {
+				case violation.Bypassed:
+					reported = append(reported, "OVERRIDDEN: "+text)
+				case violation.Severity == githookkit.SeverityWarning:
+					reported = append(reported, "WARNING: "+text)
+				default:
+					if message == "" {
+						message = violation.Message
+					}
+					failures = append(failures, text)
+				}
+			}
+			if len(failures) > 0 {
+				testCase.Failure = &JUnitFailure{Message: message, Type: rule, Text: strings.Join(failures, "\n")}
+				suite.Failures++
+			}
+			testCase.SystemOut = strings.Join(reported, "\n")
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = fmt.Sprintf("%.3f", total)
+	return report
+}
+
+// WriteJUnit writes the JUnit report of results
+func WriteJUnit(w io.Writer, name string, results []Result) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(NewJUnit(name, results)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
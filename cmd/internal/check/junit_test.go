@@ -0,0 +1,68 @@
+package check
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestNewJUnit(t *testing.T) {
+	override := config.AppliedOverride{Option: "override-license", Rules: []string{config.RuleLicenseHeader}}
+	enforced := Result{Update: Update{Project: "app", RefName: "refs/heads/master"}, Mode: config.ModeEnforce, Overrides: []config.AppliedOverride{override}, Duration: 1500 * time.Millisecond}
+	enforced.violate(config.RuleCommitMessage, "commit abc: subject too long")
+	enforced.violate(config.RuleCommitMessage, "commit def: subject too long")
+	enforced.violate(config.RuleFileSize, "files are too large", githookkit.FileInfo{Path: "a.bin"}, githookkit.FileInfo{Path: "b.bin"})
+	enforced.violate(config.RuleLicenseHeader, "missing license header", githookkit.FileInfo{Path: "main.go"})
+
+	warned := Result{Update: Update{Project: "app", RefName: "refs/heads/dev"}, Mode: config.ModeWarn, Duration: 500 * time.Millisecond}
+	warned.violate(config.RuleFileSize, "file is too large", githookkit.FileInfo{Path: "c.bin"})
+
+	report := NewJUnit("simulate", []Result{enforced, warned})
+	if report.Name != "simulate" || len(report.Suites) != 2 || report.Tests != 2*len(config.Rules) || report.Failures != 2 || report.Time != "2.000" {
+		t.Fatalf("NewJUnit() = %s %d suites, %d tests, %d failures in %s, expected 2 suites, %d tests and 2 failures in 2.000",
+			report.Name, len(report.Suites), report.Tests, report.Failures, report.Time, 2*len(config.Rules))
+	}
+
+	cases := map[string]JUnitTestCase{}
+	for _, testCase := range report.Suites[0].Cases {
+		cases[testCase.Name] = testCase
+	}
+	if report.Suites[0].Name != "app refs/heads/master" || cases[config.RuleFileSize].ClassName != "app refs/heads/master" {
+		t.Errorf("suite %q has class name %q, expected the project and the ref", report.Suites[0].Name, cases[config.RuleFileSize].ClassName)
+	}
+	if failure := cases[config.RuleCommitMessage].Failure; failure == nil || failure.Message != "commit abc: subject too long" || failure.Text != "commit abc: subject too long\ncommit def: subject too long" {
+		t.Errorf("commit_message failure = %+v, expected both violations", failure)
+	}
+	if failure := cases[config.RuleFileSize].Failure; failure == nil || failure.Text != "files are too large\n  a.bin\n  b.bin" {
+		t.Errorf("file_size failure = %+v, expected the files", failure)
+	}
+	if testCase := cases[config.RuleLicenseHeader]; testCase.Failure != nil || !strings.HasPrefix(testCase.SystemOut, "OVERRIDDEN: missing license header") {
+		t.Errorf("bypassed license_header = %+v, expected a passing test reporting the violation", testCase)
+	}
+	if testCase := cases[config.RulePushSize]; testCase.Failure != nil || testCase.SystemOut != "" {
+		t.Errorf("push_size = %+v, expected a passing test", testCase)
+	}
+
+	for _, testCase := range report.Suites[1].Cases {
+		if testCase.Name == config.RuleFileSize && (testCase.Failure != nil || !strings.HasPrefix(testCase.SystemOut, "WARNING: file is too large")) {
+			t.Errorf("file_size in warn mode = %+v, expected a passing test reporting the violation", testCase)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteJUnit(&buffer, "scan", []Result{enforced}); err != nil {
+		t.Fatalf("WriteJUnit() returned error: %v", err)
+	}
+	if !strings.HasPrefix(buffer.String(), xml.Header+"<testsuites name=\"scan\"") {
+		t.Errorf("WriteJUnit() wrote %s, expected an XML declaration and testsuites", buffer.String())
+	}
+	var decoded JUnitReport
+	if err := xml.Unmarshal(buffer.Bytes(), &decoded); err != nil || decoded.Failures != 2 {
+		t.Errorf("WriteJUnit() wrote a report with %d failures (%v), expected 2", decoded.Failures, err)
+	}
+}
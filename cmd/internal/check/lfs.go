@@ -0,0 +1,147 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// lfsPointerMaxSize is the size git lfs itself treats as the upper bound of a pointer file
+const lfsPointerMaxSize = 1024
+
+// lfsPointer matches the pointer file format of the git lfs specification
+var lfsPointer = regexp.MustCompile(`^version https://git-lfs\.github\.com/spec/v1\n(?:[a-z0-9.-]+ [^\n]*\n)*$`)
+
+var (
+	lfsPointerOID  = regexp.MustCompile(`(?m)^oid sha256:[0-9a-f]{64}$`)
+	lfsPointerSize = regexp.MustCompile(`(?m)^size [0-9]+$`)
+)
+
+// isLFSPointer reports whether content is a valid git lfs pointer file
+func isLFSPointer(content []byte) bool {
+	return len(content) < lfsPointerMaxSize && lfsPointer.Match(content) && lfsPointerOID.Match(content) && lfsPointerSize.Match(content)
+}
+
+// matchAttributePattern checks if a path matches a .gitattributes pattern
+// Patterns without a slash match the file name in any directory, other patterns match the path from the repository root
+func matchAttributePattern(pattern, filePath string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(filePath))
+		return matched
+	}
+	matched, _ := path.Match(strings.TrimPrefix(pattern, "/"), filePath)
+	return matched
+}
+
+// lfsPointerRule rejects updates adding raw content for files that must be stored in git lfs
+func lfsPointerRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	patterns := config.GetLFSPatterns(cfg, update.Project)
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	// Files above the pointer size can't be pointers and don't need to be read
+	var rawFiles, pointers []githookkit.FileInfo
+	for _, file := range newFiles {
+		if !matchAttributePatterns(patterns, file.Path) {
+			continue
+		}
+		if file.Size >= lfsPointerMaxSize || file.Hash == "" {
+			rawFiles = append(rawFiles, file)
+		} else {
+			pointers = append(pointers, file)
+		}
+	}
+	objects, files := githookkit.GroupBlobs(pointers, nil)
+
+	read := func(object string, content []byte) {
+		if !isLFSPointer(content) {
+			rawFiles = append(rawFiles, files[object]...)
+		}
+	}
+
+	report := func() error {
+		if len(rawFiles) == 0 {
+			return nil
+		}
+		var largest githookkit.FileInfo
+		for _, file := range rawFiles {
+			if file.Size > largest.Size {
+				largest = file
+			}
+		}
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(patterns, ", "), Actual: rawFiles[0].Path, Count: len(rawFiles), Files: rawFiles, Largest: largest}
+		result.violate(config.RuleLFS, config.RenderMessage(cfg, config.RuleLFS, data,
+			fmt.Sprintf("%d files matching the lfs patterns %s are not lfs pointers, the first one is %s, run git lfs install and recommit them!", len(rawFiles), data.Limit, rawFiles[0].Path)), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: lfsPointerMaxSize, read: read, report: report}, nil
+}
+
+// checkLFSAttributes rejects updates of .gitattributes that stop tracking one of the lfs patterns with git lfs
+// Only the .gitattributes file at the root is checked, and only patterns tracked before the update have to stay tracked
+func checkLFSAttributes(cfg config.Config, update Update, result *Result) error {
+	patterns := config.GetLFSPatterns(cfg, update.Project)
+	if len(patterns) == 0 || update.OldRev == ZeroRev || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	oldAttributes, _, err := update.Repository.ReadFile(update.OldRev, ".gitattributes")
+	if err != nil {
+		return err
+	}
+	newAttributes, _, err := update.Repository.ReadFile(update.NewRev, ".gitattributes")
+	if err != nil {
+		return err
+	}
+
+	var untracked []string
+	for _, pattern := range patterns {
+		if isLFSTracked(oldAttributes, pattern) && !isLFSTracked(newAttributes, pattern) {
+			untracked = append(untracked, pattern)
+		}
+	}
+	if len(untracked) == 0 {
+		return nil
+	}
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(patterns, ", "), Actual: strings.Join(untracked, ", "), Count: len(untracked)}
+	result.violate(config.RuleLFSAttributes, config.RenderMessage(cfg, config.RuleLFSAttributes, data,
+		fmt.Sprintf(".gitattributes no longer tracks %s with git lfs, restore the filter=lfs lines!", data.Actual)))
+	return nil
+}
+
+// isLFSTracked reports whether the .gitattributes content sets filter=lfs for exactly pattern, the last matching line wins
+func isLFSTracked(attributes []byte, pattern string) bool {
+	tracked := false
+	for _, line := range strings.Split(string(attributes), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != pattern {
+			continue
+		}
+		for _, attribute := range fields[1:] {
+			switch {
+			case attribute == "filter=lfs":
+				tracked = true
+			case attribute == "-filter", attribute == "!filter", strings.HasPrefix(attribute, "filter="):
+				tracked = false
+			}
+		}
+	}
+	return tracked
+}
+
+// matchAttributePatterns checks if a path matches one of the .gitattributes patterns
+func matchAttributePatterns(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if matchAttributePattern(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+const testPointer = "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+
+func TestIsLFSPointer(t *testing.T) {
+	tests := map[string]bool{
+		testPointer: true,
+		"version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\next-0-foo sha256:abc\n": true,
+		"version https://git-lfs.github.com/spec/v1\nsize 12345\n":                  false,
+		"version https://git-lfs.github.com/spec/v1\noid sha256:4d7a\nsize 12345\n": false,
+		"\x89PNG\r\n\x1a\n":             false,
+		strings.Repeat(testPointer, 20): false,
+	}
+	for content, expected := range tests {
+		if result := isLFSPointer([]byte(content)); result != expected {
+			t.Errorf("isLFSPointer(%q) = %v, expected %v", content, result, expected)
+		}
+	}
+}
+
+func TestMatchAttributePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"*.psd", "art/cover.psd", true},
+		{"*.psd", "cover.psd", true},
+		{"*.psd", "cover.psd.txt", false},
+		{"/assets/*.bin", "assets/data.bin", true},
+		{"assets/*.bin", "assets/data.bin", true},
+		{"assets/*.bin", "sub/assets/data.bin", false},
+	}
+	for _, test := range tests {
+		if result := matchAttributePattern(test.pattern, test.path); result != test.expected {
+			t.Errorf("matchAttributePattern(%s, %s) = %v, expected %v", test.pattern, test.path, result, test.expected)
+		}
+	}
+}
+
+func TestCheckLFSPointers(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"art/cover.psd":   testPointer,
+		"art/raw.psd":     "8BPS raw photoshop content",
+		"video/intro.mp4": strings.Repeat("x", 2048),
+		"src/main.go":     "package main\n",
+	}, "Add assets")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+
+	tests := []struct {
+		name      string
+		cfg       config.Config
+		wantCount int
+		expected  string
+	}{
+		{"No patterns", config.Config{}, 0, ""},
+		{"Raw content", config.Config{LFSPatterns: []string{"*.psd", "*.mp4"}}, 1, "2 files matching the lfs patterns *.psd, *.mp4 are not lfs pointers"},
+		{"Pointers only", config.Config{LFSPatterns: []string{"cover.psd"}}, 0, ""},
+		{"Project patterns", config.Config{
+			LFSPatterns: []string{"*.psd"},
+			Projects:    map[string]config.ProjectPolicy{"p": {LFSPatterns: []string{"*.mp4"}}},
+		}, 1, "the first one is video/intro.mp4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Check(test.cfg, logger, update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != test.wantCount {
+				t.Fatalf("Check() returned violations %v, expected %d", result.Violations, test.wantCount)
+			}
+			if test.wantCount > 0 && !strings.Contains(result.Violations[0], test.expected) {
+				t.Errorf("violation %q does not contain %q", result.Violations[0], test.expected)
+			}
+		})
+	}
+}
+
+func TestIsLFSTracked(t *testing.T) {
+	attributes := []byte("# Assets\n*.psd filter=lfs diff=lfs merge=lfs -text\n*.mp4 filter=lfs diff=lfs merge=lfs -text\n*.mp4 -filter\n*.txt text\n")
+
+	tests := map[string]bool{"*.psd": true, "*.mp4": false, "*.txt": false, "*.zip": false}
+	for pattern, expected := range tests {
+		if result := isLFSTracked(attributes, pattern); result != expected {
+			t.Errorf("isLFSTracked(%s) = %v, expected %v", pattern, result, expected)
+		}
+	}
+}
+
+func TestCheckLFSAttributes(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	tracked := testutil.CommitContents(t, dir, map[string]string{".gitattributes": "*.psd filter=lfs diff=lfs merge=lfs -text\n"}, "Track psd files")
+	edited := testutil.CommitContents(t, dir, map[string]string{".gitattributes": "*.psd filter=lfs diff=lfs merge=lfs -text\n*.txt text\n"}, "Add text attributes")
+	untracked := testutil.CommitContents(t, dir, map[string]string{".gitattributes": "*.txt text\n"}, "Stop tracking psd files")
+	testutil.Git(t, dir, "rm", "-q", ".gitattributes")
+	testutil.Git(t, dir, "commit", "-q", "-m", "Remove attributes")
+	removed := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{LFSPatterns: []string{"*.psd", "*.mp4"}}
+
+	tests := []struct {
+		name      string
+		oldRev    string
+		newRev    string
+		wantCount int
+	}{
+		{"Still tracked", tracked, edited, 0},
+		{"Pattern removed", edited, untracked, 1},
+		{"File removed", edited, removed, 1},
+		{"Never tracked", untracked, removed, 0},
+		{"Tracking added", untracked, edited, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			update := Update{Project: "p", OldRev: test.oldRev, NewRev: test.newRev, RefName: "refs/heads/master"}
+			result, err := Check(cfg, logger, update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != test.wantCount {
+				t.Fatalf("Check() returned violations %v, expected %d", result.Violations, test.wantCount)
+			}
+			if test.wantCount > 0 && !strings.Contains(result.Violations[0], ".gitattributes no longer tracks *.psd with git lfs") {
+				t.Errorf("violation %q does not name the untracked pattern", result.Violations[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// licenseHeaderSampleSize is the number of bytes of each added file searched for the header
+const licenseHeaderSampleSize = 64 * 1024
+
+// Placeholders of license header templates
+var licenseHeaderPlaceholders = strings.NewReplacer(
+	regexp.QuoteMeta("{year}"), `[0-9]{4}(\s*[-,]\s*[0-9]{4})*`,
+	regexp.QuoteMeta("{author}"), `[^\n]+?`,
+)
+
+// licenseHeaderRule rejects updates adding source files that don't start with their license header
+func licenseHeaderRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	headers := config.GetLicenseHeaders(cfg, update.Project)
+	if len(headers) == 0 || update.NewRev == ZeroRev {
+		return nil, nil
+	}
+
+	changes, err := update.Repository.GetChanges(update.NewRev, update.OldRev)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := make([]*regexp.Regexp, len(headers))
+	for i, header := range headers {
+		patterns[i] = licenseHeaderPattern(header.Template)
+	}
+	// pattern returns the header of the first template matching path, nil when none does
+	pattern := func(path string) *regexp.Regexp {
+		for i, header := range headers {
+			if matchAny(header.Paths, path) {
+				return patterns[i]
+			}
+		}
+		return nil
+	}
+
+	// Only added files need the header, the new blobs of the push also include modified files
+	var added []githookkit.FileInfo
+	for _, change := range changes {
+		if change.Status == "A" && change.NewMode != githookkit.ModeSymlink && change.NewMode != githookkit.ModeGitlink {
+			added = append(added, githookkit.FileInfo{Path: change.Path, Hash: change.NewHash})
+		}
+	}
+	objects, paths := githookkit.GroupBlobs(added, func(file githookkit.FileInfo) bool {
+		return pattern(file.Path) == nil
+	})
+
+	var files []githookkit.FileInfo
+	read := func(object string, content []byte) {
+		for _, file := range paths[object] {
+			if !hasLicenseHeader(content, pattern(file.Path)) {
+				files = append(files, file)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d added files without license header:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s", files[i].Path)
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: files[0].Path, Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleLicenseHeader, config.RenderMessage(cfg, config.RuleLicenseHeader, data,
+			fmt.Sprintf("push adds %d files without license header, the first one is %s, add the header to them!", len(files), files[0].Path)), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: licenseHeaderSampleSize, read: read, report: report}, nil
+}
+
+// licenseHeaderPattern compiles a license header template, trailing whitespace of its lines doesn't matter
+func licenseHeaderPattern(template string) *regexp.Regexp {
+	lines := strings.Split(strings.TrimRight(template, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = licenseHeaderPlaceholders.Replace(regexp.QuoteMeta(strings.TrimRight(line, " \t\r"))) + `[ \t\r]*`
+	}
+	return regexp.MustCompile(`\A` + strings.Join(lines, `\n`))
+}
+
+// hasLicenseHeader reports whether content starts with the header, after an optional #! line and a UTF-8 byte order mark
+func hasLicenseHeader(content []byte, header *regexp.Regexp) bool {
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	if strings.HasPrefix(text, "#!") {
+		_, text, _ = strings.Cut(text, "\n")
+	}
+	return header.MatchString(text)
+}
@@ -0,0 +1,64 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+const goLicense = `// Copyright {year} {author}
+// SPDX-License-Identifier: Apache-2.0
+`
+
+func TestHasLicenseHeader(t *testing.T) {
+	header := licenseHeaderPattern(goLicense)
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"header", "// Copyright 2024 Example Inc.\n// SPDX-License-Identifier: Apache-2.0\n\npackage main\n", true},
+		{"year range", "// Copyright 2019-2024 Jane Doe  \n// SPDX-License-Identifier: Apache-2.0\npackage main\n", true},
+		{"year list", "// Copyright 2019, 2021 Jane Doe\n// SPDX-License-Identifier: Apache-2.0\n", true},
+		{"crlf", "// Copyright 2024 Example Inc.\r\n// SPDX-License-Identifier: Apache-2.0\r\n", true},
+		{"shebang", "#!/usr/bin/env gorun\n// Copyright 2024 Example Inc.\n// SPDX-License-Identifier: Apache-2.0\n", true},
+		{"missing", "package main\n", false},
+		{"wrong license", "// Copyright 2024 Example Inc.\n// SPDX-License-Identifier: MIT\n", false},
+		{"no year", "// Copyright Example Inc.\n// SPDX-License-Identifier: Apache-2.0\n", false},
+		{"not at the start", "package main\n// Copyright 2024 Example Inc.\n// SPDX-License-Identifier: Apache-2.0\n", false},
+	}
+
+	for _, test := range tests {
+		if found := hasLicenseHeader([]byte(test.content), header); found != test.expected {
+			t.Errorf("%s: hasLicenseHeader() = %v, expected %v", test.name, found, test.expected)
+		}
+	}
+}
+
+func TestCheckLicenseHeaders(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"old.go": "package old\n"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"old.go":      "package old\n\nvar x = 1\n",
+		"main.go":     "// Copyright 2024 Example Inc.\n// SPDX-License-Identifier: Apache-2.0\n\npackage main\n",
+		"util.go":     "package main\n",
+		"run.py":      "print(1)\n",
+		"docs/api.md": "# API\n",
+	}, "Add sources")
+	chdir(t, dir)
+
+	cfg := config.Config{LicenseHeaders: []config.LicenseHeader{
+		{Paths: []string{"*.go"}, Template: goLicense},
+		{Paths: []string{"*.py"}, Template: "# Copyright {year} {author}\n"},
+	}}
+	result, err := Check(cfg, newTestLogger(t), Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	expected := "push adds 2 files without license header, the first one is run.py"
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+		t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+	}
+}
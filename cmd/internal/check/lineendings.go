@@ -0,0 +1,104 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// lineEndingSampleSize is the number of bytes of each new file searched for line endings
+const lineEndingSampleSize = 1024 * 1024
+
+// lineEndingRule reports new text files with CRLF line endings, unless .gitattributes decides their line endings
+func lineEndingRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetLineEndingPolicy(cfg, update.Project)
+	if policy.Deny == "" || update.NewRev == ZeroRev {
+		return nil, nil
+	}
+
+	attributes, _, err := update.Repository.ReadFile(update.NewRev, ".gitattributes")
+	if err != nil {
+		return nil, err
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		return hasEOLAttribute(attributes, file.Path)
+	})
+
+	var files []githookkit.FileInfo
+	var problems []string
+	read := func(object string, content []byte) {
+		if isBinary(content[:min(len(content), config.DefaultBinarySampleSize)]) {
+			return
+		}
+		if problem := lineEndingProblem(content, policy.Deny); problem != "" {
+			for _, file := range paths[object] {
+				files = append(files, file)
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d files with CRLF line endings:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: policy.Deny, Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+		message := config.RenderMessage(cfg, config.RuleLineEnding, data,
+			fmt.Sprintf("push adds %d files with CRLF line endings, the first one is %s (%s), convert them with dos2unix or set eol in .gitattributes!", len(files), files[0].Path, problems[0]))
+		if policy.Warn {
+			result.Warnings = append(result.Warnings, message)
+			return nil
+		}
+		result.violate(config.RuleLineEnding, message)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: lineEndingSampleSize, read: read, report: report}, nil
+}
+
+// lineEndingProblem describes the line endings of content denied by deny, or returns ""
+func lineEndingProblem(content []byte, deny string) string {
+	crlf := bytes.Count(content, []byte("\r\n"))
+	lf := bytes.Count(content, []byte("\n")) - crlf
+	switch {
+	case crlf == 0:
+		return ""
+	case lf > 0:
+		return fmt.Sprintf("%d CRLF and %d LF line endings", crlf, lf)
+	case deny == config.LineEndingsCRLF:
+		return fmt.Sprintf("%d CRLF line endings", crlf)
+	}
+	return ""
+}
+
+// hasEOLAttribute reports whether the .gitattributes content decides the line endings of path:
+// eol is set, or text is unset (-text or binary). For each attribute the last matching line wins.
+func hasEOLAttribute(attributes []byte, path string) bool {
+	eol, noText := false, false
+	for _, line := range strings.Split(string(attributes), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || !matchAttributePattern(fields[0], path) {
+			continue
+		}
+		for _, attribute := range fields[1:] {
+			switch {
+			case strings.HasPrefix(attribute, "eol="):
+				eol = true
+			case attribute == "-eol", attribute == "!eol":
+				eol = false
+			case attribute == "-text", attribute == "binary":
+				noText = true
+			case attribute == "text", strings.HasPrefix(attribute, "text="), attribute == "!text":
+				noText = false
+			}
+		}
+	}
+	return eol || noText
+}
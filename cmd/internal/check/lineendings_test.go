@@ -0,0 +1,91 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestLineEndingProblem(t *testing.T) {
+	tests := []struct {
+		content  string
+		deny     string
+		expected string
+	}{
+		{"a\nb\n", config.LineEndingsCRLF, ""},
+		{"a\r\nb\r\n", config.LineEndingsCRLF, "2 CRLF line endings"},
+		{"a\r\nb\r\n", config.LineEndingsMixed, ""},
+		{"a\r\nb\nc\n", config.LineEndingsMixed, "1 CRLF and 2 LF line endings"},
+		{"a\r\nb\nc\n", config.LineEndingsCRLF, "1 CRLF and 2 LF line endings"},
+		{"old mac\rline", config.LineEndingsCRLF, ""},
+	}
+
+	for _, test := range tests {
+		if problem := lineEndingProblem([]byte(test.content), test.deny); problem != test.expected {
+			t.Errorf("lineEndingProblem(%q, %s) = %q, expected %q", test.content, test.deny, problem, test.expected)
+		}
+	}
+}
+
+func TestHasEOLAttribute(t *testing.T) {
+	attributes := []byte("# line endings\n*.bat eol=crlf\n*.png binary\n*.txt -text\ndocs/*.txt text\n*.sh text eol=lf\n")
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"build.bat", true},
+		{"tools/run.sh", true},
+		{"images/logo.png", true},
+		{"notes.txt", true},
+		{"docs/readme.txt", false},
+		{"src/main.c", false},
+	}
+
+	for _, test := range tests {
+		if exempt := hasEOLAttribute(attributes, test.path); exempt != test.expected {
+			t.Errorf("hasEOLAttribute(%q) = %v, expected %v", test.path, exempt, test.expected)
+		}
+	}
+}
+
+func TestCheckLineEndings(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	testutil.Git(t, dir, "config", "core.autocrlf", "false")
+	oldRev := testutil.CommitContents(t, dir, map[string]string{".gitattributes": "*.bat eol=crlf\n"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"src/main.c": "int main() {\r\n}\r\n",
+		"build.bat":  "@echo off\r\n",
+		"README":     "readme\n",
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+	expected := "push adds 1 files with CRLF line endings, the first one is src/main.c (2 CRLF line endings)"
+
+	result, err := Check(config.Config{LineEndings: config.LineEndingPolicy{Deny: config.LineEndingsCRLF}}, logger, update)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+		t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+	}
+
+	result, err = Check(config.Config{LineEndings: config.LineEndingPolicy{Deny: config.LineEndingsCRLF, Warn: true}}, logger, update)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], expected) {
+		t.Errorf("Check() returned violations %v and warnings %v, expected only the warning %q", result.Violations, result.Warnings, expected)
+	}
+
+	result, err = Check(config.Config{LineEndings: config.LineEndingPolicy{Deny: config.LineEndingsMixed}}, logger, update)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none without mixed line endings", result.Violations)
+	}
+}
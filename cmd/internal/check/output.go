@@ -0,0 +1,167 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Output formats of the hooks, selected with --format or GITHOOK_OUTPUT
+// Human-readable messages always go to stderr, the json format adds a decision document on stdout
+// and the sarif format a SARIF log of the violations
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputSARIF = "sarif"
+)
+
+// Decisions of a hook and of its ref updates in the decision document
+const (
+	DecisionAccept = "accept"
+	DecisionReject = "reject"
+	DecisionWarn   = "warn" // Only for ref updates: violations were reported in warn mode and the update is accepted
+)
+
+// Document is the decision document a hook writes to stdout in the json output format
+type Document struct {
+	Hook     string        `json:"hook"`
+	Decision string        `json:"decision"`
+	Reason   string        `json:"reason,omitempty"` // Why the hook decided without its checks, e.g. an internal error
+	Duration int64         `json:"duration_ms"`
+	Refs     []RefDocument `json:"refs"`
+}
+
+// RefDocument is the outcome of the checks of one ref update
+type RefDocument struct {
+	Project    string                   `json:"project"`
+	RefName    string                   `json:"ref_name"`
+	OldRev     string                   `json:"old_rev"`
+	NewRev     string                   `json:"new_rev"`
+	Mode       string                   `json:"mode,omitempty"`
+	Decision   string                   `json:"decision"`
+	Violations []githookkit.Violation   `json:"violations"` // Including the bypassed ones
+	Warnings   []string                 `json:"warnings,omitempty"`
+	Overrides  []config.AppliedOverride `json:"overrides,omitempty"`
+	Files      []FileDocument           `json:"files"`
+	Size       int64                    `json:"size"` // Total size of the new files
+	Duration   int64                    `json:"duration_ms"`
+}
+
+// FileDocument is a new file of a ref update
+type FileDocument struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// output holds the format of the running hook and the results it decided on, until WriteOutput
+var output = struct {
+	hook    string
+	format  string
+	start   time.Time
+	results []Result
+}{format: OutputText, start: time.Now()}
+
+// SetOutput selects the output format of hookName, GITHOOK_OUTPUT is used when format is empty
+func SetOutput(hookName, format string) error {
+	if format == "" {
+		format = os.Getenv("GITHOOK_OUTPUT")
+	}
+	switch format {
+	case "", OutputText:
+		format = OutputText
+	case OutputJSON, OutputSARIF:
+	default:
+		return fmt.Errorf("unknown output format %q, expected %s, %s or %s", format, OutputText, OutputJSON, OutputSARIF)
+	}
+	output.hook, output.format = hookName, format
+	return nil
+}
+
+// AddOutput adds results to the output of the hook
+func AddOutput(results ...Result) {
+	output.results = append(output.results, results...)
+}
+
+// WriteOutput writes the decision document or the SARIF log to stdout, code is the exit code of the hook
+func WriteOutput(code int, reason string) {
+	switch output.format {
+	case OutputJSON:
+		writeDocument(os.Stdout, newDocument(output.hook, code, reason, time.Since(output.start), output.results))
+	case OutputSARIF:
+		if err := WriteSARIF(os.Stdout, output.hook, output.results); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write the SARIF log: %v\n", err)
+		}
+	}
+}
+
+// Exit writes the output of the hook and ends it with code
+func Exit(code int, reason string) {
+	WriteOutput(code, reason)
+	os.Exit(code)
+}
+
+// hookStdout returns where chained hooks write their stdout, it is kept free for the document of the hook
+func hookStdout() io.Writer {
+	if output.format != OutputText {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// newDocument returns the decision document of a hook ending with code after duration
+func newDocument(hookName string, code int, reason string, duration time.Duration, results []Result) Document {
+	document := Document{
+		Hook:     hookName,
+		Decision: DecisionAccept,
+		Reason:   reason,
+		Duration: duration.Milliseconds(),
+		Refs:     []RefDocument{},
+	}
+	if code != 0 {
+		document.Decision = DecisionReject
+	}
+
+	for _, result := range results {
+		ref := RefDocument{
+			Project:    result.Update.Project,
+			RefName:    result.Update.RefName,
+			OldRev:     result.Update.OldRev,
+			NewRev:     result.Update.NewRev,
+			Mode:       result.Mode,
+			Decision:   DecisionAccept,
+			Violations: result.Findings,
+			Warnings:   result.Warnings,
+			Overrides:  result.Overrides,
+			Files:      make([]FileDocument, 0, len(result.NewFiles)),
+			Duration:   result.Duration.Milliseconds(),
+		}
+		if ref.Violations == nil {
+			ref.Violations = []githookkit.Violation{}
+		}
+		switch {
+		case result.Rejected():
+			ref.Decision = DecisionReject
+		case len(result.Violations) > 0:
+			ref.Decision = DecisionWarn
+		}
+		for _, file := range result.NewFiles {
+			ref.Files = append(ref.Files, FileDocument{Path: file.Path, Hash: file.Hash, Size: file.Size})
+			ref.Size += file.Size
+		}
+		document.Refs = append(document.Refs, ref)
+	}
+	return document
+}
+
+// writeDocument writes the decision document as one line of JSON
+func writeDocument(w io.Writer, document Document) {
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the decision document: %v\n", err)
+	}
+}
@@ -0,0 +1,96 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestSetOutput(t *testing.T) {
+	t.Cleanup(func() { output.format = OutputText })
+
+	tests := []struct {
+		name     string
+		env      string
+		format   string
+		expected string
+		wantErr  bool
+	}{
+		{"Default", "", "", OutputText, false},
+		{"Environment", "json", "", OutputJSON, false},
+		{"Flag over environment", "json", "text", OutputText, false},
+		{"Unknown format", "", "xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHOOK_OUTPUT", tt.env)
+			output.format = ""
+			err := SetOutput("update", tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && output.format != tt.expected {
+				t.Errorf("SetOutput() selected %q, expected %q", output.format, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewDocument(t *testing.T) {
+	override := config.AppliedOverride{Option: "override-size", Rules: []string{config.RuleFileSize}}
+	rejected := Result{Update: Update{Project: "p", RefName: "refs/heads/large"}, Mode: config.ModeEnforce, Overrides: []config.AppliedOverride{override}}
+	rejected.violate(config.RuleFileSize, "large.bin is too large", githookkit.FileInfo{Path: "large.bin", Size: 8192})
+	rejected.violate(config.RuleNewFiles, "too many new files")
+	rejected.NewFiles = []githookkit.FileInfo{{Path: "large.bin", Hash: "abc", Size: 8192}, {Path: "small.txt", Hash: "def", Size: 100}}
+	rejected.Duration = 1500 * time.Millisecond
+
+	warned := Result{Update: Update{Project: "p", RefName: "refs/heads/warn"}, Mode: config.ModeWarn}
+	warned.violate(config.RuleNewFiles, "too many new files")
+
+	document := newDocument("pre-receive", 1, "", 2*time.Second, []Result{rejected, warned, {Update: Update{RefName: "refs/heads/master"}}})
+	if document.Hook != "pre-receive" || document.Decision != DecisionReject || document.Duration != 2000 || len(document.Refs) != 3 {
+		t.Fatalf("newDocument() = %+v, expected the rejection of 3 refs after 2000 ms", document)
+	}
+
+	ref := document.Refs[0]
+	expectedViolations := []githookkit.Violation{
+		{RuleID: config.RuleFileSize, Severity: githookkit.SeverityWarning, Path: "large.bin", Size: 8192, Message: "large.bin is too large", Bypassed: true},
+		{RuleID: config.RuleNewFiles, Severity: githookkit.SeverityError, Message: "too many new files"},
+	}
+	if ref.Decision != DecisionReject || !reflect.DeepEqual(ref.Violations, expectedViolations) {
+		t.Errorf("rejected ref has decision %s and violations %+v, expected %s and %+v", ref.Decision, ref.Violations, DecisionReject, expectedViolations)
+	}
+	if len(ref.Files) != 2 || ref.Size != 8292 || ref.Duration != 1500 {
+		t.Errorf("rejected ref has files %+v of %d bytes checked in %d ms, expected 2 files of 8292 bytes in 1500 ms", ref.Files, ref.Size, ref.Duration)
+	}
+	if document.Refs[1].Decision != DecisionWarn {
+		t.Errorf("ref in warn mode has decision %s, expected %s", document.Refs[1].Decision, DecisionWarn)
+	}
+	if document.Refs[2].Decision != DecisionAccept {
+		t.Errorf("ref without violations has decision %s, expected %s", document.Refs[2].Decision, DecisionAccept)
+	}
+
+	// Empty lists are written as such, so that consumers need no null checks
+	var buffer bytes.Buffer
+	writeDocument(&buffer, newDocument("update", 0, "project is in the whitelist", 0, nil))
+	expected := `{"hook":"update","decision":"accept","reason":"project is in the whitelist","duration_ms":0,"refs":[]}` + "\n"
+	if buffer.String() != expected {
+		t.Errorf("writeDocument() wrote %s, expected %s", buffer.String(), expected)
+	}
+
+	buffer.Reset()
+	writeDocument(&buffer, newDocument("update", 0, "", 0, []Result{{}}))
+	var decoded struct{ Refs []map[string]json.RawMessage }
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode %s: %v", buffer.String(), err)
+	}
+	if ref := decoded.Refs[0]; string(ref["violations"]) != "[]" || string(ref["files"]) != "[]" {
+		t.Errorf("writeDocument() wrote %s, expected empty violations and files", buffer.String())
+	}
+}
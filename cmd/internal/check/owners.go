@@ -0,0 +1,70 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkPathOwners rejects updates changing paths the uploader doesn't own
+func checkPathOwners(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	if update.NewRev == ZeroRev {
+		return nil
+	}
+
+	var owners []config.PathOwner
+	for _, owner := range config.GetPathOwners(cfg, update.Project) {
+		if (len(owner.Refs) == 0 || matchAny(owner.Refs, update.RefName)) && !config.IsPathOwner(cfg, owner, update.UploaderUsername) {
+			owners = append(owners, owner)
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	paths, err := update.Repository.GetChangedPaths(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	var files []githookkit.FileInfo
+	var first config.PathOwner
+	for _, path := range paths {
+		for _, owner := range owners {
+			if matchAny(owner.Paths, path) {
+				if len(files) == 0 {
+					first = owner
+				}
+				files = append(files, githookkit.FileInfo{Path: path})
+				break
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d changed files owned by other users:", len(files)), len(files), func(i int) string {
+		return fmt.Sprintf("Path: %s", files[i].Path)
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Count: len(files), Files: files, Largest: files[0]}
+	result.violate(config.RulePathOwner, config.RenderMessage(cfg, config.RulePathOwner, data,
+		fmt.Sprintf("push changes %d files you don't own, the first one is %s which only %s may change!", len(files), files[0].Path, describeOwners(first))), data.Files...)
+	return nil
+}
+
+// describeOwners lists the users and groups of a path owner entry for messages
+func describeOwners(owner config.PathOwner) string {
+	var names []string
+	names = append(names, owner.Users...)
+	for _, group := range owner.Groups {
+		names = append(names, "members of "+group)
+	}
+	if len(names) == 0 {
+		return "nobody"
+	}
+	return strings.Join(names, ", ")
+}
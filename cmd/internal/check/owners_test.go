@@ -0,0 +1,52 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckPathOwners(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"tools/release/build.sh": "v1", "README": "r"}, "Initial")
+	testutil.CommitContents(t, dir, map[string]string{"README": "r2"}, "Update README")
+	testutil.Git(t, dir, "rm", "-q", "tools/release/build.sh")
+	testutil.CommitContents(t, dir, map[string]string{}, "Remove build script")
+	newRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		Groups:     map[string][]string{"release-admins": {"alice"}},
+		PathOwners: []config.PathOwner{{Paths: []string{"tools/release/*"}, Refs: []string{"refs/heads/*"}, Groups: []string{"release-admins"}}},
+	}
+
+	tests := []struct {
+		name      string
+		username  string
+		refName   string
+		violation string
+	}{
+		{"Owner", "alice", "refs/heads/master", ""},
+		{"Other user", "bob", "refs/heads/master", "push changes 1 files you don't own, the first one is tools/release/build.sh which only members of release-admins may change!"},
+		{"Unknown user", "", "refs/heads/master", "the first one is tools/release/build.sh"},
+		{"Other refs", "bob", "refs/for/master", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Check(cfg, logger, Update{Project: "p", UploaderUsername: test.username, OldRev: oldRev, NewRev: newRev, RefName: test.refName})
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if test.violation == "" && len(result.Violations) != 0 {
+				t.Errorf("Check() returned violations %v, expected none", result.Violations)
+			}
+			if test.violation != "" && (len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.violation)) {
+				t.Errorf("Check() returned violations %v, expected %q", result.Violations, test.violation)
+			}
+		})
+	}
+}
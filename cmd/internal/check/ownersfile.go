@@ -0,0 +1,213 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+)
+
+// ownersProblem is a syntax error or unknown account in an ownership file
+type ownersProblem struct {
+	Line int
+	Text string
+}
+
+// ownersAccount is a user or email an ownership file refers to
+type ownersAccount struct {
+	Line    int
+	Account string // Username without @, or email
+}
+
+var (
+	codeOwnersSection = regexp.MustCompile(`^\^?\[[^\]]+\](\[[0-9]+\])?(\s+|$)`)
+	codeOwnersUser    = regexp.MustCompile(`^@([A-Za-z0-9][A-Za-z0-9._-]*)$`)
+	codeOwnersTeam    = regexp.MustCompile(`^@[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9._-]+)+$`)
+	ownersEmail       = regexp.MustCompile(`^[^@\s,=]+@[^@\s,=]+\.[^@\s,=]+$`)
+)
+
+// checkOwnersFiles rejects updates adding or modifying CODEOWNERS and OWNERS files that don't parse
+func checkOwnersFiles(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	if !cfg.OwnersFiles.Enabled || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	paths, err := update.Repository.GetChangedPaths(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	var files []githookkit.FileInfo
+	known := map[string]bool{}
+	for _, file := range paths {
+		var parse func(string) ([]ownersProblem, []ownersAccount)
+		switch path.Base(file) {
+		case "CODEOWNERS":
+			parse = parseCodeOwners
+		case "OWNERS":
+			parse = parseOwners
+		default:
+			continue
+		}
+
+		content, found, err := update.Repository.ReadFile(update.NewRev, file)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		fileProblems, accounts := parse(string(content))
+		if cfg.OwnersFiles.CheckAccounts {
+			for _, account := range accounts {
+				exists, checked := known[account.Account]
+				if !checked {
+					if exists, err = gerrit.AccountExists(cfg.Gerrit, account.Account); err != nil {
+						return err
+					}
+					known[account.Account] = exists
+				}
+				if !exists {
+					fileProblems = append(fileProblems, ownersProblem{account.Line, fmt.Sprintf("unknown account %s", account.Account)})
+				}
+			}
+		}
+
+		if len(fileProblems) > 0 {
+			files = append(files, githookkit.FileInfo{Path: file})
+		}
+		for _, problem := range fileProblems {
+			problems = append(problems, fmt.Sprintf("%s line %d: %s", file, problem.Line, problem.Text))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d problems in ownership files:", len(problems)), len(problems), func(i int) string {
+		return problems[i]
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Count: len(problems), Files: files, Largest: files[0], Actual: problems[0]}
+	result.violate(config.RuleOwnersFile, config.RenderMessage(cfg, config.RuleOwnersFile, data,
+		fmt.Sprintf("push contains %d problems in ownership files, the first one is %s, fix them before pushing!", len(problems), problems[0])), data.Files...)
+	return nil
+}
+
+// parseCodeOwners checks a CODEOWNERS file of GitHub or GitLab: "pattern owner...", where owners are @user, @org/team or emails
+// GitLab [Section] headers may be followed by default owners
+func parseCodeOwners(content string) ([]ownersProblem, []ownersAccount) {
+	var problems []ownersProblem
+	var accounts []ownersAccount
+	for i, line := range strings.Split(content, "\n") {
+		number := i + 1
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var owners []string
+		if section := codeOwnersSection.FindString(line); section != "" {
+			owners = strings.Fields(line[len(section):])
+		} else {
+			fields := splitEscaped(line)
+			if strings.HasPrefix(fields[0], "!") {
+				problems = append(problems, ownersProblem{number, fmt.Sprintf("negated pattern %s is not supported", fields[0])})
+			}
+			owners = fields[1:]
+		}
+
+		for _, owner := range owners {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			switch {
+			case codeOwnersTeam.MatchString(owner):
+			case codeOwnersUser.MatchString(owner):
+				accounts = append(accounts, ownersAccount{number, owner[1:]})
+			case ownersEmail.MatchString(owner):
+				accounts = append(accounts, ownersAccount{number, owner})
+			default:
+				problems = append(problems, ownersProblem{number, fmt.Sprintf("invalid owner %q", owner)})
+			}
+		}
+	}
+	return problems, accounts
+}
+
+// splitEscaped splits a CODEOWNERS line on whitespace, a backslash escapes a space in the pattern
+func splitEscaped(line string) []string {
+	var fields []string
+	var field strings.Builder
+	escaped := false
+	for _, c := range line {
+		switch {
+		case escaped:
+			field.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			field.WriteRune(c)
+			escaped = true
+		case c == ' ' || c == '\t':
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(c)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// parseOwners checks an OWNERS file of the Gerrit code-owners plugin (find-owners syntax):
+// emails, *, "set noparent", "file: path", "include path" and "per-file glob,...=owner,..."
+func parseOwners(content string) ([]ownersProblem, []ownersAccount) {
+	var problems []ownersProblem
+	var accounts []ownersAccount
+	for i, line := range strings.Split(content, "\n") {
+		number := i + 1
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || line == "*" || line == "set noparent":
+		case strings.HasPrefix(line, "file:") || line == "include" || strings.HasPrefix(line, "include "):
+			if strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "file:"), "include")) == "" {
+				problems = append(problems, ownersProblem{number, fmt.Sprintf("%q has no path", line)})
+			}
+		case strings.HasPrefix(line, "per-file "):
+			globs, owners, ok := strings.Cut(strings.TrimPrefix(line, "per-file "), "=")
+			if !ok || strings.TrimSpace(globs) == "" || strings.TrimSpace(owners) == "" {
+				problems = append(problems, ownersProblem{number, "per-file must be followed by glob=owners"})
+				continue
+			}
+			for _, owner := range strings.Split(owners, ",") {
+				owner = strings.TrimSpace(owner)
+				switch {
+				case owner == "*" || owner == "set noparent" || strings.HasPrefix(owner, "file:"):
+				case ownersEmail.MatchString(owner):
+					accounts = append(accounts, ownersAccount{number, owner})
+				default:
+					problems = append(problems, ownersProblem{number, fmt.Sprintf("invalid owner %q", owner)})
+				}
+			}
+		case ownersEmail.MatchString(line):
+			accounts = append(accounts, ownersAccount{number, line})
+		default:
+			problems = append(problems, ownersProblem{number, fmt.Sprintf("unrecognized line %q", line)})
+		}
+	}
+	return problems, accounts
+}
@@ -0,0 +1,99 @@
+package check
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestParseCodeOwners(t *testing.T) {
+	content := `# Default owners
+* @org/core-team
+/docs/ docs@example.com @alice
+my\ file.txt @bob # inline comment
+!/vendor/ @alice
+/tools/ alice
+[Release][2] @release/managers
+^[Optional] @carol
+`
+	expectedProblems := []ownersProblem{
+		{5, "negated pattern !/vendor/ is not supported"},
+		{6, `invalid owner "alice"`},
+	}
+	expectedAccounts := []ownersAccount{{3, "docs@example.com"}, {3, "alice"}, {4, "bob"}, {5, "alice"}, {8, "carol"}}
+
+	problems, accounts := parseCodeOwners(content)
+	if !reflect.DeepEqual(problems, expectedProblems) {
+		t.Errorf("parseCodeOwners() problems = %v, expected %v", problems, expectedProblems)
+	}
+	if !reflect.DeepEqual(accounts, expectedAccounts) {
+		t.Errorf("parseCodeOwners() accounts = %v, expected %v", accounts, expectedAccounts)
+	}
+}
+
+func TestParseOwners(t *testing.T) {
+	content := `set noparent
+alice@example.com # lead
+file: /build/OWNERS
+per-file *.sql,*.ddl=db@example.com, *
+per-file *.md
+bob
+include
+`
+	expectedProblems := []ownersProblem{
+		{5, "per-file must be followed by glob=owners"},
+		{6, `unrecognized line "bob"`},
+		{7, `"include" has no path`},
+	}
+	expectedAccounts := []ownersAccount{{2, "alice@example.com"}, {4, "db@example.com"}}
+
+	problems, accounts := parseOwners(content)
+	if !reflect.DeepEqual(problems, expectedProblems) {
+		t.Errorf("parseOwners() problems = %v, expected %v", problems, expectedProblems)
+	}
+	if !reflect.DeepEqual(accounts, expectedAccounts) {
+		t.Errorf("parseOwners() accounts = %v, expected %v", accounts, expectedAccounts)
+	}
+}
+
+func TestCheckOwnersFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a/accounts/alice" {
+			w.Write([]byte(")]}'\n{}"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"lib/OWNERS": "bad line\n"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{".github/CODEOWNERS": "* @alice\n/docs/ @ghost\n"}, "Add CODEOWNERS")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+
+	cfg := config.Config{OwnersFiles: config.OwnersFiles{Enabled: true}}
+	result, err := Check(cfg, logger, update)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none for valid syntax and unchanged OWNERS", result.Violations)
+	}
+
+	cfg = config.Config{OwnersFiles: config.OwnersFiles{Enabled: true, CheckAccounts: true}, Gerrit: config.GerritConfig{URL: server.URL}}
+	result, err = Check(cfg, logger, update)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "the first one is .github/CODEOWNERS line 2: unknown account ghost") {
+		t.Errorf("Check() returned violations %v, expected the unknown account", result.Violations)
+	}
+}
@@ -0,0 +1,287 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// pathRule checks the paths of added files
+type pathRule struct {
+	id    string                     // Rule ID
+	what  string                     // Describes the offending paths, e.g. "invalid on Windows"
+	fix   string                     // Tells the user what to do, e.g. "rename them"
+	check func(path string) []string // Returns what is wrong with a path
+}
+
+// checkPaths applies the path rules on the paths added by an update
+// The paths are only listed when one of the rules is enabled for the project
+func checkPaths(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	if update.NewRev == ZeroRev {
+		return nil
+	}
+
+	rules, err := pathRules(config.GetPathPolicy(cfg, update.Project), update)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	paths, err := update.Repository.GetAddedPaths(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		var files []githookkit.FileInfo
+		var problems []string
+		for _, path := range paths {
+			if pathProblems := rule.check(path); len(pathProblems) > 0 {
+				files = append(files, githookkit.FileInfo{Path: path})
+				problems = append(problems, strings.Join(pathProblems, ", "))
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		logFindings(logger, cfg, fmt.Sprintf("Found %d paths %s:", len(files), rule.what), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Count: len(files), Files: files, Largest: files[0], Actual: problems[0]}
+		result.violate(rule.id, config.RenderMessage(cfg, rule.id, data,
+			fmt.Sprintf("push adds %d paths %s, the first one is %s: %s, %s!", len(files), rule.what, files[0].Path, problems[0], rule.fix)), data.Files...)
+	}
+	return nil
+}
+
+// pathRules returns the path rules enabled by policy
+func pathRules(policy config.PathPolicy, update Update) ([]pathRule, error) {
+	var rules []pathRule
+	if policy.WindowsCompatible {
+		rules = append(rules, pathRule{config.RuleWindowsPath, "invalid on Windows", "rename them", windowsPathProblems})
+	}
+
+	if policy.DenyUnsafeNames {
+		rules = append(rules, pathRule{config.RuleUnsafePath, "unsafe for scripts", "rename them", unsafePathProblems})
+	}
+
+	if len(policy.DeniedPaths) > 0 {
+		fix := "remove them and add them to .gitignore"
+		if policy.DeniedPathsHint != "" {
+			fix += ", " + policy.DeniedPathsHint
+		}
+		rules = append(rules, pathRule{config.RuleDeniedPath, "that must not be committed", fix, func(path string) []string {
+			return deniedPathProblems(path, policy.DeniedPaths)
+		}})
+	}
+
+	if policy.DenyNestedRepositories {
+		rules = append(rules, pathRule{config.RuleNestedRepository, "of nested repositories", "remove them", nestedRepositoryProblems})
+	}
+
+	if policy.MaxPathLength > 0 || policy.MaxComponentLength > 0 {
+		rules = append(rules, pathRule{config.RulePathLength, "too long", "rename them", func(path string) []string {
+			return pathLengthProblems(path, policy.MaxPathLength, policy.MaxComponentLength)
+		}})
+	}
+
+	if policy.MaxPathDepth > 0 {
+		rules = append(rules, pathRule{config.RulePathDepth, "nested too deeply", "flatten the directory structure", func(path string) []string {
+			return pathDepthProblems(path, policy.MaxPathDepth)
+		}})
+	}
+
+	if policy.DenyCaseConflicts {
+		index, err := caseIndex(update)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, pathRule{config.RuleCaseConflict, "conflicting by case", "rename them", func(path string) []string {
+			return caseConflictProblems(index, path)
+		}})
+	}
+	return rules, nil
+}
+
+// windowsReservedNames are device names Windows doesn't allow as file names, with or without extension
+var windowsReservedNames = []string{"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"}
+
+// windowsPathProblems returns why a path can't be checked out on Windows
+func windowsPathProblems(path string) []string {
+	var problems []string
+	for _, name := range strings.Split(path, "/") {
+		// Windows ignores trailing spaces of the name before the extension, "aux .c" is reserved too
+		stem, _, _ := strings.Cut(name, ".")
+		if config.Contains(windowsReservedNames, strings.ToUpper(strings.TrimRight(stem, " "))) {
+			problems = append(problems, fmt.Sprintf("%s is a reserved name", name))
+		}
+		if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+			problems = append(problems, fmt.Sprintf("%q ends with a dot or space", name))
+		}
+		if i := strings.IndexFunc(name, isWindowsInvalidRune); i >= 0 {
+			problems = append(problems, fmt.Sprintf("%q contains %q", name, name[i]))
+		}
+	}
+	return problems
+}
+
+// isWindowsInvalidRune reports whether r is not allowed in Windows file names
+func isWindowsInvalidRune(r rune) bool {
+	return r < 32 || strings.ContainsRune(`<>:"|?*\`, r)
+}
+
+// unsafePathProblems returns why the names of a path break scripts: invalid UTF-8, control characters or a leading dash read as option
+func unsafePathProblems(path string) []string {
+	var problems []string
+	for _, name := range strings.Split(path, "/") {
+		if !utf8.ValidString(name) {
+			problems = append(problems, fmt.Sprintf("%q is not valid UTF-8", name))
+		} else if i := strings.IndexFunc(name, unicode.IsControl); i >= 0 {
+			r, _ := utf8.DecodeRuneInString(name[i:])
+			problems = append(problems, fmt.Sprintf("%q contains control character %U", name, r))
+		}
+		if strings.HasPrefix(name, "-") {
+			problems = append(problems, fmt.Sprintf("%q starts with a dash", name))
+		}
+	}
+	return problems
+}
+
+// nestedRepositoryProblems returns a problem when path is inside a .git directory or is a .git file
+// Case and the NTFS short name git~1 don't matter, they all name .git on some file system
+func nestedRepositoryProblems(path string) []string {
+	names := strings.Split(path, "/")
+	for i, name := range names {
+		if strings.EqualFold(name, ".git") || strings.EqualFold(name, "git~1") {
+			return []string{fmt.Sprintf("%s is a git directory", strings.Join(names[:i+1], "/"))}
+		}
+	}
+	return nil
+}
+
+// deniedPathProblems returns the first denied pattern path matches
+// Patterns match the path from any directory, like .gitignore patterns, unless they start with /
+func deniedPathProblems(path string, patterns []string) []string {
+	for _, pattern := range patterns {
+		if anchored, ok := strings.CutPrefix(pattern, "/"); ok {
+			if config.MatchRef(anchored, path) {
+				return []string{fmt.Sprintf("matches %s", pattern)}
+			}
+			continue
+		}
+		for suffix := path; ; {
+			if config.MatchRef(pattern, suffix) {
+				return []string{fmt.Sprintf("matches %s", pattern)}
+			}
+			var found bool
+			if _, suffix, found = strings.Cut(suffix, "/"); !found {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// pathLengthProblems returns the limits path exceeds, lengths are counted in characters and 0 is unlimited
+func pathLengthProblems(path string, maxPath, maxComponent int) []string {
+	var problems []string
+	if length := utf8.RuneCountInString(path); maxPath > 0 && length > maxPath {
+		problems = append(problems, fmt.Sprintf("path has %d characters, limit is %d", length, maxPath))
+	}
+	if maxComponent > 0 {
+		for _, name := range strings.Split(path, "/") {
+			if length := utf8.RuneCountInString(name); length > maxComponent {
+				problems = append(problems, fmt.Sprintf("%s has %d characters, limit is %d", name, length, maxComponent))
+			}
+		}
+	}
+	return problems
+}
+
+// pathDepthProblems returns a problem when path is nested in more than maxDepth directories
+func pathDepthProblems(path string, maxDepth int) []string {
+	if depth := strings.Count(path, "/"); depth > maxDepth {
+		return []string{fmt.Sprintf("%d directories deep, limit is %d", depth, maxDepth)}
+	}
+	return nil
+}
+
+// caseIndex maps the lower case paths of the pushed tree and of the target branch, including their directories, to their spellings
+// The target branch of refs/for/<branch> is refs/heads/<branch>, other refs are their own target
+// Files of the target branch the push changes are left out, so that renaming readme.md to README.md doesn't conflict
+func caseIndex(update Update) (map[string][]string, error) {
+	index := map[string][]string{}
+	add := func(path string) {
+		for end := 0; end < len(path); end++ {
+			end = nextSeparator(path, end)
+			prefix := path[:end]
+			if key := strings.ToLower(prefix); !config.Contains(index[key], prefix) {
+				index[key] = append(index[key], prefix)
+			}
+		}
+	}
+
+	paths, err := update.Repository.ListTree(update.NewRev)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		add(path)
+	}
+
+	target := update.RefName
+	if branch, ok := strings.CutPrefix(target, "refs/for/"); ok {
+		target = "refs/heads/" + branch
+	}
+	refs, err := update.Repository.GetRefs(target)
+	if err != nil || !config.Contains(refs, target) {
+		return index, err
+	}
+
+	changedPaths, err := update.Repository.GetChangedPaths(update.NewRev, update.OldRev)
+	if err != nil {
+		return nil, err
+	}
+	changed := map[string]bool{}
+	for _, path := range changedPaths {
+		changed[path] = true
+	}
+	if paths, err = update.Repository.ListTree(target); err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if !changed[path] {
+			add(path)
+		}
+	}
+	return index, nil
+}
+
+// nextSeparator returns the index of the first / of path at or after start, or the length of path
+func nextSeparator(path string, start int) int {
+	if i := strings.IndexByte(path[start:], '/'); i >= 0 {
+		return start + i
+	}
+	return len(path)
+}
+
+// caseConflictProblems returns a problem when path or one of its directories differs only by case from another path of index
+func caseConflictProblems(index map[string][]string, path string) []string {
+	for end := 0; end < len(path); end++ {
+		end = nextSeparator(path, end)
+		prefix := path[:end]
+		for _, other := range index[strings.ToLower(prefix)] {
+			if other != prefix {
+				return []string{fmt.Sprintf("%s conflicts with %s", prefix, other)}
+			}
+		}
+	}
+	return nil
+}
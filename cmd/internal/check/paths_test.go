@@ -0,0 +1,248 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestWindowsPathProblems(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"src/main.go", nil},
+		{"docs/aux.c", []string{"aux.c is a reserved name"}},
+		{"Con/readme", []string{"Con is a reserved name"}},
+		{"lpt1 .txt", []string{"lpt1 .txt is a reserved name"}},
+		{"console.log", nil},
+		{"notes.", []string{`"notes." ends with a dot or space`}},
+		{"dir /file", []string{`"dir " ends with a dot or space`}},
+		{"a:b|c", []string{`"a:b|c" contains ':'`}},
+		{"tab\there", []string{`"tab\there" contains '\t'`}},
+	}
+
+	for _, test := range tests {
+		if problems := windowsPathProblems(test.path); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("windowsPathProblems(%q) = %v, expected %v", test.path, problems, test.expected)
+		}
+	}
+}
+
+func TestCheckWindowsPaths(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"old:name": "o"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"old:name": "changed", "src/aux.h": "a", "src/main.c": "m"}, "Add sources")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		Paths:    config.PathPolicy{WindowsCompatible: true},
+		Projects: map[string]config.ProjectPolicy{"linux-only": {Paths: &config.PathPolicy{}}},
+	}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "push adds 1 paths invalid on Windows, the first one is src/aux.h: aux.h is a reserved name") {
+		t.Errorf("Check() returned violations %v, expected only the added src/aux.h", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "linux-only", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none for the project without path rules", result.Violations)
+	}
+}
+
+func TestCaseConflictProblems(t *testing.T) {
+	index := map[string][]string{
+		"readme.md":      {"readme.MD"},
+		"docs":           {"Docs"},
+		"docs/guide.txt": {"Docs/guide.txt"},
+		"src":            {"src"},
+	}
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"src/main.go", nil},
+		{"readme.MD", nil},
+		{"README.md", []string{"README.md conflicts with readme.MD"}},
+		{"docs/index.txt", []string{"docs conflicts with Docs"}},
+		{"Docs/index.txt", nil},
+	}
+
+	for _, test := range tests {
+		if problems := caseConflictProblems(index, test.path); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("caseConflictProblems(%q) = %v, expected %v", test.path, problems, test.expected)
+		}
+	}
+}
+
+func TestCheckCaseConflicts(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	base := testutil.CommitContents(t, dir, map[string]string{"main.go": "m"}, "Initial")
+	both := testutil.CommitContents(t, dir, map[string]string{"README.md": "R", "readme.MD": "r"}, "Add both readmes")
+	testutil.Git(t, dir, "checkout", "-q", "-b", "target", base)
+	testutil.CommitContents(t, dir, map[string]string{"readme.MD": "r"}, "Add readme")
+	testutil.Git(t, dir, "mv", "readme.MD", "README.md")
+	testutil.Git(t, dir, "commit", "-q", "-m", "Rename readme")
+	renamed := strings.TrimSpace(testutil.Git(t, dir, "rev-parse", "HEAD"))
+	testutil.Git(t, dir, "reset", "-q", "--hard", "HEAD~1")
+	testutil.Git(t, dir, "checkout", "-q", "--detach", base)
+	added := testutil.CommitContents(t, dir, map[string]string{"README.md": "R"}, "Add README")
+	// Pushed changes are not reachable from any ref yet
+	testutil.Git(t, dir, "checkout", "-q", "target")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{Paths: config.PathPolicy{DenyCaseConflicts: true}}
+	tests := []struct {
+		name     string
+		update   Update
+		conflict bool
+	}{
+		{"within the pushed tree", Update{Project: "p", OldRev: base, NewRev: both, RefName: "refs/heads/other"}, true},
+		{"against the target branch", Update{Project: "p", OldRev: ZeroRev, NewRev: added, RefName: "refs/for/target"}, true},
+		{"without target branch", Update{Project: "p", OldRev: ZeroRev, NewRev: added, RefName: "refs/for/other"}, false},
+		{"renamed on the target branch", Update{Project: "p", OldRev: ZeroRev, NewRev: renamed, RefName: "refs/for/target"}, false},
+	}
+
+	for _, test := range tests {
+		result, err := Check(cfg, logger, test.update)
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		conflict := len(result.Violations) == 1 && strings.Contains(result.Violations[0], "conflicting by case, the first one is README.md: README.md conflicts with readme.MD")
+		if conflict != test.conflict || (!test.conflict && len(result.Violations) != 0) {
+			t.Errorf("%s: Check() returned violations %v, expected conflict %v", test.name, result.Violations, test.conflict)
+		}
+	}
+}
+
+func TestPathLengthProblems(t *testing.T) {
+	tests := []struct {
+		path         string
+		maxPath      int
+		maxComponent int
+		expected     []string
+	}{
+		{"src/main.go", 20, 10, nil},
+		{"src/main.go", 10, 0, []string{"path has 11 characters, limit is 10"}},
+		{"src/main.go", 0, 6, []string{"main.go has 7 characters, limit is 6"}},
+		{"dokumentation/über.md", 21, 13, nil},
+		{"dokumentation/über.md", 20, 12, []string{"path has 21 characters, limit is 20", "dokumentation has 13 characters, limit is 12"}},
+	}
+
+	for _, test := range tests {
+		if problems := pathLengthProblems(test.path, test.maxPath, test.maxComponent); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("pathLengthProblems(%q, %d, %d) = %v, expected %v", test.path, test.maxPath, test.maxComponent, problems, test.expected)
+		}
+	}
+}
+
+func TestPathDepthProblems(t *testing.T) {
+	tests := []struct {
+		path     string
+		maxDepth int
+		expected []string
+	}{
+		{"README.md", 2, nil},
+		{"src/pkg/main.go", 2, nil},
+		{"vendor/a/vendor/b/vendor/c.go", 2, []string{"5 directories deep, limit is 2"}},
+	}
+
+	for _, test := range tests {
+		if problems := pathDepthProblems(test.path, test.maxDepth); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("pathDepthProblems(%q, %d) = %v, expected %v", test.path, test.maxDepth, problems, test.expected)
+		}
+	}
+}
+
+func TestUnsafePathProblems(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"src/main.go", nil},
+		{"docs/ü-ber.md", nil},
+		{"src/-rf", []string{`"-rf" starts with a dash`}},
+		{"bad\xffname/x", []string{`"bad\xffname" is not valid UTF-8`}},
+		{"line\nbreak", []string{`"line\nbreak" contains control character U+000A`}},
+		{"-esc\x1b", []string{`"-esc\x1b" contains control character U+001B`, `"-esc\x1b" starts with a dash`}},
+	}
+
+	for _, test := range tests {
+		if problems := unsafePathProblems(test.path); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("unsafePathProblems(%q) = %v, expected %v", test.path, problems, test.expected)
+		}
+	}
+}
+
+func TestNestedRepositoryProblems(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"src/main.go", nil},
+		{".gitignore", nil},
+		{"vendor/lib/.git/config", []string{"vendor/lib/.git is a git directory"}},
+		{"vendor/lib/.git", []string{"vendor/lib/.git is a git directory"}},
+		{"third_party/.GIT/HEAD", []string{"third_party/.GIT is a git directory"}},
+		{"third_party/GIT~1/HEAD", []string{"third_party/GIT~1 is a git directory"}},
+	}
+
+	for _, test := range tests {
+		if problems := nestedRepositoryProblems(test.path); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("nestedRepositoryProblems(%q) = %v, expected %v", test.path, problems, test.expected)
+		}
+	}
+}
+
+func TestDeniedPathProblems(t *testing.T) {
+	patterns := []string{"node_modules/*", "*.o", "__pycache__/*", "/dist/*"}
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"src/main.c", nil},
+		{"node_modules/left-pad/index.js", []string{"matches node_modules/*"}},
+		{"web/node_modules/left-pad/index.js", []string{"matches node_modules/*"}},
+		{"build/main.o", []string{"matches *.o"}},
+		{"tools/__pycache__/x.pyc", []string{"matches __pycache__/*"}},
+		{"dist/app.js", []string{"matches /dist/*"}},
+		{"docs/dist/app.js", nil},
+		{"my_node_modules/x", nil},
+	}
+
+	for _, test := range tests {
+		if problems := deniedPathProblems(test.path, patterns); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("deniedPathProblems(%q) = %v, expected %v", test.path, problems, test.expected)
+		}
+	}
+}
+
+func TestCheckDeniedPaths(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"package.json": "{}"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"node_modules/a/index.js": "a", "src/app.js": "app"}, "Add app")
+	chdir(t, dir)
+
+	cfg := config.Config{Paths: config.PathPolicy{DeniedPaths: []string{"node_modules/*"}, DeniedPathsHint: "run npm ci instead"}}
+	result, err := Check(cfg, newTestLogger(t), Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	expected := "push adds 1 paths that must not be committed, the first one is node_modules/a/index.js: matches node_modules/*, remove them and add them to .gitignore, run npm ci instead!"
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+		t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+	}
+}
@@ -0,0 +1,66 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/plugin"
+)
+
+// checkPlugins runs the configured plugins and merges the violations they report into the result
+func checkPlugins(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) error {
+	for _, p := range config.GetPlugins(cfg, update.Project) {
+		var files []githookkit.FileInfo
+		for _, file := range newFiles {
+			if len(p.Paths) == 0 || matchAny(p.Paths, file.Path) {
+				files = append(files, file)
+			}
+		}
+
+		pluginUpdate := plugin.Update{
+			Project:          update.Project,
+			RefName:          update.RefName,
+			OldRev:           update.OldRev,
+			NewRev:           update.NewRev,
+			Uploader:         update.Uploader,
+			UploaderUsername: update.UploaderUsername,
+			PushOptions:      update.PushOptions,
+			Settings:         p.Settings,
+			Repository:       update.Repository,
+		}
+		var stderr bytes.Buffer
+		var violations []plugin.Violation
+		var err error
+		if p.Module != "" {
+			violations, err = plugin.RunModule(p, pluginUpdate, files, cfg.PluginCacheDir, &stderr)
+		} else {
+			violations, err = plugin.Run(p, pluginUpdate, files, &stderr)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+			if line != "" {
+				logger.Debugf("Plugin %s: %s", p.Name, line)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, violation := range violations {
+			message := fmt.Sprintf("%s: %s", p.Name, violation.Message)
+			if violation.Warning {
+				result.Warnings = append(result.Warnings, message)
+				continue
+			}
+			logger.Infof("Plugin %s reported: %s", p.Name, violation.Message)
+			data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: violation.Message}
+			if violation.Path != "" {
+				data.Files = []githookkit.FileInfo{{Path: violation.Path}}
+			}
+			result.violate(config.RulePlugin, config.RenderMessage(cfg, config.RulePlugin, data, message), data.Files...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on Windows")
+	}
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"src/main.go": "package main\n", "docs/index.md": "# Docs\n"}, "Add files")
+	chdir(t, dir)
+
+	// Reports every path it receives, so only the configured paths may be sent
+	script := filepath.Join(t.TempDir(), "lint.sh")
+	content := `#!/bin/sh
+grep -o '"path":"[^"]*"' | while read -r path; do
+	echo "{\"message\":\"forbidden file\",$path}"
+done
+echo '{"message":"consider a changelog entry","warning":true}'
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+
+	logger := newTestLogger(t)
+	cfg := config.Config{Plugins: []config.Plugin{{Name: "lint", Command: script, Paths: []string{"src/*"}}}}
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+	result, err := Check(cfg, logger, update)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || result.Violations[0] != "lint: forbidden file" {
+		t.Errorf("Check() returned violations %v, expected one from lint", result.Violations)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0] != "lint: consider a changelog entry" {
+		t.Errorf("Check() returned warnings %v, expected one from lint", result.Warnings)
+	}
+
+	t.Run("Message template", func(t *testing.T) {
+		cfg := cfg
+		cfg.Messages = map[string]string{config.RulePlugin: "{{.Actual}} ({{(index .Files 0).Path}})"}
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 1 || result.Violations[0] != "forbidden file (src/main.go)" {
+			t.Errorf("Check() returned violations %v", result.Violations)
+		}
+	})
+
+	t.Run("Failing plugin", func(t *testing.T) {
+		cfg := config.Config{Plugins: []config.Plugin{{Name: "broken", Command: "false"}}}
+		_, err := Check(cfg, logger, update)
+		if err == nil || !strings.Contains(err.Error(), "plugin broken exited with code 1") {
+			t.Errorf("Check() error = %v, expected the plugin failure", err)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package check
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RepositoryProject derives the project name from the repository directory of the current working directory,
+// e.g. /srv/git/app.git gives app, used by the native git hooks which have no project parameter
+func RepositoryProject() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find repository directory: %w", err)
+	}
+
+	gitDir := strings.TrimSpace(string(output))
+	if filepath.Base(gitDir) == ".git" {
+		gitDir = filepath.Dir(gitDir)
+	}
+	return strings.TrimSuffix(filepath.Base(gitDir), ".git"), nil
+}
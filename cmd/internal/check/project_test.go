@@ -0,0 +1,40 @@
+package check
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestRepositoryProject(t *testing.T) {
+	t.Run("Bare repository", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "app.git")
+		if output, err := exec.Command("git", "init", "-q", "--bare", dir).CombinedOutput(); err != nil {
+			t.Fatalf("Failed to create bare repository: %v\n%s", err, output)
+		}
+		chdir(t, dir)
+
+		project, err := RepositoryProject()
+		if err != nil {
+			t.Fatalf("RepositoryProject() returned error: %v", err)
+		}
+		if project != "app" {
+			t.Errorf("RepositoryProject() = %s, expected app", project)
+		}
+	})
+
+	t.Run("Work tree", func(t *testing.T) {
+		dir := testutil.InitRepo(t)
+		chdir(t, dir)
+
+		project, err := RepositoryProject()
+		if err != nil {
+			t.Fatalf("RepositoryProject() returned error: %v", err)
+		}
+		if project != filepath.Base(dir) {
+			t.Errorf("RepositoryProject() = %s, expected %s", project, filepath.Base(dir))
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// projectConfigBlob is where Gerrit keeps the settings of a project
+const projectConfigBlob = "refs/meta/config:project.config"
+
+// repositoryEnv are the variables of the hook pointing git at the repository of the push and its quarantine,
+// they are dropped to read other repositories
+var repositoryEnv = []string{"GIT_DIR", "GIT_QUARANTINE_PATH", "GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES"}
+
+// withProjectConfig returns cfg with the policy of the project.config of project applied over the projects section when
+// gerrit.project_config is enabled. The policy is inherited like Gerrit does: the settings of the parent projects up to
+// All-Projects apply first and are overridden by the more specific ones.
+func withProjectConfig(cfg config.Config, repo githookkit.Repository, project string) (config.Config, error) {
+	if !cfg.Gerrit.ProjectConfig || project == "" {
+		return cfg, nil
+	}
+
+	basePath, err := gerritBasePath(cfg, repo, project)
+	if err != nil {
+		return cfg, err
+	}
+
+	var policies []config.ProjectPolicy
+	seen := map[string]bool{}
+	for name := project; name != ""; {
+		if seen[name] {
+			return cfg, fmt.Errorf("project %s: inheritFrom of its parents forms a cycle at %s", project, name)
+		}
+		seen[name] = true
+
+		projectConfig, err := readProjectConfig(filepath.Join(basePath, name+".git"))
+		if err != nil {
+			return cfg, fmt.Errorf("project %s: %w", name, err)
+		}
+		policies = append(policies, projectConfig.Policy)
+
+		parent := projectConfig.Parent
+		if parent == "" && name != config.AllProjects {
+			parent = config.AllProjects
+		}
+		name = parent
+	}
+
+	for i := len(policies) - 1; i >= 0; i-- {
+		cfg = config.WithProjectPolicy(cfg, project, policies[i])
+	}
+	return cfg, nil
+}
+
+// gerritBasePath returns gerrit.base_path, or derives it from repo: <base path>/<project>.git
+func gerritBasePath(cfg config.Config, repo githookkit.Repository, project string) (string, error) {
+	if cfg.Gerrit.BasePath != "" {
+		return cfg.Gerrit.BasePath, nil
+	}
+
+	gitDir, err := repo.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	basePath, found := strings.CutSuffix(filepath.ToSlash(gitDir), "/"+project+".git")
+	if !found {
+		return "", fmt.Errorf("repository %s is not at <base path>/%s.git, set gerrit.base_path", gitDir, project)
+	}
+	return filepath.FromSlash(basePath), nil
+}
+
+// readProjectConfig returns the settings of the project.config of the repository at gitDir, none when it has no project.config
+func readProjectConfig(gitDir string) (config.GerritProjectConfig, error) {
+	// rev-parse exits with 1 for a missing object and 128 for a missing repository
+	if err := gitCommand(gitDir, "rev-parse", "--verify", "--quiet", projectConfigBlob).Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return config.GerritProjectConfig{}, nil
+		}
+		return config.GerritProjectConfig{}, fmt.Errorf("failed to open repository %s: %w", gitDir, err)
+	}
+
+	output, err := gitCommand(gitDir, "config", "-z", "--blob", projectConfigBlob, "--list").Output()
+	if err != nil {
+		return config.GerritProjectConfig{}, fmt.Errorf("failed to parse %s: %w", projectConfigBlob, err)
+	}
+	projectConfig, err := config.ParseGerritProjectConfig(output)
+	if err != nil {
+		return config.GerritProjectConfig{}, fmt.Errorf("invalid project.config: %w", err)
+	}
+	return projectConfig, nil
+}
+
+// gitCommand returns a git command run in the repository at gitDir, outside of the quarantine of the push
+func gitCommand(gitDir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", append([]string{"--git-dir", gitDir}, args...)...)
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if !slices.Contains(repositoryEnv, name) {
+			cmd.Env = append(cmd.Env, variable)
+		}
+	}
+	return cmd
+}
@@ -0,0 +1,123 @@
+package check
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// initProject creates the bare repository of project under basePath, with content as its project.config unless empty
+func initProject(t *testing.T, basePath, project, content string) string {
+	t.Helper()
+
+	gitDir := filepath.Join(basePath, project+".git")
+	if output, err := exec.Command("git", "init", "-q", "--bare", gitDir).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create bare repository: %v\n%s", err, output)
+	}
+	if content != "" {
+		dir := testutil.InitRepo(t)
+		testutil.CommitContents(t, dir, map[string]string{"project.config": content}, "Update project configuration")
+		testutil.Git(t, dir, "push", "-q", gitDir, "HEAD:refs/meta/config")
+	}
+	return gitDir
+}
+
+func TestWithProjectConfig(t *testing.T) {
+	basePath := t.TempDir()
+	initProject(t, basePath, config.AllProjects, "[plugin \"githookkit\"]\n\tsizeLimit = 1m\n\tmode = warn\n")
+	initProject(t, basePath, "platform/parent", "[plugin \"githookkit\"]\n\tsizeLimit = 2m\n\tdisabledRule = dco\n")
+	appDir := initProject(t, basePath, "platform/app", "[access]\n\tinheritFrom = platform/parent\n[plugin \"githookkit\"]\n\tpushSizeLimit = 10m\n")
+	initProject(t, basePath, "other", "")
+
+	yamlLimit := int64(100)
+	cfg := config.Config{
+		Gerrit:   config.GerritConfig{ProjectConfig: true},
+		Projects: map[string]config.ProjectPolicy{"platform/app": {SizeLimit: &yamlLimit, NewFileLimit: new(int)}},
+	}
+
+	t.Run("Inherited from the parents", func(t *testing.T) {
+		// Without gerrit.base_path the base path is derived from the repository of the hook
+		chdir(t, appDir)
+		result, err := withProjectConfig(cfg, githookkit.Repository{}, "platform/app")
+		if err != nil {
+			t.Fatalf("withProjectConfig() returned error: %v", err)
+		}
+		if limit := config.GetSizeLimit(result, "platform/app"); limit != 2*1024*1024 {
+			t.Errorf("GetSizeLimit() = %d, expected the 2m of platform/parent", limit)
+		}
+		if limit := config.GetPushSizeLimit(result, "platform/app"); limit != 10*1024*1024 {
+			t.Errorf("GetPushSizeLimit() = %d, expected the 10m of platform/app", limit)
+		}
+		if mode := config.GetMode(result, "platform/app", "refs/heads/master"); mode != config.ModeWarn {
+			t.Errorf("GetMode() = %s, expected the warn mode of All-Projects", mode)
+		}
+		if rules := config.GetDisabledRules(result, "platform/app"); len(rules) != 1 || rules[0] != config.RuleDCO {
+			t.Errorf("GetDisabledRules() = %v, expected dco", rules)
+		}
+		if result.Projects["platform/app"].NewFileLimit == nil {
+			t.Errorf("settings of the projects section missing from project.config should be kept")
+		}
+	})
+
+	t.Run("Project without project.config", func(t *testing.T) {
+		result, err := withProjectConfig(config.Config{Gerrit: config.GerritConfig{ProjectConfig: true, BasePath: basePath}}, githookkit.Repository{}, "other")
+		if err != nil {
+			t.Fatalf("withProjectConfig() returned error: %v", err)
+		}
+		if limit := config.GetSizeLimit(result, "other"); limit != 1024*1024 {
+			t.Errorf("GetSizeLimit() = %d, expected the 1m of All-Projects", limit)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		result, err := withProjectConfig(config.Config{Projects: cfg.Projects}, githookkit.Repository{}, "platform/app")
+		if err != nil || config.GetSizeLimit(result, "platform/app") != yamlLimit {
+			t.Errorf("withProjectConfig() = %v, expected the projects section unchanged without gerrit.project_config", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		initProject(t, basePath, "loop/a", "[access]\n\tinheritFrom = loop/b\n")
+		initProject(t, basePath, "loop/b", "[access]\n\tinheritFrom = loop/a\n")
+		initProject(t, basePath, "invalid", "[plugin \"githookkit\"]\n\tsizeLimit = large\n")
+
+		cfg := config.Config{Gerrit: config.GerritConfig{ProjectConfig: true, BasePath: basePath}}
+		for project, expected := range map[string]string{"loop/a": "cycle", "invalid": "invalid size", "missing": "failed to open repository"} {
+			if _, err := withProjectConfig(cfg, githookkit.Repository{}, project); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("withProjectConfig(%s) = %v, expected an error containing %q", project, err, expected)
+			}
+		}
+	})
+}
+
+func TestDisabledRules(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096})
+	chdir(t, dir)
+
+	limit := int64(1024)
+	cfg := config.Config{
+		DCO:      config.DCOPolicy{Refs: []string{"refs/heads/*"}},
+		Projects: map[string]config.ProjectPolicy{"p": {SizeLimit: &limit, DisabledRules: []string{config.RuleDCO}}},
+	}
+	result, err := Check(cfg, newTestLogger(t), Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	for _, violation := range result.Findings {
+		if violation.RuleID == config.RuleDCO {
+			t.Errorf("Findings %+v, expected no violation of the disabled dco rule", result.Findings)
+		}
+	}
+	if len(result.Violations) != 1 {
+		t.Errorf("Check() returned violations %v, expected the large file only", result.Violations)
+	}
+}
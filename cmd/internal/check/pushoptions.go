@@ -0,0 +1,22 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// PushOptions returns the options given with "git push -o", which git passes to the native hooks
+// as GIT_PUSH_OPTION_COUNT and GIT_PUSH_OPTION_<n>
+func PushOptions() []string {
+	count, err := strconv.Atoi(os.Getenv("GIT_PUSH_OPTION_COUNT"))
+	if err != nil {
+		return nil
+	}
+
+	var options []string
+	for i := 0; i < count; i++ {
+		options = append(options, os.Getenv(fmt.Sprintf("GIT_PUSH_OPTION_%d", i)))
+	}
+	return options
+}
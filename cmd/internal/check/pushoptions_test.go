@@ -0,0 +1,21 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPushOptions(t *testing.T) {
+	t.Setenv("GIT_PUSH_OPTION_COUNT", "")
+	if options := PushOptions(); options != nil {
+		t.Errorf("PushOptions() = %v, expected nil without push options", options)
+	}
+
+	t.Setenv("GIT_PUSH_OPTION_COUNT", "2")
+	t.Setenv("GIT_PUSH_OPTION_0", "large-files-ok=JIRA-1")
+	t.Setenv("GIT_PUSH_OPTION_1", "ci.skip")
+	expected := []string{"large-files-ok=JIRA-1", "ci.skip"}
+	if options := PushOptions(); !reflect.DeepEqual(options, expected) {
+		t.Errorf("PushOptions() = %v, expected %v", options, expected)
+	}
+}
@@ -0,0 +1,162 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// refStateFile is the name of the ref state file in the git directory
+const refStateFile = "githookkit-refs.json"
+
+// refState records which user created which ref, git itself doesn't keep track of it
+type refState struct {
+	Owners map[string]string `json:"owners"` // Ref name to the username that created it
+}
+
+// refStatePath returns the location of the ref state of repo
+func refStatePath(repo githookkit.Repository) (string, error) {
+	gitDir, err := repo.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, refStateFile), nil
+}
+
+// loadRefState reads the ref state, a missing file gives the empty state
+func loadRefState(path string) (refState, error) {
+	state := refState{Owners: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read ref state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse ref state %s: %w", path, err)
+	}
+	if state.Owners == nil {
+		state.Owners = map[string]string{}
+	}
+	return state, nil
+}
+
+// save writes the ref state through a temporary file so that concurrent readers never see a partial file
+func (s refState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ref state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), refStateFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write ref state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write ref state: %w", err)
+	}
+	return nil
+}
+
+// checkRefQuota limits the number of refs a user may create under a namespace
+// Refs recorded in the state that no longer exist, e.g. because they were deleted or the push failed, are not counted
+func checkRefQuota(cfg config.Config, update Update, result *Result) error {
+	if update.OldRev != ZeroRev || update.NewRev == ZeroRev || update.UploaderUsername == "" {
+		return nil
+	}
+
+	quota, ok := config.GetRefQuota(cfg, update.RefName, update.UploaderUsername)
+	if !ok || quota.Max == 0 {
+		return nil
+	}
+
+	path, err := refStatePath(update.Repository)
+	if err != nil {
+		return err
+	}
+	state, err := loadRefState(path)
+	if err != nil {
+		return err
+	}
+	refs, err := update.Repository.GetRefs()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, ref := range refs {
+		if ref != update.RefName && state.Owners[ref] == update.UploaderUsername && config.MatchRef(quota.Ref, ref) {
+			count++
+		}
+	}
+	if count < quota.Max {
+		return nil
+	}
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strconv.Itoa(quota.Max), Actual: strconv.Itoa(count), Count: count}
+	result.violate(config.RuleRefQuota, config.RenderMessage(cfg, config.RuleRefQuota, data,
+		fmt.Sprintf("you already created %d refs matching %s, the maximum is %d, delete refs you no longer need!", count, quota.Ref, quota.Max)))
+	return nil
+}
+
+// RecordRefs records the creators of the refs created by accepted results in the ref state, deleted refs are forgotten
+// Nothing is recorded when no ref quota is configured
+func RecordRefs(logger *config.Logger, cfg config.Config, results []Result) {
+	if len(cfg.RefQuotas) == 0 || len(results) == 0 {
+		return
+	}
+
+	// The results of a push share their repository
+	path, err := refStatePath(results[0].Update.Repository)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+	state, err := loadRefState(path)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+
+	changed := false
+	for _, result := range results {
+		update := result.Update
+		switch {
+		case update.NewRev == ZeroRev:
+			if _, exists := state.Owners[update.RefName]; exists {
+				delete(state.Owners, update.RefName)
+				changed = true
+			}
+		case update.OldRev == ZeroRev && update.UploaderUsername != "":
+			if _, ok := config.GetRefQuota(cfg, update.RefName, update.UploaderUsername); ok {
+				state.Owners[update.RefName] = update.UploaderUsername
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		if err := state.save(path); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckRefQuota(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	rev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{RefQuotas: []config.RefQuota{{Ref: "refs/heads/sandbox/{user}/*", Max: 2}}}
+
+	create := func(refName string) Update {
+		return Update{Project: "p", UploaderUsername: "alice", OldRev: ZeroRev, NewRev: rev, RefName: refName}
+	}
+
+	// Creating refs records them, the ref itself is created by git after the hook accepted the push
+	for _, refName := range []string{"refs/heads/sandbox/alice/one", "refs/heads/sandbox/alice/two"} {
+		result, err := Check(cfg, logger, create(refName))
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Fatalf("Check() returned violations %v, expected none", result.Violations)
+		}
+		RecordRefs(logger, cfg, []Result{result})
+		testutil.Git(t, dir, "update-ref", refName, rev)
+	}
+
+	result, err := Check(cfg, logger, create("refs/heads/sandbox/alice/three"))
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "the maximum is 2") {
+		t.Errorf("Check() returned violations %v, expected the quota violation", result.Violations)
+	}
+
+	// Other users and refs outside the namespace are not affected
+	other := create("refs/heads/sandbox/bob/one")
+	other.UploaderUsername = "bob"
+	for _, update := range []Update{other, create("refs/heads/topic")} {
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 {
+			t.Errorf("Check(%s) returned violations %v, expected none", update.RefName, result.Violations)
+		}
+	}
+
+	// Deleting a ref gives the quota back
+	deletion := Update{Project: "p", UploaderUsername: "alice", OldRev: rev, NewRev: ZeroRev, RefName: "refs/heads/sandbox/alice/one"}
+	RecordRefs(logger, cfg, []Result{{Update: deletion}})
+	testutil.Git(t, dir, "update-ref", "-d", deletion.RefName)
+
+	result, err = Check(cfg, logger, create("refs/heads/sandbox/alice/three"))
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v after a deletion, expected none", result.Violations)
+	}
+}
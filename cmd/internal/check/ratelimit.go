@@ -0,0 +1,224 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Hooks of concurrent pushes serialize their access to the rate limit state through a lock file
+const (
+	rateLockRetry = 10 * time.Millisecond
+	rateLockWait  = 5 * time.Second
+	rateLockStale = 30 * time.Second // A lock older than this was left behind by a killed hook
+)
+
+// bucket is the token bucket of an uploader or project
+type bucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// rateState holds the buckets keyed by "user:<name>" and "project:<name>"
+type rateState struct {
+	Buckets map[string]bucket `json:"buckets"`
+}
+
+// RateLimited takes a token for each ref update from the buckets of the uploader and of the project and
+// reports whether the push is rejected because a bucket ran out, rejected pushes take no tokens
+// A rate limit that can't read or write its state is logged and lets the push through
+func RateLimited(logger *config.Logger, cfg config.Config, updates []Update) bool {
+	limit := cfg.RateLimit
+	if limit.UserRate == 0 && limit.ProjectRate == 0 {
+		return false
+	}
+
+	var checked []Update
+	for _, update := range updates {
+		if !config.IsRefSkipped(cfg, update.RefName) {
+			checked = append(checked, update)
+		}
+	}
+	if len(checked) == 0 {
+		return false
+	}
+	update := checked[0]
+
+	if update.UploaderUsername != "" && config.IsRateLimitExempt(cfg, update.UploaderUsername) {
+		return false
+	}
+	overrides, _ := config.GetOverrides(cfg, update.UploaderUsername, update.PushOptions)
+	for _, override := range overrides {
+		if override.Covers(config.RuleRateLimit) {
+			logger.Warnf("Override %s granted to %s, skipping the rate limit", override, update.UploaderUsername)
+			return false
+		}
+	}
+
+	type take struct {
+		key, what string
+		rate      int
+	}
+	var takes []take
+	if limit.UserRate > 0 && update.UploaderUsername != "" {
+		takes = append(takes, take{"user:" + update.UploaderUsername, "user " + update.UploaderUsername, limit.UserRate})
+	}
+	if limit.ProjectRate > 0 {
+		takes = append(takes, take{"project:" + update.Project, "project " + update.Project, limit.ProjectRate})
+	}
+	if len(takes) == 0 {
+		return false
+	}
+
+	unlock, err := lockRateState(limit.StatePath)
+	if err != nil {
+		logger.Errorf("%v, not rate limiting the push", err)
+		return false
+	}
+	defer unlock()
+
+	state, err := loadRateState(limit.StatePath)
+	if err != nil {
+		logger.Errorf("%v, not rate limiting the push", err)
+		return false
+	}
+
+	now := time.Now()
+	for _, t := range takes {
+		burst := rateBurst(limit, t.rate)
+		b := state.refill(t.key, t.rate, burst, now)
+		// A push of more refs than the burst can never pass otherwise, it needs a full bucket
+		needed := math.Min(float64(len(checked)), float64(burst))
+		if b.Tokens < needed {
+			retryAfter := time.Duration((needed - b.Tokens) / float64(t.rate) * float64(time.Minute)).Round(time.Second)
+			if retryAfter < time.Second {
+				retryAfter = time.Second
+			}
+			data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: fmt.Sprintf("%d per minute", t.rate), Actual: retryAfter.String(), Count: len(checked)}
+			message := config.RenderMessage(cfg, config.RuleRateLimit, data,
+				fmt.Sprintf("too many pushes by %s, the limit is %d ref updates per minute, retry after %s!", t.what, t.rate, retryAfter))
+			logger.Errorf("REJECTED: %s", message)
+			return true
+		}
+		b.Tokens -= needed
+		state.Buckets[t.key] = b
+	}
+
+	// Full buckets are the same as missing ones, dropping them keeps the file small
+	for key := range state.Buckets {
+		rate := limit.ProjectRate
+		if strings.HasPrefix(key, "user:") {
+			rate = limit.UserRate
+		}
+		if rate == 0 || state.refill(key, rate, rateBurst(limit, rate), now).Tokens >= float64(rateBurst(limit, rate)) {
+			delete(state.Buckets, key)
+		}
+	}
+	if err := state.save(limit.StatePath); err != nil {
+		logger.Errorf("%v", err)
+	}
+	return false
+}
+
+// rateBurst returns the size of the buckets refilling at rate
+func rateBurst(limit config.RateLimit, rate int) int {
+	if limit.Burst == 0 {
+		return rate
+	}
+	return limit.Burst
+}
+
+// refill returns the bucket of key with the tokens gained since its last update, a new bucket is full
+func (s rateState) refill(key string, rate, burst int, now time.Time) bucket {
+	b, ok := s.Buckets[key]
+	if !ok {
+		return bucket{Tokens: float64(burst), Updated: now}
+	}
+	if elapsed := now.Sub(b.Updated); elapsed > 0 {
+		b.Tokens = math.Min(float64(burst), b.Tokens+elapsed.Minutes()*float64(rate))
+	}
+	b.Updated = now
+	return b
+}
+
+// lockRateState creates the lock file of the state at path, waiting for other hooks holding it
+func lockRateState(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create rate limit directory: %w", err)
+	}
+	lock := path + ".lock"
+	deadline := time.Now().Add(rateLockWait)
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock rate limit state: %w", err)
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > rateLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("rate limit state %s is locked", path)
+		}
+		time.Sleep(rateLockRetry)
+	}
+}
+
+// loadRateState reads the rate limit state, a missing file gives the empty state
+func loadRateState(path string) (rateState, error) {
+	state := rateState{Buckets: map[string]bucket{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read rate limit state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse rate limit state %s: %w", path, err)
+	}
+	if state.Buckets == nil {
+		state.Buckets = map[string]bucket{}
+	}
+	return state, nil
+}
+
+// save writes the rate limit state through a temporary file so that a killed hook never leaves a partial file
+func (s rateState) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode rate limit state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write rate limit state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write rate limit state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package check
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestRateLimited(t *testing.T) {
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		RateLimit: config.RateLimit{UserRate: 2, ProjectRate: 3, StatePath: filepath.Join(t.TempDir(), "state", "rate.json"), ExemptUsers: []string{"mirror"}},
+		Overrides: map[string]config.Override{"ci-storm": {Rules: []string{config.RuleRateLimit}, Users: []string{"alice"}}},
+	}
+	push := func(project, username string, pushOptions ...string) []Update {
+		return []Update{{Project: project, RefName: "refs/heads/master", UploaderUsername: username, PushOptions: pushOptions}}
+	}
+
+	for i := 0; i < 2; i++ {
+		if RateLimited(logger, cfg, push("p", "alice")) {
+			t.Fatalf("RateLimited() rejected push %d within the rate", i+1)
+		}
+	}
+	if !RateLimited(logger, cfg, push("p", "alice")) {
+		t.Errorf("RateLimited() accepted a third push of alice within a minute")
+	}
+	if RateLimited(logger, cfg, push("p", "alice", "ci-storm=JIRA-1")) {
+		t.Errorf("RateLimited() rejected a push with an override")
+	}
+	if RateLimited(logger, cfg, push("p", "bob")) {
+		t.Errorf("RateLimited() rejected the first push of bob, the rejected push of alice shouldn't take a token of p")
+	}
+	if !RateLimited(logger, cfg, push("p", "carol")) {
+		t.Errorf("RateLimited() accepted a fourth push to p within a minute")
+	}
+	if RateLimited(logger, cfg, push("p", "mirror")) {
+		t.Errorf("RateLimited() rejected an exempt user")
+	}
+
+	// Pushes of more refs than the burst need a full bucket
+	updates := append(push("q", "dave"), push("q", "dave")...)
+	updates = append(updates, push("q", "dave")...)
+	if RateLimited(logger, cfg, updates) {
+		t.Errorf("RateLimited() rejected a push of 3 refs with full buckets")
+	}
+}
+
+func TestRateStateRefill(t *testing.T) {
+	now := time.Now()
+	state := rateState{Buckets: map[string]bucket{"user:alice": {Tokens: 0, Updated: now.Add(-30 * time.Second)}}}
+
+	tests := []struct {
+		key      string
+		rate     int
+		burst    int
+		expected float64
+	}{
+		{"user:alice", 4, 10, 2},
+		{"user:alice", 60, 10, 10},
+		{"user:bob", 4, 10, 10},
+	}
+	for _, test := range tests {
+		b := state.refill(test.key, test.rate, test.burst, now)
+		if math.Abs(b.Tokens-test.expected) > 0.001 || !b.Updated.Equal(now) {
+			t.Errorf("refill(%q, %d, %d) = %+v, expected %v tokens", test.key, test.rate, test.burst, b, test.expected)
+		}
+	}
+}
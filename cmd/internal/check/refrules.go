@@ -0,0 +1,126 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkTag applies the project's tag policy to updates of refs/tags/*
+func checkTag(cfg config.Config, update Update, result *Result) error {
+	name, ok := strings.CutPrefix(update.RefName, "refs/tags/")
+	if !ok {
+		return nil
+	}
+
+	policy := config.GetTagPolicy(cfg, update.Project)
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: name}
+
+	if update.NewRev == ZeroRev {
+		if policy.DenyDelete {
+			result.violate(config.RuleTag, config.RenderMessage(cfg, config.RuleTag, data,
+				fmt.Sprintf("deleting tag %s is not allowed!", name)))
+		}
+		return nil
+	}
+
+	if update.OldRev != ZeroRev && policy.DenyMove {
+		result.violate(config.RuleTag, config.RenderMessage(cfg, config.RuleTag, data,
+			fmt.Sprintf("tag %s already exists and tags can't be moved, create a new tag instead!", name)))
+	}
+
+	if len(policy.NamePatterns) > 0 && !matchAny(policy.NamePatterns, name) {
+		data.Limit = strings.Join(policy.NamePatterns, ", ")
+		result.violate(config.RuleTag, config.RenderMessage(cfg, config.RuleTag, data,
+			fmt.Sprintf("tag name %s doesn't match any of the allowed patterns %s!", name, data.Limit)))
+	}
+
+	if policy.RequireAnnotated || policy.RequireSigned {
+		objectType, err := update.Repository.GetObjectType(update.NewRev)
+		if err != nil {
+			return err
+		}
+
+		if objectType != "tag" {
+			result.violate(config.RuleTag, config.RenderMessage(cfg, config.RuleTag, data,
+				fmt.Sprintf("tag %s is a lightweight tag, create an annotated tag with git tag -a!", name)))
+		} else if policy.RequireSigned {
+			signed, err := update.Repository.IsSignedTag(update.NewRev)
+			if err != nil {
+				return err
+			}
+			if !signed {
+				result.violate(config.RuleTag, config.RenderMessage(cfg, config.RuleTag, data,
+					fmt.Sprintf("tag %s is not signed, create a signed tag with git tag -s!", name)))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBranchName applies the branch name patterns to the creation of refs/heads/*
+func checkBranchName(cfg config.Config, update Update, result *Result) {
+	name, ok := strings.CutPrefix(update.RefName, "refs/heads/")
+	if !ok || update.OldRev != ZeroRev || update.NewRev == ZeroRev {
+		return
+	}
+
+	patterns := config.GetBranchNamePatterns(cfg, update.Project)
+	if len(patterns) == 0 || matchAny(patterns, name) {
+		return
+	}
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(patterns, ", "), Actual: name}
+	result.violate(config.RuleBranchName, config.RenderMessage(cfg, config.RuleBranchName, data,
+		fmt.Sprintf("branch name %s doesn't match any of the allowed patterns %s, rename the branch!", name, data.Limit)))
+}
+
+// checkDeletion rejects the deletion of protected refs
+func checkDeletion(cfg config.Config, update Update, result *Result) {
+	if update.NewRev != ZeroRev || !matchAny(config.GetProtectedRefs(cfg, update.Project), update.RefName) {
+		return
+	}
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName}
+	result.violate(config.RuleRefDeletion, config.RenderMessage(cfg, config.RuleRefDeletion, data,
+		fmt.Sprintf("%s is protected and can't be deleted!", update.RefName)))
+}
+
+// checkForcePush applies the force_push policy to non-fast-forward updates
+func checkForcePush(cfg config.Config, update Update, result *Result) error {
+	if update.OldRev == ZeroRev || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	action := config.GetForcePushAction(cfg, update.RefName)
+	if action == config.ForcePushAllow {
+		return nil
+	}
+
+	fastForward, err := update.Repository.IsFastForward(update.OldRev, update.NewRev)
+	if err != nil || fastForward {
+		return err
+	}
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName}
+	message := config.RenderMessage(cfg, config.RuleForcePush, data,
+		fmt.Sprintf("%s is force pushed, the update discards commits that are already on the branch!", update.RefName))
+	if action == config.ForcePushWarn {
+		result.Warnings = append(result.Warnings, message)
+	} else {
+		result.violate(config.RuleForcePush, message)
+	}
+	return nil
+}
+
+// matchAny checks if name matches one of the ref patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if config.MatchRef(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package check
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// signedTag writes a tag object carrying a (fake) PGP signature and returns its name
+func signedTag(t *testing.T, dir, commit, name string) string {
+	t.Helper()
+
+	content := fmt.Sprintf("object %s\ntype commit\ntag %s\ntagger Test <test@example.com> 1700000000 +0000\n\nRelease\n-----BEGIN PGP SIGNATURE-----\n\nfake\n-----END PGP SIGNATURE-----\n", commit, name)
+	cmd := exec.Command("git", "hash-object", "-t", "tag", "-w", "--stdin")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(content)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to write tag object: %v", err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestCheckTag(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"small.txt": 100})
+	testutil.Git(t, dir, "tag", "-a", "-m", "Release", "v1.0", newRev)
+	annotated := testutil.Git(t, dir, "rev-parse", "v1.0")
+	signed := signedTag(t, dir, newRev, "v1.1")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	strict := config.TagPolicy{RequireSigned: true, NamePatterns: []string{"^v[0-9]+\\.[0-9]+$"}, DenyDelete: true, DenyMove: true}
+
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		update      Update
+		wantMessage string
+	}{
+		{
+			name:   "No tag policy",
+			cfg:    config.Config{},
+			update: Update{Project: "p", OldRev: oldRev, NewRev: ZeroRev, RefName: "refs/tags/v1.0"},
+		},
+		{
+			name:        "Lightweight tag",
+			cfg:         config.Config{Tags: config.TagPolicy{RequireAnnotated: true}},
+			update:      Update{Project: "p", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/tags/v1.0"},
+			wantMessage: "tag v1.0 is a lightweight tag",
+		},
+		{
+			name:   "Annotated tag",
+			cfg:    config.Config{Tags: config.TagPolicy{RequireAnnotated: true}},
+			update: Update{Project: "p", OldRev: ZeroRev, NewRev: annotated, RefName: "refs/tags/v1.0"},
+		},
+		{
+			name:        "Unsigned tag",
+			cfg:         config.Config{Tags: strict},
+			update:      Update{Project: "p", OldRev: ZeroRev, NewRev: annotated, RefName: "refs/tags/v1.0"},
+			wantMessage: "tag v1.0 is not signed",
+		},
+		{
+			name:   "Signed tag",
+			cfg:    config.Config{Tags: strict},
+			update: Update{Project: "p", OldRev: ZeroRev, NewRev: signed, RefName: "refs/tags/v1.1"},
+		},
+		{
+			name:        "Tag name pattern",
+			cfg:         config.Config{Tags: strict},
+			update:      Update{Project: "p", OldRev: ZeroRev, NewRev: signed, RefName: "refs/tags/nightly"},
+			wantMessage: "tag name nightly doesn't match any of the allowed patterns",
+		},
+		{
+			name:        "Tag deletion",
+			cfg:         config.Config{Tags: strict},
+			update:      Update{Project: "p", OldRev: signed, NewRev: ZeroRev, RefName: "refs/tags/v1.1"},
+			wantMessage: "deleting tag v1.1 is not allowed",
+		},
+		{
+			name:        "Tag move",
+			cfg:         config.Config{Tags: strict},
+			update:      Update{Project: "p", OldRev: annotated, NewRev: signed, RefName: "refs/tags/v1.1"},
+			wantMessage: "tag v1.1 already exists and tags can't be moved",
+		},
+		{
+			name:   "Project policy replaces the global one",
+			cfg:    config.Config{Tags: strict, Projects: map[string]config.ProjectPolicy{"p": {Tags: &config.TagPolicy{}}}},
+			update: Update{Project: "p", OldRev: signed, NewRev: ZeroRev, RefName: "refs/tags/v1.1"},
+		},
+		{
+			name:   "Branches are not affected",
+			cfg:    config.Config{Tags: strict},
+			update: Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(tt.cfg, logger, tt.update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if tt.wantMessage == "" {
+				if len(result.Violations) != 0 {
+					t.Errorf("Check() returned violations %v, expected none", result.Violations)
+				}
+				return
+			}
+			if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], tt.wantMessage) {
+				t.Errorf("violations %v, expected one containing %q", result.Violations, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestCheckForcePush(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	baseRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"a.txt": 10})
+	testutil.Git(t, dir, "checkout", "-q", baseRev)
+	rewrittenRev := testutil.CommitFiles(t, dir, map[string]int{"b.txt": 10})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{ForcePush: []config.ForcePushRule{
+		{Ref: "refs/heads/master", Action: config.ForcePushReject},
+		{Ref: "refs/heads/release/*", Action: config.ForcePushWarn},
+	}}
+
+	tests := []struct {
+		name           string
+		update         Update
+		wantViolations int
+		wantWarnings   int
+	}{
+		{"Fast-forward", Update{Project: "p", OldRev: baseRev, NewRev: oldRev, RefName: "refs/heads/master"}, 0, 0},
+		{"Rejected force push", Update{Project: "p", OldRev: oldRev, NewRev: rewrittenRev, RefName: "refs/heads/master"}, 1, 0},
+		{"Warned force push", Update{Project: "p", OldRev: oldRev, NewRev: rewrittenRev, RefName: "refs/heads/release/1.0"}, 0, 1},
+		{"Unprotected branch", Update{Project: "p", OldRev: oldRev, NewRev: rewrittenRev, RefName: "refs/heads/topic"}, 0, 0},
+		{"Branch creation", Update{Project: "p", OldRev: ZeroRev, NewRev: rewrittenRev, RefName: "refs/heads/master"}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(cfg, logger, tt.update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != tt.wantViolations || len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Check() violations = %v, warnings = %v, expected %d and %d", result.Violations, result.Warnings, tt.wantViolations, tt.wantWarnings)
+			}
+			for _, message := range append(result.Violations, result.Warnings...) {
+				if !strings.Contains(message, "is force pushed") {
+					t.Errorf("message %q does not describe the force push", message)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckBranchName(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"a.txt": 10})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		BranchNames: []string{"feature/*", "bugfix/*", `^release/\d+\.\d+$`},
+		Projects:    map[string]config.ProjectPolicy{"legacy": {BranchNames: []string{}}},
+	}
+
+	tests := []struct {
+		name      string
+		update    Update
+		wantCount int
+	}{
+		{"Allowed feature branch", Update{Project: "p", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/heads/feature/login"}, 0},
+		{"Allowed release branch", Update{Project: "p", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/heads/release/2.1"}, 0},
+		{"Invalid release branch", Update{Project: "p", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/heads/release/next"}, 1},
+		{"Invalid branch", Update{Project: "p", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/heads/my-stuff"}, 1},
+		{"Existing branch update", Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}, 0},
+		{"Branch deletion", Update{Project: "p", OldRev: newRev, NewRev: ZeroRev, RefName: "refs/heads/my-stuff"}, 0},
+		{"Tags are not affected", Update{Project: "p", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/tags/my-stuff"}, 0},
+		{"Project without patterns", Update{Project: "legacy", OldRev: ZeroRev, NewRev: newRev, RefName: "refs/heads/my-stuff"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(cfg, logger, tt.update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != tt.wantCount {
+				t.Errorf("Check() returned violations %v, expected %d", result.Violations, tt.wantCount)
+			}
+			if tt.wantCount > 0 && !strings.Contains(result.Violations[0], "doesn't match any of the allowed patterns") {
+				t.Errorf("violation %q does not explain the branch name rule", result.Violations[0])
+			}
+		})
+	}
+}
+
+func TestCheckDeletion(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		ProtectedRefs: []string{"refs/heads/master", "refs/heads/release/*"},
+		Projects:      map[string]config.ProjectPolicy{"sandbox": {ProtectedRefs: []string{}}},
+	}
+
+	tests := []struct {
+		name      string
+		update    Update
+		wantCount int
+	}{
+		{"Protected branch", Update{Project: "p", OldRev: oldRev, NewRev: ZeroRev, RefName: "refs/heads/release/1.0"}, 1},
+		{"Unprotected branch", Update{Project: "p", OldRev: oldRev, NewRev: ZeroRev, RefName: "refs/heads/topic"}, 0},
+		{"Protected branch update", Update{Project: "p", OldRev: ZeroRev, NewRev: oldRev, RefName: "refs/heads/release/1.0"}, 0},
+		{"Project without protected refs", Update{Project: "sandbox", OldRev: oldRev, NewRev: ZeroRev, RefName: "refs/heads/master"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(cfg, logger, tt.update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if len(result.Violations) != tt.wantCount {
+				t.Errorf("Check() returned violations %v, expected %d", result.Violations, tt.wantCount)
+			}
+			if tt.wantCount > 0 && !strings.Contains(result.Violations[0], "is protected and can't be deleted") {
+				t.Errorf("violation %q does not explain the deletion rule", result.Violations[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+)
+
+// lfsRules are the rules whose offending files are fixed by moving them to git lfs
+var lfsRules = []string{config.RuleFileSize, config.RulePathBudget, config.RuleLFS, config.RuleBinary}
+
+// remediation returns the lines telling how to fix the violations of lfsRules in the result, nil when there are none
+func remediation(cfg config.Config, result Result) []string {
+	if cfg.Remediation.Disabled {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var files []githookkit.FileInfo
+	for _, violation := range result.Findings {
+		if violation.Path == "" || violation.Bypassed || !config.Contains(lfsRules, violation.RuleID) || seen[violation.Path] {
+			continue
+		}
+		seen[violation.Path] = true
+		files = append(files, githookkit.FileInfo{Path: violation.Path, Size: violation.Size})
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	sortBySize(files)
+
+	patterns := lfsPatterns(files)
+	var pathspecs []string
+	for _, pattern := range patterns {
+		pathspecs = append(pathspecs, "'"+pattern+"'")
+	}
+	data := config.RemediationData{
+		Project:   result.Update.Project,
+		RefName:   result.Update.RefName,
+		NewRev:    result.Update.NewRev,
+		Includes:  strings.Join(patterns, ","),
+		Pathspecs: strings.Join(pathspecs, " "),
+		Files:     files,
+	}
+	if result.Update.OldRev != ZeroRev {
+		data.OldRev = result.Update.OldRev
+	}
+
+	lines := []string{
+		locale.T("move the files to git lfs, rewriting the commits that are not pushed yet:"),
+		fmt.Sprintf("  git lfs migrate import --include=%q", data.Includes),
+	}
+	if data.OldRev != "" {
+		lines = append(lines,
+			locale.T("or remove them from these commits, keeping the other changes:"),
+			"  git reset --soft "+data.OldRev,
+			"  git rm --cached -- "+data.Pathspecs,
+			"  git commit",
+		)
+	}
+	lines = append(lines, locale.T("then push again"))
+
+	text := config.RenderRemediation(cfg, data, strings.Join(lines, "\n"))
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+// lfsPatterns returns the patterns matching files, *.ext for an extension shared by several files and the path otherwise
+func lfsPatterns(files []githookkit.FileInfo) []string {
+	extensions := map[string]int{}
+	for _, file := range files {
+		if ext := path.Ext(file.Path); ext != "" {
+			extensions[ext]++
+		}
+	}
+
+	seen := map[string]bool{}
+	var patterns []string
+	for _, file := range files {
+		pattern := file.Path
+		if ext := path.Ext(file.Path); extensions[ext] > 1 {
+			pattern = "*" + ext
+		}
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
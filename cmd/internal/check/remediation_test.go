@@ -0,0 +1,58 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestRemediation(t *testing.T) {
+	newResult := func(oldRev string) Result {
+		result := Result{Update: Update{Project: "app", RefName: "refs/heads/master", OldRev: oldRev, NewRev: "def"}}
+		result.violate(config.RuleFileSize, "files too large",
+			githookkit.FileInfo{Path: "lib/a.bin", Size: 10},
+			githookkit.FileInfo{Path: "assets/video.mp4", Size: 300},
+			githookkit.FileInfo{Path: "b.bin", Size: 20},
+		)
+		result.violate(config.RuleLFS, "not in lfs", githookkit.FileInfo{Path: "assets/video.mp4", Size: 300})
+		result.violate(config.RuleCommitMessage, "bad message")
+		return result
+	}
+
+	expected := []string{
+		"move the files to git lfs, rewriting the commits that are not pushed yet:",
+		`  git lfs migrate import --include="assets/video.mp4,*.bin"`,
+		"or remove them from these commits, keeping the other changes:",
+		"  git reset --soft abc",
+		"  git rm --cached -- 'assets/video.mp4' '*.bin'",
+		"  git commit",
+		"then push again",
+	}
+	if lines := remediation(config.Config{}, newResult("abc")); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("remediation() = %q, expected %q", lines, expected)
+	}
+
+	// A new branch has no pushed state to reset to
+	expected = []string{expected[0], expected[1], expected[6]}
+	if lines := remediation(config.Config{}, newResult(ZeroRev)); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("remediation() of a new branch = %q, expected %q", lines, expected)
+	}
+
+	cfg := config.Config{ContactURL: "https://wiki.example.com/lfs", Remediation: config.Remediation{Template: "track {{.Includes}} on {{.RefName}}, see {{.ContactURL}}\n"}}
+	expected = []string{"track assets/video.mp4,*.bin on refs/heads/master, see https://wiki.example.com/lfs"}
+	if lines := remediation(cfg, newResult("abc")); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("remediation() with a template = %q, expected %q", lines, expected)
+	}
+
+	if lines := remediation(config.Config{Remediation: config.Remediation{Disabled: true}}, newResult("abc")); lines != nil {
+		t.Errorf("remediation() when disabled = %q, expected nil", lines)
+	}
+
+	other := Result{}
+	other.violate(config.RuleSymlink, "symlink", githookkit.FileInfo{Path: "link"})
+	if lines := remediation(config.Config{}, other); lines != nil {
+		t.Errorf("remediation() of other rules = %q, expected nil", lines)
+	}
+}
@@ -0,0 +1,87 @@
+package check
+
+import (
+	"strings"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+)
+
+// reviewTag marks the reviews of githookkit as generated, Gerrit hides them with the other bot comments
+const reviewTag = "autogenerated:githookkit"
+
+// ReviewMessage returns the report of the result as the message of a Gerrit review, empty when there is nothing to report
+func ReviewMessage(cfg config.Config, result Result) string {
+	lines, _ := reportLines(cfg, []Result{result})
+	if len(lines) == 0 {
+		return ""
+	}
+
+	texts := []string{locale.T("githookkit found problems in this patch set:"), ""}
+	for _, line := range lines {
+		texts = append(texts, line.text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// PostReview posts the report of the result as a review of revision of change when gerrit.review is enabled,
+// with the negative vote of the config when the violations reject the patch set
+// The gerrit.actions whose rules the result violates add their reviewers and votes to the review
+func PostReview(cfg config.Config, change, revision string, result Result) error {
+	review := gerrit.Review{Tag: reviewTag}
+	if cfg.Gerrit.Review.Enabled {
+		review.Message = ReviewMessage(cfg, result)
+		if review.Message != "" && result.Rejected() {
+			label, vote := config.GetReviewVote(cfg)
+			review.Labels = map[string]int{label: vote}
+		}
+	}
+
+	rules := addActions(&review, cfg.Gerrit.Actions, result)
+	if review.Message == "" && len(rules) > 0 {
+		review.Message = locale.T("githookkit routed this change because of %s", strings.Join(rules, ", "))
+	}
+	if review.Message == "" {
+		return nil
+	}
+	return gerrit.PostReview(cfg.Gerrit, change, revision, review)
+}
+
+// addActions adds to the review the reviewers and votes of the actions whose rules the result violates,
+// also in warn mode or through an override, and returns these rules
+// A label voted by several actions gets the lowest vote
+func addActions(review *gerrit.Review, actions []config.GerritAction, result Result) []string {
+	var rules []string
+	reviewers := map[string]bool{}
+	for _, action := range actions {
+		hit := false
+		for _, violation := range result.Findings {
+			if config.Contains(action.Rules, violation.RuleID) {
+				hit = true
+				if !config.Contains(rules, violation.RuleID) {
+					rules = append(rules, violation.RuleID)
+				}
+			}
+		}
+		if !hit {
+			continue
+		}
+
+		for _, reviewer := range action.Reviewers {
+			if !reviewers[reviewer] {
+				reviewers[reviewer] = true
+				review.Reviewers = append(review.Reviewers, gerrit.ReviewerInput{Reviewer: reviewer, Confirmed: true})
+			}
+		}
+		for label, vote := range action.Labels {
+			if review.Labels == nil {
+				review.Labels = map[string]int{}
+			}
+			if current, ok := review.Labels[label]; !ok || vote < current {
+				review.Labels[label] = vote
+			}
+		}
+	}
+	return rules
+}
@@ -0,0 +1,109 @@
+package check
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+)
+
+func TestReviewMessage(t *testing.T) {
+	if message := ReviewMessage(config.Config{}, Result{}); message != "" {
+		t.Errorf("ReviewMessage() without violations = %q, expected empty", message)
+	}
+
+	result := Result{Update: Update{Project: "app", RefName: "refs/heads/master", OldRev: "abc^", NewRev: "abc"}, Mode: config.ModeEnforce}
+	result.violate(config.RuleFileSize, "large.bin is too large", githookkit.FileInfo{Path: "large.bin", Size: 8192})
+	message := ReviewMessage(config.Config{}, result)
+	if !strings.HasPrefix(message, "githookkit found problems in this patch set:\n\nREJECTED: large.bin is too large\n") {
+		t.Errorf("ReviewMessage() = %q, expected the rejection", message)
+	}
+	if !strings.Contains(message, "git lfs migrate import") {
+		t.Errorf("ReviewMessage() = %q, expected the remediation", message)
+	}
+}
+
+func TestPostReview(t *testing.T) {
+	var reviews []gerrit.Review
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review gerrit.Review
+		json.NewDecoder(r.Body).Decode(&review)
+		reviews = append(reviews, review)
+	}))
+	defer server.Close()
+
+	rejected := Result{Mode: config.ModeEnforce}
+	rejected.violate(config.RuleNewFiles, "too many new files")
+	warned := Result{Mode: config.ModeWarn}
+	warned.violate(config.RuleNewFiles, "too many new files")
+
+	cfg := config.Config{Gerrit: config.GerritConfig{URL: server.URL, Review: config.GerritReview{Enabled: true, Label: "Verified"}}}
+	for _, result := range []Result{rejected, warned, {}} {
+		if err := PostReview(cfg, "I0123", "abc", result); err != nil {
+			t.Fatalf("PostReview() returned error: %v", err)
+		}
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("PostReview() posted %d reviews, expected 2 as the result without violations has nothing to report", len(reviews))
+	}
+	if expected := map[string]int{"Verified": -1}; !reflect.DeepEqual(reviews[0].Labels, expected) || reviews[0].Tag != reviewTag {
+		t.Errorf("review of the rejected result = %+v, expected the vote %v", reviews[0], expected)
+	}
+	if reviews[1].Labels != nil {
+		t.Errorf("review of the result in warn mode = %+v, expected no vote", reviews[1])
+	}
+
+	cfg.Gerrit.Review.Enabled = false
+	if err := PostReview(cfg, "I0123", "abc", rejected); err != nil || len(reviews) != 2 {
+		t.Errorf("PostReview() = %v and posted %d reviews while disabled, expected nothing", err, len(reviews)-2)
+	}
+}
+
+func TestPostReviewActions(t *testing.T) {
+	var reviews []gerrit.Review
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review gerrit.Review
+		json.NewDecoder(r.Body).Decode(&review)
+		reviews = append(reviews, review)
+	}))
+	defer server.Close()
+
+	// Large files accepted in warn mode still route the change to the people reviewing them
+	result := Result{Mode: config.ModeWarn}
+	result.violate(config.RuleFileSize, "large.bin is too large", githookkit.FileInfo{Path: "large.bin", Size: 8192})
+	result.violate(config.RuleSubmoduleChange, ".gitmodules changed", githookkit.FileInfo{Path: ".gitmodules"})
+
+	cfg := config.Config{Gerrit: config.GerritConfig{URL: server.URL, Actions: []config.GerritAction{
+		{Rules: []string{config.RuleFileSize}, Reviewers: []string{"storage-team"}, Labels: map[string]int{"Size-Review": -1}},
+		{Rules: []string{config.RuleSubmoduleChange}, Reviewers: []string{"storage-team", "platform-team"}, Labels: map[string]int{"Size-Review": -2}},
+		{Rules: []string{config.RuleSecret}, Reviewers: []string{"security-team"}},
+	}}}
+	if err := PostReview(cfg, "I0123", "abc", result); err != nil {
+		t.Fatalf("PostReview() returned error: %v", err)
+	}
+	if len(reviews) != 1 {
+		t.Fatalf("PostReview() posted %d reviews, expected 1", len(reviews))
+	}
+
+	review := reviews[0]
+	expectedReviewers := []gerrit.ReviewerInput{{Reviewer: "storage-team", Confirmed: true}, {Reviewer: "platform-team", Confirmed: true}}
+	if !reflect.DeepEqual(review.Reviewers, expectedReviewers) {
+		t.Errorf("reviewers = %+v, expected %+v", review.Reviewers, expectedReviewers)
+	}
+	if expected := map[string]int{"Size-Review": -2}; !reflect.DeepEqual(review.Labels, expected) {
+		t.Errorf("labels = %v, expected the lowest vote %v", review.Labels, expected)
+	}
+	if expected := "githookkit routed this change because of file_size, submodule_change"; review.Message != expected {
+		t.Errorf("message = %q, expected %q without gerrit.review", review.Message, expected)
+	}
+
+	if err := PostReview(cfg, "I0123", "abc", Result{}); err != nil || len(reviews) != 1 {
+		t.Errorf("PostReview() = %v and posted %d reviews without violations, expected nothing", err, len(reviews)-1)
+	}
+}
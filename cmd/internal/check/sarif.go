@@ -0,0 +1,133 @@
+package check
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// SARIF 2.1.0, the format code-scanning dashboards ingest
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifInfoURI = "https://github.com/bwinhwang/githookkit"
+)
+
+// SARIFLog is a SARIF log with a single run of a githookkit binary
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun holds the violations found by one binary
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the binary and the rules it reports
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the binary that found the violations
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is a rule ID of the config
+type SARIFRule struct {
+	ID string `json:"id"`
+}
+
+// SARIFResult is a violation of a rule
+type SARIFResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"` // The severity of the violation
+	Message      SARIFMessage       `json:"message"`
+	Locations    []SARIFLocation    `json:"locations,omitempty"`
+	Suppressions []SARIFSuppression `json:"suppressions,omitempty"` // The violation was bypassed by an override
+	Properties   map[string]string  `json:"properties,omitempty"`   // The project, the ref and the commit
+}
+
+// SARIFMessage is the text of a result
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFLocation is the file of a result
+type SARIFLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// SARIFSuppression tells that a violation was accepted
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// NewSARIF returns the SARIF log of the violations in results, toolName is the binary that checked them
+func NewSARIF(toolName string, results []Result) SARIFLog {
+	run := SARIFRun{
+		Tool: SARIFTool{Driver: SARIFDriver{
+			Name:           toolName,
+			Version:        version.Get().Version,
+			InformationURI: sarifInfoURI,
+			Rules:          []SARIFRule{},
+		}},
+		Results: []SARIFResult{},
+	}
+
+	rules := map[string]bool{}
+	for _, result := range results {
+		for _, violation := range result.Findings {
+			if !rules[violation.RuleID] {
+				rules[violation.RuleID] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, SARIFRule{ID: violation.RuleID})
+			}
+
+			sarifResult := SARIFResult{
+				RuleID:     violation.RuleID,
+				Level:      violation.Severity,
+				Message:    SARIFMessage{Text: violation.Message},
+				Properties: map[string]string{"project": result.Update.Project, "ref": result.Update.RefName},
+			}
+			if violation.Commit != "" {
+				sarifResult.Properties["commit"] = violation.Commit
+			}
+			if violation.Path != "" {
+				var location SARIFLocation
+				location.PhysicalLocation.ArtifactLocation.URI = (&url.URL{Path: violation.Path}).String()
+				sarifResult.Locations = []SARIFLocation{location}
+			}
+			if violation.Bypassed {
+				var overrides []string
+				for _, override := range result.Overrides {
+					overrides = append(overrides, override.String())
+				}
+				sarifResult.Suppressions = []SARIFSuppression{{Kind: "external", Justification: "override " + strings.Join(overrides, ", ")}}
+			}
+			run.Results = append(run.Results, sarifResult)
+		}
+	}
+
+	return SARIFLog{Version: sarifVersion, Schema: sarifSchema, Runs: []SARIFRun{run}}
+}
+
+// WriteSARIF writes the SARIF log of the violations in results
+func WriteSARIF(w io.Writer, toolName string, results []Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewSARIF(toolName, results))
+}
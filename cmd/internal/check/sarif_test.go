@@ -0,0 +1,82 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestNewSARIF(t *testing.T) {
+	override := config.AppliedOverride{Option: "override-size", Reason: "INC-7", Rules: []string{config.RuleLicenseHeader}}
+	enforced := Result{Update: Update{Project: "p", RefName: "refs/heads/master"}, Mode: config.ModeEnforce, Overrides: []config.AppliedOverride{override}}
+	enforced.violate(config.RuleFileSize, "files are too large", githookkit.FileInfo{Path: "a.bin"}, githookkit.FileInfo{Path: "docs/my file.bin"})
+	enforced.violate(config.RuleNewFiles, "too many new files")
+	enforced.violate(config.RuleLicenseHeader, "missing license header", githookkit.FileInfo{Path: "main.go"})
+
+	warned := Result{Update: Update{Project: "p", RefName: "refs/heads/dev"}, Mode: config.ModeWarn}
+	warned.violate(config.RuleFileSize, "file is too large", githookkit.FileInfo{Path: "b.bin"})
+
+	log := NewSARIF("pre-receive", []Result{enforced, warned, {Update: Update{RefName: "refs/heads/clean"}}})
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "pre-receive" {
+		t.Fatalf("NewSARIF() = %+v, expected a 2.1.0 log with one run of pre-receive", log)
+	}
+
+	run := log.Runs[0]
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	if len(rules) != 3 || rules[0] != config.RuleFileSize || rules[1] != config.RuleNewFiles || rules[2] != config.RuleLicenseHeader {
+		t.Errorf("NewSARIF() declared rules %v, expected each violated rule once", rules)
+	}
+
+	type expectedResult struct {
+		rule, level, uri string
+		suppressed       bool
+	}
+	expected := []expectedResult{
+		{config.RuleFileSize, "error", "a.bin", false},
+		{config.RuleFileSize, "error", "docs/my%20file.bin", false},
+		{config.RuleNewFiles, "error", "", false},
+		{config.RuleLicenseHeader, "warning", "main.go", true},
+		{config.RuleFileSize, "warning", "b.bin", false},
+	}
+	if len(run.Results) != len(expected) {
+		t.Fatalf("NewSARIF() returned %d results, expected %d: %+v", len(run.Results), len(expected), run.Results)
+	}
+	for i, result := range run.Results {
+		uri := ""
+		if len(result.Locations) > 0 {
+			uri = result.Locations[0].PhysicalLocation.ArtifactLocation.URI
+		}
+		got := expectedResult{result.RuleID, result.Level, uri, len(result.Suppressions) > 0}
+		if got != expected[i] {
+			t.Errorf("result %d = %+v, expected %+v", i, got, expected[i])
+		}
+	}
+	if suppression := run.Results[3].Suppressions[0]; suppression.Kind != "external" || suppression.Justification != "override override-size=INC-7" {
+		t.Errorf("suppression = %+v, expected the override", suppression)
+	}
+	if run.Results[0].Properties["ref"] != "refs/heads/master" {
+		t.Errorf("properties = %v, expected the ref", run.Results[0].Properties)
+	}
+
+	// A log without violations still lists its (empty) results, as SARIF requires
+	var buffer bytes.Buffer
+	if err := WriteSARIF(&buffer, "scan", nil); err != nil {
+		t.Fatalf("WriteSARIF() returned error: %v", err)
+	}
+	var decoded struct {
+		Schema string `json:"$schema"`
+		Runs   []struct{ Results []json.RawMessage }
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode %s: %v", buffer.String(), err)
+	}
+	if decoded.Schema == "" || len(decoded.Runs) != 1 || decoded.Runs[0].Results == nil {
+		t.Errorf("WriteSARIF() wrote %s, expected a run with empty results", buffer.String())
+	}
+}
@@ -0,0 +1,137 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// secretFinding is a possible secret in a new file, the secret itself is never reported
+type secretFinding struct {
+	Path     string
+	Line     int
+	Detector string
+}
+
+// secretDetector is a compiled config.SecretDetector
+type secretDetector struct {
+	config.SecretDetector
+	re *regexp.Regexp
+}
+
+// secretRule rejects updates adding files that contain secrets, binary files and files above the scan size are skipped
+func secretRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	if !cfg.SecretScan.Enabled {
+		return nil, nil
+	}
+
+	detectors, err := secretDetectors(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := cfg.SecretScan.MaxSize
+	if maxSize == 0 {
+		maxSize = config.DefaultSecretScanMaxSize
+	}
+	allowlist := config.GetSecretAllowlist(cfg, update.Project)
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		return file.Size > maxSize || matchAny(allowlist, file.Path)
+	})
+
+	var findings []secretFinding
+	read := func(object string, content []byte) {
+		if isBinary(content[:min(len(content), config.DefaultBinarySampleSize)]) {
+			return
+		}
+		for _, file := range paths[object] {
+			for _, finding := range findSecrets(detectors, content) {
+				finding.Path = file.Path
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(findings) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d possible secrets:", len(findings)), len(findings), func(i int) string {
+			return fmt.Sprintf("Path: %s, Line: %d, Type: %s", findings[i].Path, findings[i].Line, findings[i].Detector)
+		})
+		var files []githookkit.FileInfo
+		for _, finding := range findings {
+			if len(files) == 0 || files[len(files)-1].Path != finding.Path {
+				files = append(files, githookkit.FileInfo{Path: finding.Path})
+			}
+		}
+
+		first := findings[0]
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: first.Detector, Count: len(findings), Files: files, Largest: files[0]}
+		result.violate(config.RuleSecret, config.RenderMessage(cfg, config.RuleSecret, data,
+			fmt.Sprintf("push adds %d possible secrets, the first one is a %s in %s line %d, remove them from the history and revoke them!", len(findings), first.Detector, first.Path, first.Line)), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, read: read, report: report}, nil
+}
+
+// secretDetectors compiles the secret detectors of cfg
+func secretDetectors(cfg config.Config) ([]secretDetector, error) {
+	var detectors []secretDetector
+	for _, detector := range config.GetSecretDetectors(cfg) {
+		re, err := regexp.Compile(detector.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("secret detector %s: %w", detector.Name, err)
+		}
+		detectors = append(detectors, secretDetector{detector, re})
+	}
+	return detectors, nil
+}
+
+// findSecrets returns the secrets the detectors find in content, without path
+func findSecrets(detectors []secretDetector, content []byte) []secretFinding {
+	var findings []secretFinding
+	for _, detector := range detectors {
+		for _, match := range detector.re.FindAllSubmatchIndex(content, -1) {
+			// The last capture group that matched is the secret, e.g. the value of "api_key = ..."
+			start, end := match[0], match[1]
+			for i := len(match)/2 - 1; i > 0; i-- {
+				if match[2*i] >= 0 {
+					start, end = match[2*i], match[2*i+1]
+					break
+				}
+			}
+
+			if detector.MinEntropy > 0 && entropy(content[start:end]) < detector.MinEntropy {
+				continue
+			}
+			findings = append(findings, secretFinding{
+				Line:     bytes.Count(content[:start], []byte("\n")) + 1,
+				Detector: detector.Name,
+			})
+		}
+	}
+	return findings
+}
+
+// entropy returns the Shannon entropy of data in bits per byte
+func entropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var result float64
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(len(data))
+			result -= p * math.Log2(p)
+		}
+	}
+	return result
+}
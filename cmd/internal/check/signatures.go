@@ -0,0 +1,29 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bwinhwang/githookkit"
+)
+
+// signatureProblems returns a problem unless the signature is good and made by a trusted key
+func signatureProblems(signature githookkit.Signature) []string {
+	switch signature.Status {
+	case "G":
+		return nil
+	case "N", "":
+		return []string{"commit is not signed"}
+	case "B":
+		return []string{"signature is bad"}
+	case "U":
+		return []string{fmt.Sprintf("key %s is not trusted", signature.Key)}
+	case "X":
+		return []string{"signature has expired"}
+	case "Y":
+		return []string{fmt.Sprintf("key %s has expired", signature.Key)}
+	case "R":
+		return []string{fmt.Sprintf("key %s is revoked", signature.Key)}
+	default:
+		return []string{fmt.Sprintf("signature can't be checked, key %s is unknown", signature.Key)}
+	}
+}
@@ -0,0 +1,85 @@
+package check
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestSignatureProblems(t *testing.T) {
+	tests := []struct {
+		signature githookkit.Signature
+		expected  []string
+	}{
+		{githookkit.Signature{Status: "G", Key: "K"}, nil},
+		{githookkit.Signature{Status: "N"}, []string{"commit is not signed"}},
+		{githookkit.Signature{}, []string{"commit is not signed"}},
+		{githookkit.Signature{Status: "B", Key: "K"}, []string{"signature is bad"}},
+		{githookkit.Signature{Status: "U", Key: "K"}, []string{"key K is not trusted"}},
+		{githookkit.Signature{Status: "E", Key: "K"}, []string{"signature can't be checked, key K is unknown"}},
+	}
+
+	for _, test := range tests {
+		if problems := signatureProblems(test.signature); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("signatureProblems(%v) = %v, expected %v", test.signature, problems, test.expected)
+		}
+	}
+}
+
+func TestCheckSignatures(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not available")
+	}
+
+	dir := testutil.InitRepo(t)
+	keys := t.TempDir()
+	for _, name := range []string{"trusted", "other"} {
+		if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", filepath.Join(keys, name)).CombinedOutput(); err != nil {
+			t.Fatalf("ssh-keygen failed: %v\n%s", err, output)
+		}
+	}
+	publicKey, err := os.ReadFile(filepath.Join(keys, "trusted.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedSigners := filepath.Join(keys, "allowed_signers")
+	if err := os.WriteFile(allowedSigners, []byte("test@example.com "+string(publicKey)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.Git(t, dir, "config", "gpg.format", "ssh")
+	testutil.Git(t, dir, "config", "user.signingkey", filepath.Join(keys, "trusted.pub"))
+	testutil.Git(t, dir, "commit", "-q", "-S", "--allow-empty", "-m", "Signed")
+	testutil.Git(t, dir, "config", "user.signingkey", filepath.Join(keys, "other.pub"))
+	testutil.Git(t, dir, "commit", "-q", "-S", "--allow-empty", "-m", "Signed by another key")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Unsigned")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{CommitSignatures: config.CommitSignatures{Refs: []string{"refs/heads/master"}, AllowedSigners: allowedSigners}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 2 || !strings.Contains(result.Violations[0], "(Unsigned): commit is not signed!") ||
+		!strings.Contains(result.Violations[1], "(Signed by another key): key SHA256:") {
+		t.Errorf("Check() returned violations %v, expected the unsigned commit and the untrusted key", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/feature"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none", result.Violations)
+	}
+}
@@ -0,0 +1,41 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadUpdates parses ref updates in git's pre-receive/post-receive stdin format, one "<oldrev> <newrev> <refname>" per line
+// project and uploaderUsername are applied to every update
+func ReadUpdates(r io.Reader, project, uploaderUsername string) ([]Update, error) {
+	var updates []Update
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid ref update line %q, expected \"<oldrev> <newrev> <refname>\"", line)
+		}
+
+		updates = append(updates, Update{
+			Project:          project,
+			UploaderUsername: uploaderUsername,
+			OldRev:           fields[0],
+			NewRev:           fields[1],
+			RefName:          fields[2],
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ref updates: %w", err)
+	}
+
+	return updates, nil
+}
@@ -0,0 +1,28 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadUpdates(t *testing.T) {
+	input := "1111 2222 refs/heads/master\n\n0000 3333 refs/tags/v1.0\n"
+
+	updates, err := ReadUpdates(strings.NewReader(input), "project1", "alice")
+	if err != nil {
+		t.Fatalf("ReadUpdates() returned error: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("ReadUpdates() returned %d updates, expected 2", len(updates))
+	}
+
+	expected := Update{Project: "project1", UploaderUsername: "alice", OldRev: "0000", NewRev: "3333", RefName: "refs/tags/v1.0"}
+	if !reflect.DeepEqual(updates[1], expected) {
+		t.Errorf("updates[1] = %+v, expected %+v", updates[1], expected)
+	}
+
+	if _, err := ReadUpdates(strings.NewReader("1111 refs/heads/master\n"), "project1", "alice"); err == nil {
+		t.Errorf("ReadUpdates() should return error for a malformed line")
+	}
+}
@@ -0,0 +1,159 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkSubmodules rejects updates whose .gitmodules files declare submodules with disallowed URLs,
+// or adding submodules .gitmodules doesn't declare
+func checkSubmodules(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	policy := config.GetSubmodulePolicy(cfg, update.Project)
+	if !policy.Enabled || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	changes, err := update.Repository.GetChanges(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	// Each commit is checked against its own .gitmodules
+	declared := map[string][]githookkit.Submodule{}
+	submodules := func(commit string) ([]githookkit.Submodule, error) {
+		if submodules, ok := declared[commit]; ok {
+			return submodules, nil
+		}
+		submodules, err := update.Repository.GetSubmodules(commit)
+		declared[commit] = submodules
+		return submodules, err
+	}
+
+	var files []githookkit.FileInfo
+	var problems []string
+	seen := map[string]bool{}
+	report := func(path, problem string) {
+		if !seen[path+"\x00"+problem] {
+			seen[path+"\x00"+problem] = true
+			files = append(files, githookkit.FileInfo{Path: path})
+			problems = append(problems, fmt.Sprintf("%s: %s", path, problem))
+		}
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Path == ".gitmodules" && change.Status != "D":
+			modules, err := submodules(change.Commit)
+			if err != nil {
+				return err
+			}
+			for _, module := range modules {
+				if problem := submoduleURLProblem(module.URL, policy.AllowedURLs); problem != "" {
+					report(".gitmodules", fmt.Sprintf("submodule %s %s", module.Name, problem))
+				}
+			}
+		case change.NewMode == githookkit.ModeGitlink && change.OldMode != githookkit.ModeGitlink:
+			modules, err := submodules(change.Commit)
+			if err != nil {
+				return err
+			}
+			if !isSubmoduleDeclared(modules, change.Path) {
+				report(change.Path, "submodule is not declared in .gitmodules")
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d submodule problems:", len(problems)), len(problems), func(i int) string {
+		return problems[i]
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Limit: strings.Join(policy.AllowedURLs, ", "), Count: len(problems), Files: files, Largest: files[0], Actual: problems[0]}
+	result.violate(config.RuleSubmodule, config.RenderMessage(cfg, config.RuleSubmodule, data,
+		fmt.Sprintf("push contains %d submodule problems, the first one is %s, fix them before pushing!", len(problems), problems[0])), data.Files...)
+	return nil
+}
+
+// checkSubmoduleChanges rejects updates changing .gitmodules or submodule pointers when the uploader is
+// neither one of the allowed changers nor gave the acknowledging push option
+func checkSubmoduleChanges(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	policy := config.GetSubmodulePolicy(cfg, update.Project)
+	if (len(policy.ChangeUsers) == 0 && len(policy.ChangeGroups) == 0) || update.NewRev == ZeroRev {
+		return nil
+	}
+	if config.IsSubmoduleChanger(cfg, policy, update.UploaderUsername) {
+		return nil
+	}
+	if policy.ChangeOption != "" {
+		for _, option := range update.PushOptions {
+			if name, _, _ := strings.Cut(option, "="); name == policy.ChangeOption {
+				logger.Warnf("User %s changes submodules with push option %s", update.UploaderUsername, option)
+				return nil
+			}
+		}
+	}
+
+	changes, err := update.Repository.GetChanges(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	var files []githookkit.FileInfo
+	seen := map[string]bool{}
+	for _, change := range changes {
+		if change.Path != ".gitmodules" && change.NewMode != githookkit.ModeGitlink && change.OldMode != githookkit.ModeGitlink {
+			continue
+		}
+		if !seen[change.Path] {
+			seen[change.Path] = true
+			files = append(files, githookkit.FileInfo{Path: change.Path})
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d changed submodules:", len(files)), len(files), func(i int) string {
+		return fmt.Sprintf("Path: %s", files[i].Path)
+	})
+
+	owner := config.PathOwner{Users: policy.ChangeUsers, Groups: policy.ChangeGroups}
+	text := fmt.Sprintf("push changes submodules, the first change is %s which only %s may make", files[0].Path, describeOwners(owner))
+	if policy.ChangeOption != "" {
+		text += fmt.Sprintf(", push with -o %s to confirm the change", policy.ChangeOption)
+	}
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Count: len(files), Files: files, Largest: files[0]}
+	result.violate(config.RuleSubmoduleChange, config.RenderMessage(cfg, config.RuleSubmoduleChange, data, text+"!"), data.Files...)
+	return nil
+}
+
+// submoduleURLProblem returns why a submodule URL isn't allowed, or "" when it is
+// Relative URLs resolve against the URL of the superproject and are always allowed
+func submoduleURLProblem(url string, allowed []string) string {
+	switch {
+	case url == "":
+		return "has no url"
+	case strings.HasPrefix(url, "./") || strings.HasPrefix(url, "../"):
+		return ""
+	case strings.HasPrefix(strings.ToLower(url), "http://"):
+		return fmt.Sprintf("url %s is not encrypted, use https or ssh", url)
+	case len(allowed) > 0 && !matchAny(allowed, url):
+		return fmt.Sprintf("url %s is not allowed, allowed are %s", url, strings.Join(allowed, ", "))
+	}
+	return ""
+}
+
+// isSubmoduleDeclared reports whether one of modules is checked out at path
+func isSubmoduleDeclared(modules []githookkit.Submodule, path string) bool {
+	for _, module := range modules {
+		if module.Path == path {
+			return true
+		}
+	}
+	return false
+}
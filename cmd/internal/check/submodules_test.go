@@ -0,0 +1,130 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestSubmoduleURLProblem(t *testing.T) {
+	allowed := []string{"ssh://gerrit.example.com:29418/*", "https://git.example.com/*"}
+	tests := []struct {
+		url      string
+		allowed  []string
+		expected string
+	}{
+		{"ssh://gerrit.example.com:29418/lib/x", allowed, ""},
+		{"../x.git", allowed, ""},
+		{"https://github.com/x/y.git", nil, ""},
+		{"https://github.com/x/y.git", allowed, "url https://github.com/x/y.git is not allowed, allowed are ssh://gerrit.example.com:29418/*, https://git.example.com/*"},
+		{"HTTP://git.example.com/x", nil, "url HTTP://git.example.com/x is not encrypted, use https or ssh"},
+		{"", nil, "has no url"},
+	}
+
+	for _, test := range tests {
+		if problem := submoduleURLProblem(test.url, test.allowed); problem != test.expected {
+			t.Errorf("submoduleURLProblem(%q, %v) = %q, expected %q", test.url, test.allowed, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckSubmodules(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	gitmodules := `[submodule "lib.x"]
+	path = lib/x
+	url = https://git.example.com/lib/x.git
+[submodule "tools"]
+	path = tools
+	url = http://mirror.example.com/tools.git
+`
+	testutil.CommitContents(t, dir, map[string]string{".gitmodules": gitmodules}, "Declare submodules")
+	testutil.Git(t, dir, "update-index", "--add", "--cacheinfo", "160000,"+oldRev+",lib/x")
+	testutil.Git(t, dir, "update-index", "--add", "--cacheinfo", "160000,"+oldRev+",vendor/y")
+	testutil.Git(t, dir, "commit", "-q", "-m", "Add submodules")
+	newRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		policy   config.SubmodulePolicy
+		expected []string
+	}{
+		{"disabled", config.SubmodulePolicy{}, nil},
+		{"any URL", config.SubmodulePolicy{Enabled: true}, []string{
+			"push contains 2 submodule problems, the first one is vendor/y: submodule is not declared in .gitmodules",
+		}},
+		{"allowed URLs", config.SubmodulePolicy{Enabled: true, AllowedURLs: []string{"ssh://*"}}, []string{
+			"push contains 3 submodule problems",
+		}},
+	}
+
+	for _, test := range tests {
+		result, err := Check(config.Config{Submodules: test.policy}, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		if len(result.Violations) != len(test.expected) {
+			t.Fatalf("%s: Check() returned violations %v, expected %v", test.name, result.Violations, test.expected)
+		}
+		for i, expected := range test.expected {
+			if !strings.Contains(result.Violations[i], expected) {
+				t.Errorf("%s: Check() returned violation %q, expected %q", test.name, result.Violations[i], expected)
+			}
+		}
+	}
+}
+
+func TestCheckSubmoduleChanges(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	gitmodules := `[submodule "lib"]
+	path = lib
+	url = ../lib.git
+`
+	testutil.CommitContents(t, dir, map[string]string{".gitmodules": gitmodules}, "Declare submodule")
+	testutil.Git(t, dir, "update-index", "--add", "--cacheinfo", "160000,"+oldRev+",lib")
+	testutil.Git(t, dir, "commit", "-q", "-m", "Add submodule")
+	newRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	testutil.Git(t, dir, "commit", "-q", "--allow-empty", "-m", "Unrelated")
+	otherRev := testutil.Git(t, dir, "rev-parse", "HEAD")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{
+		Submodules: config.SubmodulePolicy{ChangeGroups: []string{"release"}, ChangeOption: "submodule-change"},
+		Groups:     map[string][]string{"release": {"rita"}},
+	}
+
+	tests := []struct {
+		name        string
+		username    string
+		pushOptions []string
+		oldRev      string
+		newRev      string
+		expected    string
+	}{
+		{"Other uploader", "bob", nil, oldRev, newRev, "push changes submodules, the first change is lib which only members of release may make, push with -o submodule-change to confirm the change!"},
+		{"Group member", "rita", nil, oldRev, newRev, ""},
+		{"Push option", "bob", []string{"submodule-change=REL-12"}, oldRev, newRev, ""},
+		{"No submodule change", "bob", nil, newRev, otherRev, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			update := Update{Project: "p", OldRev: test.oldRev, NewRev: test.newRev, RefName: "refs/heads/master", UploaderUsername: test.username, PushOptions: test.pushOptions}
+			result, err := Check(cfg, logger, update)
+			if err != nil {
+				t.Fatalf("Check() returned error: %v", err)
+			}
+			if test.expected == "" && len(result.Violations) != 0 {
+				t.Errorf("Check() returned violations %v, expected none", result.Violations)
+			}
+			if test.expected != "" && (len(result.Violations) != 1 || result.Violations[0] != test.expected) {
+				t.Errorf("Check() returned violations %v, expected %q", result.Violations, test.expected)
+			}
+		})
+	}
+}
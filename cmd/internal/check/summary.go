@@ -0,0 +1,99 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+)
+
+// outputCap limits the lines Report writes for the violations of a push, Gerrit truncates or drops very large hook output
+// Lines beyond the limits are counted per rule and summarized at the end
+type outputCap struct {
+	maxLines, maxBytes int // Negative means no limit
+	lines, bytes       int
+	omitted            map[string]int // Omitted lines per rule
+	rules              []string       // Rules with omitted lines, in the order they were first omitted
+	first              *Update        // First ref update with omitted lines
+}
+
+// newOutputCap returns the output cap of the config
+func newOutputCap(cfg config.Config) *outputCap {
+	maxLines, maxBytes := config.GetOutputLimits(cfg)
+	return &outputCap{maxLines: maxLines, maxBytes: maxBytes, omitted: map[string]int{}}
+}
+
+// allow returns line when it fits in the limits, otherwise it counts the line as omitted for rule
+// The first line is always written, cut to the byte limit
+func (c *outputCap) allow(update Update, rule, line string) (string, bool) {
+	size := len(line) + 1
+	full := (c.maxLines >= 0 && c.lines >= c.maxLines) || (c.maxBytes >= 0 && c.bytes+size > c.maxBytes)
+	if full && c.lines > 0 {
+		if c.omitted[rule] == 0 {
+			c.rules = append(c.rules, rule)
+		}
+		c.omitted[rule]++
+		if c.first == nil {
+			c.first = &update
+		}
+		return "", false
+	}
+
+	if full {
+		line = truncate(line, c.maxBytes)
+		size = len(line) + 1
+	}
+	c.lines++
+	c.bytes += size
+	return line, true
+}
+
+// summary returns the line telling what was omitted, empty when nothing was
+func (c *outputCap) summary(cfg config.Config) string {
+	if c.first == nil {
+		return ""
+	}
+
+	total := 0
+	var counts []string
+	for _, rule := range c.rules {
+		total += c.omitted[rule]
+		counts = append(counts, fmt.Sprintf("%s %d", rule, c.omitted[rule]))
+	}
+
+	update := *c.first
+	report := config.RenderReportURL(cfg, config.ReportData{Project: update.Project, RefName: update.RefName, OldRev: update.OldRev, NewRev: update.NewRev})
+	if report == "" {
+		report = locale.T("githookkit simulate -project %s -refname %s -oldrev %s -newrev %s on the server", update.Project, update.RefName, update.OldRev, update.NewRev)
+	}
+	return locale.T("%d more lines not shown (%s), full report: %s", total, strings.Join(counts, ", "), report)
+}
+
+// truncate cuts line to at most size bytes with the marker, without splitting a UTF-8 character
+func truncate(line string, size int) string {
+	const marker = " [truncated]"
+	if size < 0 || len(line) < size {
+		return line
+	}
+
+	cut := size - len(marker) - 1
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + marker
+}
+
+// ruleOf returns the rule behind the violation message of the result
+func (r Result) ruleOf(message string) string {
+	for _, violation := range r.Findings {
+		if violation.Message == message {
+			return violation.RuleID
+		}
+	}
+	return "other"
+}
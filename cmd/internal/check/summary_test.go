@@ -0,0 +1,57 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestOutputCap(t *testing.T) {
+	t.Setenv("GITHOOK_MAX_OUTPUT_LINES", "")
+	t.Setenv("GITHOOK_MAX_OUTPUT_BYTES", "")
+	update := Update{Project: "app", RefName: "refs/heads/master", OldRev: "abc", NewRev: "def"}
+
+	t.Run("Line limit", func(t *testing.T) {
+		limit := newOutputCap(config.Config{MaxOutputLines: 2})
+		var written []string
+		for _, rule := range []string{config.RuleFileSize, config.RuleFileSize, config.RuleFileSize, config.RuleDCO, config.RuleFileSize} {
+			if line, ok := limit.allow(update, rule, "REJECTED: "+rule); ok {
+				written = append(written, line)
+			}
+		}
+		if len(written) != 2 {
+			t.Errorf("allow() let %v through, expected 2 lines", written)
+		}
+		expected := "3 more lines not shown (file_size 2, dco 1), full report: githookkit simulate -project app -refname refs/heads/master -oldrev abc -newrev def on the server"
+		if summary := limit.summary(config.Config{}); summary != expected {
+			t.Errorf("summary() = %q, expected %q", summary, expected)
+		}
+		if summary := limit.summary(config.Config{ReportURL: "https://ci.example.com/{{.Project}}/{{.NewRev}}"}); !strings.HasSuffix(summary, "full report: https://ci.example.com/app/def") {
+			t.Errorf("summary() = %q, expected the report URL", summary)
+		}
+	})
+
+	t.Run("Byte limit", func(t *testing.T) {
+		limit := newOutputCap(config.Config{MaxOutputBytes: 30})
+		line, ok := limit.allow(update, config.RuleFileSize, strings.Repeat("é", 40))
+		if !ok || len(line) >= 30 || !strings.HasSuffix(line, " [truncated]") || !strings.HasPrefix(line, "éé") {
+			t.Errorf("allow() returned %q, %v, expected the first line cut to 30 bytes", line, ok)
+		}
+		if _, ok := limit.allow(update, config.RuleFileSize, "short"); ok {
+			t.Errorf("allow() let a line through beyond the byte limit")
+		}
+	})
+
+	t.Run("Nothing omitted", func(t *testing.T) {
+		limit := newOutputCap(config.Config{MaxOutputLines: -1, MaxOutputBytes: -1})
+		for i := 0; i < 100; i++ {
+			if _, ok := limit.allow(update, config.RuleFileSize, strings.Repeat("x", 1000)); !ok {
+				t.Fatalf("allow() omitted line %d without limits", i)
+			}
+		}
+		if summary := limit.summary(config.Config{}); summary != "" {
+			t.Errorf("summary() = %q, expected empty", summary)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// checkSymlinks rejects updates adding symlinks, or symlinks with unsafe targets, depending on the project policy
+func checkSymlinks(cfg config.Config, logger *config.Logger, update Update, result *Result) error {
+	policy := config.GetSymlinkPolicy(cfg, update.Project)
+	if (!policy.Deny && !policy.DenyUnsafeTargets) || update.NewRev == ZeroRev {
+		return nil
+	}
+
+	changes, err := update.Repository.GetChanges(update.NewRev, update.OldRev)
+	if err != nil {
+		return err
+	}
+
+	// The content of a symlink blob is its target, the same target is read once
+	type symlink struct {
+		path  string
+		added bool // False when an existing symlink gets a new target
+	}
+	links := map[string][]symlink{}
+	var objects []string
+	for _, change := range changes {
+		if change.NewMode != githookkit.ModeSymlink {
+			continue
+		}
+		if _, exists := links[change.NewHash]; !exists {
+			objects = append(objects, change.NewHash)
+		}
+		links[change.NewHash] = append(links[change.NewHash], symlink{change.Path, change.OldMode != githookkit.ModeSymlink})
+	}
+
+	var files []githookkit.FileInfo
+	var problems []string
+	seen := map[string]bool{}
+	err = update.Repository.ReadBlobs(objects, 0, func(object string, content []byte) error {
+		target := string(content)
+		for _, link := range links[object] {
+			var problem string
+			if policy.DenyUnsafeTargets {
+				problem = symlinkProblem(link.path, target)
+			}
+			if problem == "" && policy.Deny && link.added {
+				problem = "symlinks are not allowed"
+			}
+			if problem == "" || seen[link.path+"\x00"+target] {
+				continue
+			}
+			seen[link.path+"\x00"+target] = true
+			files = append(files, githookkit.FileInfo{Path: link.path, Hash: object})
+			problems = append(problems, fmt.Sprintf("%s -> %s: %s", link.path, target, problem))
+		}
+		return nil
+	})
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	logFindings(logger, cfg, fmt.Sprintf("Found %d rejected symlinks:", len(files)), len(files), func(i int) string {
+		return problems[i]
+	})
+
+	data := config.MessageData{Project: update.Project, RefName: update.RefName, Count: len(files), Files: files, Largest: files[0], Actual: problems[0]}
+	result.violate(config.RuleSymlink, config.RenderMessage(cfg, config.RuleSymlink, data,
+		fmt.Sprintf("push adds %d rejected symlinks, the first one is %s, commit the files instead!", len(files), problems[0])), data.Files...)
+	return nil
+}
+
+// symlinkProblem returns why the target of the symlink at link is unsafe, or "" when it stays inside the repository
+func symlinkProblem(link, target string) string {
+	// C:\ and \\server\share are absolute on Windows
+	if path.IsAbs(target) || strings.HasPrefix(target, `\`) || (len(target) > 1 && target[1] == ':') {
+		return "target is absolute"
+	}
+	resolved := path.Join(path.Dir(link), strings.ReplaceAll(target, `\`, "/"))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "target is outside of the repository"
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// commitSymlinks commits symlinks through the index, so that the test doesn't depend on the file system supporting them
+func commitSymlinks(t *testing.T, dir string, links map[string]string, message string) string {
+	t.Helper()
+
+	target := filepath.Join(t.TempDir(), "target")
+	for link, content := range links {
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write target of %s: %v", link, err)
+		}
+		hash := testutil.Git(t, dir, "hash-object", "-w", target)
+		testutil.Git(t, dir, "update-index", "--add", "--cacheinfo", "120000,"+hash+","+link)
+	}
+	testutil.Git(t, dir, "commit", "-q", "-m", message)
+	return testutil.Git(t, dir, "rev-parse", "HEAD")
+}
+
+func TestSymlinkProblem(t *testing.T) {
+	tests := []struct {
+		link     string
+		target   string
+		expected string
+	}{
+		{"docs/latest", "v2", ""},
+		{"docs/api/common", "../../common", ""},
+		{"docs/passwd", "../../etc/passwd", "target is outside of the repository"},
+		{"up", "..", "target is outside of the repository"},
+		{"docs/hosts", "/etc/hosts", "target is absolute"},
+		{"docs/system", `C:\Windows`, "target is absolute"},
+		{"docs/share", `\\server\share`, "target is absolute"},
+		{"docs/back", `..\..\secret`, "target is outside of the repository"},
+	}
+
+	for _, test := range tests {
+		if problem := symlinkProblem(test.link, test.target); problem != test.expected {
+			t.Errorf("symlinkProblem(%q, %q) = %q, expected %q", test.link, test.target, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckSymlinks(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := commitSymlinks(t, dir, map[string]string{"current": "docs"}, "Initial")
+	newRev := commitSymlinks(t, dir, map[string]string{"current": "../outside", "latest": "docs/v2"}, "Add symlinks")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	tests := []struct {
+		name     string
+		policy   config.SymlinkPolicy
+		expected string
+	}{
+		{"no policy", config.SymlinkPolicy{}, ""},
+		{"deny", config.SymlinkPolicy{Deny: true}, "push adds 1 rejected symlinks, the first one is latest -> docs/v2: symlinks are not allowed"},
+		{"deny unsafe targets", config.SymlinkPolicy{DenyUnsafeTargets: true}, "push adds 1 rejected symlinks, the first one is current -> ../outside: target is outside of the repository"},
+		{"both", config.SymlinkPolicy{Deny: true, DenyUnsafeTargets: true}, "push adds 2 rejected symlinks"},
+	}
+
+	for _, test := range tests {
+		result, err := Check(config.Config{Symlinks: test.policy}, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+		if err != nil {
+			t.Fatalf("%s: Check() returned error: %v", test.name, err)
+		}
+		if test.expected == "" {
+			if len(result.Violations) != 0 {
+				t.Errorf("%s: Check() returned violations %v, expected none", test.name, result.Violations)
+			}
+			continue
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], test.expected) {
+			t.Errorf("%s: Check() returned violations %v, expected %q", test.name, result.Violations, test.expected)
+		}
+	}
+}
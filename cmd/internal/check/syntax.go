@@ -0,0 +1,148 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// syntaxMaxSize is the size of the largest file parsed, larger files are not checked
+const syntaxMaxSize = 16 * 1024 * 1024
+
+// syntaxRule rejects updates adding YAML, JSON or XML files that don't parse
+func syntaxRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	checks := config.GetSyntaxChecks(cfg, update.Project)
+	if len(checks) == 0 {
+		return nil, nil
+	}
+
+	// format returns the format of the first check matching path, empty when none does
+	format := func(path string) string {
+		for _, check := range checks {
+			if matchAny(check.Paths, path) {
+				return check.Format
+			}
+		}
+		return ""
+	}
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		if format(file.Path) == "" {
+			return true
+		}
+		if file.Size > syntaxMaxSize {
+			logger.Debugf("Not parsing %s, it is larger than %s", file.Path, githookkit.FormatSize(syntaxMaxSize))
+			return true
+		}
+		return false
+	})
+
+	var files []githookkit.FileInfo
+	var problems []string
+	read := func(object string, content []byte) {
+		for _, file := range paths[object] {
+			if problem := syntaxProblem(content, format(file.Path)); problem != "" {
+				files = append(files, file)
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d files with syntax errors:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleSyntax, config.RenderMessage(cfg, config.RuleSyntax, data,
+			fmt.Sprintf("push adds %d files with syntax errors, the first one is %s: %s, fix them!", len(files), files[0].Path, problems[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: syntaxMaxSize, read: read, report: report}, nil
+}
+
+// syntaxProblem parses content as format and returns the syntax error, or ""
+func syntaxProblem(content []byte, format string) string {
+	var err error
+	switch format {
+	case config.FormatYAML:
+		err = parseYAML(content)
+	case config.FormatJSON:
+		err = parseJSON(content)
+	case config.FormatXML:
+		err = parseXML(content)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// parseYAML parses every document of a YAML stream
+func parseYAML(content []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document interface{}
+		if err := decoder.Decode(&document); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// parseJSON parses a single JSON value, reporting the line of syntax errors
+func parseJSON(content []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var value json.RawMessage
+	err := decoder.Decode(&value)
+	if err == nil {
+		if _, err = decoder.Token(); err == io.EOF {
+			return nil
+		} else if err == nil {
+			err = errors.New("data after the top-level value")
+		}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("unexpected end of JSON input")
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset := min(int(syntaxErr.Offset), len(content))
+		return fmt.Errorf("line %d: %s", bytes.Count(content[:offset], []byte("\n"))+1, syntaxErr.Error())
+	}
+	return fmt.Errorf("line %d: %v", bytes.Count(content[:decoder.InputOffset()], []byte("\n"))+1, err)
+}
+
+// parseXML parses an XML document, which needs a root element
+func parseXML(content []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	// Documents aren't rejected for their declared encoding, only for their syntax
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	decoder.Strict = true
+	root := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			if !root {
+				return errors.New("XML document has no root element")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			root = true
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestSyntaxProblem(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		format   string
+		expected string
+	}{
+		{"yaml", "a: 1\nb: [1, 2]\n", config.FormatYAML, ""},
+		{"yaml documents", "a: 1\n---\nb: 2\n", config.FormatYAML, ""},
+		{"empty yaml", "", config.FormatYAML, ""},
+		{"bad yaml", "a: 1\n  b: 2\n", config.FormatYAML, "yaml: line 2: mapping values are not allowed in this context"},
+		{"bad second yaml document", "a: 1\n---\nb: [1\n", config.FormatYAML, "yaml: line 3: did not find expected ',' or ']'"},
+		{"json", "{\"a\": [1, 2]}\n", config.FormatJSON, ""},
+		{"bad json", "{\n  \"a\": 1,\n}\n", config.FormatJSON, "line 3: invalid character '}' looking for beginning of object key string"},
+		{"truncated json", "{\"a\": 1", config.FormatJSON, "unexpected end of JSON input"},
+		{"empty json", "", config.FormatJSON, "unexpected end of JSON input"},
+		{"json with trailing data", "{}\n{}\n", config.FormatJSON, "line 2: data after the top-level value"},
+		{"xml", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<a><b/></a>\n", config.FormatXML, ""},
+		{"bad xml", "<a>\n<b></a>\n", config.FormatXML, "XML syntax error on line 2: element <b> closed by </a>"},
+		{"unclosed xml", "<a>\n<b/>\n", config.FormatXML, "XML syntax error on line 3: unexpected EOF"},
+		{"empty xml", "<!-- nothing -->\n", config.FormatXML, "XML document has no root element"},
+	}
+
+	for _, test := range tests {
+		if problem := syntaxProblem([]byte(test.content), test.format); problem != test.expected {
+			t.Errorf("%s: syntaxProblem() = %q, expected %q", test.name, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckSyntax(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		".gitlab-ci.yml":    "stages: [build\n",
+		"config/app.json":   "{\"debug\": true}\n",
+		"config/bad.json":   "{\"debug\": true,}\n",
+		"templates/x.yaml":  "{{ .Values }}: [\n",
+		"src/main.go":       "package main\n",
+		"config/layout.xml": "<layout><view></layout>\n",
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{SyntaxChecks: []config.SyntaxCheck{
+		{Paths: []string{"*.yml", "*.yaml"}, Format: config.FormatYAML},
+		{Paths: []string{"*.json"}, Format: config.FormatJSON},
+		{Paths: []string{"*.xml"}, Format: config.FormatXML},
+	}}
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "push adds 4 files with syntax errors") {
+		t.Errorf("Check() returned violations %v, expected 4 files with syntax errors", result.Violations)
+	}
+}
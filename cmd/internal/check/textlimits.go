@@ -0,0 +1,73 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+const (
+	textLimitSampleSize = 64 * 1024 * 1024 // Bytes of each new file whose lines are counted
+	minifiedMinSize     = 1024             // Smaller files are never considered minified, a short file may well be a single line
+)
+
+// textLimitRule rejects updates adding text files with too many lines or too long lines on average
+func textLimitRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	policy := config.GetTextLimitPolicy(cfg, update.Project)
+	if policy.MaxLines == 0 && policy.MaxAverageLineLength == 0 {
+		return nil, nil
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		// Every line has at least one byte, smaller files can't have too many lines
+		return matchAny(policy.AllowedPaths, file.Path) ||
+			(file.Size < minifiedMinSize && (policy.MaxLines == 0 || file.Size <= int64(policy.MaxLines)))
+	})
+
+	var files []githookkit.FileInfo
+	var problems []string
+	read := func(object string, content []byte) {
+		if isBinary(content[:min(len(content), config.DefaultBinarySampleSize)]) {
+			return
+		}
+		if problem := textLimitProblem(content, policy); problem != "" {
+			for _, file := range paths[object] {
+				files = append(files, file)
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	report := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d generated or minified files:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, problems[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: problems[0], Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleTextLimit, config.RenderMessage(cfg, config.RuleTextLimit, data,
+			fmt.Sprintf("push adds %d files that look generated or minified, the first one is %s (%s), commit their sources instead!", len(files), files[0].Path, problems[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, limit: textLimitSampleSize, read: read, report: report}, nil
+}
+
+// textLimitProblem returns the text limit content exceeds, or ""
+func textLimitProblem(content []byte, policy config.TextLimitPolicy) string {
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+
+	switch {
+	case policy.MaxLines > 0 && lines > policy.MaxLines:
+		return fmt.Sprintf("%d lines, the maximum is %d", lines, policy.MaxLines)
+	case policy.MaxAverageLineLength > 0 && len(content) >= minifiedMinSize && len(content)/lines > policy.MaxAverageLineLength:
+		return fmt.Sprintf("%d bytes per line on average, the maximum is %d", len(content)/lines, policy.MaxAverageLineLength)
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestTextLimitProblem(t *testing.T) {
+	minified := "var a=1;" + strings.Repeat("function f(){return 1};", 100)
+	tests := []struct {
+		name     string
+		content  string
+		policy   config.TextLimitPolicy
+		expected string
+	}{
+		{"short", "a\nb\nc\n", config.TextLimitPolicy{MaxLines: 3}, ""},
+		{"too many lines", "a\nb\nc\nd", config.TextLimitPolicy{MaxLines: 3}, "4 lines, the maximum is 3"},
+		{"minified", minified, config.TextLimitPolicy{MaxAverageLineLength: 500}, "2308 bytes per line on average, the maximum is 500"},
+		{"short single line", strings.Repeat("x", 900), config.TextLimitPolicy{MaxAverageLineLength: 500}, ""},
+		{"source", strings.Repeat("\tx := compute(y)\n", 100), config.TextLimitPolicy{MaxLines: 1000, MaxAverageLineLength: 500}, ""},
+	}
+
+	for _, test := range tests {
+		if problem := textLimitProblem([]byte(test.content), test.policy); problem != test.expected {
+			t.Errorf("%s: textLimitProblem() = %q, expected %q", test.name, problem, test.expected)
+		}
+	}
+}
+
+func TestCheckTextLimits(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"web/app.js":    strings.Repeat("var x=1;", 500),
+		"web/vendor.js": strings.Repeat("var y=2;", 500),
+		"src/main.go":   strings.Repeat("x := 1\n", 200),
+		"data/dump.csv": strings.Repeat("1,2,3\n", 2000),
+	}, "Add files")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{TextLimits: config.TextLimitPolicy{MaxLines: 1000, MaxAverageLineLength: 500, AllowedPaths: []string{"web/vendor.js"}}}
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	expected := "push adds 2 files that look generated or minified, the first one is data/dump.csv (2000 lines, the maximum is 1000)"
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+		t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+	}
+}
@@ -0,0 +1,78 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// trailer is a "Key: value" line of the trailer block at the end of a commit message
+type trailer struct {
+	Key   string
+	Value string
+}
+
+// trailerLine matches a trailer, git accepts whitespace before the separator
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*)[ \t]*:[ \t]*(.*)$`)
+
+// parseTrailers returns the trailers of a commit message the way git interpret-trailers finds them:
+// the trailer block is the last paragraph, it can't be the subject paragraph, and it must consist of trailers only,
+// or of at least 25% trailers when it contains a Signed-off-by or (cherry picked from commit ...) line.
+// Lines starting with whitespace continue the value of the previous trailer.
+func parseTrailers(message string) []trailer {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	// The subject paragraph is never a trailer block
+	if start == 0 {
+		return nil
+	}
+
+	var trailers []trailer
+	others := 0
+	recognized := false
+	for _, line := range lines[start:] {
+		if matches := trailerLine.FindStringSubmatch(line); matches != nil {
+			trailers = append(trailers, trailer{Key: matches[1], Value: strings.TrimSpace(matches[2])})
+			recognized = recognized || strings.EqualFold(matches[1], "Signed-off-by")
+			continue
+		}
+		if len(trailers) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		if strings.HasPrefix(line, "(cherry picked from commit ") {
+			recognized = true
+		}
+		others++
+	}
+
+	if len(trailers) == 0 || others > 0 && !(recognized && len(trailers)*3 >= others) {
+		return nil
+	}
+	return trailers
+}
+
+// missingTrailers returns a problem for each required trailer key the commit message doesn't carry with a value
+// Trailer keys are compared case-insensitively like git does
+func missingTrailers(required []string, message string) []string {
+	trailers := parseTrailers(message)
+
+	var problems []string
+	for _, key := range required {
+		found := false
+		for _, existing := range trailers {
+			if strings.EqualFold(existing.Key, key) && existing.Value != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("missing %s trailer", key))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,66 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected []trailer
+	}{
+		{"Trailer block", "Add parser\n\nBody text.\n\nChange-Id: I0123\nSigned-off-by: Alice <alice@example.com>\n",
+			[]trailer{{"Change-Id", "I0123"}, {"Signed-off-by", "Alice <alice@example.com>"}}},
+		{"Subject only", "Change-Id: I0123\n", nil},
+		{"Not the last paragraph", "Add parser\n\nChange-Id: I0123\n\nMore text.\n", nil},
+		{"Mixed paragraph", "Add parser\n\nChange-Id: I0123\nThis is not a trailer.\n", nil},
+		{"Mixed with Signed-off-by", "Add parser\n\nSigned-off-by: Alice <alice@example.com>\n[fixed conflict]\n",
+			[]trailer{{"Signed-off-by", "Alice <alice@example.com>"}}},
+		{"Continuation", "Add parser\n\nReviewed-by: Alice\n  and Bob\n", []trailer{{"Reviewed-by", "Alice and Bob"}}},
+		{"Space before separator", "Add parser\n\nChange-Id : I0123\n", []trailer{{"Change-Id", "I0123"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := parseTrailers(test.message); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("parseTrailers() = %v, expected %v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestCheckTrailers(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.CommitContents(t, dir, map[string]string{"a.txt": "a"}, "Add a\n\nChange-Id: I0123\nSigned-off-by: Alice <alice@example.com>")
+	newRev := testutil.CommitContents(t, dir, map[string]string{"b.txt": "b"}, "Add b\n\nsigned-off-by: Alice <alice@example.com>")
+	chdir(t, dir)
+
+	logger := newTestLogger(t)
+	cfg := config.Config{RequiredTrailers: []config.RequiredTrailers{
+		{Ref: "refs/for/*", Trailers: []string{"Change-Id"}},
+		{Ref: "*", Trailers: []string{"Signed-off-by"}},
+	}}
+
+	result, err := Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/for/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "(Add b): missing Change-Id trailer!") {
+		t.Errorf("Check() returned violations %v, expected the missing Change-Id of Add b", result.Violations)
+	}
+
+	result, err = Check(cfg, logger, Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"})
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("Check() returned violations %v, expected none", result.Violations)
+	}
+}
@@ -0,0 +1,122 @@
+package check
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/clamav"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// scanJob is a blob waiting for clamd
+type scanJob struct {
+	object  string
+	content []byte
+}
+
+// virusRule streams the new files to clamd and rejects updates with detections
+func virusRule(cfg config.Config, logger *config.Logger, update Update, newFiles []githookkit.FileInfo, result *Result) (*contentRule, error) {
+	scan := cfg.VirusScan
+	if scan.Address == "" {
+		return nil, nil
+	}
+	maxFileSize := scan.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = config.DefaultVirusScanMaxFileSize
+	}
+	concurrency := scan.Concurrency
+	if concurrency == 0 {
+		concurrency = config.DefaultVirusScanConcurrency
+	}
+	timeout := scan.Timeout
+	if timeout == 0 {
+		timeout = config.DefaultVirusScanTimeout
+	}
+
+	objects, paths := githookkit.GroupBlobs(newFiles, func(file githookkit.FileInfo) bool {
+		if file.Size > maxFileSize {
+			logger.Infof("Not scanning %s for viruses, it is larger than %s", file.Path, githookkit.FormatSize(maxFileSize))
+			return true
+		}
+		return false
+	})
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	// Blobs are read one after the other and scanned by concurrency connections,
+	// the buffered channel bounds the content held in memory
+	jobs := make(chan scanJob, concurrency)
+	var mu sync.Mutex
+	signatures := map[string]string{}
+	var scanErr error
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// Once clamd failed the remaining files are drained, not waited for one timeout after the other
+				mu.Lock()
+				failed := scanErr != nil
+				mu.Unlock()
+				if failed {
+					continue
+				}
+				signature, err := clamav.Scan(scan.Address, timeout, job.content)
+				mu.Lock()
+				if err != nil && scanErr == nil {
+					scanErr = fmt.Errorf("failed to scan %s: %w", paths[job.object][0].Path, err)
+				}
+				if signature != "" {
+					signatures[job.object] = signature
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	read := func(object string, content []byte) {
+		jobs <- scanJob{object: object, content: content}
+	}
+	done := func() {
+		close(jobs)
+		wg.Wait()
+	}
+
+	report := func() error {
+		if scanErr != nil {
+			logger.Warnf("Virus scan failed: %v", scanErr)
+			if scan.OnError == config.OnInternalErrorAllow {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("virus scan is unavailable, new files were not scanned: %v", scanErr))
+			} else {
+				data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: scanErr.Error()}
+				result.violate(config.RuleVirus, config.RenderMessage(cfg, config.RuleVirus, data,
+					fmt.Sprintf("virus scan failed, the push can't be accepted unscanned: %v", scanErr)))
+			}
+		}
+
+		var files []githookkit.FileInfo
+		var found []string
+		for _, object := range objects {
+			if signature, ok := signatures[object]; ok {
+				for _, file := range paths[object] {
+					files = append(files, file)
+					found = append(found, signature)
+				}
+			}
+		}
+		if len(files) == 0 {
+			return nil
+		}
+		logFindings(logger, cfg, fmt.Sprintf("Found %d infected files:", len(files)), len(files), func(i int) string {
+			return fmt.Sprintf("Path: %s (%s)", files[i].Path, found[i])
+		})
+
+		data := config.MessageData{Project: update.Project, RefName: update.RefName, Actual: found[0], Count: len(files), Files: files, Largest: files[0]}
+		result.violate(config.RuleVirus, config.RenderMessage(cfg, config.RuleVirus, data,
+			fmt.Sprintf("push adds %d infected files, the first one is %s (%s), remove them!", len(files), files[0].Path, found[0])), data.Files...)
+		return nil
+	}
+	return &contentRule{objects: objects, read: read, report: report, done: done}, nil
+}
@@ -0,0 +1,60 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestCheckViruses(t *testing.T) {
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitContents(t, dir, map[string]string{"README": "r"}, "Initial")
+	newRev := testutil.CommitContents(t, dir, map[string]string{
+		"tools/eicar.com":  testutil.EICAR,
+		"tools/copy.com":   testutil.EICAR,
+		"tools/large.bin":  strings.Repeat("x", 2048) + testutil.EICAR,
+		"src/main.go":      "package main\n",
+		"docs/readme.txt":  "hello",
+		"docs/readme2.txt": "world",
+	}, "Add files")
+	chdir(t, dir)
+	update := Update{Project: "p", OldRev: oldRev, NewRev: newRev, RefName: "refs/heads/master"}
+	logger := newTestLogger(t)
+
+	t.Run("Detections", func(t *testing.T) {
+		cfg := config.Config{VirusScan: config.VirusScan{Address: testutil.FakeClamd(t, 1024*1024), MaxFileSize: 1024, Concurrency: 2}}
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		// Both copies of EICAR are one blob, tools/large.bin is above max_file_size and not scanned
+		expected := "push adds 1 infected files, the first one is tools/copy.com (Eicar-Test-Signature)"
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], expected) {
+			t.Errorf("Check() returned violations %v, expected %q", result.Violations, expected)
+		}
+	})
+
+	t.Run("clamd failing closed", func(t *testing.T) {
+		cfg := config.Config{VirusScan: config.VirusScan{Address: "unix:" + t.TempDir() + "/missing.sock"}}
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "virus scan failed") {
+			t.Errorf("Check() returned violations %v, expected the scan failure", result.Violations)
+		}
+	})
+
+	t.Run("clamd failing open", func(t *testing.T) {
+		cfg := config.Config{VirusScan: config.VirusScan{Address: "unix:" + t.TempDir() + "/missing.sock", OnError: config.OnInternalErrorAllow}}
+		result, err := Check(cfg, logger, update)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(result.Violations) != 0 || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "virus scan is unavailable") {
+			t.Errorf("Check() returned violations %v and warnings %v, expected a warning", result.Violations, result.Warnings)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+// Package clamav scans content with clamd through its INSTREAM command
+package clamav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the chunks content is streamed in
+const chunkSize = 64 * 1024
+
+// Scan streams content to the clamd at address, "unix:<path>" or host:port, and returns the name of
+// the signature it detected, or "" when the content is clean
+func Scan(address string, timeout time.Duration, content []byte) (string, error) {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	}
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// The z prefix makes clamd use NUL terminated commands and replies
+	writer := bufio.NewWriter(conn)
+	writer.WriteString("zINSTREAM\x00")
+	var length [4]byte
+	for offset := 0; offset < len(content); offset += chunkSize {
+		chunk := content[offset:min(offset+chunkSize, len(content))]
+		binary.BigEndian.PutUint32(length[:], uint32(len(chunk)))
+		writer.Write(length[:])
+		writer.Write(chunk)
+	}
+	binary.BigEndian.PutUint32(length[:], 0)
+	writer.Write(length[:])
+	// clamd replies and closes the connection early when the stream exceeds its StreamMaxLength
+	flushErr := writer.Flush()
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		if flushErr != nil {
+			return "", fmt.Errorf("failed to send content to clamd: %w", flushErr)
+		}
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseReply(strings.TrimSuffix(reply, "\x00"))
+}
+
+// parseReply parses "stream: OK", "stream: <signature> FOUND" or "<message> ERROR"
+func parseReply(reply string) (string, error) {
+	switch {
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(reply, " FOUND"), "stream:")), nil
+	case strings.HasSuffix(reply, ": OK"):
+		return "", nil
+	default:
+		return "", fmt.Errorf("clamd failed: %s", strings.TrimSuffix(reply, " ERROR"))
+	}
+}
@@ -0,0 +1,56 @@
+package clamav
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestScan(t *testing.T) {
+	address := testutil.FakeClamd(t, 1024*1024)
+
+	tests := []struct {
+		name      string
+		content   string
+		signature string
+		err       string
+	}{
+		{"clean", "hello world", "", ""},
+		{"empty", "", "", ""},
+		{"eicar", "prefix " + testutil.EICAR, "Eicar-Test-Signature", ""},
+		{"eicar after the first chunk", strings.Repeat("x", 100*1024) + testutil.EICAR, "Eicar-Test-Signature", ""},
+		{"too large", strings.Repeat("x", 2*1024*1024), "", "clamd failed: INSTREAM size limit exceeded."},
+	}
+
+	for _, test := range tests {
+		signature, err := Scan(address, time.Second, []byte(test.content))
+		if signature != test.signature || (err == nil) != (test.err == "") || err != nil && err.Error() != test.err {
+			t.Errorf("%s: Scan() = %q, %v, expected %q, %q", test.name, signature, err, test.signature, test.err)
+		}
+	}
+
+	if _, err := Scan("unix:/nonexistent/clamd.sock", time.Second, []byte("x")); err == nil || !strings.Contains(err.Error(), "failed to connect to clamd") {
+		t.Errorf("Scan() error = %v, expected a connection error", err)
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		signature string
+		wantErr   bool
+	}{
+		{"stream: OK", "", false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND", "Win.Test.EICAR_HDB-1", false},
+		{"INSTREAM size limit exceeded. ERROR", "", true},
+		{"garbage", "", true},
+	}
+	for _, test := range tests {
+		signature, err := parseReply(test.reply)
+		if signature != test.signature || (err != nil) != test.wantErr {
+			t.Errorf("parseReply(%q) = %q, %v", test.reply, signature, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"flag"
+	"strings"
+)
+
+// ParseArgs parses args with the flags defined on fs like fs.Parse, except that flags fs doesn't know are
+// skipped together with their value instead of failing. Gerrit adds hook parameters over time and an
+// unknown one must not break every push. The skipped arguments are returned so that they can be logged.
+func ParseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var known, unknown []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			known = append(known, args[i:]...)
+			break
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		hasValue := strings.Contains(name, "=")
+		if hasValue {
+			name = name[:strings.Index(name, "=")]
+		}
+
+		if f := fs.Lookup(name); f != nil || name == "h" || name == "help" {
+			known = append(known, arg)
+			// The value of a non-boolean flag is the next argument, even if it starts with -
+			if !hasValue && f != nil && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				known = append(known, args[i])
+			}
+			continue
+		}
+
+		// Gerrit passes every parameter as "--name value", the value is skipped unless it is the next flag
+		unknown = append(unknown, arg)
+		if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			unknown = append(unknown, args[i])
+		}
+	}
+
+	return unknown, fs.Parse(known)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// StringList is a flag that can be given several times, collecting all values
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a value
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		project  string
+		refName  string
+		version  bool
+		unknown  []string
+		rest     []string
+		parseErr bool
+	}{
+		{
+			name:    "Known flags",
+			args:    []string{"--project", "app", "--refname", "refs/heads/master"},
+			project: "app",
+			refName: "refs/heads/master",
+		},
+		{
+			name:    "Unknown flags are skipped with their value",
+			args:    []string{"--project", "app", "--cmdref", "refs/for/master", "--refname", "refs/heads/master"},
+			project: "app",
+			refName: "refs/heads/master",
+			unknown: []string{"--cmdref", "refs/for/master"},
+		},
+		{
+			name:    "Unknown flag with empty value",
+			args:    []string{"--topic", "", "--project", "app"},
+			project: "app",
+			unknown: []string{"--topic", ""},
+		},
+		{
+			name:    "Unknown flag without value",
+			args:    []string{"--dry-run", "--project=app"},
+			project: "app",
+			unknown: []string{"--dry-run"},
+		},
+		{
+			name:    "Known value starting with a dash",
+			args:    []string{"--refname", "-weird", "-version"},
+			refName: "-weird",
+			version: true,
+		},
+		{
+			name:    "Positional arguments",
+			args:    []string{"--new-param=x", "-project", "app", "refs/heads/master", "--other"},
+			project: "app",
+			unknown: []string{"--new-param=x"},
+			rest:    []string{"refs/heads/master", "--other"},
+		},
+		{
+			name:     "Invalid value of a known flag",
+			args:     []string{"-version=maybe"},
+			parseErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			project := fs.String("project", "", "")
+			refName := fs.String("refname", "", "")
+			version := fs.Bool("version", false, "")
+
+			unknown, err := ParseArgs(fs, test.args)
+			if (err != nil) != test.parseErr {
+				t.Fatalf("ParseArgs() error = %v, wantErr %v", err, test.parseErr)
+			}
+			if test.parseErr {
+				return
+			}
+			if *project != test.project || *refName != test.refName || *version != test.version {
+				t.Errorf("ParseArgs() parsed project=%q refname=%q version=%v, expected %q %q %v", *project, *refName, *version, test.project, test.refName, test.version)
+			}
+			if !reflect.DeepEqual(unknown, test.unknown) {
+				t.Errorf("ParseArgs() unknown = %q, expected %q", unknown, test.unknown)
+			}
+			if len(fs.Args()) != len(test.rest) || (len(test.rest) > 0 && !reflect.DeepEqual(fs.Args(), test.rest)) {
+				t.Errorf("ParseArgs() rest = %q, expected %q", fs.Args(), test.rest)
+			}
+		})
+	}
+}
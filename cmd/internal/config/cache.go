@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Cache holds the parsed config for long-running modes
+// The config is reloaded when the file's modification time changes or on SIGHUP,
+// callers keep using the snapshot they got until they ask for a new one
+type Cache struct {
+	path    string
+	current atomic.Pointer[Config]
+
+	mu      sync.Mutex // Serializes reloads
+	modTime time.Time  // Modification time of the loaded file
+}
+
+// NewCache creates a cache for the config file at path, loading it once
+// A missing or invalid file results in the empty config, like LoadConfig
+func NewCache(path string) *Cache {
+	c := &Cache{path: path}
+	config := emptyConfig()
+	c.current.Store(&config)
+
+	if err := c.Reload(); err != nil {
+		log.Printf("%v, using empty config", err)
+	}
+	return c
+}
+
+// Get returns the current config snapshot, reloading it first if the file has changed
+func (c *Cache) Get() *Config {
+	if info, err := os.Stat(c.path); err == nil && !info.ModTime().Equal(c.loadedModTime()) {
+		if err := c.Reload(); err != nil {
+			log.Printf("%v, keeping previous config", err)
+		}
+	}
+	return c.current.Load()
+}
+
+// Reload reads the config file and swaps it in, the previous snapshot is kept if the file is invalid
+func (c *Cache) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("config file does not exist or cannot be read: %w", err)
+	}
+
+	configData, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("config file does not exist or cannot be read: %w", err)
+	}
+
+	// Remember the modification time even for invalid files so they are not parsed on every Get
+	c.modTime = info.ModTime()
+
+	config, err := parseConfig(configData)
+	if err != nil {
+		return err
+	}
+
+	c.current.Store(&config)
+	return nil
+}
+
+// ReloadOnSignal reloads the config whenever the process receives SIGHUP until stop is closed
+func (c *Cache) ReloadOnSignal(stop <-chan struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-signals:
+				if err := c.Reload(); err != nil {
+					log.Printf("Reload on SIGHUP failed: %v, keeping previous config", err)
+				} else {
+					log.Printf("Reloaded config from %s", c.path)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Cache) loadedModTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.modTime
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".githook_config")
+
+	// writeConfig writes content and moves the modification time forward so the change is always detected
+	modTime := time.Now()
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		modTime = modTime.Add(time.Second)
+		if err := os.Chtimes(configPath, modTime, modTime); err != nil {
+			t.Fatalf("Failed to change config modification time: %v", err)
+		}
+	}
+
+	// Test 1: Missing file gives the empty config
+	cache := NewCache(configPath)
+	if len(cache.Get().ProjectsWhitelist) != 0 {
+		t.Errorf("ProjectsWhitelist should be empty when config file doesn't exist")
+	}
+
+	// Test 2: File created later is picked up
+	writeConfig("projects_whitelist:\n  - project1\n")
+	first := cache.Get()
+	if !IsProjectWhitelisted(*first, "project1") {
+		t.Errorf("project1 should be whitelisted after the config file was created")
+	}
+
+	// Test 3: Unchanged file returns the same snapshot
+	if cache.Get() != first {
+		t.Errorf("Get() should return the same snapshot while the file is unchanged")
+	}
+
+	// Test 4: Modified file is reloaded while the old snapshot stays intact
+	writeConfig("projects_whitelist:\n  - project2\n")
+	second := cache.Get()
+	if !IsProjectWhitelisted(*second, "project2") || IsProjectWhitelisted(*second, "project1") {
+		t.Errorf("Get() should return the reloaded config, got %v", second.ProjectsWhitelist)
+	}
+	if !IsProjectWhitelisted(*first, "project1") {
+		t.Errorf("Old snapshot should not be modified by a reload")
+	}
+
+	// Test 5: Invalid file keeps the previous config
+	writeConfig("version: 99\n")
+	if cache.Get() != second {
+		t.Errorf("Get() should keep the previous config when the file is invalid")
+	}
+	if err := cache.Reload(); err == nil {
+		t.Errorf("Reload() should return error for an invalid config file")
+	}
+}
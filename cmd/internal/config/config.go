@@ -6,18 +6,390 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
 
 // Config contains all possible configuration options
 type Config struct {
-	ProjectsWhitelist []string         `yaml:"projects_whitelist"`
-	ProjectSizeLimits map[string]int64 `yaml:"project_size_limits"`
-	LogConfig         LogConfig        `yaml:"log_config"`
+	Version           int                      `yaml:"version"` // Config schema version, missing means 1
+	ProjectsWhitelist []string                 `yaml:"projects_whitelist"`
+	MaxPushSize       int64                    `yaml:"max_push_size"` // Total bytes of new blobs per ref update, 0 = unlimited
+	MaxNewFiles       int                      `yaml:"max_new_files"` // New blobs per ref update, 0 = unlimited
+	MaxCommitsPerPush []RefCommitLimit         `yaml:"max_commits_per_push"`
+	CommitMessages    []CommitMessageRule      `yaml:"commit_messages"`     // Commit message format of pushed commits, first matching ref wins
+	RequiredTrailers  []RequiredTrailers       `yaml:"required_trailers"`   // Trailers every pushed commit must carry, all matching entries apply
+	IssueReferences   []IssueReferenceRule     `yaml:"issue_references"`    // Issue keys commit messages must reference, first matching ref wins
+	IssueTracker      IssueTracker             `yaml:"issue_tracker"`       // REST API the issue keys are validated against
+	DCO               DCOPolicy                `yaml:"dco"`                 // Developer Certificate of Origin sign-off requirement
+	DenyEmptyCommits  []string                 `yaml:"deny_empty_commits"`  // Ref patterns where commits without changes are rejected, merges are exempt
+	DenyRootCommits   []string                 `yaml:"deny_root_commits"`   // Ref patterns where commits without parents are rejected, they start history unrelated to the project
+	LinearHistory     []string                 `yaml:"linear_history"`      // Ref patterns where merge commits are rejected, for teams that rebase instead of merging
+	MaxParents        int                      `yaml:"max_parents"`         // Commits with more parents are rejected, 2 rejects octopus merges, 0 = unlimited
+	CommitDates       CommitDates              `yaml:"commit_dates"`        // Plausible range of author and committer dates
+	CommitSignatures  CommitSignatures         `yaml:"commit_signatures"`   // Pushed commits must be signed by trusted GPG or SSH keys
+	UploaderIdentity  UploaderIdentity         `yaml:"uploader_identity"`   // Pushers may only push commits they committed (or authored)
+	BlockedIdentities []BlockedIdentity        `yaml:"blocked_identities"`  // Authors and committers whose commits are rejected
+	EmailDomains      []string                 `yaml:"email_domains"`       // Author and committer emails must be in one of these domains, e.g. example.com or *.example.com, empty = any
+	ForcePush         []ForcePushRule          `yaml:"force_push"`          // Policy for non-fast-forward updates, first matching ref wins
+	SkipRefs          []string                 `yaml:"skip_refs"`           // Ref patterns that are never checked, nil = DefaultSkipRefs
+	Mode              string                   `yaml:"mode"`                // Enforcement mode: enforce (default) or warn
+	OnInternalError   string                   `yaml:"on_internal_error"`   // What to do when the hook itself fails: reject (default) or allow
+	MaxReportedFiles  int                      `yaml:"max_reported_files"`  // Offending files listed in the output, 0 = default, -1 = all
+	MaxOutputLines    int                      `yaml:"max_output_lines"`    // Violations written to the pusher before the rest are summarized, 0 = default, -1 = all
+	MaxOutputBytes    int                      `yaml:"max_output_bytes"`    // Bytes of violations written to the pusher before the rest are summarized, 0 = default, -1 = unlimited
+	ReportURL         string                   `yaml:"report_url"`          // Where the full report of a summarized output is, a template with Project, RefName, OldRev and NewRev
+	Remediation       Remediation              `yaml:"remediation"`         // Fix instructions added to rejections by size and LFS rules
+	Messages          map[string]string        `yaml:"messages"`            // Rejection message templates keyed by rule ID
+	Lang              string                   `yaml:"lang"`                // Language of the hook output: en (default) or zh
+	ContactURL        string                   `yaml:"contact_url"`         // Shown to users through the ContactURL template placeholder
+	ChainedHooks      []ChainedHook            `yaml:"chained_hooks"`       // Site-local hooks run after githookkit's own checks pass
+	Plugins           []Plugin                 `yaml:"plugins"`             // Site-local checkers run on every ref update, their violations are merged into the result
+	PluginCacheDir    string                   `yaml:"plugin_cache_dir"`    // Where compiled WebAssembly plugins are kept between pushes, empty = compiled on every push
+	ExpressionRules   []ExpressionRule         `yaml:"expression_rules"`    // Conditions written in CEL that reject the push when they are true
+	SizeWarning       int64                    `yaml:"size_warning"`        // Files above this size but within the size limit get a warning in the push output, 0 = disabled
+	PathBudgets       []PathBudget             `yaml:"path_budgets"`        // Total bytes of new files per ref update under path patterns, the first entry matching a file applies
+	AdvisorySizeLimit int64                    `yaml:"advisory_size_limit"` // Files above this size are reported after the push, 0 = disabled
+	DuplicateContent  DuplicatePolicy          `yaml:"duplicate_content"`   // Added files copying content present at many other paths are reported
+	GrowthBudget      GrowthBudget             `yaml:"growth_budget"`       // Bytes a project may add within a rolling window, summed from the audit log
+	UploadQuota       UploadQuota              `yaml:"upload_quota"`        // Bytes a single uploader may add per day across all projects, summed from the audit log
+	RateLimit         RateLimit                `yaml:"rate_limit"`          // Ref updates per minute per uploader and project, pushes beyond it are rejected until tokens refill
+	AuditLog          string                   `yaml:"audit_log"`           // Path of the JSON lines audit log, empty = disabled
+	Notify            NotifyConfig             `yaml:"notify"`
+	Gerrit            GerritConfig             `yaml:"gerrit"`               // REST API of the Gerrit server, used to look up accounts
+	Overrides         map[string]Override      `yaml:"overrides"`            // Push options that let users bypass rules, keyed by option name
+	Groups            map[string][]string      `yaml:"groups"`               // Named lists of usernames
+	EmergencyBypass   EmergencyBypass          `yaml:"emergency_bypass"`     // Admins allowed to skip all rules with GITHOOK_EMERGENCY_BYPASS=<reason>
+	AdminBypass       AdminBypass              `yaml:"admin_bypass"`         // Gerrit capabilities and groups whose members skip all rules
+	Daemon            string                   `yaml:"daemon"`               // Address of githookkit serve ("unix:<path>" or host:port), empty = check in process
+	BranchNames       []string                 `yaml:"branch_name_patterns"` // New branch names (without refs/heads/) must match one of these ref patterns, empty = any name
+	ProtectedRefs     []string                 `yaml:"protected_refs"`       // Ref patterns that can't be deleted
+	OwnersFiles       OwnersFiles              `yaml:"owners_files"`         // Validation of pushed CODEOWNERS and OWNERS files
+	PathOwners        []PathOwner              `yaml:"path_owners"`          // Paths only some users may modify, every matching entry must allow the uploader
+	RefQuotas         []RefQuota               `yaml:"ref_quotas"`           // Maximum number of refs a user may create under a namespace, first matching entry wins
+	Tags              TagPolicy                `yaml:"tags"`                 // Rules for refs/tags/*, the zero value allows everything
+	SecretScan        SecretScan               `yaml:"secret_scan"`
+	VirusScan         VirusScan                `yaml:"virus_scan"` // New files are scanned by clamd
+	BinaryFiles       BinaryPolicy             `yaml:"binary_files"`
+	Archives          ArchivePolicy            `yaml:"archives"`         // Limits on the content of added zip, jar and tar files
+	ContentTypes      ContentTypePolicy        `yaml:"content_types"`    // Content types of new files that are rejected, detected from their first bytes
+	Images            ImagePolicy              `yaml:"images"`           // Limits on the dimensions of added PNG and JPEG images
+	LineEndings       LineEndingPolicy         `yaml:"line_endings"`     // CRLF line endings in new text files
+	TextEncoding      TextEncodingPolicy       `yaml:"text_encoding"`    // New text files must be UTF-8
+	TextLimits        TextLimitPolicy          `yaml:"text_limits"`      // Line count and line length limits catching generated and minified files
+	LicenseHeaders    []LicenseHeader          `yaml:"license_headers"`  // Headers added source files must start with, the first entry matching a path applies
+	SyntaxChecks      []SyntaxCheck            `yaml:"syntax_checks"`    // Formats new YAML, JSON and XML files must parse as, the first entry matching a path applies
+	Paths             PathPolicy               `yaml:"paths"`            // Rules for the paths of added files
+	Symlinks          SymlinkPolicy            `yaml:"symlinks"`         // Rules for added symbolic links
+	ExecutableFiles   ExecutablePolicy         `yaml:"executable_files"` // Where files may become executable
+	Submodules        SubmodulePolicy          `yaml:"submodules"`       // Allowed submodule URLs
+	LFSPatterns       []string                 `yaml:"lfs_patterns"`     // gitattributes patterns of files that must be pushed as LFS pointers, e.g. *.psd
+	Projects          map[string]ProjectPolicy `yaml:"projects"`         // Per-project settings, since version 2
+	LogConfig         LogConfig                `yaml:"log_config"`
+
+	// Version 1 per-project settings, moved into Projects by MigrateConfig
+	ProjectSizeLimits     map[string]int64 `yaml:"project_size_limits,omitempty"`
+	ProjectPushSizeLimits map[string]int64 `yaml:"project_push_size_limits,omitempty"`
+	ProjectNewFileLimits  map[string]int   `yaml:"project_new_file_limits,omitempty"`
+}
+
+// ProjectPolicy contains the settings of a single project, unset fields fall back to the global values
+// Keys ending with / (e.g. "platform/") apply to all projects below them unless a more specific key overrides them
+// All fields must be pointers, slices or maps so that nil means "inherit"
+type ProjectPolicy struct {
+	SizeLimit         *int64              `yaml:"size_limit,omitempty"`           // Maximum size of a single file
+	SizeWarning       *int64              `yaml:"size_warning,omitempty"`         // Files above this size but within the size limit get a warning, 0 = disabled
+	RefSizeLimits     []RefSizeLimit      `yaml:"ref_size_limits,omitempty"`      // File size thresholds for ref patterns, first match wins over size_limit and size_warning
+	PathBudgets       []PathBudget        `yaml:"path_budgets,omitempty"`         // Replaces the global path budgets
+	PushSizeLimit     *int64              `yaml:"push_size_limit,omitempty"`      // Total bytes of new blobs per ref update, 0 = unlimited
+	NewFileLimit      *int                `yaml:"new_file_limit,omitempty"`       // New blobs per ref update, 0 = unlimited
+	AdvisorySizeLimit *int64              `yaml:"advisory_size_limit,omitempty"`  // Files above this size are reported after the push, 0 = disabled
+	DuplicateContent  *DuplicatePolicy    `yaml:"duplicate_content,omitempty"`    // Replaces the global duplicate content policy
+	GrowthBudget      *GrowthBudget       `yaml:"growth_budget,omitempty"`        // Replaces the global growth budget
+	Mode              *string             `yaml:"mode,omitempty"`                 // Enforcement mode: enforce or warn
+	RefModes          []RefMode           `yaml:"ref_modes,omitempty"`            // Enforcement modes for ref patterns, first match wins over Mode
+	BranchNames       []string            `yaml:"branch_name_patterns,omitempty"` // Replaces the global branch name patterns
+	ProtectedRefs     []string            `yaml:"protected_refs,omitempty"`       // Replaces the global protected refs
+	PathOwners        []PathOwner         `yaml:"path_owners,omitempty"`          // Replaces the global path owners
+	Tags              *TagPolicy          `yaml:"tags,omitempty"`                 // Replaces the global tag policy
+	SecretAllowlist   []string            `yaml:"secret_allowlist,omitempty"`     // Replaces the global secret scan allowlist
+	BinaryFiles       *BinaryPolicy       `yaml:"binary_files,omitempty"`         // Replaces the global binary file policy
+	Archives          *ArchivePolicy      `yaml:"archives,omitempty"`             // Replaces the global archive policy
+	ContentTypes      *ContentTypePolicy  `yaml:"content_types,omitempty"`        // Replaces the global content type policy
+	Images            *ImagePolicy        `yaml:"images,omitempty"`               // Replaces the global image policy
+	LineEndings       *LineEndingPolicy   `yaml:"line_endings,omitempty"`         // Replaces the global line ending policy
+	TextEncoding      *TextEncodingPolicy `yaml:"text_encoding,omitempty"`        // Replaces the global text encoding policy
+	TextLimits        *TextLimitPolicy    `yaml:"text_limits,omitempty"`          // Replaces the global text limits
+	LicenseHeaders    []LicenseHeader     `yaml:"license_headers,omitempty"`      // Replaces the global license headers
+	SyntaxChecks      []SyntaxCheck       `yaml:"syntax_checks,omitempty"`        // Replaces the global syntax checks
+	Plugins           []Plugin            `yaml:"plugins,omitempty"`              // Replaces the global plugins
+	ExpressionRules   []ExpressionRule    `yaml:"expression_rules,omitempty"`     // Replaces the global expression rules
+	LFSPatterns       []string            `yaml:"lfs_patterns,omitempty"`         // Replaces the global LFS patterns
+	Paths             *PathPolicy         `yaml:"paths,omitempty"`                // Replaces the global path rules
+	Symlinks          *SymlinkPolicy      `yaml:"symlinks,omitempty"`             // Replaces the global symlink rules
+	ExecutableFiles   *ExecutablePolicy   `yaml:"executable_files,omitempty"`     // Replaces the global executable file policy
+	Submodules        *SubmodulePolicy    `yaml:"submodules,omitempty"`           // Replaces the global submodule policy
+	EmailDomains      []string            `yaml:"email_domains,omitempty"`        // Replaces the global email domains, [] allows any domain
+	DisabledRules     []string            `yaml:"disabled_rules,omitempty"`       // Rule IDs not checked for the project
+}
+
+// Enforcement modes
+const (
+	ModeEnforce = "enforce" // Violations reject the push
+	ModeWarn    = "warn"    // Violations are reported and logged, the push is allowed
+)
+
+// Internal error policies
+const (
+	OnInternalErrorReject = "reject" // Fail closed, infrastructure errors block the push
+	OnInternalErrorAllow  = "allow"  // Fail open, infrastructure errors let the push through unchecked
+)
+
+// TagPolicy contains the rules applied to updates of refs/tags/*
+type TagPolicy struct {
+	RequireAnnotated bool     `yaml:"require_annotated"` // Lightweight tags are rejected
+	RequireSigned    bool     `yaml:"require_signed"`    // Unsigned tags are rejected, implies require_annotated
+	NamePatterns     []string `yaml:"name_patterns"`     // Tag names (without refs/tags/) must match one of these ref patterns, empty = any name
+	DenyDelete       bool     `yaml:"deny_delete"`       // Tags can't be deleted
+	DenyMove         bool     `yaml:"deny_move"`         // Existing tags can't be re-pointed
+}
+
+// DuplicatePolicy warns about added files whose content is byte-identical to files at other paths, it never rejects
+type DuplicatePolicy struct {
+	MinCopies int   `yaml:"min_copies"` // Warn when the content exists at this many other paths, 0 = disabled
+	MinSize   int64 `yaml:"min_size"`   // Smaller files are not reported, e.g. empty __init__.py files
+}
+
+// GrowthBudget limits how much a project grows, the bytes of the new files of the pushes accepted within the window
+// are read from the audit log, so it needs audit_log and counts nothing pushed before the log was enabled
+type GrowthBudget struct {
+	Warn   int64         `yaml:"warn"`   // Pushes taking the growth above this are accepted with a warning, 0 = disabled
+	Limit  int64         `yaml:"limit"`  // Pushes taking the growth above this are rejected, 0 = disabled
+	Window time.Duration `yaml:"window"` // 0 = DefaultGrowthWindow
+}
+
+// DefaultGrowthWindow is the window of growth budgets, a month
+const DefaultGrowthWindow = 30 * 24 * time.Hour
+
+// UploadQuota limits the bytes of new files a single uploader adds within 24 hours across all projects,
+// catching runaway automation accounts whose pushes are compliant one by one
+type UploadQuota struct {
+	Daily        int64    `yaml:"daily"`         // 0 = unlimited
+	ExemptUsers  []string `yaml:"exempt_users"`  // Uploaders without quota, e.g. mirroring accounts
+	ExemptGroups []string `yaml:"exempt_groups"` // Groups of the groups section without quota
+}
+
+// RateLimit rejects pushes of uploaders or to projects that update refs faster than the rate, e.g. CI jobs pushing
+// in a loop. Each uploader and project has a token bucket holding up to Burst ref updates that refills at the rate.
+type RateLimit struct {
+	UserRate     int      `yaml:"user_rate"`     // Ref updates per minute per uploader, 0 = unlimited
+	ProjectRate  int      `yaml:"project_rate"`  // Ref updates per minute per project, 0 = unlimited
+	Burst        int      `yaml:"burst"`         // Ref updates allowed at once after a quiet period, 0 = the rate
+	StatePath    string   `yaml:"state_path"`    // JSON file keeping the buckets, shared by all repositories, required with a rate
+	ExemptUsers  []string `yaml:"exempt_users"`  // Uploaders without rate limit, e.g. replication accounts
+	ExemptGroups []string `yaml:"exempt_groups"` // Groups of the groups section without rate limit
+}
+
+// BinaryPolicy blocks binary files outside of the allowed paths, whatever their size
+type BinaryPolicy struct {
+	Block        bool     `yaml:"block"`
+	AllowedPaths []string `yaml:"allowed_paths"` // Path patterns where binary files may be added
+	SampleSize   int64    `yaml:"sample_size"`   // Bytes read from the start of each file to classify it, 0 = DefaultBinarySampleSize
+}
+
+// ArchivePolicy limits the content of added zip (jar, war, apk...) and tar files, optionally gzip compressed
+// Archives are recognized by their content, not by their name
+type ArchivePolicy struct {
+	MaxUncompressedSize int64    `yaml:"max_uncompressed_size"` // Total size of the entries of an archive, 0 = unlimited
+	DeniedEntries       []string `yaml:"denied_entries"`        // Path patterns of entries archives must not contain, e.g. *.exe
+	ReadLimit           int64    `yaml:"read_limit"`            // Larger archives are not inspected, 0 = DefaultArchiveReadLimit
+}
+
+// DefaultArchiveReadLimit is the size of the largest archive read into memory to inspect its content
+const DefaultArchiveReadLimit = 64 * 1024 * 1024
+
+// ContentTypePolicy blocks new files by the MIME type detected from their first bytes, whatever their name
+type ContentTypePolicy struct {
+	Deny         []string `yaml:"deny"`          // MIME type patterns, e.g. video/* or application/x-executable
+	AllowedPaths []string `yaml:"allowed_paths"` // Path patterns where files of any type may be added
+}
+
+// ImagePolicy limits the dimensions of added PNG and JPEG images, read from their headers
+type ImagePolicy struct {
+	MaxWidth       int      `yaml:"max_width"`        // Pixels, 0 = unlimited
+	MaxHeight      int      `yaml:"max_height"`       // Pixels, 0 = unlimited
+	MaxDecodedSize int64    `yaml:"max_decoded_size"` // Bytes of the decoded image estimated with 4 bytes per pixel, 0 = unlimited
+	AllowedPaths   []string `yaml:"allowed_paths"`    // Path patterns where images of any size may be added
+}
+
+// LineEndingPolicy finds CRLF line endings in new text files, files with an eol, -text or binary attribute in the root .gitattributes are exempt
+type LineEndingPolicy struct {
+	Deny string `yaml:"deny"` // crlf or mixed, empty = disabled
+	Warn bool   `yaml:"warn"` // Report the files without rejecting the push
+}
+
+// Line ending checks
+const (
+	LineEndingsCRLF  = "crlf"  // Files with any CRLF line ending
+	LineEndingsMixed = "mixed" // Files mixing LF and CRLF line endings
+)
+
+// TextEncodingPolicy requires new text files to be valid UTF-8, binary files are not checked
+type TextEncodingPolicy struct {
+	Enabled   bool     `yaml:"enabled"`
+	Paths     []string `yaml:"paths"`      // Path patterns of the checked files, e.g. *.go, empty = all text files
+	DenyBOM   bool     `yaml:"deny_bom"`   // Reject UTF-8 files starting with a byte order mark
+	DenyUTF16 bool     `yaml:"deny_utf16"` // Reject UTF-16 files, recognized by their byte order mark, instead of skipping them
+}
+
+// TextLimitPolicy rejects new text files with too many lines or too long lines on average, typical for generated or minified files
+type TextLimitPolicy struct {
+	MaxLines             int      `yaml:"max_lines"`               // 0 = unlimited
+	MaxAverageLineLength int      `yaml:"max_average_line_length"` // Bytes per line of files of at least 1 KB, e.g. 500, 0 = unlimited
+	AllowedPaths         []string `yaml:"allowed_paths"`           // Path patterns of exempt files, e.g. *.min.js or *.svg
+}
+
+// LicenseHeader is the header added files matching Paths must start with, after an optional #! line
+// {year} matches a year or a range or list of years, {author} any text up to the end of the line
+type LicenseHeader struct {
+	Paths    []string `yaml:"paths"` // Path patterns, e.g. *.go
+	Template string   `yaml:"template"`
+}
+
+// PathBudget limits the total size of the new files a ref update adds under Paths, so that asset directories can
+// get more room than the source tree
+type PathBudget struct {
+	Paths []string `yaml:"paths"` // Path patterns, e.g. docs/*
+	Max   int64    `yaml:"max"`   // Bytes of new files
+}
+
+// SyntaxCheck parses the new files matching Paths, files that don't parse are rejected
+type SyntaxCheck struct {
+	Paths  []string `yaml:"paths"`  // Path patterns, e.g. *.yaml or .gitlab-ci.yml
+	Format string   `yaml:"format"` // yaml, json or xml
+}
+
+// Syntax check formats
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatXML  = "xml"
+)
+
+// PathPolicy contains the rules applied to the paths of files added by a push, existing files are not checked
+type PathPolicy struct {
+	WindowsCompatible      bool     `yaml:"windows_compatible"`       // Reject reserved names (CON, aux.c), trailing dots and spaces and characters like : and |
+	DenyCaseConflicts      bool     `yaml:"deny_case_conflicts"`      // Reject paths differing only by case from another path of the pushed tree or the target branch
+	MaxPathLength          int      `yaml:"max_path_length"`          // Maximum characters of a path, Windows checkouts fail beyond 260 including the checkout directory, 0 = unlimited
+	MaxComponentLength     int      `yaml:"max_component_length"`     // Maximum characters of a file or directory name, most file systems allow 255, 0 = unlimited
+	MaxPathDepth           int      `yaml:"max_path_depth"`           // Maximum directories a file is nested in, catching recursive vendoring, 0 = unlimited
+	MaxDirectoryEntries    int      `yaml:"max_directory_entries"`    // Maximum files and subdirectories of a directory the push adds entries to, e.g. 10000, 0 = unlimited
+	DenyUnsafeNames        bool     `yaml:"deny_unsafe_names"`        // Reject names with invalid UTF-8, control characters or a leading dash, which break scripts
+	DenyNestedRepositories bool     `yaml:"deny_nested_repositories"` // Reject .git directories and files below the top level, left behind by copying other repositories
+	DeniedPaths            []string `yaml:"denied_paths"`             // Patterns of paths that are never committed, e.g. node_modules/*, *.o; they match below any directory unless they start with /
+	DeniedPathsHint        string   `yaml:"denied_paths_hint"`        // Added to the rejection of denied paths, e.g. "dependencies are fetched with make deps"
+}
+
+// SymlinkPolicy contains the rules applied to symbolic links added by a push, or whose target a push changes
+type SymlinkPolicy struct {
+	Deny              bool `yaml:"deny"`                // Reject all added symlinks
+	DenyUnsafeTargets bool `yaml:"deny_unsafe_targets"` // Reject symlinks to absolute paths or leaving the repository through ..
+}
+
+// ExecutablePolicy blocks files added as executable, or made executable, outside of the allowed paths
+type ExecutablePolicy struct {
+	Restrict     bool     `yaml:"restrict"`
+	AllowedPaths []string `yaml:"allowed_paths"` // Path patterns where files may be executable, e.g. scripts/*
+}
+
+// SubmodulePolicy checks the submodules declared by pushed .gitmodules files and the submodules added by a push
+type SubmodulePolicy struct {
+	Enabled     bool     `yaml:"enabled"`
+	AllowedURLs []string `yaml:"allowed_urls"` // URL patterns, e.g. ssh://gerrit.example.com:29418/*, empty = any URL; relative URLs are always allowed, http:// never
+
+	// Changes of .gitmodules and of submodule pointers are limited to these users and groups, when any are set,
+	// whether or not Enabled is set. Others may make them with the push option ChangeOption, acknowledging the change.
+	ChangeUsers  []string `yaml:"change_users"`
+	ChangeGroups []string `yaml:"change_groups"` // Groups of the groups section
+	ChangeOption string   `yaml:"change_option"` // e.g. submodule-change, empty = no push option
+}
+
+// DefaultBinarySampleSize is the number of bytes git itself looks at to decide whether a file is binary
+const DefaultBinarySampleSize = 8000
+
+// SecretScan configures the scanning of new blobs for credentials
+type SecretScan struct {
+	Enabled   bool             `yaml:"enabled"`
+	Detectors []SecretDetector `yaml:"detectors"` // nil = DefaultSecretDetectors
+	Allowlist []string         `yaml:"allowlist"` // Path patterns that are not scanned, e.g. test fixtures
+	MaxSize   int64            `yaml:"max_size"`  // Larger blobs are not scanned, 0 = DefaultSecretScanMaxSize
+}
+
+// SecretDetector finds one kind of secret
+type SecretDetector struct {
+	Name       string  `yaml:"name"`
+	Pattern    string  `yaml:"pattern"`     // Regular expression, the last capture group is the secret when it has groups
+	MinEntropy float64 `yaml:"min_entropy"` // Secrets with a lower Shannon entropy in bits per character are ignored, 0 = no entropy check
+}
+
+// DefaultSecretScanMaxSize is the size above which blobs are not scanned for secrets
+const DefaultSecretScanMaxSize = 1024 * 1024
+
+// DefaultSecretDetectors are used when secret_scan has no detectors
+var DefaultSecretDetectors = []SecretDetector{
+	{Name: "aws_access_key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "private_key", Pattern: `-----BEGIN (?:RSA |DSA |EC |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`},
+	{Name: "github_token", Pattern: `\bgh[pousr]_[A-Za-z0-9]{36}\b`},
+	{Name: "slack_token", Pattern: `\bxox[abprs]-[0-9A-Za-z-]{10,}`},
+	{Name: "generic_token", Pattern: `(?i)(?:api[_-]?key|secret|token|passw(?:or)?d)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-+/=]{16,})`, MinEntropy: 3.5},
+}
+
+// Force push actions
+const (
+	ForcePushAllow  = "allow"
+	ForcePushWarn   = "warn"   // The update is allowed with a warning
+	ForcePushReject = "reject" // The update is a violation
+)
+
+// ForcePushRule sets what happens to non-fast-forward updates of refs matching Ref
+type ForcePushRule struct {
+	Ref    string `yaml:"ref"`
+	Action string `yaml:"action"` // allow, warn or reject
+}
+
+// OwnersFiles rejects pushes of CODEOWNERS (GitHub/GitLab syntax) and OWNERS (Gerrit code-owners syntax) files that don't parse
+type OwnersFiles struct {
+	Enabled       bool `yaml:"enabled"`
+	CheckAccounts bool `yaml:"check_accounts"` // Every referenced user and email must be a Gerrit account, requires gerrit.url
+}
+
+// PathOwner restricts who may push commits changing files below Paths
+type PathOwner struct {
+	Paths  []string `yaml:"paths"`  // Path patterns, * matches any sequence of characters including /, e.g. tools/release/*
+	Refs   []string `yaml:"refs"`   // Ref patterns the restriction applies to, empty = all refs
+	Users  []string `yaml:"users"`  // Usernames allowed to change the paths
+	Groups []string `yaml:"groups"` // Groups of the groups section allowed to change the paths
+}
+
+// RefQuota limits the number of refs matching Ref that a single user may create
+// {user} in Ref is replaced with the username of the pusher, e.g. refs/heads/sandbox/{user}/*
+type RefQuota struct {
+	Ref string `yaml:"ref"`
+	Max int    `yaml:"max"` // 0 = unlimited
+}
+
+// RefMode sets the enforcement mode of refs matching Ref
+type RefMode struct {
+	Ref  string `yaml:"ref"`
+	Mode string `yaml:"mode"`
 }
 
 // LogConfig defines logging configuration
@@ -26,6 +398,223 @@ type LogConfig struct {
 	Output string `yaml:"output"` // Log output: stdout, stderr, or file path
 }
 
+// NotifyConfig defines where post-push notifications are sent
+type NotifyConfig struct {
+	WebhookURL string `yaml:"webhook_url"` // JSON payloads are POSTed here, empty = disabled
+	Token      string `yaml:"token"`       // Sent as bearer token, use token_file or token_env to keep it out of the config
+}
+
+// GerritConfig defines how the Gerrit REST API is reached
+// The account needs the Modify Account capability to read the emails of other accounts
+type GerritConfig struct {
+	URL      string         `yaml:"url"`      // Base URL, e.g. https://gerrit.example.com
+	Username string         `yaml:"username"` // HTTP credentials of the account used by the hooks
+	Password string         `yaml:"password"` // HTTP password, use password_file or password_env to keep it out of the config
+	Review   GerritReview   `yaml:"review"`
+	Actions  []GerritAction `yaml:"actions"` // Done by patchset-created on the changes violating their rules
+
+	// Read the project policy from the [plugin "githookkit"] section of refs/meta/config:project.config,
+	// inherited from the parent projects and applied over the projects section
+	ProjectConfig bool   `yaml:"project_config"`
+	BasePath      string `yaml:"base_path"` // Directory of the repositories (gerrit.basePath), empty = derived from the repository of the hook
+}
+
+// GerritAction routes a change to the right people when a patch set violates one of Rules,
+// also when the violation is accepted through warn mode or an override, e.g. large files or submodule changes
+type GerritAction struct {
+	Rules     []string       `yaml:"rules"`     // Rule IDs triggering the action
+	Reviewers []string       `yaml:"reviewers"` // Gerrit accounts or groups added as reviewers
+	Labels    map[string]int `yaml:"labels"`    // Votes given on labels, e.g. Size-Review: -1
+}
+
+// GerritReview makes patchset-created post the violations of a new patch set as a review of it, with the account of GerritConfig
+type GerritReview struct {
+	Enabled bool   `yaml:"enabled"`
+	Label   string `yaml:"label"` // Label voted on when the violations reject the patch set, default Code-Review
+	Vote    int    `yaml:"vote"`  // Negative vote on Label, default -1
+}
+
+// Defaults of the review posted by patchset-created
+const (
+	DefaultReviewLabel = "Code-Review"
+	DefaultReviewVote  = -1
+)
+
+// GetReviewVote gets the label and the vote of the review posted on patch sets with rejecting violations
+func GetReviewVote(config Config) (label string, vote int) {
+	label, vote = config.Gerrit.Review.Label, config.Gerrit.Review.Vote
+	if label == "" {
+		label = DefaultReviewLabel
+	}
+	if vote == 0 {
+		vote = DefaultReviewVote
+	}
+	return label, vote
+}
+
+// ChainedHook is a site-local hook executed with the same arguments after the checks pass
+type ChainedHook struct {
+	Hook    string `yaml:"hook"`    // Hook name the command is chained to, e.g. ref-update, empty = all hooks
+	Command string `yaml:"command"` // Path of the executable
+}
+
+// Plugin is a site-local checker, an executable or a WebAssembly module, the plugin package describes their protocols
+type Plugin struct {
+	Name           string            `yaml:"name"`             // Shown before the violations the plugin reports
+	Command        string            `yaml:"command"`          // Path of the executable
+	Args           []string          `yaml:"args"`             // Arguments of the executable
+	Module         string            `yaml:"module"`           // Path of a WebAssembly module run in a sandbox instead of a command
+	Settings       map[string]string `yaml:"settings"`         // Passed to the plugin with the update
+	Timeout        time.Duration     `yaml:"timeout"`          // The plugin is killed after it, 0 = DefaultPluginTimeout
+	Env            []string          `yaml:"env"`              // Names of environment variables passed on to a command, besides PATH, locale and git variables
+	Paths          []string          `yaml:"paths"`            // Only new files matching these patterns are sent, empty = all
+	Content        bool              `yaml:"content"`          // Send the content of the new files to a command, modules can always read it
+	MaxContentSize int64             `yaml:"max_content_size"` // Bytes of content sent per file, 0 = DefaultPluginContentSize
+	MaxMemory      int64             `yaml:"max_memory"`       // Memory a module may use, 0 = DefaultPluginMemory
+}
+
+// Plugin defaults
+const (
+	DefaultPluginTimeout     = 30 * time.Second
+	DefaultPluginContentSize = 1024 * 1024
+	DefaultPluginMemory      = 256 * 1024 * 1024
+)
+
+// ExpressionRule rejects pushes for which a CEL expression is true, see https://cel.dev for the language
+// The variables are project, ref, old_rev, new_rev, uploader, username, push_options and the sizes KB, MB and GB.
+// An expression referencing file (file.path, file.size, file.hash) is evaluated for every new file.
+type ExpressionRule struct {
+	Name    string `yaml:"name"`    // Shown before the message
+	Reject  string `yaml:"reject"`  // e.g. ref.startsWith("refs/heads/release/") && file.size > 2*MB && !file.path.matches("^third_party/")
+	Message string `yaml:"message"` // Tells the user why the push is rejected
+}
+
+// CommitMessageRule sets the message format of the commits pushed to refs matching Ref
+type CommitMessageRule struct {
+	Ref                   string `yaml:"ref"`
+	SubjectMaxLength      int    `yaml:"subject_max_length"`       // 0 = unlimited
+	SubjectPattern        string `yaml:"subject_pattern"`          // Regular expression the subject must match, e.g. ^[A-Z][a-z]+ for an imperative verb
+	BodyMaxLineLength     int    `yaml:"body_max_line_length"`     // 0 = unlimited, lines without spaces such as URLs are exempt
+	BlankLineAfterSubject bool   `yaml:"blank_line_after_subject"` // The subject must be followed by an empty line
+	DenyAutosquash        bool   `yaml:"deny_autosquash"`          // Reject fixup!, squash! and amend! commits
+}
+
+// RequiredTrailers lists the trailers the commits pushed to refs matching Ref must carry
+type RequiredTrailers struct {
+	Ref      string   `yaml:"ref"`
+	Trailers []string `yaml:"trailers"` // Trailer keys, e.g. Change-Id or Signed-off-by
+}
+
+// IssueReferenceRule requires the messages of the commits pushed to refs matching Ref to reference an issue
+type IssueReferenceRule struct {
+	Ref      string `yaml:"ref"`
+	Pattern  string `yaml:"pattern"`  // Regular expression of an issue key, e.g. \b[A-Z]+-[0-9]+\b, the first capture group is the key when it has groups
+	Validate bool   `yaml:"validate"` // The referenced issues must exist and be open in the issue_tracker
+}
+
+// Issue tracker types
+const (
+	TrackerJira    = "jira"
+	TrackerRedmine = "redmine"
+)
+
+// IssueTracker defines how issues are looked up, failed lookups never block pushes unless on_error is reject
+type IssueTracker struct {
+	Type           string        `yaml:"type"`            // jira or redmine
+	URL            string        `yaml:"url"`             // Base URL, e.g. https://jira.example.com
+	Username       string        `yaml:"username"`        // Basic authentication, with password
+	Password       string        `yaml:"password"`        // Use password_file or password_env to keep it out of the config
+	Token          string        `yaml:"token"`           // Sent as bearer token (jira) or API key (redmine) instead of basic authentication
+	ClosedStatuses []string      `yaml:"closed_statuses"` // Status names treated as closed in addition to the tracker's own closed states
+	Timeout        time.Duration `yaml:"timeout"`         // Per request, 0 = DefaultTrackerTimeout
+	CacheTTL       time.Duration `yaml:"cache_ttl"`       // How long lookups are reused, 0 = DefaultTrackerCacheTTL
+	CachePath      string        `yaml:"cache_path"`      // Keeps lookups across hook runs, empty = cached for one run only
+	OnError        string        `yaml:"on_error"`        // When the tracker can't be reached: allow (default) with a warning, or reject
+}
+
+// Issue tracker defaults
+const (
+	DefaultTrackerTimeout  = 5 * time.Second
+	DefaultTrackerCacheTTL = 10 * time.Minute
+)
+
+// VirusScan streams new files to clamd, files it detects something in are rejected
+type VirusScan struct {
+	Address     string        `yaml:"address"`       // clamd socket, "unix:<path>" or host:port, empty = disabled
+	MaxFileSize int64         `yaml:"max_file_size"` // Larger files are not scanned, 0 = DefaultVirusScanMaxFileSize, keep it below StreamMaxLength of clamd
+	Concurrency int           `yaml:"concurrency"`   // Files scanned at once, 0 = DefaultVirusScanConcurrency
+	Timeout     time.Duration `yaml:"timeout"`       // Per file, 0 = DefaultVirusScanTimeout
+	OnError     string        `yaml:"on_error"`      // When clamd fails: reject (default) or allow with a warning
+}
+
+// Virus scan defaults
+const (
+	DefaultVirusScanMaxFileSize = 25 * 1024 * 1024 // The default StreamMaxLength of clamd
+	DefaultVirusScanConcurrency = 4
+	DefaultVirusScanTimeout     = 30 * time.Second
+)
+
+// Remediation are the instructions added to a push rejected by size and LFS rules, telling how to move the files to LFS
+type Remediation struct {
+	Disabled bool   `yaml:"disabled"` // No instructions are added
+	Template string `yaml:"template"` // Replaces the default instructions, a template with the RemediationData placeholders
+}
+
+// DCOPolicy requires the commits pushed to refs matching Refs to be signed off by their author
+type DCOPolicy struct {
+	Refs           []string `yaml:"refs"`            // Ref patterns the sign-off is required on, empty = disabled
+	UploaderEmails bool     `yaml:"uploader_emails"` // Also accept sign-offs with an email of the uploader's Gerrit account, requires gerrit.url
+}
+
+// CommitDates rejects commits whose author or committer date is far from the server clock, usually a broken clock or a botched rewrite
+// Durations are written like 1h or 8760h
+type CommitDates struct {
+	MaxFuture time.Duration `yaml:"max_future"` // How far dates may be ahead of the server clock, 0 = unchecked
+	MaxAge    time.Duration `yaml:"max_age"`    // How far dates may be behind the server clock, 0 = unchecked
+}
+
+// CommitSignatures requires the commits pushed to refs matching Refs to carry a good signature of a trusted key
+type CommitSignatures struct {
+	Refs           []string `yaml:"refs"`            // Ref patterns signatures are required on, e.g. protected branches, empty = disabled
+	GPGHome        string   `yaml:"gpg_home"`        // GNUPGHOME with the trusted GPG keys, they must be trusted, e.g. with trust-model always in its gpg.conf
+	AllowedSigners string   `yaml:"allowed_signers"` // SSH allowed signers file with the trusted SSH keys
+}
+
+// Commit identities compared with the uploader
+const (
+	IdentityCommitter = "committer"
+	IdentityAuthor    = "author"
+)
+
+// UploaderIdentity requires the email of the pusher to match the committer or author of the new commits on refs matching Refs
+// The uploader email comes from the --uploader hook parameter ("Name <email>") and, with account_emails, from the Gerrit account
+type UploaderIdentity struct {
+	Refs          []string `yaml:"refs"`           // Ref patterns the rule applies to, e.g. direct-push branches, empty = disabled
+	Match         string   `yaml:"match"`          // committer (default) or author
+	AccountEmails bool     `yaml:"account_emails"` // Also accept every email of the uploader's Gerrit account, requires gerrit.url
+}
+
+// BlockedIdentity is an author or committer whose commits are rejected, e.g. a departed contractor or a misconfigured bot
+// An entry with both Email and Name only matches identities with both
+type BlockedIdentity struct {
+	Email  string `yaml:"email"`  // Email pattern, * matches any sequence of characters, compared case-insensitively
+	Name   string `yaml:"name"`   // Exact name
+	Reason string `yaml:"reason"` // Shown in the rejection
+}
+
+// RefSizeLimit sets the file size thresholds of pushes to refs matching Ref, e.g. stricter ones for release branches
+type RefSizeLimit struct {
+	Ref  string `yaml:"ref"`
+	Warn int64  `yaml:"warn"` // Files above it get a warning, 0 = the size_warning of the project
+	Max  int64  `yaml:"max"`  // Files above it are rejected, 0 = the size_limit of the project
+}
+
+// RefCommitLimit limits the number of commits in one push to refs matching Ref
+type RefCommitLimit struct {
+	Ref string `yaml:"ref"` // Ref pattern, e.g. refs/for/* or ^refs/heads/release-.*
+	Max int    `yaml:"max"` // Maximum number of new commits, 0 = unlimited
+}
+
 // CommandParams contains all possible command line parameters
 type CommandParams struct {
 	Project          string
@@ -57,89 +646,1176 @@ func (l *Logger) GetLevel() logrus.Level {
 	return l.Logger.GetLevel()
 }
 
-// LoadConfig loads configuration from the config file
-func LoadConfig() (Config, error) {
+// ConfigPath returns the location of the config file in the user's home directory
+func ConfigPath() string {
 	// Try both HOME (Linux/macOS) and USERPROFILE (Windows)
 	homeDir := os.Getenv("HOME")
 	if homeDir == "" {
 		homeDir = os.Getenv("USERPROFILE")
 	}
 
-	configPath := filepath.Join(homeDir, ".githook_config")
-	configData, err := os.ReadFile(configPath)
+	return filepath.Join(homeDir, ".githook_config")
+}
 
-	config := Config{
-		ProjectsWhitelist: []string{},
-		ProjectSizeLimits: map[string]int64{},
-	}
+// LoadConfig loads configuration from the config file
+// A missing file gives the empty config, an invalid file gives the empty config together with the error
+func LoadConfig() (Config, error) {
+	configData, err := os.ReadFile(ConfigPath())
 
 	if err != nil {
 		log.Printf("Config file does not exist or cannot be read: %v, using empty config", err)
-		return config, nil
+		return emptyConfig(), nil
 	}
 
-	if err := yaml.Unmarshal(configData, &config); err != nil {
-		log.Printf("Failed to parse config file: %v, using empty config", err)
-		return Config{
-			ProjectsWhitelist: []string{},
-			ProjectSizeLimits: map[string]int64{},
-		}, nil
+	config, err := parseConfig(configData)
+	if err != nil {
+		log.Printf("%v, using empty config", err)
+		return emptyConfig(), err
 	}
 
 	return config, nil
 }
 
-// IsProjectWhitelisted checks if a project is in the whitelist
-func IsProjectWhitelisted(config Config, project string) bool {
-	return Contains(config.ProjectsWhitelist, project)
+// ParseConfigFile reads and parses the config file at path, unlike LoadConfig a missing file is an error
+func ParseConfigFile(path string) (Config, error) {
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return emptyConfig(), fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config, err := parseConfig(configData)
+	if err != nil {
+		return emptyConfig(), err
+	}
+	return config, nil
 }
 
-// GetSizeLimit gets the file size limit (from env var or project-specific)
-func GetSizeLimit(config Config, project string) int64 {
-	// Default value 5MB
-	var sizeLimit int64 = 5 * 1024 * 1024
+// parseConfig parses and migrates the content of a config file
+func parseConfig(configData []byte) (Config, error) {
+	config := emptyConfig()
 
-	// From environment variable
-	if envSize := os.Getenv("GITHOOK_FILE_SIZE_MAX"); envSize != "" {
-		if size, err := strconv.ParseInt(envSize, 10, 64); err == nil {
-			sizeLimit = size
-		}
+	// Resolve secret references (*_file, *_env keys) before decoding into the typed config
+	var document interface{}
+	if err := yaml.Unmarshal(configData, &document); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := resolveSecrets(document); err != nil {
+		return Config{}, fmt.Errorf("invalid config file: %w", err)
+	}
+	resolvedData, err := yaml.Marshal(document)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	// Check project-specific size limit
-	if projectLimit, exists := config.ProjectSizeLimits[project]; exists {
-		fmt.Printf("Using project-specific size limit for %s: %s\n", project, githookkit.FormatSize(projectLimit))
-		return projectLimit
+	// The version comes from the file, a missing version field is handled by MigrateConfig
+	config.Version = 0
+	if err := yaml.Unmarshal(resolvedData, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	return sizeLimit
+	if err := MigrateConfig(&config); err != nil {
+		return Config{}, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	if err := ValidateConfig(config); err != nil {
+		return Config{}, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	return config, nil
 }
 
-// Contains checks if a string is in a slice
-func Contains(slice []string, item string) bool {
-	for _, a := range slice {
-		if a == item {
-			return true
+// ValidateConfig checks the values of a migrated config
+func ValidateConfig(config Config) error {
+	if err := validateMode(config.Mode); err != nil {
+		return err
+	}
+
+	switch config.OnInternalError {
+	case "", OnInternalErrorReject, OnInternalErrorAllow:
+	default:
+		return fmt.Errorf("unknown on_internal_error %q, expected %s or %s", config.OnInternalError, OnInternalErrorReject, OnInternalErrorAllow)
+	}
+
+	for _, hook := range config.ChainedHooks {
+		if hook.Command == "" {
+			return fmt.Errorf("chained hook for %q has no command", hook.Hook)
 		}
 	}
-	return false
-}
 
-// InitLogger initializes the logging system
-func InitLogger(config Config) (*Logger, error) {
-	// From environment variables (优先于配置文件)
-	level := os.Getenv("GITHOOK_LOG_LEVEL")
-	if level == "" {
-		level = config.LogConfig.Level
+	plugins := config.Plugins
+	for _, policy := range config.Projects {
+		plugins = append(plugins[:len(plugins):len(plugins)], policy.Plugins...)
 	}
-	output := os.Getenv("GITHOOK_LOG_OUTPUT")
-	if output == "" {
-		output = config.LogConfig.Output
+	for _, plugin := range plugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("plugin %s has no name", plugin.Command+plugin.Module)
+		}
+		if (plugin.Command == "") == (plugin.Module == "") {
+			return fmt.Errorf("plugin %s needs either a command or a module", plugin.Name)
+		}
+		if plugin.Timeout < 0 || plugin.MaxContentSize < 0 || plugin.MaxMemory < 0 {
+			return fmt.Errorf("plugin %s: timeout, max_content_size and max_memory can't be negative", plugin.Name)
+		}
 	}
 
-	// Set default values
-	if level == "" {
-		level = "info"
+	expressionRules := config.ExpressionRules
+	for _, policy := range config.Projects {
+		expressionRules = append(expressionRules[:len(expressionRules):len(expressionRules)], policy.ExpressionRules...)
+	}
+	for _, rule := range expressionRules {
+		if rule.Name == "" || rule.Message == "" {
+			return fmt.Errorf("expression rule %q needs a name and a message", rule.Reject)
+		}
+		if _, err := CompileExpression(rule.Reject); err != nil {
+			return fmt.Errorf("expression rule %s: %w", rule.Name, err)
+		}
+	}
+
+	scan := config.VirusScan
+	if scan.MaxFileSize < 0 || scan.Concurrency < 0 || scan.Timeout < 0 {
+		return fmt.Errorf("virus_scan max_file_size, concurrency and timeout can't be negative")
+	}
+	switch scan.OnError {
+	case "", OnInternalErrorAllow, OnInternalErrorReject:
+	default:
+		return fmt.Errorf("unknown virus_scan on_error %q, expected %s or %s", scan.OnError, OnInternalErrorReject, OnInternalErrorAllow)
+	}
+
+	if config.Gerrit.Review.Vote > 0 {
+		return fmt.Errorf("gerrit review vote must be negative, got %d", config.Gerrit.Review.Vote)
+	}
+	if config.Gerrit.Review.Enabled && config.Gerrit.URL == "" {
+		return fmt.Errorf("gerrit review requires gerrit url")
+	}
+	for i, action := range config.Gerrit.Actions {
+		if len(action.Rules) == 0 || (len(action.Reviewers) == 0 && len(action.Labels) == 0) {
+			return fmt.Errorf("gerrit actions entry %d needs rules and reviewers or labels", i+1)
+		}
+		for _, rule := range action.Rules {
+			if !Contains(Rules, rule) {
+				return fmt.Errorf("gerrit actions entry %d: unknown rule %q", i+1, rule)
+			}
+		}
+		if config.Gerrit.URL == "" {
+			return fmt.Errorf("gerrit actions require gerrit url")
+		}
+	}
+
+	if config.Lang != "" && !locale.Supported(config.Lang) {
+		return fmt.Errorf("unknown lang %q, expected one of %v", config.Lang, locale.Languages())
+	}
+
+	for rule, text := range config.Messages {
+		if _, err := parseMessageTemplate(rule, text); err != nil {
+			return err
+		}
+	}
+	if _, err := parseMessageTemplate("report_url", config.ReportURL); err != nil {
+		return err
+	}
+	if _, err := parseMessageTemplate("remediation", config.Remediation.Template); err != nil {
+		return err
+	}
+
+	for _, rule := range config.ForcePush {
+		switch rule.Action {
+		case ForcePushAllow, ForcePushWarn, ForcePushReject:
+		default:
+			return fmt.Errorf("force_push %s: unknown action %q, expected %s, %s or %s", rule.Ref, rule.Action, ForcePushAllow, ForcePushWarn, ForcePushReject)
+		}
+	}
+
+	for _, rule := range config.CommitMessages {
+		if _, err := regexp.Compile(rule.SubjectPattern); err != nil {
+			return fmt.Errorf("commit_messages %s: invalid subject_pattern: %w", rule.Ref, err)
+		}
+	}
+
+	if config.MaxParents < 0 {
+		return fmt.Errorf("max_parents must not be negative, got %d", config.MaxParents)
+	}
+
+	if config.CommitDates.MaxFuture < 0 || config.CommitDates.MaxAge < 0 {
+		return fmt.Errorf("commit_dates durations must not be negative")
+	}
+
+	if err := validateArchivePolicy(config.Archives); err != nil {
+		return err
+	}
+
+	if err := validateImagePolicy(config.Images); err != nil {
+		return err
+	}
+
+	if err := validateDuplicatePolicy(config.DuplicateContent); err != nil {
+		return err
+	}
+
+	if config.SizeWarning < 0 {
+		return fmt.Errorf("size_warning must not be negative")
+	}
+
+	if err := validateGrowthBudget(config.GrowthBudget); err != nil {
+		return err
+	}
+
+	if config.UploadQuota.Daily < 0 {
+		return fmt.Errorf("upload_quota daily must not be negative")
+	}
+
+	if err := validateRateLimit(config.RateLimit); err != nil {
+		return err
+	}
+
+	if err := validateLineEndingPolicy(config.LineEndings); err != nil {
+		return err
+	}
+
+	if err := validateTextLimitPolicy(config.TextLimits); err != nil {
+		return err
+	}
+
+	headers := config.LicenseHeaders
+	for _, policy := range config.Projects {
+		headers = append(headers[:len(headers):len(headers)], policy.LicenseHeaders...)
+	}
+	for i, header := range headers {
+		if len(header.Paths) == 0 || strings.TrimSpace(header.Template) == "" {
+			return fmt.Errorf("license_headers entry %d needs paths and a template", i+1)
+		}
+	}
+
+	budgets := config.PathBudgets
+	for _, policy := range config.Projects {
+		budgets = append(budgets[:len(budgets):len(budgets)], policy.PathBudgets...)
+	}
+	for i, budget := range budgets {
+		if len(budget.Paths) == 0 || budget.Max <= 0 {
+			return fmt.Errorf("path_budgets entry %d needs paths and a positive max", i+1)
+		}
+	}
+
+	checks := config.SyntaxChecks
+	for _, policy := range config.Projects {
+		checks = append(checks[:len(checks):len(checks)], policy.SyntaxChecks...)
+	}
+	for _, check := range checks {
+		switch check.Format {
+		case FormatYAML, FormatJSON, FormatXML:
+		default:
+			return fmt.Errorf("syntax_checks %s: unknown format %q, expected %s, %s or %s", strings.Join(check.Paths, ", "), check.Format, FormatYAML, FormatJSON, FormatXML)
+		}
+	}
+
+	if err := validatePathPolicy(config.Paths); err != nil {
+		return err
+	}
+
+	for i, identity := range config.BlockedIdentities {
+		if identity.Email == "" && identity.Name == "" {
+			return fmt.Errorf("blocked_identities entry %d has neither email nor name", i+1)
+		}
+	}
+
+	if config.OwnersFiles.CheckAccounts && config.Gerrit.URL == "" {
+		return fmt.Errorf("owners_files check_accounts requires gerrit url")
+	}
+
+	if config.DCO.UploaderEmails && config.Gerrit.URL == "" {
+		return fmt.Errorf("dco uploader_emails requires gerrit url")
+	}
+
+	switch config.UploaderIdentity.Match {
+	case "", IdentityCommitter, IdentityAuthor:
+	default:
+		return fmt.Errorf("unknown uploader_identity match %q, expected %s or %s", config.UploaderIdentity.Match, IdentityCommitter, IdentityAuthor)
+	}
+	if config.UploaderIdentity.AccountEmails && config.Gerrit.URL == "" {
+		return fmt.Errorf("uploader_identity account_emails requires gerrit url")
+	}
+
+	if err := validateIssueReferences(config); err != nil {
+		return err
+	}
+
+	for _, detector := range config.SecretScan.Detectors {
+		if _, err := regexp.Compile(detector.Pattern); err != nil {
+			return fmt.Errorf("secret detector %s: %w", detector.Name, err)
+		}
+	}
+
+	for _, quota := range config.RefQuotas {
+		if quota.Max < 0 {
+			return fmt.Errorf("ref_quotas %s: max must not be negative, got %d", quota.Ref, quota.Max)
+		}
+	}
+
+	if err := validateOverrides(config); err != nil {
+		return err
+	}
+
+	for project, policy := range config.Projects {
+		if policy.Mode != nil {
+			if err := validateMode(*policy.Mode); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		for _, refMode := range policy.RefModes {
+			if err := validateMode(refMode.Mode); err != nil {
+				return fmt.Errorf("project %s ref %s: %w", project, refMode.Ref, err)
+			}
+		}
+		for _, rule := range policy.DisabledRules {
+			if !Contains(Rules, rule) {
+				return fmt.Errorf("project %s: unknown rule %q in disabled_rules", project, rule)
+			}
+		}
+		if policy.Paths != nil {
+			if err := validatePathPolicy(*policy.Paths); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.Archives != nil {
+			if err := validateArchivePolicy(*policy.Archives); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.Images != nil {
+			if err := validateImagePolicy(*policy.Images); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.DuplicateContent != nil {
+			if err := validateDuplicatePolicy(*policy.DuplicateContent); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.SizeWarning != nil && *policy.SizeWarning < 0 {
+			return fmt.Errorf("project %s: size_warning must not be negative", project)
+		}
+		for _, limit := range policy.RefSizeLimits {
+			if err := validateRefSizeLimit(limit); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.GrowthBudget != nil {
+			if err := validateGrowthBudget(*policy.GrowthBudget); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.LineEndings != nil {
+			if err := validateLineEndingPolicy(*policy.LineEndings); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+		if policy.TextLimits != nil {
+			if err := validateTextLimitPolicy(*policy.TextLimits); err != nil {
+				return fmt.Errorf("project %s: %w", project, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateArchivePolicy(policy ArchivePolicy) error {
+	if policy.MaxUncompressedSize < 0 || policy.ReadLimit < 0 {
+		return fmt.Errorf("archives max_uncompressed_size and read_limit must not be negative")
+	}
+	return nil
+}
+
+func validateImagePolicy(policy ImagePolicy) error {
+	if policy.MaxWidth < 0 || policy.MaxHeight < 0 || policy.MaxDecodedSize < 0 {
+		return fmt.Errorf("images max_width, max_height and max_decoded_size must not be negative")
+	}
+	return nil
+}
+
+func validateDuplicatePolicy(policy DuplicatePolicy) error {
+	if policy.MinCopies < 0 || policy.MinSize < 0 {
+		return fmt.Errorf("duplicate_content min_copies and min_size must not be negative")
+	}
+	return nil
+}
+
+func validateRefSizeLimit(limit RefSizeLimit) error {
+	if limit.Ref == "" {
+		return fmt.Errorf("ref_size_limits entry without ref")
+	}
+	if limit.Warn < 0 || limit.Max < 0 {
+		return fmt.Errorf("ref_size_limits %s: warn and max must not be negative", limit.Ref)
+	}
+	if limit.Warn > 0 && limit.Max > 0 && limit.Warn >= limit.Max {
+		return fmt.Errorf("ref_size_limits %s: warn %d must be below max %d", limit.Ref, limit.Warn, limit.Max)
+	}
+	return nil
+}
+
+func validateGrowthBudget(budget GrowthBudget) error {
+	if budget.Warn < 0 || budget.Limit < 0 || budget.Window < 0 {
+		return fmt.Errorf("growth_budget warn, limit and window must not be negative")
+	}
+	if budget.Warn > 0 && budget.Limit > 0 && budget.Warn > budget.Limit {
+		return fmt.Errorf("growth_budget warn %d is above limit %d", budget.Warn, budget.Limit)
+	}
+	return nil
+}
+
+func validateRateLimit(limit RateLimit) error {
+	if limit.UserRate < 0 || limit.ProjectRate < 0 || limit.Burst < 0 {
+		return fmt.Errorf("rate_limit user_rate, project_rate and burst must not be negative")
+	}
+	if (limit.UserRate > 0 || limit.ProjectRate > 0) && limit.StatePath == "" {
+		return fmt.Errorf("rate_limit needs a state_path")
+	}
+	return nil
+}
+
+func validateLineEndingPolicy(policy LineEndingPolicy) error {
+	switch policy.Deny {
+	case "", LineEndingsCRLF, LineEndingsMixed:
+		return nil
+	}
+	return fmt.Errorf("unknown line_endings deny %q, expected %s or %s", policy.Deny, LineEndingsCRLF, LineEndingsMixed)
+}
+
+func validateTextLimitPolicy(policy TextLimitPolicy) error {
+	if policy.MaxLines < 0 || policy.MaxAverageLineLength < 0 {
+		return fmt.Errorf("text_limits max_lines and max_average_line_length must not be negative")
+	}
+	return nil
+}
+
+func validatePathPolicy(policy PathPolicy) error {
+	if policy.MaxPathLength < 0 || policy.MaxComponentLength < 0 || policy.MaxPathDepth < 0 || policy.MaxDirectoryEntries < 0 {
+		return fmt.Errorf("paths max_path_length, max_component_length, max_path_depth and max_directory_entries must not be negative")
+	}
+	return nil
+}
+
+func validateIssueReferences(config Config) error {
+	validate := false
+	for _, rule := range config.IssueReferences {
+		if rule.Pattern == "" {
+			return fmt.Errorf("issue_references %s: pattern is required", rule.Ref)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("issue_references %s: invalid pattern: %w", rule.Ref, err)
+		}
+		validate = validate || rule.Validate
+	}
+	if !validate {
+		return nil
+	}
+
+	tracker := config.IssueTracker
+	switch tracker.Type {
+	case TrackerJira, TrackerRedmine:
+	default:
+		return fmt.Errorf("unknown issue_tracker type %q, expected %s or %s", tracker.Type, TrackerJira, TrackerRedmine)
+	}
+	if tracker.URL == "" {
+		return fmt.Errorf("issue_tracker url is required to validate issue references")
+	}
+	switch tracker.OnError {
+	case "", OnInternalErrorAllow, OnInternalErrorReject:
+	default:
+		return fmt.Errorf("unknown issue_tracker on_error %q, expected %s or %s", tracker.OnError, OnInternalErrorAllow, OnInternalErrorReject)
+	}
+	return nil
+}
+
+func validateMode(mode string) error {
+	switch mode {
+	case "", ModeEnforce, ModeWarn:
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q, expected %s or %s", mode, ModeEnforce, ModeWarn)
+	}
+}
+
+// emptyConfig returns a config of the current version without any settings
+func emptyConfig() Config {
+	return Config{
+		Version:           CurrentConfigVersion,
+		ProjectsWhitelist: []string{},
+		Projects:          map[string]ProjectPolicy{},
+	}
+}
+
+// projectPolicy returns the effective settings of a project
+// Settings of parent keys (a/, a/b/) are applied first and overridden by more specific ones (a/b/c)
+func projectPolicy(config Config, project string) ProjectPolicy {
+	var policy ProjectPolicy
+	for _, key := range projectKeys(project) {
+		if override, exists := config.Projects[key]; exists {
+			policy = mergePolicy(policy, override)
+		}
+	}
+	return policy
+}
+
+// projectKeys returns the lookup keys of a project from the least to the most specific one
+func projectKeys(project string) []string {
+	var keys []string
+	for i, c := range project {
+		if c == '/' {
+			keys = append(keys, project[:i+1])
+		}
+	}
+	return append(keys, project)
+}
+
+// mergePolicy returns base with every field that is set in override replaced
+func mergePolicy(base, override ProjectPolicy) ProjectPolicy {
+	result := reflect.ValueOf(&base).Elem()
+	overrideValue := reflect.ValueOf(override)
+	for i := 0; i < overrideValue.NumField(); i++ {
+		if field := overrideValue.Field(i); !field.IsNil() {
+			result.Field(i).Set(field)
+		}
+	}
+	return base
+}
+
+// IsProjectWhitelisted checks if a project is in the whitelist
+// Entries ending with / whitelist all projects below them
+func IsProjectWhitelisted(config Config, project string) bool {
+	for _, key := range projectKeys(project) {
+		if Contains(config.ProjectsWhitelist, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSizeLimit gets the file size limit (from env var or project-specific)
+func GetSizeLimit(config Config, project string) int64 {
+	// Default value 5MB
+	var sizeLimit int64 = 5 * 1024 * 1024
+
+	// From environment variable
+	if envSize := os.Getenv("GITHOOK_FILE_SIZE_MAX"); envSize != "" {
+		if size, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			sizeLimit = size
+		}
+	}
+
+	// Check project-specific size limit
+	if projectLimit := projectPolicy(config, project).SizeLimit; projectLimit != nil {
+		fmt.Fprintf(os.Stderr, "Using project-specific size limit for %s: %s\n", project, githookkit.FormatSize(*projectLimit))
+		return *projectLimit
+	}
+
+	return sizeLimit
+}
+
+// GetRefSizeLimit gets the file size limit of a push to a ref, from the first matching ref size limit of the project
+// with a max or else GetSizeLimit
+func GetRefSizeLimit(config Config, project, refName string) int64 {
+	for _, limit := range projectPolicy(config, project).RefSizeLimits {
+		if MatchRef(limit.Ref, refName) {
+			if limit.Max > 0 {
+				return limit.Max
+			}
+			break
+		}
+	}
+	return GetSizeLimit(config, project)
+}
+
+// GetSizeWarning gets the file size above which a push to a ref gets a warning (from the ref size limits, project or config)
+// A value of 0 means no warning
+func GetSizeWarning(config Config, project, refName string) int64 {
+	policy := projectPolicy(config, project)
+	for _, limit := range policy.RefSizeLimits {
+		if MatchRef(limit.Ref, refName) {
+			if limit.Warn > 0 {
+				return limit.Warn
+			}
+			break
+		}
+	}
+	if policy.SizeWarning != nil {
+		return *policy.SizeWarning
+	}
+	return config.SizeWarning
+}
+
+// GetPushSizeLimit gets the aggregate push size limit (from env var, config or project-specific)
+// A value of 0 means the total size of a push is not limited
+func GetPushSizeLimit(config Config, project string) int64 {
+	sizeLimit := config.MaxPushSize
+
+	// From environment variable
+	if envSize := os.Getenv("GITHOOK_PUSH_SIZE_MAX"); envSize != "" {
+		if size, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			sizeLimit = size
+		}
+	}
+
+	// Check project-specific push size limit
+	if projectLimit := projectPolicy(config, project).PushSizeLimit; projectLimit != nil {
+		return *projectLimit
+	}
+
+	return sizeLimit
+}
+
+// GetNewFileLimit gets the maximum number of new files per push (from env var, config or project-specific)
+// A value of 0 means the number of new files is not limited
+func GetNewFileLimit(config Config, project string) int {
+	fileLimit := config.MaxNewFiles
+
+	// From environment variable
+	if envCount := os.Getenv("GITHOOK_NEW_FILES_MAX"); envCount != "" {
+		if count, err := strconv.Atoi(envCount); err == nil {
+			fileLimit = count
+		}
+	}
+
+	// Check project-specific new file limit
+	if projectLimit := projectPolicy(config, project).NewFileLimit; projectLimit != nil {
+		return *projectLimit
+	}
+
+	return fileLimit
+}
+
+// GetDuplicatePolicy gets the duplicate content policy of a project
+func GetDuplicatePolicy(config Config, project string) DuplicatePolicy {
+	if policy := projectPolicy(config, project).DuplicateContent; policy != nil {
+		return *policy
+	}
+	return config.DuplicateContent
+}
+
+// GetGrowthBudget gets the growth budget of a project
+func GetGrowthBudget(config Config, project string) GrowthBudget {
+	if budget := projectPolicy(config, project).GrowthBudget; budget != nil {
+		return *budget
+	}
+	return config.GrowthBudget
+}
+
+// GetAdvisorySizeLimit gets the file size above which files are reported after the push (from config or project-specific)
+// A value of 0 means no advisory reporting
+func GetAdvisorySizeLimit(config Config, project string) int64 {
+	if projectLimit := projectPolicy(config, project).AdvisorySizeLimit; projectLimit != nil {
+		return *projectLimit
+	}
+	return config.AdvisorySizeLimit
+}
+
+// GetAuditLogPath gets the audit log path (from env var or config), empty means auditing is disabled
+func GetAuditLogPath(config Config) string {
+	if path := os.Getenv("GITHOOK_AUDIT_LOG"); path != "" {
+		return path
+	}
+	return config.AuditLog
+}
+
+// GetDaemonAddress gets the address of the check daemon (from env var or config), empty means checks run in the hook process
+func GetDaemonAddress(config Config) string {
+	if address := os.Getenv("GITHOOK_DAEMON"); address != "" {
+		return address
+	}
+	return config.Daemon
+}
+
+// DefaultSkipRefs are the Gerrit internal and notes refs skipped when skip_refs is not configured
+var DefaultSkipRefs = []string{"refs/changes/*", "refs/notes/*", "refs/cache-automerge/*", "refs/meta/*"}
+
+// IsRefSkipped checks if updates of a ref are exempt from all checks
+// An empty skip_refs list checks every ref, a missing one uses DefaultSkipRefs
+func IsRefSkipped(config Config, refName string) bool {
+	patterns := config.SkipRefs
+	if patterns == nil {
+		patterns = DefaultSkipRefs
+	}
+	for _, pattern := range patterns {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetForcePushAction gets the action for non-fast-forward updates of a ref, allow when no rule matches
+func GetForcePushAction(config Config, refName string) string {
+	for _, rule := range config.ForcePush {
+		if MatchRef(rule.Ref, refName) {
+			return rule.Action
+		}
+	}
+	return ForcePushAllow
+}
+
+// GetBranchNamePatterns gets the patterns new branch names of a project must match, empty means any name
+func GetBranchNamePatterns(config Config, project string) []string {
+	if patterns := projectPolicy(config, project).BranchNames; patterns != nil {
+		return patterns
+	}
+	return config.BranchNames
+}
+
+// GetProtectedRefs gets the ref patterns of a project that can't be deleted
+func GetProtectedRefs(config Config, project string) []string {
+	if patterns := projectPolicy(config, project).ProtectedRefs; patterns != nil {
+		return patterns
+	}
+	return config.ProtectedRefs
+}
+
+// GetPathOwners gets the path ownership entries of a project
+func GetPathOwners(config Config, project string) []PathOwner {
+	if owners := projectPolicy(config, project).PathOwners; owners != nil {
+		return owners
+	}
+	return config.PathOwners
+}
+
+// GetRefQuota gets the quota that applies when username creates a ref, with {user} already replaced in its pattern
+// The second return value is false when no quota matches the ref
+func GetRefQuota(config Config, refName, username string) (RefQuota, bool) {
+	for _, quota := range config.RefQuotas {
+		quota.Ref = strings.ReplaceAll(quota.Ref, "{user}", username)
+		if MatchRef(quota.Ref, refName) {
+			return quota, true
+		}
+	}
+	return RefQuota{}, false
+}
+
+// GetSecretDetectors gets the detectors new blobs are scanned with
+func GetSecretDetectors(config Config) []SecretDetector {
+	if config.SecretScan.Detectors == nil {
+		return DefaultSecretDetectors
+	}
+	return config.SecretScan.Detectors
+}
+
+// GetSecretAllowlist gets the path patterns of a project that are not scanned for secrets
+func GetSecretAllowlist(config Config, project string) []string {
+	if patterns := projectPolicy(config, project).SecretAllowlist; patterns != nil {
+		return patterns
+	}
+	return config.SecretScan.Allowlist
+}
+
+// GetBinaryPolicy gets the binary file policy of a project, a project policy replaces the global one as a whole
+func GetBinaryPolicy(config Config, project string) BinaryPolicy {
+	if policy := projectPolicy(config, project).BinaryFiles; policy != nil {
+		return *policy
+	}
+	return config.BinaryFiles
+}
+
+// GetArchivePolicy gets the archive policy of a project, a project policy replaces the global one as a whole
+func GetArchivePolicy(config Config, project string) ArchivePolicy {
+	if policy := projectPolicy(config, project).Archives; policy != nil {
+		return *policy
+	}
+	return config.Archives
+}
+
+// GetContentTypePolicy gets the content type policy of a project, a project policy replaces the global one as a whole
+func GetContentTypePolicy(config Config, project string) ContentTypePolicy {
+	if policy := projectPolicy(config, project).ContentTypes; policy != nil {
+		return *policy
+	}
+	return config.ContentTypes
+}
+
+// GetImagePolicy gets the image policy of a project, a project policy replaces the global one as a whole
+func GetImagePolicy(config Config, project string) ImagePolicy {
+	if policy := projectPolicy(config, project).Images; policy != nil {
+		return *policy
+	}
+	return config.Images
+}
+
+// GetLineEndingPolicy gets the line ending policy of a project, a project policy replaces the global one as a whole
+func GetLineEndingPolicy(config Config, project string) LineEndingPolicy {
+	if policy := projectPolicy(config, project).LineEndings; policy != nil {
+		return *policy
+	}
+	return config.LineEndings
+}
+
+// GetTextEncodingPolicy gets the text encoding policy of a project, a project policy replaces the global one as a whole
+func GetTextEncodingPolicy(config Config, project string) TextEncodingPolicy {
+	if policy := projectPolicy(config, project).TextEncoding; policy != nil {
+		return *policy
+	}
+	return config.TextEncoding
+}
+
+// GetTextLimitPolicy gets the text limits of a project, a project policy replaces the global one as a whole
+func GetTextLimitPolicy(config Config, project string) TextLimitPolicy {
+	if policy := projectPolicy(config, project).TextLimits; policy != nil {
+		return *policy
+	}
+	return config.TextLimits
+}
+
+// GetLicenseHeaders gets the license headers of a project
+func GetLicenseHeaders(config Config, project string) []LicenseHeader {
+	if headers := projectPolicy(config, project).LicenseHeaders; headers != nil {
+		return headers
+	}
+	return config.LicenseHeaders
+}
+
+// GetPathBudgets gets the path budgets of a project
+func GetPathBudgets(config Config, project string) []PathBudget {
+	if budgets := projectPolicy(config, project).PathBudgets; budgets != nil {
+		return budgets
+	}
+	return config.PathBudgets
+}
+
+// GetSyntaxChecks gets the syntax checks of a project
+func GetSyntaxChecks(config Config, project string) []SyntaxCheck {
+	if checks := projectPolicy(config, project).SyntaxChecks; checks != nil {
+		return checks
+	}
+	return config.SyntaxChecks
+}
+
+// GetExpressionRules gets the expression rules of a project
+func GetExpressionRules(config Config, project string) []ExpressionRule {
+	if rules := projectPolicy(config, project).ExpressionRules; rules != nil {
+		return rules
+	}
+	return config.ExpressionRules
+}
+
+// GetPlugins gets the plugins run for a project
+func GetPlugins(config Config, project string) []Plugin {
+	if plugins := projectPolicy(config, project).Plugins; plugins != nil {
+		return plugins
+	}
+	return config.Plugins
+}
+
+// GetPathPolicy gets the path rules of a project
+func GetPathPolicy(config Config, project string) PathPolicy {
+	if policy := projectPolicy(config, project).Paths; policy != nil {
+		return *policy
+	}
+	return config.Paths
+}
+
+// GetSymlinkPolicy gets the symlink rules of a project
+func GetSymlinkPolicy(config Config, project string) SymlinkPolicy {
+	if policy := projectPolicy(config, project).Symlinks; policy != nil {
+		return *policy
+	}
+	return config.Symlinks
+}
+
+// GetExecutablePolicy gets the executable file policy of a project, a project policy replaces the global one as a whole
+func GetExecutablePolicy(config Config, project string) ExecutablePolicy {
+	if policy := projectPolicy(config, project).ExecutableFiles; policy != nil {
+		return *policy
+	}
+	return config.ExecutableFiles
+}
+
+// GetSubmodulePolicy gets the submodule policy of a project, a project policy replaces the global one as a whole
+func GetSubmodulePolicy(config Config, project string) SubmodulePolicy {
+	if policy := projectPolicy(config, project).Submodules; policy != nil {
+		return *policy
+	}
+	return config.Submodules
+}
+
+// GetLFSPatterns gets the gitattributes patterns of the files of a project that must be LFS pointers
+func GetLFSPatterns(config Config, project string) []string {
+	if patterns := projectPolicy(config, project).LFSPatterns; patterns != nil {
+		return patterns
+	}
+	return config.LFSPatterns
+}
+
+// GetEmailDomains gets the domain patterns author and committer emails must match, empty when any domain is allowed
+func GetEmailDomains(config Config, project string) []string {
+	if domains := projectPolicy(config, project).EmailDomains; domains != nil {
+		return domains
+	}
+	return config.EmailDomains
+}
+
+// GetTagPolicy gets the tag policy of a project, a project policy replaces the global one as a whole
+func GetTagPolicy(config Config, project string) TagPolicy {
+	if policy := projectPolicy(config, project).Tags; policy != nil {
+		return *policy
+	}
+	return config.Tags
+}
+
+// GetCommitLimit gets the maximum number of commits per push for a ref
+// The first matching entry of max_commits_per_push wins, 0 means unlimited
+func GetCommitLimit(config Config, refName string) int {
+	for _, limit := range config.MaxCommitsPerPush {
+		if MatchRef(limit.Ref, refName) {
+			return limit.Max
+		}
+	}
+	return 0
+}
+
+// GetCommitMessageRule gets the commit message rule of a ref, false when no rule matches
+func GetCommitMessageRule(config Config, refName string) (CommitMessageRule, bool) {
+	for _, rule := range config.CommitMessages {
+		if MatchRef(rule.Ref, refName) {
+			return rule, true
+		}
+	}
+	return CommitMessageRule{}, false
+}
+
+// GetRequiredTrailers gets the trailer keys the commits pushed to a ref must carry, collected from all matching entries
+func GetRequiredTrailers(config Config, refName string) []string {
+	var trailers []string
+	for _, entry := range config.RequiredTrailers {
+		if MatchRef(entry.Ref, refName) {
+			for _, trailer := range entry.Trailers {
+				if !Contains(trailers, trailer) {
+					trailers = append(trailers, trailer)
+				}
+			}
+		}
+	}
+	return trailers
+}
+
+// GetIssueReferenceRule gets the issue reference rule of a ref, false when no rule matches
+func GetIssueReferenceRule(config Config, refName string) (IssueReferenceRule, bool) {
+	for _, rule := range config.IssueReferences {
+		if MatchRef(rule.Ref, refName) {
+			return rule, true
+		}
+	}
+	return IssueReferenceRule{}, false
+}
+
+// IsDCORequired checks if the commits pushed to a ref must be signed off
+func IsDCORequired(config Config, refName string) bool {
+	for _, pattern := range config.DCO.Refs {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmptyCommitDenied checks if commits without changes are rejected on a ref
+func IsEmptyCommitDenied(config Config, refName string) bool {
+	for _, pattern := range config.DenyEmptyCommits {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRootCommitDenied checks if commits without parents are rejected on a ref
+func IsRootCommitDenied(config Config, refName string) bool {
+	for _, pattern := range config.DenyRootCommits {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLinearHistoryRequired checks if merge commits are rejected on a ref
+func IsLinearHistoryRequired(config Config, refName string) bool {
+	for _, pattern := range config.LinearHistory {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSignatureRequired checks if the commits pushed to a ref must be signed
+func IsSignatureRequired(config Config, refName string) bool {
+	for _, pattern := range config.CommitSignatures.Refs {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUploaderIdentityRequired checks if the commits pushed to a ref must match the identity of the uploader
+func IsUploaderIdentityRequired(config Config, refName string) bool {
+	for _, pattern := range config.UploaderIdentity.Refs {
+		if MatchRef(pattern, refName) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchRef checks if a ref name matches a Gerrit style ref pattern
+// Patterns starting with ^ are regular expressions, otherwise * matches any sequence of characters
+func MatchRef(pattern, refName string) bool {
+	if strings.HasPrefix(pattern, "^") {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("Invalid ref pattern %s: %v", pattern, err)
+			return false
+		}
+		return re.MatchString(refName)
+	}
+
+	if !strings.Contains(pattern, "*") {
+		return pattern == refName
+	}
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(refName)
+}
+
+// GetDisabledRules gets the rules not checked for a project
+func GetDisabledRules(config Config, project string) []string {
+	return projectPolicy(config, project).DisabledRules
+}
+
+// GetMode gets the enforcement mode for a push to a ref of a project
+// Project ref modes take precedence over the project mode, which takes precedence over the global mode
+func GetMode(config Config, project, refName string) string {
+	policy := projectPolicy(config, project)
+
+	for _, refMode := range policy.RefModes {
+		if MatchRef(refMode.Ref, refName) && refMode.Mode != "" {
+			return refMode.Mode
+		}
+	}
+
+	if policy.Mode != nil {
+		return *policy.Mode
+	}
+
+	if config.Mode != "" {
+		return config.Mode
+	}
+
+	return ModeEnforce
+}
+
+// GetOnInternalError gets the internal error policy (from env var or config), defaulting to reject
+// The env var also covers the case where the config file itself is broken
+func GetOnInternalError(config Config) string {
+	if env := os.Getenv("GITHOOK_ON_INTERNAL_ERROR"); env == OnInternalErrorAllow || env == OnInternalErrorReject {
+		return env
+	}
+
+	if config.OnInternalError != "" {
+		return config.OnInternalError
+	}
+
+	return OnInternalErrorReject
+}
+
+// DefaultMaxReportedFiles is the number of offending files listed when max_reported_files is not set
+const DefaultMaxReportedFiles = 20
+
+// GetReportLimit gets the maximum number of offending files listed in the hook output (from env var or config)
+// A negative value means all files are listed
+func GetReportLimit(config Config) int {
+	limit := config.MaxReportedFiles
+
+	// From environment variable
+	if envLimit := os.Getenv("GITHOOK_MAX_REPORTED_FILES"); envLimit != "" {
+		if value, err := strconv.Atoi(envLimit); err == nil {
+			limit = value
+		}
+	}
+
+	if limit == 0 {
+		return DefaultMaxReportedFiles
+	}
+	return limit
+}
+
+// GetLang gets the language of the hook output (from env var or config), empty means English
+func GetLang(config Config) string {
+	if lang := os.Getenv("GITHOOK_LANG"); lang != "" {
+		return lang
+	}
+	return config.Lang
+}
+
+// Defaults of max_output_lines and max_output_bytes, Gerrit truncates or drops larger hook output
+const (
+	DefaultMaxOutputLines = 50
+	DefaultMaxOutputBytes = 16 * 1024
+)
+
+// GetOutputLimits gets the number of violation lines and bytes written to the pusher before the rest are summarized
+// (from env vars or config), a negative value means no limit
+func GetOutputLimits(config Config) (lines, bytes int) {
+	lines, bytes = config.MaxOutputLines, config.MaxOutputBytes
+
+	// From environment variables
+	if envLines := os.Getenv("GITHOOK_MAX_OUTPUT_LINES"); envLines != "" {
+		if value, err := strconv.Atoi(envLines); err == nil {
+			lines = value
+		}
+	}
+	if envBytes := os.Getenv("GITHOOK_MAX_OUTPUT_BYTES"); envBytes != "" {
+		if value, err := strconv.Atoi(envBytes); err == nil {
+			bytes = value
+		}
+	}
+
+	if lines == 0 {
+		lines = DefaultMaxOutputLines
+	}
+	if bytes == 0 {
+		bytes = DefaultMaxOutputBytes
+	}
+	return lines, bytes
+}
+
+// Contains checks if a string is in a slice
+func Contains(slice []string, item string) bool {
+	for _, a := range slice {
+		if a == item {
+			return true
+		}
+	}
+	return false
+}
+
+// InitLogger initializes the logging system and selects the language of the messages
+func InitLogger(config Config) (*Logger, error) {
+	// From environment variables (优先于配置文件)
+	level := os.Getenv("GITHOOK_LOG_LEVEL")
+	if level == "" {
+		level = config.LogConfig.Level
+	}
+	output := os.Getenv("GITHOOK_LOG_OUTPUT")
+	if output == "" {
+		output = config.LogConfig.Output
+	}
+
+	// Set default values
+	if level == "" {
+		level = "info"
+	}
+
+	if err := locale.Set(GetLang(config)); err != nil {
+		return nil, err
 	}
 
 	// Create logger
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -71,11 +73,12 @@ log_config:
 	if config.ProjectsWhitelist[0] != "project1" || config.ProjectsWhitelist[1] != "project2" {
 		t.Errorf("ProjectsWhitelist content is incorrect")
 	}
-	if len(config.ProjectSizeLimits) != 2 {
-		t.Errorf("ProjectSizeLimits length should be 2, got %d", len(config.ProjectSizeLimits))
+	// project_size_limits is migrated into projects
+	if len(config.Projects) != 2 {
+		t.Errorf("Projects length should be 2, got %d", len(config.Projects))
 	}
-	if config.ProjectSizeLimits["project1"] != 10485760 || config.ProjectSizeLimits["project2"] != 20971520 {
-		t.Errorf("ProjectSizeLimits content is incorrect")
+	if *config.Projects["project1"].SizeLimit != 10485760 || *config.Projects["project2"].SizeLimit != 20971520 {
+		t.Errorf("Projects size limits are incorrect")
 	}
 	// Test log config
 	if config.LogConfig.Level != "debug" {
@@ -91,9 +94,10 @@ log_config:
 		t.Fatalf("Failed to create test config file: %v", err)
 	}
 
+	// The error is reported so that on_internal_error can be applied, together with the empty config
 	config, err = LoadConfig()
-	if err != nil {
-		t.Errorf("LoadConfig() returned error: %v", err)
+	if err == nil {
+		t.Errorf("LoadConfig() should return error for invalid config")
 	}
 	if len(config.ProjectsWhitelist) != 0 {
 		t.Errorf("ProjectsWhitelist should be empty for invalid config")
@@ -138,9 +142,9 @@ func TestGetSizeLimit(t *testing.T) {
 	defer os.Setenv("GITHOOK_FILE_SIZE_MAX", oldEnv)
 
 	config := Config{
-		ProjectSizeLimits: map[string]int64{
-			"project1": 10 * 1024 * 1024,
-			"project2": 20 * 1024 * 1024,
+		Projects: map[string]ProjectPolicy{
+			"project1": {SizeLimit: int64Ptr(10 * 1024 * 1024)},
+			"project2": {SizeLimit: int64Ptr(20 * 1024 * 1024)},
 		},
 	}
 
@@ -413,7 +417,729 @@ func TestFormat(t *testing.T) {
 	})
 }
 
+// Helper functions to build optional project settings
+func int64Ptr(v int64) *int64 { return &v }
+func intPtr(v int) *int       { return &v }
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+func TestGetPushSizeLimit(t *testing.T) {
+	oldEnv := os.Getenv("GITHOOK_PUSH_SIZE_MAX")
+	defer os.Setenv("GITHOOK_PUSH_SIZE_MAX", oldEnv)
+
+	config := Config{
+		MaxPushSize: 100 * 1024 * 1024,
+		Projects: map[string]ProjectPolicy{
+			"project1": {PushSizeLimit: int64Ptr(10 * 1024 * 1024)},
+		},
+	}
+
+	// Test 1: No limit configured
+	os.Unsetenv("GITHOOK_PUSH_SIZE_MAX")
+	if result := GetPushSizeLimit(Config{}, "project3"); result != 0 {
+		t.Errorf("GetPushSizeLimit(project3) = %d, expected 0", result)
+	}
+
+	// Test 2: Use config value
+	if result := GetPushSizeLimit(config, "project3"); result != 100*1024*1024 {
+		t.Errorf("GetPushSizeLimit(project3) = %d, expected %d", result, 100*1024*1024)
+	}
+
+	// Test 3: Environment variable overrides config value
+	os.Setenv("GITHOOK_PUSH_SIZE_MAX", "52428800") // 50MB
+	if result := GetPushSizeLimit(config, "project3"); result != 50*1024*1024 {
+		t.Errorf("GetPushSizeLimit(project3) = %d, expected %d", result, 50*1024*1024)
+	}
+
+	// Test 4: Use project-specific limit
+	if result := GetPushSizeLimit(config, "project1"); result != 10*1024*1024 {
+		t.Errorf("GetPushSizeLimit(project1) = %d, expected %d", result, 10*1024*1024)
+	}
+}
+
+func TestGetNewFileLimit(t *testing.T) {
+	oldEnv := os.Getenv("GITHOOK_NEW_FILES_MAX")
+	defer os.Setenv("GITHOOK_NEW_FILES_MAX", oldEnv)
+
+	config := Config{
+		MaxNewFiles: 1000,
+		Projects: map[string]ProjectPolicy{
+			"project1": {NewFileLimit: intPtr(50)},
+		},
+	}
+
+	// Test 1: No limit configured
+	os.Unsetenv("GITHOOK_NEW_FILES_MAX")
+	if result := GetNewFileLimit(Config{}, "project3"); result != 0 {
+		t.Errorf("GetNewFileLimit(project3) = %d, expected 0", result)
+	}
+
+	// Test 2: Use config value
+	if result := GetNewFileLimit(config, "project3"); result != 1000 {
+		t.Errorf("GetNewFileLimit(project3) = %d, expected 1000", result)
+	}
+
+	// Test 3: Environment variable overrides config value
+	os.Setenv("GITHOOK_NEW_FILES_MAX", "200")
+	if result := GetNewFileLimit(config, "project3"); result != 200 {
+		t.Errorf("GetNewFileLimit(project3) = %d, expected 200", result)
+	}
+
+	// Test 4: Use project-specific limit
+	if result := GetNewFileLimit(config, "project1"); result != 50 {
+		t.Errorf("GetNewFileLimit(project1) = %d, expected 50", result)
+	}
+}
+
+func TestMatchRef(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		refName  string
+		expected bool
+	}{
+		{"refs/heads/master", "refs/heads/master", true},
+		{"refs/heads/master", "refs/heads/main", false},
+		{"refs/heads/*", "refs/heads/feature/foo", true},
+		{"refs/heads/*", "refs/tags/v1.0", false},
+		{"refs/for/*", "refs/for/master", true},
+		{"*", "refs/meta/config", true},
+		{"refs/heads/release-*", "refs/heads/release-1.0", true},
+		{"^refs/heads/release-[0-9]+$", "refs/heads/release-12", true},
+		{"^refs/heads/release-[0-9]+$", "refs/heads/release-x", false},
+		{"^refs/heads/(", "refs/heads/master", false},
+	}
+
+	for _, test := range tests {
+		result := MatchRef(test.pattern, test.refName)
+		if result != test.expected {
+			t.Errorf("MatchRef(%s, %s) = %v, expected %v", test.pattern, test.refName, result, test.expected)
+		}
+	}
+}
+
+func TestGetCommitLimit(t *testing.T) {
+	config := Config{
+		MaxCommitsPerPush: []RefCommitLimit{
+			{Ref: "refs/for/*", Max: 10},
+			{Ref: "refs/heads/import/*", Max: 0},
+			{Ref: "refs/heads/*", Max: 100},
+		},
+	}
+
+	tests := []struct {
+		refName  string
+		expected int
+	}{
+		{"refs/for/master", 10},
+		{"refs/heads/import/legacy", 0},
+		{"refs/heads/master", 100},
+		{"refs/tags/v1.0", 0},
+	}
+
+	for _, test := range tests {
+		result := GetCommitLimit(config, test.refName)
+		if result != test.expected {
+			t.Errorf("GetCommitLimit(%s) = %d, expected %d", test.refName, result, test.expected)
+		}
+	}
+}
+
+func TestProjectInheritance(t *testing.T) {
+	oldEnv := os.Getenv("GITHOOK_FILE_SIZE_MAX")
+	defer os.Setenv("GITHOOK_FILE_SIZE_MAX", oldEnv)
+	os.Unsetenv("GITHOOK_FILE_SIZE_MAX")
+
+	config := Config{
+		ProjectsWhitelist: []string{"sandbox/", "tools"},
+		Projects: map[string]ProjectPolicy{
+			"platform/":             {SizeLimit: int64Ptr(1024), NewFileLimit: intPtr(10)},
+			"platform/media/":       {SizeLimit: int64Ptr(4096)},
+			"platform/media/assets": {NewFileLimit: intPtr(0)},
+		},
+	}
+
+	tests := []struct {
+		project      string
+		sizeLimit    int64
+		newFileLimit int
+	}{
+		{"platform/build", 1024, 10},
+		{"platform/media/codecs", 4096, 10},
+		{"platform/media/assets", 4096, 0},
+		{"platform", 5 * 1024 * 1024, 0},
+		{"other/platform/build", 5 * 1024 * 1024, 0},
+	}
+
+	for _, test := range tests {
+		if result := GetSizeLimit(config, test.project); result != test.sizeLimit {
+			t.Errorf("GetSizeLimit(%s) = %d, expected %d", test.project, result, test.sizeLimit)
+		}
+		if result := GetNewFileLimit(config, test.project); result != test.newFileLimit {
+			t.Errorf("GetNewFileLimit(%s) = %d, expected %d", test.project, result, test.newFileLimit)
+		}
+	}
+
+	whitelistTests := []struct {
+		project  string
+		expected bool
+	}{
+		{"sandbox/alice/test", true},
+		{"sandbox", false},
+		{"tools", true},
+		{"tools/sub", false},
+	}
+
+	for _, test := range whitelistTests {
+		if result := IsProjectWhitelisted(config, test.project); result != test.expected {
+			t.Errorf("IsProjectWhitelisted(%s) = %v, expected %v", test.project, result, test.expected)
+		}
+	}
+}
+
+func TestGetMode(t *testing.T) {
+	warn := ModeWarn
+	enforce := ModeEnforce
+	config := Config{
+		Projects: map[string]ProjectPolicy{
+			"rollout/": {Mode: &warn},
+			"rollout/strict": {
+				Mode:     &enforce,
+				RefModes: []RefMode{{Ref: "refs/heads/sandbox/*", Mode: ModeWarn}},
+			},
+		},
+	}
+
+	tests := []struct {
+		config   Config
+		project  string
+		refName  string
+		expected string
+	}{
+		{config, "other", "refs/heads/master", ModeEnforce},
+		{config, "rollout/app", "refs/heads/master", ModeWarn},
+		{config, "rollout/strict", "refs/heads/master", ModeEnforce},
+		{config, "rollout/strict", "refs/heads/sandbox/test", ModeWarn},
+		{Config{Mode: ModeWarn}, "other", "refs/heads/master", ModeWarn},
+	}
+
+	for _, test := range tests {
+		result := GetMode(test.config, test.project, test.refName)
+		if result != test.expected {
+			t.Errorf("GetMode(%s, %s) = %s, expected %s", test.project, test.refName, result, test.expected)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	invalid := "audit"
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"Empty config", Config{}, false},
+		{"Valid global mode", Config{Mode: ModeWarn}, false},
+		{"Invalid global mode", Config{Mode: invalid}, true},
+		{"Invalid project mode", Config{Projects: map[string]ProjectPolicy{"p": {Mode: &invalid}}}, true},
+		{"Invalid ref mode", Config{Projects: map[string]ProjectPolicy{"p": {RefModes: []RefMode{{Ref: "*", Mode: invalid}}}}}, true},
+		{"DCO uploader emails without gerrit", Config{DCO: DCOPolicy{Refs: []string{"*"}, UploaderEmails: true}}, true},
+		{"Invalid uploader identity match", Config{UploaderIdentity: UploaderIdentity{Match: "pusher"}}, true},
+		{"Uploader account emails without gerrit", Config{UploaderIdentity: UploaderIdentity{AccountEmails: true}}, true},
+		{"Blocked identity without email and name", Config{BlockedIdentities: []BlockedIdentity{{Reason: "left"}}}, true},
+		{"Negative max_parents", Config{MaxParents: -1}, true},
+		{"Issue reference without pattern", Config{IssueReferences: []IssueReferenceRule{{Ref: "*"}}}, true},
+		{"Invalid issue pattern", Config{IssueReferences: []IssueReferenceRule{{Ref: "*", Pattern: "("}}}, true},
+		{"Issue validation without tracker", Config{IssueReferences: []IssueReferenceRule{{Ref: "*", Pattern: "X-[0-9]+", Validate: true}}}, true},
+		{"Issue validation", Config{IssueReferences: []IssueReferenceRule{{Ref: "*", Pattern: "X-[0-9]+", Validate: true}}, IssueTracker: IssueTracker{Type: TrackerJira, URL: "https://jira.example.com"}}, false},
+		{"Path owner with unknown group", Config{PathOwners: []PathOwner{{Paths: []string{"tools/*"}, Groups: []string{"admins"}}}}, true},
+		{"Project path owner with unknown group", Config{Projects: map[string]ProjectPolicy{"p": {PathOwners: []PathOwner{{Paths: []string{"tools/*"}, Groups: []string{"admins"}}}}}}, true},
+		{"Owners file accounts without gerrit", Config{OwnersFiles: OwnersFiles{Enabled: true, CheckAccounts: true}}, true},
+		{"Negative max_path_length", Config{Paths: PathPolicy{MaxPathLength: -1}}, true},
+		{"Negative project max_component_length", Config{Projects: map[string]ProjectPolicy{"p": {Paths: &PathPolicy{MaxComponentLength: -1}}}}, true},
+		{"Negative max_directory_entries", Config{Paths: PathPolicy{MaxDirectoryEntries: -1}}, true},
+		{"Negative max_path_depth", Config{Paths: PathPolicy{MaxPathDepth: -1}}, true},
+		{"Negative archive read_limit", Config{Projects: map[string]ProjectPolicy{"p": {Archives: &ArchivePolicy{ReadLimit: -1}}}}, true},
+		{"Negative image max_width", Config{Images: ImagePolicy{MaxWidth: -1}}, true},
+		{"Unknown line_endings deny", Config{Projects: map[string]ProjectPolicy{"p": {LineEndings: &LineEndingPolicy{Deny: "cr"}}}}, true},
+		{"Negative text max_lines", Config{TextLimits: TextLimitPolicy{MaxLines: -1}}, true},
+		{"License header without template", Config{LicenseHeaders: []LicenseHeader{{Paths: []string{"*.go"}}}}, true},
+		{"Unknown syntax check format", Config{Projects: map[string]ProjectPolicy{"p": {SyntaxChecks: []SyntaxCheck{{Paths: []string{"*.toml"}, Format: "toml"}}}}}, true},
+		{"Plugin without command", Config{Plugins: []Plugin{{Name: "lint"}}}, true},
+		{"Project plugin with negative timeout", Config{Projects: map[string]ProjectPolicy{"p": {Plugins: []Plugin{{Name: "lint", Command: "/bin/lint", Timeout: -time.Second}}}}}, true},
+		{"Plugin with command and module", Config{Plugins: []Plugin{{Name: "lint", Command: "/bin/lint", Module: "/opt/lint.wasm"}}}, true},
+		{"Module plugin", Config{Plugins: []Plugin{{Name: "lint", Module: "/opt/lint.wasm", MaxMemory: 64 << 20}}}, false},
+		{"Invalid expression rule", Config{Projects: map[string]ProjectPolicy{"p": {ExpressionRules: []ExpressionRule{{Name: "big", Reject: "file.size >", Message: "too big"}}}}}, true},
+		{"Expression rule without message", Config{ExpressionRules: []ExpressionRule{{Name: "big", Reject: "file.size > MB"}}}, true},
+		{"Unknown virus scan on_error", Config{VirusScan: VirusScan{Address: "unix:/run/clamd.sock", OnError: "ignore"}}, true},
+		{"Negative duplicate content copies", Config{Projects: map[string]ProjectPolicy{"p": {DuplicateContent: &DuplicatePolicy{MinCopies: -1}}}}, true},
+		{"Negative growth budget", Config{GrowthBudget: GrowthBudget{Limit: -1}}, true},
+		{"Growth budget warn above limit", Config{Projects: map[string]ProjectPolicy{"p": {GrowthBudget: &GrowthBudget{Warn: 2048, Limit: 1024}}}}, true},
+		{"Negative upload quota", Config{UploadQuota: UploadQuota{Daily: -1}}, true},
+		{"Upload quota with unknown group", Config{UploadQuota: UploadQuota{Daily: 1 << 30, ExemptGroups: []string{"bots"}}}, true},
+		{"Rate limit without state path", Config{RateLimit: RateLimit{UserRate: 10}}, true},
+		{"Negative rate limit burst", Config{RateLimit: RateLimit{ProjectRate: 10, Burst: -1, StatePath: "/var/lib/githookkit/rate.json"}}, true},
+		{"Rate limit with unknown group", Config{RateLimit: RateLimit{UserRate: 10, StatePath: "/var/lib/githookkit/rate.json", ExemptGroups: []string{"bots"}}}, true},
+		{"Submodule changers with unknown group", Config{Projects: map[string]ProjectPolicy{"p": {Submodules: &SubmodulePolicy{ChangeGroups: []string{"release"}}}}}, true},
+		{"Ref size warning above max", Config{Projects: map[string]ProjectPolicy{"p": {RefSizeLimits: []RefSizeLimit{{Ref: "refs/heads/*", Warn: 2048, Max: 1024}}}}}, true},
+		{"Path budget without max", Config{Projects: map[string]ProjectPolicy{"p": {PathBudgets: []PathBudget{{Paths: []string{"docs/*"}}}}}}, true},
+		{"DCO uploader emails with gerrit", Config{DCO: DCOPolicy{Refs: []string{"*"}, UploaderEmails: true}, Gerrit: GerritConfig{URL: "https://gerrit.example.com"}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateConfig(test.config); (err != nil) != test.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetOnInternalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		env      string
+		expected string
+	}{
+		{"Default is reject", Config{}, "", OnInternalErrorReject},
+		{"Config allow", Config{OnInternalError: OnInternalErrorAllow}, "", OnInternalErrorAllow},
+		{"Env overrides config", Config{OnInternalError: OnInternalErrorAllow}, OnInternalErrorReject, OnInternalErrorReject},
+		{"Env without config", Config{}, OnInternalErrorAllow, OnInternalErrorAllow},
+		{"Invalid env is ignored", Config{OnInternalError: OnInternalErrorAllow}, "maybe", OnInternalErrorAllow},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHOOK_ON_INTERNAL_ERROR", test.env)
+			if result := GetOnInternalError(test.config); result != test.expected {
+				t.Errorf("GetOnInternalError() = %s, expected %s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetReportLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		env      string
+		expected int
+	}{
+		{"Default", Config{}, "", DefaultMaxReportedFiles},
+		{"Config value", Config{MaxReportedFiles: 5}, "", 5},
+		{"Unlimited", Config{MaxReportedFiles: -1}, "", -1},
+		{"Env overrides config", Config{MaxReportedFiles: 5}, "50", 50},
+		{"Invalid env is ignored", Config{MaxReportedFiles: 5}, "many", 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHOOK_MAX_REPORTED_FILES", test.env)
+			if result := GetReportLimit(test.config); result != test.expected {
+				t.Errorf("GetReportLimit() = %d, expected %d", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetReviewVote(t *testing.T) {
+	if label, vote := GetReviewVote(Config{}); label != DefaultReviewLabel || vote != DefaultReviewVote {
+		t.Errorf("GetReviewVote() = %s %d, expected the defaults", label, vote)
+	}
+	if label, vote := GetReviewVote(Config{Gerrit: GerritConfig{Review: GerritReview{Label: "Verified", Vote: -2}}}); label != "Verified" || vote != -2 {
+		t.Errorf("GetReviewVote() = %s %d, expected Verified -2", label, vote)
+	}
+
+	if err := ValidateConfig(Config{Gerrit: GerritConfig{Review: GerritReview{Vote: 1}}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for a positive vote")
+	}
+	if err := ValidateConfig(Config{Gerrit: GerritConfig{Review: GerritReview{Enabled: true}}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for a review without gerrit url")
+	}
+}
+
+func TestValidateGerritActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []GerritAction
+		url     string
+		wantErr bool
+	}{
+		{"Reviewers", []GerritAction{{Rules: []string{RuleSubmoduleChange}, Reviewers: []string{"platform-team"}}}, "https://gerrit", false},
+		{"Labels", []GerritAction{{Rules: []string{RuleFileSize}, Labels: map[string]int{"Size-Review": -1}}}, "https://gerrit", false},
+		{"No rules", []GerritAction{{Reviewers: []string{"platform-team"}}}, "https://gerrit", true},
+		{"Nothing to do", []GerritAction{{Rules: []string{RuleFileSize}}}, "https://gerrit", true},
+		{"Unknown rule", []GerritAction{{Rules: []string{"gitmodules"}, Reviewers: []string{"platform-team"}}}, "https://gerrit", true},
+		{"No gerrit url", []GerritAction{{Rules: []string{RuleFileSize}, Reviewers: []string{"platform-team"}}}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(Config{Gerrit: GerritConfig{URL: tt.url, Actions: tt.actions}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetLang(t *testing.T) {
+	t.Setenv("GITHOOK_LANG", "")
+	if lang := GetLang(Config{Lang: "zh"}); lang != "zh" {
+		t.Errorf("GetLang() = %q, expected the config value", lang)
+	}
+	t.Setenv("GITHOOK_LANG", "en")
+	if lang := GetLang(Config{Lang: "zh"}); lang != "en" {
+		t.Errorf("GetLang() = %q, expected the env value", lang)
+	}
+
+	if err := ValidateConfig(Config{Lang: "zh"}); err != nil {
+		t.Errorf("ValidateConfig() returned error for lang zh: %v", err)
+	}
+	if err := ValidateConfig(Config{Lang: "fr"}); err == nil {
+		t.Errorf("ValidateConfig() should return error for an unknown lang")
+	}
+}
+
+func TestGetOutputLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		envLines      string
+		envBytes      string
+		expectedLines int
+		expectedBytes int
+	}{
+		{"Default", Config{}, "", "", DefaultMaxOutputLines, DefaultMaxOutputBytes},
+		{"Config values", Config{MaxOutputLines: 5, MaxOutputBytes: 1000}, "", "", 5, 1000},
+		{"Unlimited", Config{MaxOutputLines: -1, MaxOutputBytes: -1}, "", "", -1, -1},
+		{"Env overrides config", Config{MaxOutputLines: 5, MaxOutputBytes: 1000}, "10", "2000", 10, 2000},
+		{"Invalid env is ignored", Config{MaxOutputLines: 5}, "many", "lots", 5, DefaultMaxOutputBytes},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHOOK_MAX_OUTPUT_LINES", test.envLines)
+			t.Setenv("GITHOOK_MAX_OUTPUT_BYTES", test.envBytes)
+			lines, bytes := GetOutputLimits(test.config)
+			if lines != test.expectedLines || bytes != test.expectedBytes {
+				t.Errorf("GetOutputLimits() = %d, %d, expected %d, %d", lines, bytes, test.expectedLines, test.expectedBytes)
+			}
+		})
+	}
+}
+
+func TestGetRefSizeLimits(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+	config := Config{
+		SizeWarning: 1024,
+		Projects: map[string]ProjectPolicy{
+			"project1": {
+				SizeLimit:   int64Ptr(8192),
+				SizeWarning: int64Ptr(4096),
+				RefSizeLimits: []RefSizeLimit{
+					{Ref: "refs/heads/release/*", Warn: 512, Max: 2048},
+					{Ref: "refs/heads/stable", Max: 6144},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		project      string
+		refName      string
+		expectedWarn int64
+		expectedMax  int64
+	}{
+		{"project1", "refs/heads/release/1.0", 512, 2048},
+		{"project1", "refs/heads/stable", 4096, 6144},
+		{"project1", "refs/heads/master", 4096, 8192},
+		{"project2", "refs/heads/master", 1024, 5 * 1024 * 1024},
+	}
+	for _, test := range tests {
+		if warn := GetSizeWarning(config, test.project, test.refName); warn != test.expectedWarn {
+			t.Errorf("GetSizeWarning(%s, %s) = %d, expected %d", test.project, test.refName, warn, test.expectedWarn)
+		}
+		if max := GetRefSizeLimit(config, test.project, test.refName); max != test.expectedMax {
+			t.Errorf("GetRefSizeLimit(%s, %s) = %d, expected %d", test.project, test.refName, max, test.expectedMax)
+		}
+	}
+}
+
+func TestGetAdvisorySizeLimit(t *testing.T) {
+	config := Config{
+		AdvisorySizeLimit: 1024,
+		Projects: map[string]ProjectPolicy{
+			"project1": {AdvisorySizeLimit: int64Ptr(4096)},
+		},
+	}
+
+	if result := GetAdvisorySizeLimit(Config{}, "project1"); result != 0 {
+		t.Errorf("GetAdvisorySizeLimit(project1) = %d, expected 0", result)
+	}
+	if result := GetAdvisorySizeLimit(config, "project2"); result != 1024 {
+		t.Errorf("GetAdvisorySizeLimit(project2) = %d, expected 1024", result)
+	}
+	if result := GetAdvisorySizeLimit(config, "project1"); result != 4096 {
+		t.Errorf("GetAdvisorySizeLimit(project1) = %d, expected 4096", result)
+	}
+}
+
+func TestGetAuditLogPath(t *testing.T) {
+	oldEnv := os.Getenv("GITHOOK_AUDIT_LOG")
+	defer os.Setenv("GITHOOK_AUDIT_LOG", oldEnv)
+
+	os.Unsetenv("GITHOOK_AUDIT_LOG")
+	if result := GetAuditLogPath(Config{AuditLog: "/var/log/githook/audit.jsonl"}); result != "/var/log/githook/audit.jsonl" {
+		t.Errorf("GetAuditLogPath() = %s, expected config value", result)
+	}
+
+	os.Setenv("GITHOOK_AUDIT_LOG", "/tmp/audit.jsonl")
+	if result := GetAuditLogPath(Config{AuditLog: "/var/log/githook/audit.jsonl"}); result != "/tmp/audit.jsonl" {
+		t.Errorf("GetAuditLogPath() = %s, expected /tmp/audit.jsonl", result)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	config, err := parseConfig([]byte("rate_limit:\n  user_rate: 10\n  state_path: /var/lib/githookkit/rate.json\n"))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	if config.RateLimit.UserRate != 10 || config.RateLimit.StatePath != "/var/lib/githookkit/rate.json" {
+		t.Errorf("RateLimit = %+v, expected the rate and the state path", config.RateLimit)
+	}
+}
+
+func TestParseIssueTracker(t *testing.T) {
+	config, err := parseConfig([]byte("issue_tracker:\n  type: jira\n  url: https://jira.example.com\n  cache_path: /var/cache/githookkit/issues.json\n"))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	if config.IssueTracker.CachePath != "/var/cache/githookkit/issues.json" {
+		t.Errorf("IssueTracker = %+v, expected the cache path", config.IssueTracker)
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	if _, err := ParseConfigFile(path); err == nil {
+		t.Errorf("ParseConfigFile() should return error for a missing file")
+	}
+
+	if err := os.WriteFile(path, []byte("max_new_files: 10\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	config, err := ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() returned error: %v", err)
+	}
+	if config.MaxNewFiles != 10 {
+		t.Errorf("MaxNewFiles = %d, expected 10", config.MaxNewFiles)
+	}
+}
+
+func TestGetDaemonAddress(t *testing.T) {
+	t.Setenv("GITHOOK_DAEMON", "")
+	if result := GetDaemonAddress(Config{Daemon: "unix:/run/githookkit.sock"}); result != "unix:/run/githookkit.sock" {
+		t.Errorf("GetDaemonAddress() = %s, expected config value", result)
+	}
+
+	t.Setenv("GITHOOK_DAEMON", "127.0.0.1:7070")
+	if result := GetDaemonAddress(Config{Daemon: "unix:/run/githookkit.sock"}); result != "127.0.0.1:7070" {
+		t.Errorf("GetDaemonAddress() = %s, expected 127.0.0.1:7070", result)
+	}
+}
+
+func TestIsRefSkipped(t *testing.T) {
+	configured, err := parseConfig([]byte("skip_refs:\n  - refs/heads/mirror/*\n"))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	disabled, err := parseConfig([]byte("skip_refs: []\n"))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		refName  string
+		expected bool
+	}{
+		{"Default change ref", Config{}, "refs/changes/34/1234/1", true},
+		{"Default notes ref", Config{}, "refs/notes/review", true},
+		{"Default meta ref", Config{}, "refs/meta/config", true},
+		{"Default cache-automerge ref", Config{}, "refs/cache-automerge/ab/cdef", true},
+		{"Default branch", Config{}, "refs/heads/master", false},
+		{"Configured pattern", configured, "refs/heads/mirror/upstream", true},
+		{"Configured list replaces the defaults", configured, "refs/changes/34/1234/1", false},
+		{"Empty list checks every ref", disabled, "refs/meta/config", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := IsRefSkipped(test.config, test.refName); result != test.expected {
+				t.Errorf("IsRefSkipped(%s) = %v, expected %v", test.refName, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetForcePushAction(t *testing.T) {
+	config := Config{ForcePush: []ForcePushRule{
+		{Ref: "refs/heads/master", Action: ForcePushReject},
+		{Ref: "refs/heads/*", Action: ForcePushWarn},
+	}}
+
+	tests := map[string]string{
+		"refs/heads/master": ForcePushReject,
+		"refs/heads/topic":  ForcePushWarn,
+		"refs/tags/v1.0":    ForcePushAllow,
+	}
+	for refName, expected := range tests {
+		if result := GetForcePushAction(config, refName); result != expected {
+			t.Errorf("GetForcePushAction(%s) = %s, expected %s", refName, result, expected)
+		}
+	}
+
+	if err := ValidateConfig(Config{ForcePush: []ForcePushRule{{Ref: "refs/heads/*", Action: "block"}}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for an unknown force push action")
+	}
+}
+
+func TestGetRefQuota(t *testing.T) {
+	config := Config{RefQuotas: []RefQuota{
+		{Ref: "refs/heads/sandbox/{user}/*", Max: 2},
+		{Ref: "refs/heads/*", Max: 100},
+	}}
+
+	quota, ok := GetRefQuota(config, "refs/heads/sandbox/alice/topic", "alice")
+	if !ok || quota.Ref != "refs/heads/sandbox/alice/*" || quota.Max != 2 {
+		t.Errorf("GetRefQuota() = %+v, %v, expected the sandbox quota of alice", quota, ok)
+	}
+
+	// Another user's sandbox falls through to the next entry
+	if quota, _ := GetRefQuota(config, "refs/heads/sandbox/alice/topic", "bob"); quota.Max != 100 {
+		t.Errorf("GetRefQuota() = %+v, expected the refs/heads/* quota", quota)
+	}
+
+	if _, ok := GetRefQuota(config, "refs/tags/v1.0", "alice"); ok {
+		t.Errorf("GetRefQuota() should not match refs/tags/v1.0")
+	}
+
+	if err := ValidateConfig(Config{RefQuotas: []RefQuota{{Ref: "refs/heads/*", Max: -1}}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for a negative ref quota")
+	}
+}
+
+func TestSecretScanConfig(t *testing.T) {
+	config := Config{
+		SecretScan: SecretScan{Allowlist: []string{"testdata/*"}},
+		Projects:   map[string]ProjectPolicy{"fixtures": {SecretAllowlist: []string{"*"}}},
+	}
+
+	if patterns := GetSecretAllowlist(config, "app"); !reflect.DeepEqual(patterns, []string{"testdata/*"}) {
+		t.Errorf("GetSecretAllowlist(app) = %v, expected the global allowlist", patterns)
+	}
+	if patterns := GetSecretAllowlist(config, "fixtures"); !reflect.DeepEqual(patterns, []string{"*"}) {
+		t.Errorf("GetSecretAllowlist(fixtures) = %v, expected the project allowlist", patterns)
+	}
+
+	if detectors := GetSecretDetectors(config); !reflect.DeepEqual(detectors, DefaultSecretDetectors) {
+		t.Errorf("GetSecretDetectors() = %v, expected the default detectors", detectors)
+	}
+
+	invalid := Config{SecretScan: SecretScan{Detectors: []SecretDetector{{Name: "broken", Pattern: "(unclosed"}}}}
+	if err := ValidateConfig(invalid); err == nil {
+		t.Errorf("ValidateConfig() should return error for an invalid detector pattern")
+	}
+}
+
+func TestGetCommitMessageRule(t *testing.T) {
+	config := Config{CommitMessages: []CommitMessageRule{
+		{Ref: "refs/heads/master", SubjectMaxLength: 50},
+		{Ref: "refs/heads/*", SubjectMaxLength: 72},
+	}}
+
+	if rule, ok := GetCommitMessageRule(config, "refs/heads/master"); !ok || rule.SubjectMaxLength != 50 {
+		t.Errorf("GetCommitMessageRule(master) = %+v, %v, expected the master rule", rule, ok)
+	}
+	if rule, ok := GetCommitMessageRule(config, "refs/heads/topic"); !ok || rule.SubjectMaxLength != 72 {
+		t.Errorf("GetCommitMessageRule(topic) = %+v, %v, expected the refs/heads/* rule", rule, ok)
+	}
+	if _, ok := GetCommitMessageRule(config, "refs/tags/v1.0"); ok {
+		t.Errorf("GetCommitMessageRule() should not match refs/tags/v1.0")
+	}
+
+	if err := ValidateConfig(Config{CommitMessages: []CommitMessageRule{{Ref: "refs/heads/*", SubjectPattern: "(unclosed"}}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for an invalid subject pattern")
+	}
+}
+
+func TestGetRequiredTrailers(t *testing.T) {
+	config := Config{RequiredTrailers: []RequiredTrailers{
+		{Ref: "refs/for/*", Trailers: []string{"Change-Id"}},
+		{Ref: "*", Trailers: []string{"Signed-off-by", "Change-Id"}},
+	}}
+
+	if trailers := GetRequiredTrailers(config, "refs/for/master"); !reflect.DeepEqual(trailers, []string{"Change-Id", "Signed-off-by"}) {
+		t.Errorf("GetRequiredTrailers(refs/for/master) = %v, expected Change-Id and Signed-off-by", trailers)
+	}
+	if trailers := GetRequiredTrailers(Config{}, "refs/heads/master"); len(trailers) != 0 {
+		t.Errorf("GetRequiredTrailers() = %v, expected none", trailers)
+	}
+}
+
+func TestGetEmailDomains(t *testing.T) {
+	config, err := parseConfig([]byte(`
+version: 2
+email_domains: [example.com]
+projects:
+  oss/:
+    email_domains: []
+  oss/partner:
+    email_domains: [partner.example]
+`))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+
+	tests := []struct {
+		project  string
+		expected []string
+	}{
+		{"internal", []string{"example.com"}},
+		{"oss/tool", []string{}},
+		{"oss/partner", []string{"partner.example"}},
+	}
+
+	for _, test := range tests {
+		if domains := GetEmailDomains(config, test.project); !reflect.DeepEqual(domains, test.expected) {
+			t.Errorf("GetEmailDomains(%s) = %v, expected %v", test.project, domains, test.expected)
+		}
+	}
+}
+
+func TestCommitDatesConfig(t *testing.T) {
+	config, err := parseConfig([]byte(`
+version: 2
+commit_dates:
+  max_future: 1h
+  max_age: 8760h
+`))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	if config.CommitDates.MaxFuture != time.Hour || config.CommitDates.MaxAge != 8760*time.Hour {
+		t.Errorf("CommitDates = %+v, expected 1h and 8760h", config.CommitDates)
+	}
+
+	if _, err := parseConfig([]byte("version: 2\ncommit_dates:\n  max_future: -1h\n")); err == nil {
+		t.Errorf("parseConfig() should reject negative durations")
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// expressionCostLimit bounds the work of a single evaluation, so that an expression can't stall the hook
+const expressionCostLimit = 1000000
+
+// Variables of expressions evaluated once per update
+var updateVariables = []cel.EnvOption{
+	cel.Variable("project", cel.StringType),
+	cel.Variable("ref", cel.StringType),
+	cel.Variable("old_rev", cel.StringType),
+	cel.Variable("new_rev", cel.StringType),
+	cel.Variable("uploader", cel.StringType),
+	cel.Variable("username", cel.StringType),
+	cel.Variable("push_options", cel.ListType(cel.StringType)),
+	cel.Variable("KB", cel.IntType),
+	cel.Variable("MB", cel.IntType),
+	cel.Variable("GB", cel.IntType),
+}
+
+// Expression is a compiled CEL expression of an expression rule
+type Expression struct {
+	program cel.Program
+	PerFile bool // The expression references file and is evaluated for every new file
+}
+
+// ExpressionInput holds the values of the variables of an expression
+type ExpressionInput struct {
+	Project     string
+	RefName     string
+	OldRev      string
+	NewRev      string
+	Uploader    string
+	Username    string
+	PushOptions []string
+	Path        string // Only for expressions evaluated per file
+	Size        int64
+	Hash        string
+}
+
+// CompileExpression compiles a CEL expression returning a bool, it is evaluated per file when it references file
+func CompileExpression(source string) (*Expression, error) {
+	updateEnv, err := cel.NewEnv(updateVariables...)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := updateEnv.Compile(source)
+	perFile := false
+	if issues.Err() != nil {
+		// file is a map so that file.path and file.size need no declared message type
+		fileEnv, err := updateEnv.Extend(cel.Variable("file", cel.MapType(cel.StringType, cel.DynType)))
+		if err != nil {
+			return nil, err
+		}
+		var fileIssues *cel.Issues
+		if ast, fileIssues = fileEnv.Compile(source); fileIssues.Err() != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", source, fileIssues.Err())
+		}
+		updateEnv, perFile = fileEnv, true
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression %q returns %s instead of bool", source, ast.OutputType())
+	}
+
+	program, err := updateEnv.Program(ast, cel.CostLimit(expressionCostLimit))
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	return &Expression{program: program, PerFile: perFile}, nil
+}
+
+// Eval evaluates the expression
+func (e *Expression) Eval(input ExpressionInput) (bool, error) {
+	pushOptions := input.PushOptions
+	if pushOptions == nil {
+		pushOptions = []string{}
+	}
+	variables := map[string]interface{}{
+		"project":      input.Project,
+		"ref":          input.RefName,
+		"old_rev":      input.OldRev,
+		"new_rev":      input.NewRev,
+		"uploader":     input.Uploader,
+		"username":     input.Username,
+		"push_options": pushOptions,
+		"KB":           int64(1024),
+		"MB":           int64(1024 * 1024),
+		"GB":           int64(1024 * 1024 * 1024),
+	}
+	if e.PerFile {
+		variables["file"] = map[string]interface{}{"path": input.Path, "size": input.Size, "hash": input.Hash}
+	}
+
+	value, _, err := e.program.Eval(variables)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %v instead of bool", value)
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestCompileExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		perFile bool
+		wantErr bool
+	}{
+		{"update", `ref.startsWith("refs/heads/tmp/") && username != "ci"`, false, false},
+		{"file", `ref.startsWith("refs/heads/release/") && file.size > 2*MB && !file.path.matches("^third_party/")`, true, false},
+		{"push options", `"skip-ci" in push_options`, false, false},
+		{"syntax error", `ref.startsWith(`, false, true},
+		{"unknown variable", `branch == "main"`, false, true},
+		{"not a bool", `file.size * 2`, false, true},
+	}
+
+	for _, test := range tests {
+		expression, err := CompileExpression(test.source)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: CompileExpression() error = %v, wantErr %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err == nil && expression.PerFile != test.perFile {
+			t.Errorf("%s: PerFile = %v, expected %v", test.name, expression.PerFile, test.perFile)
+		}
+	}
+}
+
+func TestExpressionEval(t *testing.T) {
+	expression, err := CompileExpression(`ref.startsWith("refs/heads/release/") && file.size > 2*MB && !file.path.matches("^third_party/")`)
+	if err != nil {
+		t.Fatalf("CompileExpression() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		input    ExpressionInput
+		expected bool
+	}{
+		{"large file on release", ExpressionInput{RefName: "refs/heads/release/1.0", Path: "assets/video.mp4", Size: 3 << 20}, true},
+		{"small file on release", ExpressionInput{RefName: "refs/heads/release/1.0", Path: "assets/logo.png", Size: 1 << 20}, false},
+		{"third party", ExpressionInput{RefName: "refs/heads/release/1.0", Path: "third_party/lib.a", Size: 3 << 20}, false},
+		{"other branch", ExpressionInput{RefName: "refs/heads/main", Path: "assets/video.mp4", Size: 3 << 20}, false},
+	}
+	for _, test := range tests {
+		result, err := expression.Eval(test.input)
+		if err != nil {
+			t.Errorf("%s: Eval() returned error: %v", test.name, err)
+		} else if result != test.expected {
+			t.Errorf("%s: Eval() = %v, expected %v", test.name, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"text/template"
+
+	"github.com/bwinhwang/githookkit"
+)
+
+// Rule IDs, used as keys of the messages config section
+const (
+	RuleFileSize         = "file_size"
+	RulePushSize         = "push_size"
+	RulePathBudget       = "path_budget"
+	RuleNewFiles         = "new_files"
+	RuleCommitCount      = "commit_count"
+	RuleTag              = "tag"
+	RuleForcePush        = "force_push"
+	RuleBranchName       = "branch_name"
+	RuleRefDeletion      = "ref_deletion"
+	RuleRefQuota         = "ref_quota"
+	RuleSecret           = "secret"
+	RuleVirus            = "virus"
+	RuleBinary           = "binary"
+	RuleArchive          = "archive"
+	RuleContentType      = "content_type"
+	RuleImage            = "image"
+	RuleLineEnding       = "line_ending"
+	RuleEncoding         = "encoding"
+	RuleTextLimit        = "text_limit"
+	RuleLicenseHeader    = "license_header"
+	RuleSyntax           = "syntax"
+	RuleDuplicateContent = "duplicate_content"
+	RuleGrowthBudget     = "growth_budget"
+	RuleUploadQuota      = "upload_quota"
+	RuleRateLimit        = "rate_limit"
+	RuleLFS              = "lfs"
+	RuleLFSAttributes    = "lfs_attributes"
+	RuleCommitMessage    = "commit_message"
+	RuleTrailer          = "trailer"
+	RuleDCO              = "dco"
+	RuleEmailDomain      = "email_domain"
+	RuleIdentity         = "identity"
+	RuleSignature        = "signature"
+	RuleBlockedIdentity  = "blocked_identity"
+	RuleCommitDate       = "commit_date"
+	RuleParents          = "parents"
+	RuleEmptyCommit      = "empty_commit"
+	RuleRootCommit       = "root_commit"
+	RuleLinearHistory    = "linear_history"
+	RuleIssue            = "issue"
+	RulePathOwner        = "path_owner"
+	RuleOwnersFile       = "owners_file"
+	RuleWindowsPath      = "windows_path"
+	RuleCaseConflict     = "case_conflict"
+	RulePathLength       = "path_length"
+	RulePathDepth        = "path_depth"
+	RuleDirectoryEntries = "directory_entries"
+	RuleUnsafePath       = "unsafe_path"
+	RuleSymlink          = "symlink"
+	RuleExecutable       = "executable"
+	RuleSubmodule        = "submodule"
+	RuleSubmoduleChange  = "submodule_change"
+	RuleNestedRepository = "nested_repository"
+	RuleDeniedPath       = "denied_path"
+	RulePlugin           = "plugin"
+	RuleExpression       = "expression"
+)
+
+// MessageData holds the placeholders available to rejection message templates
+type MessageData struct {
+	Project    string
+	RefName    string
+	ContactURL string
+	Limit      string                // The configured limit, sizes are human-readable
+	Actual     string                // The value that exceeded the limit
+	Count      int                   // Number of offending files or commits
+	Files      []githookkit.FileInfo // Offending files
+	Largest    githookkit.FileInfo   // Largest offending file
+	Commit     string                // Offending commit, abbreviated
+}
+
+// templateFuncs are the functions available to message templates
+var templateFuncs = template.FuncMap{
+	"formatSize": githookkit.FormatSize,
+}
+
+// parseMessageTemplate parses the message template of a rule
+func parseMessageTemplate(rule, text string) (*template.Template, error) {
+	tmpl, err := template.New(rule).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template for %s: %w", rule, err)
+	}
+	return tmpl, nil
+}
+
+// RenderMessage renders the configured message template of a rule
+// defaultText is returned when no template is configured or the template fails
+func RenderMessage(config Config, rule string, data MessageData, defaultText string) string {
+	text, exists := config.Messages[rule]
+	if !exists {
+		return defaultText
+	}
+
+	tmpl, err := parseMessageTemplate(rule, text)
+	if err != nil {
+		log.Printf("%v, using default message", err)
+		return defaultText
+	}
+
+	data.ContactURL = config.ContactURL
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render message template for %s: %v, using default message", rule, err)
+		return defaultText
+	}
+	return buf.String()
+}
+
+// ReportData holds the placeholders of report_url
+type ReportData struct {
+	Project string
+	RefName string
+	OldRev  string
+	NewRev  string
+}
+
+// RenderReportURL renders report_url, empty when it is not configured or fails
+func RenderReportURL(config Config, data ReportData) string {
+	if config.ReportURL == "" {
+		return ""
+	}
+
+	tmpl, err := parseMessageTemplate("report_url", config.ReportURL)
+	if err != nil {
+		log.Printf("%v", err)
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render report_url: %v", err)
+		return ""
+	}
+	return buf.String()
+}
+
+// RemediationData holds the placeholders of the remediation template
+type RemediationData struct {
+	Project    string
+	RefName    string
+	OldRev     string // Empty for a new ref
+	NewRev     string
+	ContactURL string
+	Includes   string                // Patterns of the offending files for git lfs migrate import --include, comma-separated
+	Pathspecs  string                // The same patterns quoted for git commands, space-separated
+	Files      []githookkit.FileInfo // Offending files, biggest first
+}
+
+// RenderRemediation renders the remediation template, defaultText is returned when no template is configured or it fails
+func RenderRemediation(config Config, data RemediationData, defaultText string) string {
+	if config.Remediation.Template == "" {
+		return defaultText
+	}
+
+	tmpl, err := parseMessageTemplate("remediation", config.Remediation.Template)
+	if err != nil {
+		log.Printf("%v, using default remediation", err)
+		return defaultText
+	}
+
+	data.ContactURL = config.ContactURL
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render remediation template: %v, using default remediation", err)
+		return defaultText
+	}
+	return buf.String()
+}
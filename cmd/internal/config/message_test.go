@@ -0,0 +1,109 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+)
+
+func TestRenderMessage(t *testing.T) {
+	config := Config{
+		ContactURL: "https://wiki.example.com/lfs",
+		Messages: map[string]string{
+			RuleFileSize:    "{{.Count}} files in {{.Project}} exceed {{.Limit}}, largest {{.Largest.Path}} ({{formatSize .Largest.Size}}), see {{.ContactURL}}",
+			RulePushSize:    "{{.Missing}}",
+			RuleCommitCount: "{{.Count",
+		},
+	}
+
+	data := MessageData{
+		Project: "project1",
+		Limit:   "1.00 KB",
+		Count:   2,
+		Files: []githookkit.FileInfo{
+			{Path: "a.bin", Size: 2048},
+			{Path: "b.bin", Size: 4096},
+		},
+		Largest: githookkit.FileInfo{Path: "b.bin", Size: 4096},
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		expected string
+	}{
+		{
+			name:     "Template with placeholders and functions",
+			rule:     RuleFileSize,
+			expected: "2 files in project1 exceed 1.00 KB, largest b.bin (4.00 KB), see https://wiki.example.com/lfs",
+		},
+		{
+			name:     "No template uses default text",
+			rule:     RuleNewFiles,
+			expected: "default",
+		},
+		{
+			name:     "Unknown placeholder uses default text",
+			rule:     RulePushSize,
+			expected: "default",
+		},
+		{
+			name:     "Broken template uses default text",
+			rule:     RuleCommitCount,
+			expected: "default",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := RenderMessage(config, test.rule, data, "default"); result != test.expected {
+				t.Errorf("RenderMessage(%s) = %q, expected %q", test.rule, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateConfigMessages(t *testing.T) {
+	if err := ValidateConfig(Config{Messages: map[string]string{RuleFileSize: "{{.Limit}}"}}); err != nil {
+		t.Errorf("ValidateConfig() returned error for valid template: %v", err)
+	}
+	if err := ValidateConfig(Config{Messages: map[string]string{RuleFileSize: "{{.Limit"}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for broken template")
+	}
+}
+
+func TestRenderReportURL(t *testing.T) {
+	data := ReportData{Project: "app", RefName: "refs/heads/master", OldRev: "abc", NewRev: "def"}
+	if result := RenderReportURL(Config{}, data); result != "" {
+		t.Errorf("RenderReportURL() without report_url = %q, expected empty", result)
+	}
+
+	config := Config{ReportURL: "https://ci.example.com/push?project={{.Project}}&rev={{.NewRev}}"}
+	if result := RenderReportURL(config, data); result != "https://ci.example.com/push?project=app&rev=def" {
+		t.Errorf("RenderReportURL() = %q, expected the URL of app at def", result)
+	}
+
+	if err := ValidateConfig(Config{ReportURL: "{{.Project"}); err == nil {
+		t.Errorf("ValidateConfig() should return error for broken report_url")
+	}
+}
+
+func TestRenderRemediation(t *testing.T) {
+	data := RemediationData{RefName: "refs/heads/master", Includes: "*.bin"}
+	if result := RenderRemediation(Config{}, data, "default"); result != "default" {
+		t.Errorf("RenderRemediation() without template = %q, expected the default", result)
+	}
+
+	config := Config{Remediation: Remediation{Template: "git lfs track {{.Includes}}"}}
+	if result := RenderRemediation(config, data, "default"); result != "git lfs track *.bin" {
+		t.Errorf("RenderRemediation() = %q, expected the rendered template", result)
+	}
+
+	config.Remediation.Template = "{{.Missing}}"
+	if result := RenderRemediation(config, data, "default"); result != "default" {
+		t.Errorf("RenderRemediation() with a failing template = %q, expected the default", result)
+	}
+	if err := ValidateConfig(Config{Remediation: Remediation{Template: "{{.Includes"}}); err == nil {
+		t.Errorf("ValidateConfig() should return error for broken remediation template")
+	}
+}
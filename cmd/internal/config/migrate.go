@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+)
+
+// CurrentConfigVersion is the config schema version understood by this build
+const CurrentConfigVersion = 2
+
+// migrations[i] upgrades a config from version i+1 to version i+2
+var migrations = []func(config *Config){
+	migrateV1ToV2,
+}
+
+// MigrateConfig validates the schema version of a config and upgrades it in place to CurrentConfigVersion
+func MigrateConfig(config *Config) error {
+	// Config files written before the version field existed are version 1
+	if config.Version == 0 {
+		config.Version = 1
+	}
+
+	if config.Version < 0 || config.Version > CurrentConfigVersion {
+		return fmt.Errorf("unsupported config version %d, this build supports up to version %d", config.Version, CurrentConfigVersion)
+	}
+
+	if config.Version > 1 {
+		if len(config.ProjectSizeLimits) > 0 || len(config.ProjectPushSizeLimits) > 0 || len(config.ProjectNewFileLimits) > 0 {
+			return fmt.Errorf("project_*_limits are not supported in config version %d, use projects instead", config.Version)
+		}
+	}
+
+	for config.Version < CurrentConfigVersion {
+		migrations[config.Version-1](config)
+		config.Version++
+	}
+
+	return nil
+}
+
+// migrateV1ToV2 promotes the per-project limit maps into the projects section
+func migrateV1ToV2(config *Config) {
+	if config.Projects == nil {
+		config.Projects = map[string]ProjectPolicy{}
+	}
+
+	for project, limit := range config.ProjectSizeLimits {
+		policy := config.Projects[project]
+		policy.SizeLimit = &limit
+		config.Projects[project] = policy
+	}
+	for project, limit := range config.ProjectPushSizeLimits {
+		policy := config.Projects[project]
+		policy.PushSizeLimit = &limit
+		config.Projects[project] = policy
+	}
+	for project, limit := range config.ProjectNewFileLimits {
+		policy := config.Projects[project]
+		policy.NewFileLimit = &limit
+		config.Projects[project] = policy
+	}
+
+	config.ProjectSizeLimits = nil
+	config.ProjectPushSizeLimits = nil
+	config.ProjectNewFileLimits = nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	t.Run("Version 1 limits are promoted into projects", func(t *testing.T) {
+		config := Config{
+			ProjectSizeLimits:     map[string]int64{"project1": 1024, "project2": 2048},
+			ProjectPushSizeLimits: map[string]int64{"project1": 4096},
+			ProjectNewFileLimits:  map[string]int{"project3": 10},
+		}
+
+		if err := MigrateConfig(&config); err != nil {
+			t.Fatalf("MigrateConfig() returned error: %v", err)
+		}
+		if config.Version != CurrentConfigVersion {
+			t.Errorf("Version = %d, expected %d", config.Version, CurrentConfigVersion)
+		}
+		if len(config.Projects) != 3 {
+			t.Fatalf("Projects length should be 3, got %d", len(config.Projects))
+		}
+		if *config.Projects["project1"].SizeLimit != 1024 || *config.Projects["project1"].PushSizeLimit != 4096 {
+			t.Errorf("project1 settings are incorrect: %+v", config.Projects["project1"])
+		}
+		if *config.Projects["project2"].SizeLimit != 2048 || config.Projects["project2"].PushSizeLimit != nil {
+			t.Errorf("project2 settings are incorrect: %+v", config.Projects["project2"])
+		}
+		if *config.Projects["project3"].NewFileLimit != 10 || config.Projects["project3"].SizeLimit != nil {
+			t.Errorf("project3 settings are incorrect: %+v", config.Projects["project3"])
+		}
+		if config.ProjectSizeLimits != nil || config.ProjectPushSizeLimits != nil || config.ProjectNewFileLimits != nil {
+			t.Errorf("Version 1 maps should be cleared after migration")
+		}
+	})
+
+	t.Run("Current version is kept", func(t *testing.T) {
+		config := Config{
+			Version:  CurrentConfigVersion,
+			Projects: map[string]ProjectPolicy{"project1": {SizeLimit: int64Ptr(1024)}},
+		}
+
+		if err := MigrateConfig(&config); err != nil {
+			t.Fatalf("MigrateConfig() returned error: %v", err)
+		}
+		if *config.Projects["project1"].SizeLimit != 1024 {
+			t.Errorf("project1 settings are incorrect: %+v", config.Projects["project1"])
+		}
+	})
+
+	t.Run("Unsupported versions are rejected", func(t *testing.T) {
+		for _, version := range []int{-1, CurrentConfigVersion + 1} {
+			config := Config{Version: version}
+			if err := MigrateConfig(&config); err == nil {
+				t.Errorf("MigrateConfig() with version %d should return error", version)
+			}
+		}
+	})
+
+	t.Run("Version 1 keys are rejected in version 2", func(t *testing.T) {
+		config := Config{
+			Version:           2,
+			ProjectSizeLimits: map[string]int64{"project1": 1024},
+		}
+		if err := MigrateConfig(&config); err == nil {
+			t.Errorf("MigrateConfig() should return error for project_size_limits in version 2")
+		}
+	})
+}
+
+func TestLoadConfigVersion(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+	configPath := filepath.Join(homeDir, ".githook_config")
+
+	// Version 2 config file
+	validConfig := `
+version: 2
+projects:
+  project1:
+    size_limit: 1024
+    new_file_limit: 100
+`
+	if err := os.WriteFile(configPath, []byte(validConfig), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() returned error: %v", err)
+	}
+	if config.Version != 2 {
+		t.Errorf("Version = %d, expected 2", config.Version)
+	}
+	if *config.Projects["project1"].SizeLimit != 1024 || *config.Projects["project1"].NewFileLimit != 100 {
+		t.Errorf("project1 settings are incorrect: %+v", config.Projects["project1"])
+	}
+
+	// Config from a newer build falls back to the empty config
+	newerConfig := `
+version: 99
+projects_whitelist:
+  - project1
+`
+	if err := os.WriteFile(configPath, []byte(newerConfig), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config, err = LoadConfig()
+	if err == nil {
+		t.Errorf("LoadConfig() should return error for unsupported config version")
+	}
+	if len(config.ProjectsWhitelist) != 0 {
+		t.Errorf("ProjectsWhitelist should be empty for unsupported config version")
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules lists the IDs of all rules
+var Rules = []string{RuleFileSize, RulePushSize, RulePathBudget, RuleNewFiles, RuleCommitCount, RuleTag, RuleForcePush, RuleBranchName, RuleRefDeletion, RuleRefQuota, RuleSecret, RuleVirus, RuleBinary, RuleArchive, RuleContentType, RuleImage, RuleLineEnding, RuleEncoding, RuleTextLimit, RuleLicenseHeader, RuleSyntax, RuleDuplicateContent, RuleGrowthBudget, RuleUploadQuota, RuleRateLimit, RuleLFS, RuleLFSAttributes, RuleCommitMessage, RuleTrailer, RuleDCO, RuleEmailDomain, RuleIdentity, RuleSignature, RuleBlockedIdentity, RuleCommitDate, RuleParents, RuleEmptyCommit, RuleRootCommit, RuleLinearHistory, RuleIssue, RulePathOwner, RuleOwnersFile, RuleWindowsPath, RuleCaseConflict, RulePathLength, RulePathDepth, RuleDirectoryEntries, RuleUnsafePath, RuleSymlink, RuleExecutable, RuleSubmodule, RuleSubmoduleChange, RuleNestedRepository, RuleDeniedPath, RulePlugin, RuleExpression}
+
+// Override lets the listed users bypass rules for a single push with "git push -o <name>=<reason>"
+type Override struct {
+	Rules  []string `yaml:"rules"`  // Rule IDs bypassed, empty = all rules
+	Users  []string `yaml:"users"`  // Usernames allowed to use the override
+	Groups []string `yaml:"groups"` // Groups of the groups section allowed to use the override
+}
+
+// EmergencyBypass lists the admins allowed to skip all rules of a hook call by setting GITHOOK_EMERGENCY_BYPASS=<reason>
+type EmergencyBypass struct {
+	Users  []string `yaml:"users"`
+	Groups []string `yaml:"groups"` // Groups of the groups section
+}
+
+// AdminBypass skips all rules for the uploaders Gerrit grants one of Capabilities or Groups, looked up through the REST API
+// of gerrit, so that the server administrators need no second list of usernames
+// The account of gerrit must be allowed to read the capabilities and groups of other accounts
+type AdminBypass struct {
+	Capabilities []string `yaml:"capabilities"` // Global capabilities, e.g. administrateServer
+	Groups       []string `yaml:"groups"`       // Names or UUIDs of Gerrit groups, e.g. Administrators
+}
+
+// AppliedOverride is an override granted for a push
+type AppliedOverride struct {
+	Option string   `json:"option"`
+	Reason string   `json:"reason,omitempty"` // Value of the push option, e.g. a ticket number
+	Rules  []string `json:"rules,omitempty"`  // Empty = all rules
+}
+
+// Covers reports whether the override bypasses rule
+func (o AppliedOverride) Covers(rule string) bool {
+	return len(o.Rules) == 0 || Contains(o.Rules, rule)
+}
+
+// String formats the override like the push option it was granted for
+func (o AppliedOverride) String() string {
+	if o.Reason == "" {
+		return o.Option
+	}
+	return o.Option + "=" + o.Reason
+}
+
+// GetOverrides returns the overrides the push options grant to username, and the override
+// options the user isn't allowed to use
+func GetOverrides(config Config, username string, pushOptions []string) ([]AppliedOverride, []string) {
+	var granted []AppliedOverride
+	var denied []string
+	for _, option := range pushOptions {
+		name, reason, _ := strings.Cut(option, "=")
+		override, ok := config.Overrides[name]
+		if !ok {
+			continue
+		}
+		if !isMember(config, override.Users, override.Groups, username) {
+			denied = append(denied, option)
+			continue
+		}
+		granted = append(granted, AppliedOverride{Option: name, Reason: reason, Rules: override.Rules})
+	}
+	return granted, denied
+}
+
+// IsEmergencyBypassAllowed checks if username may use the emergency bypass
+func IsEmergencyBypassAllowed(config Config, username string) bool {
+	return isMember(config, config.EmergencyBypass.Users, config.EmergencyBypass.Groups, username)
+}
+
+// IsPathOwner checks if username may change the paths of owner
+func IsPathOwner(config Config, owner PathOwner, username string) bool {
+	return isMember(config, owner.Users, owner.Groups, username)
+}
+
+// IsUploadQuotaExempt checks if username uploads without the daily upload quota
+func IsUploadQuotaExempt(config Config, username string) bool {
+	return isMember(config, config.UploadQuota.ExemptUsers, config.UploadQuota.ExemptGroups, username)
+}
+
+// IsRateLimitExempt checks if username pushes without rate limit
+func IsRateLimitExempt(config Config, username string) bool {
+	return isMember(config, config.RateLimit.ExemptUsers, config.RateLimit.ExemptGroups, username)
+}
+
+// IsSubmoduleChanger checks if username may change the submodules of a project with the given policy
+func IsSubmoduleChanger(config Config, policy SubmodulePolicy, username string) bool {
+	return isMember(config, policy.ChangeUsers, policy.ChangeGroups, username)
+}
+
+// isMember checks if username is one of users or belongs to one of groups
+func isMember(config Config, users, groups []string, username string) bool {
+	if username == "" {
+		return false
+	}
+	if Contains(users, username) {
+		return true
+	}
+	for _, group := range groups {
+		if Contains(config.Groups[group], username) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOverrides checks that overrides only refer to known rules and groups, and the emergency bypass, upload quota, rate limit, submodule changers and path owners to known groups
+func validateOverrides(config Config) error {
+	for name, override := range config.Overrides {
+		for _, rule := range override.Rules {
+			if !Contains(Rules, rule) {
+				return fmt.Errorf("override %s: unknown rule %q", name, rule)
+			}
+		}
+		for _, group := range override.Groups {
+			if _, ok := config.Groups[group]; !ok {
+				return fmt.Errorf("override %s: unknown group %q", name, group)
+			}
+		}
+	}
+	for _, group := range config.EmergencyBypass.Groups {
+		if _, ok := config.Groups[group]; !ok {
+			return fmt.Errorf("emergency_bypass: unknown group %q", group)
+		}
+	}
+	if (len(config.AdminBypass.Capabilities) > 0 || len(config.AdminBypass.Groups) > 0) && config.Gerrit.URL == "" {
+		return fmt.Errorf("admin_bypass requires gerrit url")
+	}
+	for _, group := range config.UploadQuota.ExemptGroups {
+		if _, ok := config.Groups[group]; !ok {
+			return fmt.Errorf("upload_quota: unknown group %q", group)
+		}
+	}
+	for _, group := range config.RateLimit.ExemptGroups {
+		if _, ok := config.Groups[group]; !ok {
+			return fmt.Errorf("rate_limit: unknown group %q", group)
+		}
+	}
+
+	submodules := []SubmodulePolicy{config.Submodules}
+	for _, policy := range config.Projects {
+		if policy.Submodules != nil {
+			submodules = append(submodules, *policy.Submodules)
+		}
+	}
+	for _, policy := range submodules {
+		for _, group := range policy.ChangeGroups {
+			if _, ok := config.Groups[group]; !ok {
+				return fmt.Errorf("submodules change_groups: unknown group %q", group)
+			}
+		}
+	}
+
+	owners := config.PathOwners
+	for _, policy := range config.Projects {
+		owners = append(owners[:len(owners):len(owners)], policy.PathOwners...)
+	}
+	for _, owner := range owners {
+		for _, group := range owner.Groups {
+			if _, ok := config.Groups[group]; !ok {
+				return fmt.Errorf("path_owners %s: unknown group %q", strings.Join(owner.Paths, ", "), group)
+			}
+		}
+	}
+	return nil
+}
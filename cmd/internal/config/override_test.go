@@ -0,0 +1,126 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetOverrides(t *testing.T) {
+	config := Config{
+		Overrides: map[string]Override{
+			"large-files-ok": {Rules: []string{RuleFileSize, RulePushSize}, Users: []string{"alice"}, Groups: []string{"release"}},
+			"import":         {Users: []string{"admin"}},
+		},
+		Groups: map[string][]string{"release": {"bob"}},
+	}
+
+	tests := []struct {
+		name        string
+		username    string
+		pushOptions []string
+		granted     []AppliedOverride
+		denied      []string
+	}{
+		{
+			name:        "Allowed user",
+			username:    "alice",
+			pushOptions: []string{"large-files-ok=JIRA-1", "unrelated"},
+			granted:     []AppliedOverride{{Option: "large-files-ok", Reason: "JIRA-1", Rules: []string{RuleFileSize, RulePushSize}}},
+		},
+		{
+			name:        "Allowed group",
+			username:    "bob",
+			pushOptions: []string{"large-files-ok"},
+			granted:     []AppliedOverride{{Option: "large-files-ok", Rules: []string{RuleFileSize, RulePushSize}}},
+		},
+		{
+			name:        "Denied user",
+			username:    "carol",
+			pushOptions: []string{"large-files-ok=JIRA-1", "import=history"},
+			denied:      []string{"large-files-ok=JIRA-1", "import=history"},
+		},
+		{
+			name:        "Unknown user",
+			pushOptions: []string{"import=history"},
+			denied:      []string{"import=history"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			granted, denied := GetOverrides(config, test.username, test.pushOptions)
+			if !reflect.DeepEqual(granted, test.granted) {
+				t.Errorf("GetOverrides() granted = %+v, expected %+v", granted, test.granted)
+			}
+			if !reflect.DeepEqual(denied, test.denied) {
+				t.Errorf("GetOverrides() denied = %v, expected %v", denied, test.denied)
+			}
+		})
+	}
+}
+
+func TestAppliedOverride(t *testing.T) {
+	all := AppliedOverride{Option: "import"}
+	if !all.Covers(RuleCommitCount) || all.String() != "import" {
+		t.Errorf("override without rules should cover every rule, got %v", all)
+	}
+
+	limited := AppliedOverride{Option: "large-files-ok", Reason: "JIRA-1", Rules: []string{RuleFileSize}}
+	if !limited.Covers(RuleFileSize) || limited.Covers(RuleNewFiles) {
+		t.Errorf("override should only cover its rules")
+	}
+	if limited.String() != "large-files-ok=JIRA-1" {
+		t.Errorf("String() = %s, expected large-files-ok=JIRA-1", limited.String())
+	}
+}
+
+func TestValidateOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"Valid", Config{Overrides: map[string]Override{"ok": {Rules: []string{RuleFileSize}, Groups: []string{"admins"}}}, Groups: map[string][]string{"admins": {"alice"}}}, false},
+		{"Unknown rule", Config{Overrides: map[string]Override{"ok": {Rules: []string{"file_count"}}}}, true},
+		{"Unknown group", Config{Overrides: map[string]Override{"ok": {Groups: []string{"admins"}}}}, true},
+		{"Unknown emergency bypass group", Config{EmergencyBypass: EmergencyBypass{Groups: []string{"admins"}}}, true},
+		{"Admin bypass", Config{AdminBypass: AdminBypass{Groups: []string{"Administrators"}}, Gerrit: GerritConfig{URL: "https://gerrit"}}, false},
+		{"Admin bypass without gerrit url", Config{AdminBypass: AdminBypass{Capabilities: []string{"administrateServer"}}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateConfig(test.config); (err != nil) != test.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsEmergencyBypassAllowed(t *testing.T) {
+	config := Config{
+		EmergencyBypass: EmergencyBypass{Users: []string{"alice"}, Groups: []string{"oncall"}},
+		Groups:          map[string][]string{"oncall": {"carol"}},
+	}
+
+	tests := map[string]bool{"alice": true, "carol": true, "bob": false, "": false}
+	for username, expected := range tests {
+		if result := IsEmergencyBypassAllowed(config, username); result != expected {
+			t.Errorf("IsEmergencyBypassAllowed(%q) = %v, expected %v", username, result, expected)
+		}
+	}
+}
+
+func TestIsUploadQuotaExempt(t *testing.T) {
+	config := Config{
+		UploadQuota: UploadQuota{Daily: 1 << 30, ExemptUsers: []string{"mirror"}, ExemptGroups: []string{"bots"}},
+		Groups:      map[string][]string{"bots": {"ci"}},
+	}
+
+	tests := map[string]bool{"mirror": true, "ci": true, "alice": false, "": false}
+	for username, expected := range tests {
+		if result := IsUploadQuotaExempt(config, username); result != expected {
+			t.Errorf("IsUploadQuotaExempt(%q) = %v, expected %v", username, result, expected)
+		}
+	}
+}
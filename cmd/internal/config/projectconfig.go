@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AllProjects is the root of the Gerrit project hierarchy, the parent of the projects without inheritFrom
+const AllProjects = "All-Projects"
+
+// GerritProjectConfig is the part of a Gerrit project.config read by githookkit
+//
+//	[access]
+//		inheritFrom = platform/parent
+//	[plugin "githookkit"]
+//		sizeLimit = 10m
+//		mode = warn
+//		disabledRule = dco
+type GerritProjectConfig struct {
+	Parent string        // access.inheritFrom, empty = All-Projects
+	Policy ProjectPolicy // Settings of the [plugin "githookkit"] section
+}
+
+// ParseGerritProjectConfig parses the output of git config -z --list for a project.config
+// Like Gerrit, unknown keys are ignored; the last value of a key wins, except for disabledRule which may be repeated
+func ParseGerritProjectConfig(data []byte) (GerritProjectConfig, error) {
+	var result GerritProjectConfig
+	policy := &result.Policy
+	for _, entry := range strings.Split(string(data), "\x00") {
+		// git lower-cases the section and variable names: "<section>.<subsection>.<variable>\n<value>"
+		key, value, _ := strings.Cut(entry, "\n")
+		if key == "access.inheritfrom" {
+			result.Parent = value
+			continue
+		}
+		variable, found := strings.CutPrefix(key, "plugin.githookkit.")
+		if !found {
+			continue
+		}
+
+		var err error
+		switch variable {
+		case "sizelimit":
+			policy.SizeLimit, err = parseGitSize(value)
+		case "sizewarning":
+			policy.SizeWarning, err = parseGitSize(value)
+		case "pushsizelimit":
+			policy.PushSizeLimit, err = parseGitSize(value)
+		case "advisorysizelimit":
+			policy.AdvisorySizeLimit, err = parseGitSize(value)
+		case "newfilelimit":
+			var limit *int64
+			if limit, err = parseGitSize(value); err == nil {
+				count := int(*limit)
+				policy.NewFileLimit = &count
+			}
+		case "mode":
+			if err = validateMode(value); err == nil {
+				policy.Mode = &value
+			}
+		case "disabledrule":
+			if !Contains(Rules, value) {
+				err = fmt.Errorf("unknown rule %q", value)
+			}
+			policy.DisabledRules = append(policy.DisabledRules, value)
+		}
+		if err != nil {
+			return GerritProjectConfig{}, fmt.Errorf("plugin.githookkit.%s: %w", variable, err)
+		}
+	}
+	return result, nil
+}
+
+// parseGitSize parses an integer of git config, with an optional k, m or g suffix
+func parseGitSize(value string) (*int64, error) {
+	number, unit := strings.TrimSpace(value), int64(1)
+	if n := len(number); n > 0 {
+		switch number[n-1] {
+		case 'k', 'K':
+			unit = 1024
+		case 'm', 'M':
+			unit = 1024 * 1024
+		case 'g', 'G':
+			unit = 1024 * 1024 * 1024
+		}
+		if unit > 1 {
+			number = number[:n-1]
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("invalid size %q", value)
+	}
+	size *= unit
+	return &size, nil
+}
+
+// WithProjectPolicy returns config with the set fields of policy replacing the settings of project in the projects section
+func WithProjectPolicy(config Config, project string, policy ProjectPolicy) Config {
+	projects := make(map[string]ProjectPolicy, len(config.Projects)+1)
+	for key, value := range config.Projects {
+		projects[key] = value
+	}
+	projects[project] = mergePolicy(projects[project], policy)
+	config.Projects = projects
+	return config
+}
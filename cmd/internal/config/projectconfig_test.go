@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGerritProjectConfig(t *testing.T) {
+	data := "access.inheritfrom\nplatform/parent\x00" +
+		"plugin.githookkit.sizelimit\n10m\x00" +
+		"plugin.githookkit.pushsizelimit\n2G\x00" +
+		"plugin.githookkit.newfilelimit\n500\x00" +
+		"plugin.githookkit.mode\nwarn\x00" +
+		"plugin.githookkit.disabledrule\ndco\x00" +
+		"plugin.githookkit.disabledrule\nbinary\x00" +
+		"plugin.githookkit.unknown\nignored\x00" +
+		"plugin.other.sizelimit\n1\x00"
+
+	projectConfig, err := ParseGerritProjectConfig([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseGerritProjectConfig() returned error: %v", err)
+	}
+	if projectConfig.Parent != "platform/parent" {
+		t.Errorf("Parent = %q, expected platform/parent", projectConfig.Parent)
+	}
+	policy := projectConfig.Policy
+	if policy.SizeLimit == nil || *policy.SizeLimit != 10*1024*1024 {
+		t.Errorf("SizeLimit = %v, expected 10 MB", policy.SizeLimit)
+	}
+	if policy.PushSizeLimit == nil || *policy.PushSizeLimit != 2*1024*1024*1024 {
+		t.Errorf("PushSizeLimit = %v, expected 2 GB", policy.PushSizeLimit)
+	}
+	if policy.NewFileLimit == nil || *policy.NewFileLimit != 500 {
+		t.Errorf("NewFileLimit = %v, expected 500", policy.NewFileLimit)
+	}
+	if policy.Mode == nil || *policy.Mode != ModeWarn {
+		t.Errorf("Mode = %v, expected warn", policy.Mode)
+	}
+	if !reflect.DeepEqual(policy.DisabledRules, []string{RuleDCO, RuleBinary}) {
+		t.Errorf("DisabledRules = %v, expected dco and binary", policy.DisabledRules)
+	}
+	if policy.SizeWarning != nil || policy.AdvisorySizeLimit != nil {
+		t.Errorf("unset keys should stay nil to inherit, got %+v", policy)
+	}
+
+	for _, invalid := range []string{
+		"plugin.githookkit.sizelimit\n10 MB",
+		"plugin.githookkit.sizelimit\n-1",
+		"plugin.githookkit.mode\nstrict",
+		"plugin.githookkit.disabledrule\ngitmodules",
+	} {
+		if _, err := ParseGerritProjectConfig([]byte(invalid)); err == nil {
+			t.Errorf("ParseGerritProjectConfig(%q) should return error", invalid)
+		}
+	}
+}
+
+func TestWithProjectPolicy(t *testing.T) {
+	limit, pushLimit, override := int64(100), int64(1000), int64(200)
+	config := Config{Projects: map[string]ProjectPolicy{"platform/": {SizeLimit: &limit}, "platform/app": {PushSizeLimit: &pushLimit}}}
+
+	result := WithProjectPolicy(config, "platform/app", ProjectPolicy{SizeLimit: &override})
+	policy := projectPolicy(result, "platform/app")
+	if *policy.SizeLimit != override || *policy.PushSizeLimit != pushLimit {
+		t.Errorf("policy = %+v, expected the size limit replaced and the push size limit kept", policy)
+	}
+	if config.Projects["platform/app"].SizeLimit != nil {
+		t.Errorf("WithProjectPolicy() changed the projects of the original config")
+	}
+}
+
+func TestGetDisabledRules(t *testing.T) {
+	config := Config{Projects: map[string]ProjectPolicy{"platform/": {DisabledRules: []string{RuleDCO}}}}
+	if rules := GetDisabledRules(config, "platform/app"); !reflect.DeepEqual(rules, []string{RuleDCO}) {
+		t.Errorf("GetDisabledRules() = %v, expected the rules of platform/", rules)
+	}
+
+	config.Projects["platform/app"] = ProjectPolicy{DisabledRules: []string{"gitmodules"}}
+	if err := ValidateConfig(config); err == nil {
+		t.Errorf("ValidateConfig() should return error for an unknown rule in disabled_rules")
+	}
+}
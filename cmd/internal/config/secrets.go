@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Suffixes of config keys whose value is a reference to a secret rather than the secret itself
+// e.g. token_file: /etc/githook/token sets token to the content of the file,
+// token_env: GERRIT_TOKEN sets token to the value of the environment variable
+const (
+	secretFileSuffix = "_file"
+	secretEnvSuffix  = "_env"
+)
+
+// secretKeys are the credential settings that may be given as a secret reference, other keys ending in
+// _file or _env are kept as they are
+var secretKeys = []string{"token", "password", "webhook_url"}
+
+// secretExcludedKeys are sections whose keys are user-defined names rather than settings
+var secretExcludedKeys = []string{"messages", "settings"}
+
+// resolveSecrets replaces secret references in a parsed YAML document by their values
+func resolveSecrets(node interface{}) error {
+	switch value := node.(type) {
+	case map[interface{}]interface{}:
+		return resolveSecretsInMap(value)
+	case []interface{}:
+		for _, item := range value {
+			if err := resolveSecrets(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func resolveSecretsInMap(m map[interface{}]interface{}) error {
+	for rawKey, rawValue := range m {
+		key, ok := rawKey.(string)
+		if !ok {
+			continue
+		}
+
+		reference, isString := rawValue.(string)
+		switch {
+		case isString && isSecretReference(key, secretFileSuffix):
+			name := strings.TrimSuffix(key, secretFileSuffix)
+			data, err := os.ReadFile(reference)
+			if err != nil {
+				return fmt.Errorf("failed to read secret %s: %w", key, err)
+			}
+			if err := setSecret(m, key, name, strings.TrimRight(string(data), "\r\n")); err != nil {
+				return err
+			}
+
+		case isString && isSecretReference(key, secretEnvSuffix):
+			name := strings.TrimSuffix(key, secretEnvSuffix)
+			secret, exists := os.LookupEnv(reference)
+			if !exists {
+				return fmt.Errorf("failed to read secret %s: environment variable %s is not set", key, reference)
+			}
+			if err := setSecret(m, key, name, secret); err != nil {
+				return err
+			}
+
+		case !Contains(secretExcludedKeys, key):
+			if err := resolveSecrets(rawValue); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isSecretReference reports whether key is the reference with suffix to one of secretKeys
+func isSecretReference(key, suffix string) bool {
+	name, found := strings.CutSuffix(key, suffix)
+	return found && Contains(secretKeys, name)
+}
+
+// setSecret replaces the reference key by the resolved value
+func setSecret(m map[interface{}]interface{}, referenceKey, name, secret string) error {
+	if _, exists := m[name]; exists {
+		return fmt.Errorf("both %s and %s are set, use only one", name, referenceKey)
+	}
+	delete(m, referenceKey)
+	m[name] = secret
+	return nil
+}
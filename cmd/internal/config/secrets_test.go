@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretPath, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+	t.Setenv("GITHOOK_TEST_TOKEN", "env-secret")
+
+	tests := []struct {
+		name     string
+		document string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Secret from file",
+			document: "gerrit:\n  token_file: " + secretPath + "\n",
+			expected: "gerrit:\n  token: file-secret\n",
+		},
+		{
+			name:     "Secret from environment",
+			document: "hooks:\n- token_env: GITHOOK_TEST_TOKEN\n",
+			expected: "hooks:\n- token: env-secret\n",
+		},
+		{
+			name:     "Non-string values are kept",
+			document: "max_file: 10\n",
+			expected: "max_file: 10\n",
+		},
+		{
+			name:     "Messages are not resolved",
+			document: "messages:\n  large_file: text\n",
+			expected: "messages:\n  large_file: text\n",
+		},
+		{
+			name:     "Settings of plugins are not resolved",
+			document: "plugins:\n- settings:\n    token_file: rules.yaml\n",
+			expected: "plugins:\n- settings:\n    token_file: rules.yaml\n",
+		},
+		{
+			name:     "Only credentials are resolved",
+			document: "rules_file: " + secretPath + "\nsize_env: GITHOOK_TEST_TOKEN\n",
+			expected: "rules_file: " + secretPath + "\nsize_env: GITHOOK_TEST_TOKEN\n",
+		},
+		{
+			name:     "Missing file",
+			document: "token_file: " + filepath.Join(t.TempDir(), "missing") + "\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Missing environment variable",
+			document: "token_env: GITHOOK_TEST_MISSING\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Both plain value and reference",
+			document: "token: plain\ntoken_env: GITHOOK_TEST_TOKEN\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var document interface{}
+			if err := yaml.Unmarshal([]byte(test.document), &document); err != nil {
+				t.Fatalf("Failed to parse document: %v", err)
+			}
+
+			err := resolveSecrets(document)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("resolveSecrets() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			result, err := yaml.Marshal(document)
+			if err != nil {
+				t.Fatalf("Failed to marshal document: %v", err)
+			}
+			if string(result) != test.expected {
+				t.Errorf("resolveSecrets() = %q, expected %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseConfigSecrets(t *testing.T) {
+	t.Setenv("GITHOOK_TEST_PASSWORD", "secret")
+
+	config, err := parseConfig([]byte("gerrit:\n  password_env: GITHOOK_TEST_PASSWORD\n"))
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	if config.Gerrit.Password != "secret" {
+		t.Errorf("Gerrit.Password = %q, expected the value of the environment variable", config.Gerrit.Password)
+	}
+
+	if _, err := parseConfig([]byte("gerrit:\n  password_env: GITHOOK_TEST_MISSING\n")); err == nil {
+		t.Errorf("parseConfig() should return error for a missing secret")
+	}
+}
+
+// Settings named like the secret references of credentials would be replaced by the content of the file or
+// variable they name
+func TestSettingsAreNotSecretReferences(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	var walk func(typ reflect.Type, path string)
+	walk = func(typ reflect.Type, path string) {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || seen[typ] {
+			return
+		}
+		seen[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if isSecretReference(key, secretFileSuffix) || isSecretReference(key, secretEnvSuffix) {
+				t.Errorf("%s%s: key %q is read as a secret reference, rename it", path, field.Name, key)
+			}
+			walk(field.Type, path+field.Name+".")
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+}
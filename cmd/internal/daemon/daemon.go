@@ -0,0 +1,281 @@
+// Package daemon lets the hooks forward their checks to a long-running "githookkit serve" process,
+// which keeps the parsed config between pushes instead of paying the startup cost on every hook call
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Timeout bounds a forwarded check, large pushes can take a while to enumerate
+const Timeout = 5 * time.Minute
+
+// StoreIdleTimeout is how long the cat-file processes of a repository keep running without checks
+const StoreIdleTimeout = 10 * time.Minute
+
+// Request is sent by a thin client for the ref updates of one hook call
+type Request struct {
+	Dir     string         `json:"dir"`           // Repository the hook runs in, an absolute path
+	Env     []string       `json:"env,omitempty"` // Variables of ForwardedEnv the hook got from git, others are ignored
+	Updates []check.Update `json:"updates"`
+}
+
+// ForwardedEnv are the variables git receive-pack sets for the hooks that git needs to run for them,
+// the pushed objects are only visible through the quarantine until the push is accepted
+var ForwardedEnv = []string{"GIT_QUARANTINE_PATH", "GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES"}
+
+// Response carries the check results, Error is set when the check itself failed
+type Response struct {
+	Results []check.Result `json:"results,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// Server answers check requests with the config held by cache
+type Server struct {
+	cache  *config.Cache
+	logger *config.Logger
+
+	mu     sync.Mutex
+	stores map[string]*objectStore // By repository directory
+}
+
+// objectStore is the object store of a repository and when a check last used it
+type objectStore struct {
+	objects *githookkit.ObjectStore
+	used    time.Time
+}
+
+// NewServer creates a server using the config held by cache
+func NewServer(cache *config.Cache, logger *config.Logger) *Server {
+	return &Server{cache: cache, logger: logger, stores: map[string]*objectStore{}}
+}
+
+// Close stops the cat-file processes of all repositories
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for dir, store := range s.stores {
+		store.objects.Close()
+		delete(s.stores, dir)
+	}
+}
+
+// objectStore returns the object store of the repository at dir, the stores of repositories without checks for
+// StoreIdleTimeout are closed
+func (s *Server) objectStore(dir string) *githookkit.ObjectStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for storeDir, store := range s.stores {
+		if now.Sub(store.used) > StoreIdleTimeout {
+			store.objects.Close()
+			delete(s.stores, storeDir)
+		}
+	}
+
+	store, exists := s.stores[dir]
+	if !exists {
+		store = &objectStore{objects: githookkit.NewObjectStore(githookkit.Repository{Dir: dir})}
+		s.stores[dir] = store
+	}
+	store.used = now
+	return store.objects
+}
+
+// ServeHTTP handles POST /check
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/check" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var response Response
+	results, err := s.Check(request)
+	if err != nil {
+		s.logger.Errorf("%s: %v", request.Dir, err)
+		response.Error = err.Error()
+	}
+	response.Results = results
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Check runs the checks of a request in its repository, updates of whitelisted projects are not checked
+// Checks of different repositories and of the same repository run concurrently
+func (s *Server) Check(request Request) ([]check.Result, error) {
+	cfg := *s.cache.Get()
+
+	if !filepath.IsAbs(request.Dir) {
+		return nil, fmt.Errorf("repository %q is not an absolute path", request.Dir)
+	}
+	repo := githookkit.Repository{Dir: request.Dir}
+	for _, variable := range request.Env {
+		name, _, _ := strings.Cut(variable, "=")
+		if slices.Contains(ForwardedEnv, name) {
+			repo.Env = append(repo.Env, variable)
+		}
+	}
+	// A quarantine only lives for one push, its objects are read by processes of their own
+	if len(repo.Env) == 0 {
+		repo.Objects = s.objectStore(request.Dir)
+	} else {
+		repo.Objects = githookkit.NewObjectStore(repo)
+		defer repo.Objects.Close()
+	}
+
+	var checked []check.Update
+	for _, update := range request.Updates {
+		if !config.IsProjectWhitelisted(cfg, update.Project) {
+			update.Repository = repo
+			checked = append(checked, update)
+		}
+	}
+	checkedResults, err := check.CheckBatch(cfg, s.logger, checked)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []check.Result
+	for _, update := range request.Updates {
+		if config.IsProjectWhitelisted(cfg, update.Project) {
+			results = append(results, check.Result{Update: update})
+			continue
+		}
+
+		// The file list goes back with the violations, the client writes it to the audit log and the decision document
+		results = append(results, checkedResults[0])
+		checkedResults = checkedResults[1:]
+	}
+	return results, nil
+}
+
+// Listen opens the listener for address, "unix:<path>" for a unix socket, otherwise a TCP address
+// The requests are not authenticated, a TCP address must be a loopback address so that only local processes connect;
+// access to a unix socket is controlled by the permissions of its directory
+func Listen(address string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		// A socket left behind by a previous daemon would make the listen fail, anything else at path is kept
+		if info, err := os.Lstat(path); err == nil {
+			if info.Mode().Type() != os.ModeSocket {
+				return nil, fmt.Errorf("%s exists and is not a socket", path)
+			}
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("address %s is not a loopback address, the daemon has no access control: listen on a unix socket or on localhost", address)
+	}
+	return net.Listen("tcp", address)
+}
+
+// ErrUnavailable is returned by Forward when the daemon can't be reached
+var ErrUnavailable = errors.New("daemon unavailable")
+
+// Forward sends a request to the daemon at address
+func Forward(address string, request Request) ([]check.Result, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	url := "http://" + address + "/check"
+	transport := &http.Transport{}
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		url = "http://unix/check"
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	}
+	client := &http.Client{Transport: transport, Timeout: Timeout}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned %s", resp.Status)
+	}
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode daemon response: %w", err)
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response.Results, nil
+}
+
+// CheckUpdates checks the updates through the configured daemon, falling back to checking them
+// in this process when no daemon is configured or it can't be reached
+func CheckUpdates(cfg config.Config, logger *config.Logger, updates []check.Update) ([]check.Result, error) {
+	if address := config.GetDaemonAddress(cfg); address != "" {
+		dir, err := repositoryDir()
+		if err != nil {
+			return nil, err
+		}
+
+		results, err := Forward(address, Request{Dir: dir, Env: forwardedEnv(), Updates: updates})
+		if !errors.Is(err, ErrUnavailable) {
+			return results, err
+		}
+		logger.Warnf("%v, checking locally", err)
+	}
+
+	return check.CheckBatch(cfg, logger, updates)
+}
+
+// forwardedEnv returns the variables of ForwardedEnv that are set
+func forwardedEnv() []string {
+	var env []string
+	for _, name := range ForwardedEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// repositoryDir returns the directory the daemon has to run git in, GIT_DIR when the server sets it
+func repositoryDir() (string, error) {
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		return filepath.Abs(gitDir)
+	}
+	return os.Getwd()
+}
@@ -0,0 +1,254 @@
+package daemon
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func newTestLogger(t *testing.T) *config.Logger {
+	t.Helper()
+
+	logger, err := config.InitLogger(config.Config{})
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	return logger
+}
+
+// startServer serves checks with configYAML on a unix socket and returns its address
+func startServer(t *testing.T, configYAML string) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	address := "unix:" + filepath.Join(t.TempDir(), "githookkit.sock")
+	listener, err := Listen(address)
+	if err != nil {
+		t.Fatalf("Listen() returned error: %v", err)
+	}
+
+	server := &http.Server{Handler: NewServer(config.NewCache(configPath), newTestLogger(t))}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return address
+}
+
+func TestForward(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096, "small.txt": 100})
+
+	address := startServer(t, "max_new_files: 1\nprojects_whitelist: [legacy]\n")
+
+	t.Run("Violations are returned", func(t *testing.T) {
+		results, err := Forward(address, Request{Dir: dir, Updates: []check.Update{{Project: "app", RefName: "refs/heads/master", OldRev: oldRev, NewRev: newRev}}})
+		if err != nil {
+			t.Fatalf("Forward() returned error: %v", err)
+		}
+		if len(results) != 1 || !results[0].Rejected() {
+			t.Fatalf("Forward() = %+v, expected one rejected result", results)
+		}
+		if !strings.Contains(results[0].Violations[0], "2 new files") {
+			t.Errorf("violation = %s, expected the new file count", results[0].Violations[0])
+		}
+		// The hooks record the new files of accepted pushes in the audit log
+		if len(results[0].NewFiles) != 2 {
+			t.Errorf("NewFiles = %v, expected the 2 new files", results[0].NewFiles)
+		}
+	})
+
+	t.Run("Whitelisted projects are not checked", func(t *testing.T) {
+		results, err := Forward(address, Request{Dir: dir, Updates: []check.Update{{Project: "legacy", RefName: "refs/heads/master", OldRev: oldRev, NewRev: newRev}}})
+		if err != nil {
+			t.Fatalf("Forward() returned error: %v", err)
+		}
+		if len(results) != 1 || len(results[0].Violations) != 0 {
+			t.Errorf("Forward() = %+v, expected one result without violations", results)
+		}
+	})
+
+	t.Run("Check errors are returned", func(t *testing.T) {
+		_, err := Forward(address, Request{Dir: dir, Updates: []check.Update{{Project: "app", RefName: "refs/heads/master", OldRev: oldRev, NewRev: "missing"}}})
+		if err == nil || errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "refs/heads/master") {
+			t.Errorf("Forward() error = %v, expected the check error", err)
+		}
+	})
+
+	t.Run("Unavailable daemon", func(t *testing.T) {
+		_, err := Forward("unix:"+filepath.Join(t.TempDir(), "missing.sock"), Request{Dir: dir})
+		if !errors.Is(err, ErrUnavailable) {
+			t.Errorf("Forward() error = %v, expected ErrUnavailable", err)
+		}
+	})
+}
+
+func TestCheckUpdatesFallback(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096, "small.txt": 100})
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change to test repository directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	cfg := config.Config{MaxNewFiles: 1, Daemon: "unix:" + filepath.Join(t.TempDir(), "missing.sock")}
+	results, err := CheckUpdates(cfg, newTestLogger(t), []check.Update{{Project: "app", RefName: "refs/heads/master", OldRev: oldRev, NewRev: newRev}})
+	if err != nil {
+		t.Fatalf("CheckUpdates() returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].Rejected() {
+		t.Errorf("CheckUpdates() = %+v, expected one rejected result", results)
+	}
+}
+
+func TestServerCheck(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("max_new_files: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	server := NewServer(config.NewCache(configPath), newTestLogger(t))
+	defer server.Close()
+
+	t.Run("Repositories are checked concurrently", func(t *testing.T) {
+		// One repository has too many new files, the other doesn't
+		type repository struct {
+			request  Request
+			rejected bool
+		}
+		var repositories []repository
+		for _, files := range []map[string]int{{"a.txt": 10, "b.txt": 10}, {"a.txt": 10}} {
+			dir := testutil.InitRepo(t)
+			oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+			newRev := testutil.CommitFiles(t, dir, files)
+			update := check.Update{Project: "app", RefName: "refs/heads/master", OldRev: oldRev, NewRev: newRev}
+			repositories = append(repositories, repository{Request{Dir: dir, Updates: []check.Update{update}}, len(files) > 1})
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			repository := repositories[i%len(repositories)]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results, err := server.Check(repository.request)
+				if err != nil {
+					t.Errorf("Check(%s) returned error: %v", repository.request.Dir, err)
+					return
+				}
+				if len(results) != 1 || results[0].Rejected() != repository.rejected {
+					t.Errorf("Check(%s) = %+v, expected rejected = %v", repository.request.Dir, results, repository.rejected)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Relative repository is rejected", func(t *testing.T) {
+		_, err := server.Check(Request{Dir: "app.git"})
+		if err == nil || !strings.Contains(err.Error(), "not an absolute path") {
+			t.Errorf("Check() error = %v, expected the relative path to be rejected", err)
+		}
+	})
+
+	t.Run("Only the quarantine variables are forwarded", func(t *testing.T) {
+		dir := testutil.InitRepo(t)
+		oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+		newRev := testutil.CommitFiles(t, dir, map[string]int{"a.txt": 10})
+
+		// GIT_DIR would point git at another repository where the commits don't exist
+		request := Request{
+			Dir:     dir,
+			Env:     []string{"GIT_DIR=" + t.TempDir(), "GIT_ALTERNATE_OBJECT_DIRECTORIES=" + filepath.Join(dir, ".git", "objects")},
+			Updates: []check.Update{{Project: "app", RefName: "refs/heads/master", OldRev: oldRev, NewRev: newRev}},
+		}
+		results, err := server.Check(request)
+		if err != nil {
+			t.Fatalf("Check() returned error: %v", err)
+		}
+		if len(results) != 1 || results[0].Rejected() {
+			t.Errorf("Check() = %+v, expected one accepted result", results)
+		}
+	})
+}
+
+func TestListen(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:0", true},
+		{"localhost:0", true},
+		{"[::1]:0", true},
+		{":0", false},
+		{"0.0.0.0:0", false},
+		{"192.0.2.1:0", false},
+		{"githookkit.example.com:0", false},
+	}
+
+	for _, test := range tests {
+		listener, err := Listen(test.address)
+		if err == nil {
+			listener.Close()
+		}
+		if test.allowed && err != nil && !strings.Contains(err.Error(), "cannot assign requested address") {
+			t.Errorf("Listen(%s) returned error: %v", test.address, err)
+		}
+		if !test.allowed && (err == nil || !strings.Contains(err.Error(), "not a loopback address")) {
+			t.Errorf("Listen(%s) error = %v, expected the address to be refused", test.address, err)
+		}
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	// A socket left behind by a previous daemon is replaced
+	address := "unix:" + filepath.Join(dir, "githookkit.sock")
+	stale, err := Listen(address)
+	if err != nil {
+		t.Fatalf("Listen() returned error: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	listener, err := Listen(address)
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket returned error: %v", err)
+	}
+	listener.Close()
+
+	// A misconfigured path doesn't delete the file it names
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("daemon: unix:/run/githookkit.sock\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Listen("unix:" + path); err == nil || !strings.Contains(err.Error(), "is not a socket") {
+		t.Errorf("Listen() error = %v, expected the file to be refused", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Listen() removed the file: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package gerrit reads accounts, their capabilities and groups from the Gerrit REST API and posts reviews to it
+package gerrit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Timeout bounds each REST request so a slow server cannot stall the hook
+const Timeout = 10 * time.Second
+
+// xssiPrefix is prepended by Gerrit to every JSON response
+var xssiPrefix = []byte(")]}'")
+
+// emailInfo is an entry of the response of GET /accounts/{account}/emails
+type emailInfo struct {
+	Email               string `json:"email"`
+	PendingConfirmation bool   `json:"pending_confirmation"`
+}
+
+// AccountEmails returns the confirmed email addresses registered to the Gerrit account of username
+func AccountEmails(cfg config.GerritConfig, username string) ([]string, error) {
+	resp, err := get(cfg, "/accounts/"+url.PathEscape(username)+"/emails")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read emails of %s: %w", username, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading emails of %s returned %s", username, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read emails of %s: %w", username, err)
+	}
+	var infos []emailInfo
+	if err := json.Unmarshal(bytes.TrimPrefix(body, xssiPrefix), &infos); err != nil {
+		return nil, fmt.Errorf("failed to decode emails of %s: %w", username, err)
+	}
+
+	var emails []string
+	for _, info := range infos {
+		if !info.PendingConfirmation {
+			emails = append(emails, info.Email)
+		}
+	}
+	return emails, nil
+}
+
+// AccountExists checks if Gerrit has an active account for account, a username or an email
+func AccountExists(cfg config.GerritConfig, account string) (bool, error) {
+	resp, err := get(cfg, "/accounts/"+url.PathEscape(account))
+	if err != nil {
+		return false, fmt.Errorf("failed to look up account %s: %w", account, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("looking up account %s returned %s", account, resp.Status)
+	}
+}
+
+// AccountCapabilities returns which of the global capabilities Gerrit grants to the account of username
+func AccountCapabilities(cfg config.GerritConfig, username string, capabilities []string) ([]string, error) {
+	// The response only lists the granted capabilities, true or a range like queryLimit
+	var granted map[string]json.RawMessage
+	query := url.Values{"q": capabilities}
+	if err := getJSON(cfg, "/accounts/"+url.PathEscape(username)+"/capabilities?"+query.Encode(), &granted); err != nil {
+		return nil, fmt.Errorf("failed to read capabilities of %s: %w", username, err)
+	}
+
+	var result []string
+	for _, capability := range capabilities {
+		if value, ok := granted[capability]; ok && string(value) != "false" {
+			result = append(result, capability)
+		}
+	}
+	return result, nil
+}
+
+// groupInfo is an entry of the response of GET /accounts/{account}/groups
+type groupInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AccountGroups returns the names and UUIDs of the groups the account of username is a member of
+func AccountGroups(cfg config.GerritConfig, username string) ([]string, error) {
+	var infos []groupInfo
+	if err := getJSON(cfg, "/accounts/"+url.PathEscape(username)+"/groups", &infos); err != nil {
+		return nil, fmt.Errorf("failed to read groups of %s: %w", username, err)
+	}
+
+	var groups []string
+	for _, info := range infos {
+		groups = append(groups, info.Name, info.ID)
+	}
+	return groups, nil
+}
+
+// Review is the input of POST /changes/{change}/revisions/{revision}/review
+type Review struct {
+	Message   string          `json:"message,omitempty"`
+	Labels    map[string]int  `json:"labels,omitempty"`
+	Reviewers []ReviewerInput `json:"reviewers,omitempty"`
+	Tag       string          `json:"tag,omitempty"` // Tags starting with autogenerated: are hidden with the bot comments in the UI
+}
+
+// ReviewerInput adds an account or the members of a group as reviewers
+type ReviewerInput struct {
+	Reviewer  string `json:"reviewer"`
+	Confirmed bool   `json:"confirmed,omitempty"` // Needed to add a group with more members than Gerrit's limit
+}
+
+// PostReview posts review on revision, a commit hash or patch set number, of change
+func PostReview(cfg config.GerritConfig, change, revision string, review Review) error {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+
+	resp, err := request(cfg, http.MethodPost, "/changes/"+url.PathEscape(change)+"/revisions/"+url.PathEscape(revision)+"/review", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post review on %s: %w", change, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("posting review on %s returned %s: %s", change, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// get sends an authenticated GET request to a REST endpoint
+func get(cfg config.GerritConfig, endpoint string) (*http.Response, error) {
+	return request(cfg, http.MethodGet, endpoint, nil)
+}
+
+// getJSON decodes the response of a GET request to a REST endpoint into v
+func getJSON(cfg config.GerritConfig, endpoint string, v interface{}) error {
+	resp, err := get(cfg, endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes.TrimPrefix(body, xssiPrefix), v)
+}
+
+// request sends an authenticated request to a REST endpoint, body is sent as JSON
+func request(cfg config.GerritConfig, method, endpoint string, body io.Reader) (*http.Response, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("gerrit url is not configured")
+	}
+
+	// Authenticated REST endpoints are below /a/
+	req, err := http.NewRequest(method, strings.TrimRight(cfg.URL, "/")+"/a"+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	client := &http.Client{Timeout: Timeout}
+	return client.Do(req)
+}
@@ -0,0 +1,170 @@
+package gerrit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestAccountEmails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "hooks" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/a/accounts/alice/emails":
+			w.Write([]byte(")]}'\n[{\"email\":\"alice@example.com\",\"preferred\":true},{\"email\":\"alice@example.org\",\"pending_confirmation\":true}]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.GerritConfig{URL: server.URL + "/", Username: "hooks", Password: "secret"}
+
+	t.Run("Confirmed emails", func(t *testing.T) {
+		emails, err := AccountEmails(cfg, "alice")
+		if err != nil {
+			t.Fatalf("AccountEmails() returned error: %v", err)
+		}
+		if expected := []string{"alice@example.com"}; !reflect.DeepEqual(emails, expected) {
+			t.Errorf("AccountEmails() = %v, expected %v", emails, expected)
+		}
+	})
+
+	t.Run("Unknown account", func(t *testing.T) {
+		if _, err := AccountEmails(cfg, "bob"); err == nil {
+			t.Errorf("AccountEmails() should return error for an unknown account")
+		}
+	})
+
+	t.Run("Wrong credentials", func(t *testing.T) {
+		if _, err := AccountEmails(config.GerritConfig{URL: server.URL}, "alice"); err == nil {
+			t.Errorf("AccountEmails() should return error without credentials")
+		}
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		if _, err := AccountEmails(config.GerritConfig{}, "alice"); err == nil {
+			t.Errorf("AccountEmails() should return error without url")
+		}
+	})
+}
+
+func TestAccountCapabilitiesAndGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a/accounts/admin/capabilities":
+			if query := r.URL.Query()["q"]; !reflect.DeepEqual(query, []string{"administrateServer", "runAs"}) {
+				t.Errorf("capabilities queried with %v, expected the configured ones", query)
+			}
+			w.Write([]byte(")]}'\n{\"administrateServer\":true,\"queryLimit\":{\"min\":0,\"max\":500}}"))
+		case "/a/accounts/admin/groups":
+			w.Write([]byte(")]}'\n[{\"id\":\"6a1e70e1a88782771a91808c8af9bbb7a9871389\",\"name\":\"Administrators\"}]"))
+		case "/a/accounts/broken/capabilities", "/a/accounts/broken/groups":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.GerritConfig{URL: server.URL}
+	capabilities, err := AccountCapabilities(cfg, "admin", []string{"administrateServer", "runAs"})
+	if err != nil {
+		t.Fatalf("AccountCapabilities() returned error: %v", err)
+	}
+	if expected := []string{"administrateServer"}; !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("AccountCapabilities() = %v, expected %v", capabilities, expected)
+	}
+
+	groups, err := AccountGroups(cfg, "admin")
+	if err != nil {
+		t.Fatalf("AccountGroups() returned error: %v", err)
+	}
+	if expected := []string{"Administrators", "6a1e70e1a88782771a91808c8af9bbb7a9871389"}; !reflect.DeepEqual(groups, expected) {
+		t.Errorf("AccountGroups() = %v, expected %v", groups, expected)
+	}
+
+	if _, err := AccountCapabilities(cfg, "broken", []string{"administrateServer"}); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("AccountCapabilities() = %v, expected the status of the failed request", err)
+	}
+	if _, err := AccountGroups(cfg, "broken"); err == nil {
+		t.Errorf("AccountGroups() should return error for a failed request")
+	}
+}
+
+func TestAccountExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a/accounts/alice", "/a/accounts/alice@example.com":
+			w.Write([]byte(")]}'\n{\"_account_id\":1000}"))
+		case "/a/accounts/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.GerritConfig{URL: server.URL}
+	tests := []struct {
+		account  string
+		expected bool
+		wantErr  bool
+	}{
+		{"alice", true, false},
+		{"alice@example.com", true, false},
+		{"bob", false, false},
+		{"broken", false, true},
+	}
+
+	for _, test := range tests {
+		exists, err := AccountExists(cfg, test.account)
+		if (err != nil) != test.wantErr {
+			t.Errorf("AccountExists(%s) error = %v, wantErr %v", test.account, err, test.wantErr)
+		}
+		if exists != test.expected {
+			t.Errorf("AccountExists(%s) = %v, expected %v", test.account, exists, test.expected)
+		}
+	}
+}
+
+func TestPostReview(t *testing.T) {
+	var received Review
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != "/a/changes/group%2Fapp~master~I0123/revisions/abc/review" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("Not found: " + r.URL.EscapedPath()))
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(")]}'\n{\"labels\":{\"Code-Review\":-1}}"))
+	}))
+	defer server.Close()
+
+	cfg := config.GerritConfig{URL: server.URL}
+	review := Review{Message: "file too large", Labels: map[string]int{"Code-Review": -1}, Tag: "autogenerated:githookkit"}
+	if err := PostReview(cfg, "group/app~master~I0123", "abc", review); err != nil {
+		t.Fatalf("PostReview() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(received, review) {
+		t.Errorf("server received %+v, expected %+v", received, review)
+	}
+
+	if err := PostReview(cfg, "unknown", "abc", review); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("PostReview() error = %v, expected the 404 of the server", err)
+	}
+	if err := PostReview(config.GerritConfig{}, "group/app~master~I0123", "abc", review); err == nil {
+		t.Errorf("PostReview() should return error without url")
+	}
+}
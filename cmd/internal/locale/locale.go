@@ -0,0 +1,67 @@
+// Package locale translates the messages the hooks write to pushers and logs
+// Messages are looked up by their English format string, which is also the fallback when a catalog lacks one
+package locale
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Languages of the messages
+const (
+	English = "en"
+	Chinese = "zh"
+)
+
+// catalogs holds the translations of the English format strings, per language
+var catalogs = map[string]map[string]string{
+	English: nil,
+	Chinese: zh,
+}
+
+// current is the language selected by Set
+var current = English
+
+// Set selects the language of the messages, empty selects English
+func Set(lang string) error {
+	if lang == "" {
+		lang = English
+	}
+	if !Supported(lang) {
+		return fmt.Errorf("unknown language %q, expected one of %v", lang, Languages())
+	}
+	current = lang
+	return nil
+}
+
+// Get returns the selected language
+func Get() string {
+	return current
+}
+
+// Supported reports whether messages can be written in lang
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Languages returns the supported languages, sorted
+func Languages() []string {
+	var langs []string
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns format in the selected language, formatted with args like fmt.Sprintf when there are any
+func T(format string, args ...interface{}) string {
+	if translated, ok := catalogs[current][format]; ok {
+		format = translated
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
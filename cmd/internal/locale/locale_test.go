@@ -0,0 +1,53 @@
+package locale
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	t.Cleanup(func() { current = English })
+
+	if err := Set(Chinese); err != nil || Get() != Chinese {
+		t.Errorf("Set(%q) = %v, selected %q", Chinese, err, Get())
+	}
+	if err := Set("fr"); err == nil || Get() != Chinese {
+		t.Errorf("Set(\"fr\") = %v, expected an error keeping %q", err, Chinese)
+	}
+	if err := Set(""); err != nil || Get() != English {
+		t.Errorf("Set(\"\") = %v, selected %q, expected %q", err, Get(), English)
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { current = English })
+
+	if message := T("REJECTED: %s", "too large"); message != "REJECTED: too large" {
+		t.Errorf("T() in English = %q", message)
+	}
+
+	current = Chinese
+	if message := T("REJECTED: %s", "too large"); message != "已拒绝: too large" {
+		t.Errorf("T() in Chinese = %q", message)
+	}
+	if message := T("not translated %d%%", 5); message != "not translated 5%" {
+		t.Errorf("T() without translation = %q, expected the English message", message)
+	}
+	if message := T("100%"); message != "100%" {
+		t.Errorf("T() without args = %q, expected the message as is", message)
+	}
+}
+
+// Translations must take the arguments of the English message in the same order
+func TestCatalogVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for lang, catalog := range catalogs {
+		for key, translated := range catalog {
+			expected := strings.Join(verbs.FindAllString(key, -1), " ")
+			if got := strings.Join(verbs.FindAllString(translated, -1), " "); got != expected {
+				t.Errorf("%s translation of %q has verbs %q, expected %q", lang, key, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package locale
+
+// zh holds the Chinese messages, keyed by the English format strings
+// Translations keep the verbs of their key in the same order
+var zh = map[string]string{
+	// Hook output
+	"REJECTED: %s":                     "已拒绝: %s",
+	"WARNING: %s":                      "警告: %s",
+	"OVERRIDDEN: %s":                   "已豁免: %s",
+	"HINT: %s":                         "提示: %s",
+	"Override %s granted to %s for %s": "已授予豁免 %s，用户 %s，引用 %s",
+	"Project %s is in warn mode for %s, push allowed":               "项目 %s 在 %s 上处于警告模式，允许推送",
+	"Project %s is in the whitelist, exiting":                       "项目 %s 在白名单中，跳过检查",
+	"Failed to initialize logger: %v":                               "初始化日志失败: %v",
+	"INTERNAL ERROR":                                                "内部错误",
+	"CONFIGURATION ERROR":                                           "配置错误",
+	"%s: %v, on_internal_error is %s, push accepted without checks": "%s: %v，on_internal_error 为 %s，未经检查接受推送",
+	"%s: %v, on_internal_error is %s, push rejected":                "%s: %v，on_internal_error 为 %s，拒绝推送",
+
+	// Emergency bypass
+	"User %q is not allowed to use the emergency bypass, checking the push": "用户 %q 无权使用紧急豁免，继续检查推送",
+	"Emergency bypass requires an audit log, checking the push":             "紧急豁免需要审计日志，继续检查推送",
+	"%v, emergency bypass refused, checking the push":                       "%v，拒绝紧急豁免，继续检查推送",
+	"EMERGENCY BYPASS by %s: %s, all rules skipped for %d ref updates":      "%s 使用紧急豁免: %s，%d 个引用更新跳过所有规则",
+
+	// Admin bypass
+	"%v, admin bypass not granted, checking the push":              "%v，未授予管理员豁免，继续检查推送",
+	"Admin bypass requires an audit log, checking the push":        "管理员豁免需要审计日志，继续检查推送",
+	"ADMIN BYPASS by %s: %s, all rules skipped for %d ref updates": "%s 使用管理员豁免: %s，%d 个引用更新跳过所有规则",
+
+	// Summaries of the violations
+	"%d more lines not shown (%s), full report: %s":                                   "另有 %d 行未显示（%s），完整报告: %s",
+	"githookkit simulate -project %s -refname %s -oldrev %s -newrev %s on the server": "在服务器上运行 githookkit simulate -project %s -refname %s -oldrev %s -newrev %s",
+	"%d offending files, %s in total; worst: %s":                                      "%d 个违规文件，共 %s；最大的是 %s",
+	"by extension: %s": "按扩展名: %s",
+	"by directory: %s": "按目录: %s",
+	"%s %s (%d files)": "%s %s（%d 个文件）",
+	"%d more":          "另有 %d 个",
+	"move the files to git lfs, rewriting the commits that are not pushed yet:": "将这些文件迁移到 git lfs，改写尚未推送的提交:",
+	"or remove them from these commits, keeping the other changes:":             "或者从这些提交中移除它们，保留其他修改:",
+	"then push again": "然后重新推送",
+	"githookkit found problems in this patch set:": "githookkit 在此补丁集中发现了问题:",
+	"githookkit routed this change because of %s":  "githookkit 因 %s 将此变更转给相关人员",
+
+	// Rules
+	"one or more files exceed maximum size of %s, the largest one is %s, use git lfs!":                                       "有文件超过了 %s 的大小上限，最大的文件为 %s，请使用 git lfs！",
+	"%d files are larger than %s, the largest one is %s (%s), files above %s are rejected, consider git lfs":                 "%d 个文件大于 %s，最大的是 %s（%s），超过 %s 的文件将被拒绝，建议使用 git lfs",
+	"push adds %s in %d files, exceeding the maximum push size of %s, split the push or use git lfs!":                        "本次推送新增 %s，共 %d 个文件，超过了 %s 的推送大小上限，请拆分推送或使用 git lfs！",
+	"push adds %d new files, exceeding the maximum of %d, are vendored dependencies or generated files included by mistake?": "本次推送新增 %d 个文件，超过了 %d 个的上限，是否误提交了第三方依赖或生成的文件？",
+	"push adds %s under %s, exceeding its budget of %s, the largest file is %s (%s), split the push or use git lfs!":         "本次推送新增 %s（位于 %s），超过了 %s 的预算，最大的文件为 %s（%s），请拆分推送或使用 git lfs！",
+	"%s is %s, above the advisory size of %s, consider git lfs":                                                              "%s 大小为 %s，超过了建议的 %s，建议使用 git lfs",
+}
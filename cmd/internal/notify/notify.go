@@ -0,0 +1,48 @@
+// Package notify sends post-push notifications to a webhook
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Timeout bounds each webhook request so a slow receiver cannot stall the hook
+const Timeout = 10 * time.Second
+
+// Send POSTs payload as JSON to the configured webhook, doing nothing when no webhook is configured
+func Send(cfg config.NotifyConfig, payload interface{}) error {
+	if cfg.WebhookURL == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cfg.WebhookURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+
+	client := &http.Client{Timeout: Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestSend(t *testing.T) {
+	var received map[string]string
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("No webhook configured", func(t *testing.T) {
+		if err := Send(config.NotifyConfig{}, map[string]string{"project": "p"}); err != nil {
+			t.Errorf("Send() returned error: %v", err)
+		}
+	})
+
+	t.Run("Payload and token are sent", func(t *testing.T) {
+		cfg := config.NotifyConfig{WebhookURL: server.URL + "/hook", Token: "secret"}
+		if err := Send(cfg, map[string]string{"project": "p"}); err != nil {
+			t.Fatalf("Send() returned error: %v", err)
+		}
+		if received["project"] != "p" {
+			t.Errorf("payload = %v, expected project p", received)
+		}
+		if authorization != "Bearer secret" {
+			t.Errorf("Authorization = %q, expected bearer token", authorization)
+		}
+	})
+
+	t.Run("Error status", func(t *testing.T) {
+		cfg := config.NotifyConfig{WebhookURL: server.URL + "/fail"}
+		if err := Send(cfg, map[string]string{"project": "p"}); err == nil {
+			t.Errorf("Send() should return error for a failing webhook")
+		}
+	})
+}
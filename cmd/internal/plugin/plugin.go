@@ -0,0 +1,210 @@
+// Package plugin runs site-local checkers, executables or WebAssembly modules (see HostModule).
+//
+// An executable reads JSON lines from stdin: first the update,
+//
+//	{"type":"update","project":"p","ref_name":"refs/heads/main","old_rev":"...","new_rev":"...","uploader":"...","uploader_username":"...","push_options":[...],"settings":{...}}
+//
+// then one line for each new file, with the base64 encoded content when the plugin asks for it,
+//
+//	{"type":"file","path":"src/main.go","hash":"...","size":123,"content":"...","truncated":false}
+//
+// It writes one JSON line to stdout for each violation and exits with 0, whether it found violations or not:
+//
+//	{"message":"src/main.go calls a forbidden API","path":"src/main.go","warning":false}
+//
+// Warnings are reported without rejecting the push. Any other exit code, invalid output or running
+// longer than the timeout is a failure of the check itself. The executable runs in the repository with
+// an environment holding only PATH, the locale, the git variables of the hook and the configured names.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Environment variables every plugin receives, git needs the GIT_* ones to see quarantined objects
+var baseEnv = []string{
+	"PATH", "LANG", "LC_ALL", "LC_CTYPE", "TZ", "TMPDIR",
+	"GIT_DIR", "GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES", "GIT_QUARANTINE_PATH",
+}
+
+// waitDelay is how long the output of a killed plugin is waited for, children may keep it open
+const waitDelay = time.Second
+
+// Update is the first line a plugin reads
+type Update struct {
+	Type             string            `json:"type"` // Always "update"
+	Project          string            `json:"project"`
+	RefName          string            `json:"ref_name"`
+	OldRev           string            `json:"old_rev"`
+	NewRev           string            `json:"new_rev"`
+	Uploader         string            `json:"uploader,omitempty"`
+	UploaderUsername string            `json:"uploader_username,omitempty"`
+	PushOptions      []string          `json:"push_options,omitempty"`
+	Settings         map[string]string `json:"settings,omitempty"` // The settings of the plugin
+
+	Repository githookkit.Repository `json:"-"` // Repository the plugin runs in
+}
+
+// File is a line a plugin reads for each new file
+type File struct {
+	Type      string `json:"type"` // Always "file"
+	Path      string `json:"path"`
+	Hash      string `json:"hash"`
+	Size      int64  `json:"size"`
+	Content   []byte `json:"content,omitempty"`   // Only when the plugin sets content, base64 in JSON
+	Truncated bool   `json:"truncated,omitempty"` // Content holds the first max_content_size bytes only
+}
+
+// Violation is a line a plugin writes
+type Violation struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+// Run runs a plugin on an update and the new files it introduces, the stderr of the plugin is copied to stderr
+func Run(plugin config.Plugin, update Update, files []githookkit.FileInfo, stderr io.Writer) ([]Violation, error) {
+	timeout := plugin.Timeout
+	if timeout == 0 {
+		timeout = config.DefaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, plugin.Command, plugin.Args...)
+	cmd.Dir = update.Repository.Dir
+	cmd.Env = environment(update.Repository, append(baseEnv, plugin.Env...))
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	// Unlike a pipe from StdoutPipe, WaitDelay also ends the reading when children of a killed plugin keep stdout open
+	stdout, output := io.Pipe()
+	cmd.Stdout = output
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", plugin.Name, err)
+	}
+
+	// The input is written while the output is read, a plugin may report violations before reading everything
+	// A plugin that exits without reading all of it breaks the pipe, which is not an error of its own
+	written := make(chan error, 1)
+	go func() {
+		err := writeInput(stdin, plugin, update, files)
+		stdin.Close()
+		written <- err
+	}()
+
+	var violations []Violation
+	var readErr error
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		if violations, readErr = readViolations(stdout); readErr != nil {
+			stdout.CloseWithError(readErr)
+			cmd.Process.Kill()
+		}
+	}()
+
+	err = cmd.Wait()
+	output.Close()
+	<-read
+	writeErr := <-written
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return nil, fmt.Errorf("plugin %s timed out after %s", plugin.Name, timeout)
+	case readErr != nil:
+		return nil, fmt.Errorf("plugin %s wrote invalid output: %w", plugin.Name, readErr)
+	case err != nil:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("plugin %s exited with code %d", plugin.Name, exitErr.ExitCode())
+		}
+		return nil, fmt.Errorf("failed to run plugin %s: %w", plugin.Name, err)
+	case writeErr != nil && !errors.Is(writeErr, os.ErrClosed) && !errors.Is(writeErr, syscall.EPIPE):
+		return nil, fmt.Errorf("failed to send the update to plugin %s: %w", plugin.Name, writeErr)
+	}
+	return violations, nil
+}
+
+// writeInput writes the update and the files, reading the content of each blob once
+func writeInput(w io.Writer, plugin config.Plugin, update Update, files []githookkit.FileInfo) error {
+	output := bufio.NewWriter(w)
+	encoder := json.NewEncoder(output)
+	update.Type = "update"
+	if err := encoder.Encode(update); err != nil {
+		return err
+	}
+
+	limit := plugin.MaxContentSize
+	if limit == 0 {
+		limit = config.DefaultPluginContentSize
+	}
+
+	if !plugin.Content {
+		for _, file := range files {
+			if err := encoder.Encode(File{Type: "file", Path: file.Path, Hash: file.Hash, Size: file.Size}); err != nil {
+				return err
+			}
+		}
+		return output.Flush()
+	}
+
+	objects, paths := githookkit.GroupBlobs(files, nil)
+	err := update.Repository.ReadBlobs(objects, limit, func(object string, content []byte) error {
+		for _, file := range paths[object] {
+			line := File{Type: "file", Path: file.Path, Hash: file.Hash, Size: file.Size, Content: content, Truncated: file.Size > limit}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return output.Flush()
+}
+
+// readViolations reads the JSON lines a plugin writes
+func readViolations(r io.Reader) ([]Violation, error) {
+	var violations []Violation
+	decoder := json.NewDecoder(r)
+	for {
+		var violation Violation
+		if err := decoder.Decode(&violation); err == io.EOF {
+			return violations, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if violation.Message == "" {
+			return nil, errors.New("violation without message")
+		}
+		violations = append(violations, violation)
+	}
+}
+
+// environment returns the variables of names that are set for git in repo
+func environment(repo githookkit.Repository, names []string) []string {
+	env := []string{} // nil would pass on the whole environment
+	for _, name := range names {
+		if value, ok := repo.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
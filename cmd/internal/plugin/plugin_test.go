@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// writeScript creates an executable shell script in dir
+func writeScript(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+content+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write script %s: %v", name, err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on Windows")
+	}
+
+	repo := testutil.InitRepo(t)
+	testutil.CommitContents(t, repo, map[string]string{"a.txt": "alpha", "b.txt": "alpha", "c.txt": "gamma"}, "Add files")
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to test repository directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+
+	var files []githookkit.FileInfo
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		hash := testutil.Git(t, repo, "rev-parse", "HEAD:"+name)
+		files = append(files, githookkit.FileInfo{Path: name, Hash: hash, Size: 5})
+	}
+	update := Update{Project: "p", RefName: "refs/heads/main", OldRev: "old", NewRev: "new", PushOptions: []string{"skip"}}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+
+	t.Run("Input and violations", func(t *testing.T) {
+		record := writeScript(t, dir, "record.sh", `cat > `+input+`
+echo "checking" >&2
+echo '{"message":"a.txt is bad","path":"a.txt"}'
+echo '{"message":"c.txt is odd","warning":true}'`)
+
+		var stderr bytes.Buffer
+		plugin := config.Plugin{Name: "record", Command: record, Content: true, MaxContentSize: 3}
+		violations, err := Run(plugin, update, files, &stderr)
+		if err != nil {
+			t.Fatalf("Run() returned error: %v", err)
+		}
+		expected := []Violation{{Message: "a.txt is bad", Path: "a.txt"}, {Message: "c.txt is odd", Warning: true}}
+		if len(violations) != 2 || violations[0] != expected[0] || violations[1] != expected[1] {
+			t.Errorf("Run() = %v, expected %v", violations, expected)
+		}
+		if stderr.String() != "checking\n" {
+			t.Errorf("stderr = %q, expected %q", stderr.String(), "checking\n")
+		}
+
+		content, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatalf("Failed to read the plugin input: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("plugin read %d lines, expected 4:\n%s", len(lines), content)
+		}
+		var header Update
+		if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header.Type != "update" || header.Project != "p" || header.PushOptions[0] != "skip" {
+			t.Errorf("unexpected update line %s", lines[0])
+		}
+		var file File
+		if err := json.Unmarshal([]byte(lines[2]), &file); err != nil || file.Type != "file" || file.Path != "b.txt" || string(file.Content) != "alp" || !file.Truncated {
+			t.Errorf("unexpected file line %s", lines[2])
+		}
+	})
+
+	t.Run("Without content", func(t *testing.T) {
+		record := writeScript(t, dir, "paths.sh", `cat > `+input)
+
+		violations, err := Run(config.Plugin{Name: "paths", Command: record}, update, files[:1], nil)
+		if err != nil || len(violations) != 0 {
+			t.Fatalf("Run() = %v, %v, expected no violations", violations, err)
+		}
+		content, _ := os.ReadFile(input)
+		if strings.Contains(string(content), "content") || !strings.Contains(string(content), `"path":"a.txt"`) {
+			t.Errorf("unexpected plugin input:\n%s", content)
+		}
+	})
+
+	t.Run("Environment is cleared", func(t *testing.T) {
+		t.Setenv("PLUGIN_SECRET", "secret")
+		t.Setenv("PLUGIN_TOKEN", "token")
+		env := writeScript(t, dir, "env.sh", `echo "{\"message\":\"$PLUGIN_SECRET/$PLUGIN_TOKEN\"}"`)
+
+		violations, err := Run(config.Plugin{Name: "env", Command: env, Env: []string{"PLUGIN_TOKEN"}}, update, nil, nil)
+		if err != nil {
+			t.Fatalf("Run() returned error: %v", err)
+		}
+		if len(violations) != 1 || violations[0].Message != "/token" {
+			t.Errorf("Run() = %v, expected only PLUGIN_TOKEN to be set", violations)
+		}
+	})
+
+	t.Run("Plugin not reading its input", func(t *testing.T) {
+		quiet := writeScript(t, dir, "quiet.sh", `exit 0`)
+		many := make([]githookkit.FileInfo, 100000)
+		for i := range many {
+			many[i] = githookkit.FileInfo{Path: strings.Repeat("x", 100), Hash: files[0].Hash}
+		}
+
+		if _, err := Run(config.Plugin{Name: "quiet", Command: quiet}, update, many, nil); err != nil {
+			t.Errorf("Run() returned error: %v", err)
+		}
+	})
+
+	failures := []struct {
+		name     string
+		script   string
+		timeout  time.Duration
+		expected string
+	}{
+		{"Exit code", `echo '{"message":"x"}'; exit 2`, 0, "plugin failure exited with code 2"},
+		{"Invalid output", `echo 'not json'`, 0, "plugin failure wrote invalid output"},
+		{"Violation without message", `echo '{"path":"a.txt"}'`, 0, "plugin failure wrote invalid output: violation without message"},
+		{"Timeout", `sleep 10`, 100 * time.Millisecond, "plugin failure timed out after 100ms"},
+	}
+	for _, test := range failures {
+		t.Run(test.name, func(t *testing.T) {
+			failure := writeScript(t, dir, "failure.sh", test.script)
+
+			_, err := Run(config.Plugin{Name: "failure", Command: failure, Timeout: test.timeout}, update, files, nil)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Run() error = %v, expected %q", err, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Command guest is a WebAssembly plugin used by the tests, build it with GOOS=wasip1 GOARCH=wasm
+// It reports the new files containing TODO, the mode setting selects misbehaviors instead
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+//go:wasmimport githookkit context
+func hostContext(ptr unsafe.Pointer, capacity uint32) uint32
+
+//go:wasmimport githookkit file_count
+func hostFileCount() uint32
+
+//go:wasmimport githookkit file
+func hostFile(index uint32, ptr unsafe.Pointer, capacity uint32) uint32
+
+//go:wasmimport githookkit read
+func hostRead(index uint32, offset uint64, ptr unsafe.Pointer, capacity uint32) int32
+
+//go:wasmimport githookkit report
+func hostReport(message unsafe.Pointer, messageLen uint32, path unsafe.Pointer, pathLen uint32, warning uint32)
+
+// call calls a host function writing JSON into a buffer, growing the buffer until the value fits
+func call(fn func(ptr unsafe.Pointer, capacity uint32) uint32, value any) {
+	buffer := make([]byte, 16)
+	for {
+		n := fn(unsafe.Pointer(&buffer[0]), uint32(len(buffer)))
+		if int(n) <= len(buffer) {
+			if err := json.Unmarshal(buffer[:n], value); err != nil {
+				panic(err)
+			}
+			return
+		}
+		buffer = make([]byte, n)
+	}
+}
+
+func report(message, path string, warning bool) {
+	m := []byte(message)
+	p := []byte(path + "\x00")
+	var w uint32
+	if warning {
+		w = 1
+	}
+	hostReport(unsafe.Pointer(&m[0]), uint32(len(m)), unsafe.Pointer(&p[0]), uint32(len(path)), w)
+}
+
+func main() {
+	var update struct {
+		Project  string            `json:"project"`
+		Settings map[string]string `json:"settings"`
+	}
+	call(hostContext, &update)
+
+	switch update.Settings["mode"] {
+	case "exit":
+		os.Exit(3)
+	case "loop":
+		for {
+		}
+	case "escape":
+		_, err := os.ReadFile("/etc/hostname")
+		report(fmt.Sprintf("read /etc/hostname: %v", err), "", false)
+		report("HOME="+os.Getenv("HOME"), "", false)
+		return
+	}
+
+	fmt.Println("checking", update.Project)
+	for i := uint32(0); i < hostFileCount(); i++ {
+		var file struct {
+			Path      string `json:"path"`
+			Truncated bool   `json:"truncated"`
+		}
+		call(func(ptr unsafe.Pointer, capacity uint32) uint32 { return hostFile(i, ptr, capacity) }, &file)
+
+		var content []byte
+		chunk := make([]byte, 4)
+		for {
+			n := hostRead(i, uint64(len(content)), unsafe.Pointer(&chunk[0]), uint32(len(chunk)))
+			if n <= 0 {
+				break
+			}
+			content = append(content, chunk[:n]...)
+		}
+		if bytes.Contains(content, []byte(update.Settings["word"])) {
+			report(fmt.Sprintf("%s contains %s", file.Path, update.Settings["word"]), file.Path, file.Truncated)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package plugin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// HostModule is the name WebAssembly plugins import the host API from.
+//
+// A module is a WASI command: its _start function (main) runs the checks and returns or calls proc_exit(0),
+// any other exit code is a failure of the check itself. It gets no files, environment or network, only:
+//
+//	context(ptr, cap u32) u32                    writes the update line, as JSON with the settings, returns its length
+//	file_count() u32                             returns the number of new files
+//	file(index, ptr, cap u32) u32                writes a file line, as JSON without content, returns its length
+//	read(index u32, offset u64, ptr, cap u32) i32  copies content of a file from offset, returns the bytes copied, 0 at the end
+//	report(msg, msg_len, path, path_len, warning u32)  reports a violation, path_len 0 for none
+//
+// Functions returning a length write nothing when it exceeds cap, the module calls them again with a larger buffer.
+// Content is limited to max_content_size bytes. stdout and stderr of the module are logged.
+const HostModule = "githookkit"
+
+// wasmPageSize is the size of a WebAssembly memory page
+const wasmPageSize = 64 * 1024
+
+// host holds what a running module can reach through the host API
+type host struct {
+	repo       githookkit.Repository
+	update     []byte
+	files      []File
+	limit      int64
+	violations []Violation
+
+	// The content of the file read last, modules usually read one file after the other
+	current int
+	content []byte
+}
+
+// RunModule runs a WebAssembly plugin on an update and the new files it introduces, cacheDir keeps compiled modules
+// when it is set. The output of the module is copied to stderr.
+func RunModule(plugin config.Plugin, update Update, files []githookkit.FileInfo, cacheDir string, stderr io.Writer) ([]Violation, error) {
+	timeout := plugin.Timeout
+	if timeout == 0 {
+		timeout = config.DefaultPluginTimeout
+	}
+	memory := plugin.MaxMemory
+	if memory == 0 {
+		memory = config.DefaultPluginMemory
+	}
+	limit := plugin.MaxContentSize
+	if limit == 0 {
+		limit = config.DefaultPluginContentSize
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	binary, err := os.ReadFile(plugin.Module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin %s: %w", plugin.Name, err)
+	}
+
+	update.Type = "update"
+	state := &host{repo: update.Repository, files: make([]File, len(files)), limit: limit, current: -1}
+	if state.update, err = json.Marshal(update); err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		state.files[i] = File{Type: "file", Path: file.Path, Hash: file.Hash, Size: file.Size, Truncated: file.Size > limit}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32(min(memory/wasmPageSize, 65536)))
+	if cacheDir != "" {
+		cache, err := wazero.NewCompilationCacheWithDir(cacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open plugin cache %s: %w", cacheDir, err)
+		}
+		defer cache.Close(ctx)
+		runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	defer runtime.Close(ctx)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return nil, err
+	}
+	if _, err := state.instantiate(ctx, runtime); err != nil {
+		return nil, err
+	}
+
+	compiled, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile plugin %s: %w", plugin.Name, err)
+	}
+	moduleConfig := wazero.NewModuleConfig().
+		WithName(plugin.Name).
+		WithArgs(plugin.Name).
+		WithStdout(stderr).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+	_, err = runtime.InstantiateModule(ctx, compiled, moduleConfig)
+
+	var exitErr *sys.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return nil, fmt.Errorf("plugin %s timed out after %s", plugin.Name, timeout)
+	case errors.As(err, &exitErr):
+		return nil, fmt.Errorf("plugin %s exited with code %d", plugin.Name, exitErr.ExitCode())
+	case err != nil:
+		return nil, fmt.Errorf("plugin %s failed: %w", plugin.Name, err)
+	}
+	return state.violations, nil
+}
+
+// instantiate exports the host API to the runtime
+func (h *host) instantiate(ctx context.Context, runtime wazero.Runtime) (api.Module, error) {
+	return runtime.NewHostModuleBuilder(HostModule).
+		NewFunctionBuilder().WithFunc(h.context).Export("context").
+		NewFunctionBuilder().WithFunc(h.fileCount).Export("file_count").
+		NewFunctionBuilder().WithFunc(h.file).Export("file").
+		NewFunctionBuilder().WithFunc(h.read).Export("read").
+		NewFunctionBuilder().WithFunc(h.report).Export("report").
+		Instantiate(ctx)
+}
+
+func (h *host) context(_ context.Context, m api.Module, ptr, capacity uint32) uint32 {
+	return writeValue(m, ptr, capacity, h.update)
+}
+
+func (h *host) fileCount(context.Context) uint32 {
+	return uint32(len(h.files))
+}
+
+func (h *host) file(_ context.Context, m api.Module, index, ptr, capacity uint32) uint32 {
+	if int(index) >= len(h.files) {
+		panic(fmt.Errorf("file index %d out of range", index))
+	}
+	line, err := json.Marshal(h.files[index])
+	if err != nil {
+		panic(err)
+	}
+	return writeValue(m, ptr, capacity, line)
+}
+
+func (h *host) read(_ context.Context, m api.Module, index uint32, offset uint64, ptr, capacity uint32) int32 {
+	if int(index) >= len(h.files) {
+		return -1
+	}
+	if h.files[index].Hash == "" {
+		return 0
+	}
+	if h.current != int(index) {
+		h.content = nil
+		err := h.repo.ReadBlobs([]string{h.files[index].Hash}, h.limit, func(_ string, content []byte) error {
+			h.content = content
+			return nil
+		})
+		if err != nil {
+			panic(fmt.Errorf("failed to read %s: %w", h.files[index].Path, err))
+		}
+		h.current = int(index)
+	}
+	if offset >= uint64(len(h.content)) {
+		return 0
+	}
+	chunk := h.content[offset:]
+	chunk = chunk[:min(len(chunk), int(capacity), 1<<30)]
+	if !m.Memory().Write(ptr, chunk) {
+		panic(fmt.Errorf("buffer at %d out of memory", ptr))
+	}
+	return int32(len(chunk))
+}
+
+func (h *host) report(_ context.Context, m api.Module, message, messageLen, path, pathLen, warning uint32) {
+	violation := Violation{Message: readString(m, message, messageLen), Warning: warning != 0}
+	if pathLen > 0 {
+		violation.Path = readString(m, path, pathLen)
+	}
+	if violation.Message == "" {
+		panic(errors.New("violation without message"))
+	}
+	h.violations = append(h.violations, violation)
+}
+
+// writeValue copies value into the memory of the module when it fits into capacity and returns its length
+func writeValue(m api.Module, ptr, capacity uint32, value []byte) uint32 {
+	if len(value) <= int(capacity) && !m.Memory().Write(ptr, value) {
+		panic(fmt.Errorf("buffer at %d out of memory", ptr))
+	}
+	return uint32(len(value))
+}
+
+// readString copies a string out of the memory of the module
+func readString(m api.Module, ptr, length uint32) string {
+	value, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		panic(fmt.Errorf("string at %d out of memory", ptr))
+	}
+	return string(value)
+}
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// buildGuest compiles testdata/guest to WebAssembly
+func buildGuest(t *testing.T) string {
+	t.Helper()
+
+	module := filepath.Join(t.TempDir(), "guest.wasm")
+	cmd := exec.Command("go", "build", "-o", module, ".")
+	cmd.Dir = filepath.Join("testdata", "guest")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to compile the guest module: %v\n%s", err, output)
+	}
+	return module
+}
+
+func TestRunModule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling a WebAssembly module is slow")
+	}
+	module := buildGuest(t)
+	cache := t.TempDir()
+
+	repo := testutil.InitRepo(t)
+	testutil.CommitContents(t, repo, map[string]string{"a.go": "// TODO: fix", "b.go": "package b", "c.go": "package c // TODO"}, "Add files")
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to test repository directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+
+	var files []githookkit.FileInfo
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		hash := testutil.Git(t, repo, "rev-parse", "HEAD:"+name)
+		files = append(files, githookkit.FileInfo{Path: name, Hash: hash, Size: int64(len(testutil.Git(t, repo, "cat-file", "-p", hash)))})
+	}
+	update := Update{Project: "p", RefName: "refs/heads/main"}
+
+	t.Run("Files and violations", func(t *testing.T) {
+		var stderr strings.Builder
+		plugin := config.Plugin{Name: "todo", Module: module, Settings: map[string]string{"word": "TODO"}, MaxContentSize: 14}
+		update := update
+		update.Settings = plugin.Settings
+		violations, err := RunModule(plugin, update, files, cache, &stderr)
+		if err != nil {
+			t.Fatalf("RunModule() returned error: %v", err)
+		}
+		// c.go is truncated before its TODO, a.go is reported from its first 14 bytes
+		expected := []Violation{{Message: "a.go contains TODO", Path: "a.go"}}
+		if len(violations) != 1 || violations[0] != expected[0] {
+			t.Errorf("RunModule() = %v, expected %v", violations, expected)
+		}
+		if stderr.String() != "checking p\n" {
+			t.Errorf("output = %q, expected %q", stderr.String(), "checking p\n")
+		}
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+		t.Setenv("HOME", "/root")
+		plugin := config.Plugin{Name: "escape", Module: module}
+		update := update
+		update.Settings = map[string]string{"mode": "escape"}
+		violations, err := RunModule(plugin, update, nil, cache, nil)
+		if err != nil {
+			t.Fatalf("RunModule() returned error: %v", err)
+		}
+		if len(violations) != 2 || !strings.HasPrefix(violations[0].Message, "read /etc/hostname: open /etc/hostname:") || violations[1].Message != "HOME=" {
+			t.Errorf("RunModule() = %v, expected the module to see no files and no environment", violations)
+		}
+	})
+
+	failures := []struct {
+		name     string
+		mode     string
+		timeout  time.Duration
+		expected string
+	}{
+		{"Exit code", "exit", 0, "plugin failure exited with code 3"},
+		{"Timeout", "loop", 500 * time.Millisecond, "plugin failure timed out after 500ms"},
+	}
+	for _, test := range failures {
+		t.Run(test.name, func(t *testing.T) {
+			plugin := config.Plugin{Name: "failure", Module: module, Timeout: test.timeout}
+			update := update
+			update.Settings = map[string]string{"mode": test.mode}
+			_, err := RunModule(plugin, update, files, cache, nil)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("RunModule() error = %v, expected %q", err, test.expected)
+			}
+		})
+	}
+
+	t.Run("Invalid module", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.wasm")
+		if err := os.WriteFile(invalid, []byte("not wasm"), 0644); err != nil {
+			t.Fatalf("Failed to write module: %v", err)
+		}
+		_, err := RunModule(config.Plugin{Name: "invalid", Module: invalid}, update, nil, "", nil)
+		if err == nil || !strings.Contains(err.Error(), "failed to compile plugin invalid") {
+			t.Errorf("RunModule() error = %v, expected a compile error", err)
+		}
+	})
+}
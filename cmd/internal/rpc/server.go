@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements PolicyService with the same checks the hooks run
+type Server struct {
+	UnimplementedPolicyServiceServer
+	checker  *daemon.Server
+	basePath string
+}
+
+// NewServer creates a policy service answering through checker for the repositories under basePath
+func NewServer(checker *daemon.Server, basePath string) *Server {
+	return &Server{checker: checker, basePath: basePath}
+}
+
+// repositoryDir returns the directory of the repository named name, <base path>/<name>.git like Gerrit lays them out
+// The callers are not authenticated, names leading outside of the base path are refused
+func (s *Server) repositoryDir(name string) (string, error) {
+	name = strings.TrimSuffix(name, ".git")
+	if !filepath.IsLocal(name) {
+		return "", status.Errorf(codes.InvalidArgument, "repository %q is not a name under the base path", name)
+	}
+
+	dir := filepath.Join(s.basePath, name+".git")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", status.Errorf(codes.NotFound, "repository %q not found", name)
+	}
+	return dir, nil
+}
+
+// CheckPush evaluates the ref updates of a push, a failing check is reported as an Internal error
+func (s *Server) CheckPush(ctx context.Context, req *PushCheckRequest) (*PushCheckResponse, error) {
+	if req.GetRepository() == "" {
+		return nil, status.Error(codes.InvalidArgument, "repository is required")
+	}
+
+	dir, err := s.repositoryDir(req.GetRepository())
+	if err != nil {
+		return nil, err
+	}
+
+	request := daemon.Request{Dir: dir}
+	for _, update := range req.GetUpdates() {
+		request.Updates = append(request.Updates, check.Update{
+			Project:          req.GetProject(),
+			UploaderUsername: req.GetUploaderUsername(),
+			RefName:          update.GetRefName(),
+			OldRev:           update.GetOldRev(),
+			NewRev:           update.GetNewRev(),
+		})
+	}
+
+	results, err := s.checker.Check(request)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &PushCheckResponse{}
+	for _, result := range results {
+		rejected := result.Rejected()
+		response.Rejected = response.Rejected || rejected
+		for _, violation := range result.Violations {
+			response.Violations = append(response.Violations, &Violation{
+				RefName: result.Update.RefName,
+				Message: violation,
+				Warning: !rejected,
+			})
+		}
+		for _, warning := range result.Warnings {
+			response.Violations = append(response.Violations, &Violation{
+				RefName: result.Update.RefName,
+				Message: warning,
+				Warning: true,
+			})
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckPush(t *testing.T) {
+	t.Setenv("GITHOOK_FILE_SIZE_MAX", "")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"a.bin": 100, "b.bin": 100})
+
+	// The service checks the repositories under its base path
+	basePath := t.TempDir()
+	testutil.Git(t, dir, "clone", "-q", "--bare", dir, filepath.Join(basePath, "platform", "app.git"))
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := "max_new_files: 1\nprojects:\n  trial:\n    mode: warn\n"
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	logger, err := config.InitLogger(config.Config{})
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "policy.sock")
+	listener, err := daemon.Listen("unix:" + socket)
+	if err != nil {
+		t.Fatalf("Listen() returned error: %v", err)
+	}
+	server := grpc.NewServer()
+	RegisterPolicyServiceServer(server, NewServer(daemon.NewServer(config.NewCache(configPath), logger), basePath))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("unix:"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := NewPolicyServiceClient(conn)
+
+	updates := []*RefUpdate{{RefName: "refs/heads/master", OldRev: oldRev, NewRev: newRev}}
+
+	tests := []struct {
+		name       string
+		project    string
+		rejected   bool
+		violations int
+		warning    bool
+	}{
+		{"Enforced project", "app", true, 1, false},
+		{"Warn mode project", "trial", false, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := client.CheckPush(context.Background(), &PushCheckRequest{Repository: "platform/app", Project: test.project, Updates: updates})
+			if err != nil {
+				t.Fatalf("CheckPush() returned error: %v", err)
+			}
+			if response.GetRejected() != test.rejected {
+				t.Errorf("Rejected = %v, expected %v", response.GetRejected(), test.rejected)
+			}
+			if len(response.GetViolations()) != test.violations {
+				t.Fatalf("got %d violations, expected %d", len(response.GetViolations()), test.violations)
+			}
+			violation := response.GetViolations()[0]
+			if violation.GetRefName() != "refs/heads/master" || violation.GetWarning() != test.warning {
+				t.Errorf("violation = %v, expected ref refs/heads/master and warning %v", violation, test.warning)
+			}
+		})
+	}
+
+	t.Run("Missing repository", func(t *testing.T) {
+		_, err := client.CheckPush(context.Background(), &PushCheckRequest{Project: "app", Updates: updates})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("CheckPush() error = %v, expected InvalidArgument", err)
+		}
+	})
+
+	t.Run("Repository outside of the base path", func(t *testing.T) {
+		for _, repository := range []string{dir, "../" + filepath.Base(dir), "platform/../../app"} {
+			_, err := client.CheckPush(context.Background(), &PushCheckRequest{Repository: repository, Project: "app", Updates: updates})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("CheckPush(%s) error = %v, expected InvalidArgument", repository, err)
+			}
+		}
+	})
+
+	t.Run("Unknown repository", func(t *testing.T) {
+		_, err := client.CheckPush(context.Background(), &PushCheckRequest{Repository: "platform/missing", Project: "app", Updates: updates})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("CheckPush() error = %v, expected NotFound", err)
+		}
+	})
+
+	t.Run("Check failure", func(t *testing.T) {
+		_, err := client.CheckPush(context.Background(), &PushCheckRequest{Repository: "platform/app", Project: "app", Updates: []*RefUpdate{{RefName: "refs/heads/master", OldRev: oldRev, NewRev: "missing"}}})
+		if status.Code(err) != codes.Internal {
+			t.Errorf("CheckPush() error = %v, expected Internal", err)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package testutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// EICAR is the standard antivirus test string, the fake clamd detects it
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// FakeClamd serves the clamd INSTREAM command on a unix socket and returns its address, "unix:<path>"
+// It detects EICAR as Eicar-Test-Signature and fails streams larger than maxStream bytes like clamd does
+func FakeClamd(t *testing.T, maxStream int) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "clamd.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxStream)
+		}
+	}()
+	return "unix:" + path
+}
+
+func serveClamd(conn net.Conn, maxStream int) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	if command, err := reader.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+	var content []byte
+	for {
+		var length uint32
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return
+		}
+		if length == 0 {
+			break
+		}
+		if len(content)+int(length) > maxStream {
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			return
+		}
+		chunk := make([]byte, length)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return
+		}
+		content = append(content, chunk...)
+	}
+
+	if bytes.Contains(content, []byte(EICAR)) {
+		conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		return
+	}
+	conn.Write([]byte("stream: OK\x00"))
+}
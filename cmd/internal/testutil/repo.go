@@ -0,0 +1,125 @@
+// Package testutil provides helpers for tests that need a real git repository
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// DefaultBranch is the branch checked out in the repositories of InitRepo, whatever init.defaultBranch says
+const DefaultBranch = "master"
+
+// InitRepo creates an empty git repository in a temporary directory, on DefaultBranch
+func InitRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	Git(t, dir, "init", "-q", "-b", DefaultBranch)
+	Git(t, dir, "config", "user.name", "Test User")
+	Git(t, dir, "config", "user.email", "test@example.com")
+	return dir
+}
+
+// Git runs a git command in dir and returns its trimmed output
+func Git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// CommitFiles writes files filled with repeated bytes of the given sizes and commits them, returning the new commit hash
+func CommitFiles(t *testing.T, dir string, files map[string]int) string {
+	t.Helper()
+
+	contents := make(map[string]string, len(files))
+	for name, size := range files {
+		contents[name] = string(bytes.Repeat([]byte(name[:1]), size))
+	}
+	return CommitContents(t, dir, contents, fmt.Sprintf("add %d files", len(files)))
+}
+
+// CommitContents writes files with the given contents and commits them with message, returning the new commit hash
+func CommitContents(t *testing.T, dir string, contents map[string]string, message string) string {
+	t.Helper()
+
+	for name, content := range contents {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	Git(t, dir, "add", "-A")
+	Git(t, dir, "commit", "-q", "--allow-empty", "-m", message)
+	return Git(t, dir, "rev-parse", "HEAD")
+}
+
+// BuildBinary compiles the main package in sourceDir into a temporary directory
+func BuildBinary(t *testing.T, sourceDir, name string) string {
+	t.Helper()
+
+	if os.PathSeparator == '\\' {
+		name += ".exe"
+	}
+	execPath := filepath.Join(t.TempDir(), name)
+
+	cmd := exec.Command("go", "build", "-o", execPath)
+	cmd.Dir = sourceDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to compile %s: %v\n%s", name, err, output)
+	}
+	return execPath
+}
+
+// RunBinary runs execPath inside dir with a fresh HOME holding configYAML and returns its combined output
+func RunBinary(t *testing.T, execPath, dir, configYAML string, env []string, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var output bytes.Buffer
+	err := runBinary(t, execPath, dir, configYAML, env, stdin, &output, &output, args...)
+	return output.String(), err
+}
+
+// RunBinaryStreams is RunBinary returning stdout and stderr separately, for the output formats written to stdout
+func RunBinaryStreams(t *testing.T, execPath, dir, configYAML string, env []string, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	err := runBinary(t, execPath, dir, configYAML, env, stdin, &stdout, &stderr, args...)
+	return stdout.String(), stderr.String(), err
+}
+
+func runBinary(t *testing.T, execPath, dir, configYAML string, env []string, stdin string, stdout, stderr io.Writer, args ...string) error {
+	t.Helper()
+
+	homeDir := t.TempDir()
+	if configYAML != "" {
+		if err := os.WriteFile(filepath.Join(homeDir, ".githook_config"), []byte(configYAML), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	cmd := exec.Command(execPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), fmt.Sprintf("HOME=%s", homeDir), fmt.Sprintf("USERPROFILE=%s", homeDir))
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
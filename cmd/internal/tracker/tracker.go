@@ -0,0 +1,231 @@
+// Package tracker looks up issues in JIRA or Redmine through their REST APIs
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Issue states
+const (
+	StateOpen    = "open"
+	StateClosed  = "closed"
+	StateMissing = "missing" // The issue doesn't exist or isn't visible to the configured account
+)
+
+// cacheEntry is the result of a lookup
+type cacheEntry struct {
+	State string    `json:"state"`
+	Time  time.Time `json:"time"`
+}
+
+// Client looks up issues and caches their states, it is not safe for concurrent use
+type Client struct {
+	cfg     config.IssueTracker
+	http    *http.Client
+	entries map[string]cacheEntry // Issue key to the last lookup, nil until the cache file is loaded
+}
+
+// New returns a client for the configured tracker
+func New(cfg config.IssueTracker) *Client {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = config.DefaultTrackerTimeout
+	}
+	return &Client{cfg: cfg, http: &http.Client{Timeout: timeout}}
+}
+
+// State returns the state of the issue with key, lookups younger than the cache TTL are reused
+func (c *Client) State(key string) (string, error) {
+	c.load()
+
+	if entry, ok := c.entries[key]; ok && time.Since(entry.Time) < c.cacheTTL() {
+		return entry.State, nil
+	}
+
+	var state string
+	var err error
+	switch c.cfg.Type {
+	case config.TrackerJira:
+		state, err = c.jiraState(key)
+	case config.TrackerRedmine:
+		state, err = c.redmineState(key)
+	default:
+		return "", fmt.Errorf("unknown issue tracker type %q", c.cfg.Type)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	c.entries[key] = cacheEntry{State: state, Time: time.Now()}
+	// The cache only saves lookups, failing to write it must not fail the push
+	c.save()
+	return state, nil
+}
+
+// jiraState looks up an issue with GET /rest/api/2/issue/{key}, issues in the done status category are closed
+func (c *Client) jiraState(key string) (string, error) {
+	var issue struct {
+		Fields struct {
+			Status struct {
+				Name           string `json:"name"`
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	found, err := c.get("/rest/api/2/issue/"+url.PathEscape(key)+"?fields=status", &issue)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return StateMissing, nil
+	}
+
+	status := issue.Fields.Status
+	return c.state(status.Name, status.StatusCategory.Key == "done"), nil
+}
+
+// redmineState looks up an issue with GET /issues/{id}.json, the key is the issue number with or without #
+func (c *Client) redmineState(key string) (string, error) {
+	var issue struct {
+		Issue struct {
+			Status struct {
+				Name     string `json:"name"`
+				IsClosed bool   `json:"is_closed"` // Redmine 5.1 and newer
+			} `json:"status"`
+		} `json:"issue"`
+	}
+	found, err := c.get("/issues/"+url.PathEscape(strings.TrimPrefix(key, "#"))+".json", &issue)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return StateMissing, nil
+	}
+
+	status := issue.Issue.Status
+	return c.state(status.Name, status.IsClosed), nil
+}
+
+// state classifies a status, closed_statuses extend what the tracker itself considers closed
+func (c *Client) state(status string, closed bool) string {
+	for _, name := range c.cfg.ClosedStatuses {
+		if strings.EqualFold(name, status) {
+			closed = true
+		}
+	}
+	if closed {
+		return StateClosed
+	}
+	return StateOpen
+}
+
+// get decodes the JSON response of a GET request to path, found is false when the tracker answers 404
+func (c *Client) get(path string, response interface{}) (found bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(c.cfg.URL, "/")+path, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create issue request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.cfg.Token != "" && c.cfg.Type == config.TrackerRedmine:
+		req.Header.Set("X-Redmine-API-Key", c.cfg.Token)
+	case c.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	case c.cfg.Username != "":
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to query issue tracker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("issue tracker returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return false, fmt.Errorf("failed to decode issue: %w", err)
+	}
+	return true, nil
+}
+
+// cacheTTL returns how long a lookup is reused
+func (c *Client) cacheTTL() time.Duration {
+	if c.cfg.CacheTTL == 0 {
+		return config.DefaultTrackerCacheTTL
+	}
+	return c.cfg.CacheTTL
+}
+
+// load reads the cache file once, an unreadable cache starts empty
+func (c *Client) load() {
+	if c.entries != nil {
+		return
+	}
+	c.entries = map[string]cacheEntry{}
+	if c.cfg.CachePath == "" {
+		return
+	}
+
+	data, err := os.ReadFile(c.cfg.CachePath)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = map[string]cacheEntry{}
+	}
+}
+
+// save writes the cache file through a temporary file so that concurrent hooks never read a partial file
+// Expired lookups are dropped so that the file doesn't grow with every issue ever looked up
+func (c *Client) save() error {
+	if c.cfg.CachePath == "" {
+		return nil
+	}
+
+	ttl := c.cacheTTL()
+	for key, entry := range c.entries {
+		if time.Since(entry.Time) >= ttl {
+			delete(c.entries, key)
+		}
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.cfg.CachePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(c.cfg.CachePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.cfg.CachePath)
+}
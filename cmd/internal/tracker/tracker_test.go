@@ -0,0 +1,127 @@
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+func TestState(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/rest/api/2/issue/PROJ-1":
+			w.Write([]byte(`{"fields":{"status":{"name":"In Progress","statusCategory":{"key":"indeterminate"}}}}`))
+		case "/rest/api/2/issue/PROJ-2":
+			w.Write([]byte(`{"fields":{"status":{"name":"Done","statusCategory":{"key":"done"}}}}`))
+		case "/rest/api/2/issue/PROJ-3":
+			w.Write([]byte(`{"fields":{"status":{"name":"Won't Fix","statusCategory":{"key":"indeterminate"}}}}`))
+		case "/issues/7.json":
+			if r.Header.Get("X-Redmine-API-Key") != "key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"issue":{"status":{"name":"Closed","is_closed":true}}}`))
+		case "/rest/api/2/issue/SLOW-1":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	jira := config.IssueTracker{Type: config.TrackerJira, URL: server.URL, ClosedStatuses: []string{"won't fix"}}
+	tests := []struct {
+		name     string
+		cfg      config.IssueTracker
+		key      string
+		expected string
+	}{
+		{"Open jira issue", jira, "PROJ-1", StateOpen},
+		{"Done jira issue", jira, "PROJ-2", StateClosed},
+		{"Configured closed status", jira, "PROJ-3", StateClosed},
+		{"Missing jira issue", jira, "PROJ-4", StateMissing},
+		{"Closed redmine issue", config.IssueTracker{Type: config.TrackerRedmine, URL: server.URL + "/", Token: "key"}, "#7", StateClosed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, err := New(test.cfg).State(test.key)
+			if err != nil {
+				t.Fatalf("State() returned error: %v", err)
+			}
+			if state != test.expected {
+				t.Errorf("State(%s) = %s, expected %s", test.key, state, test.expected)
+			}
+		})
+	}
+
+	t.Run("Error status", func(t *testing.T) {
+		if _, err := New(config.IssueTracker{Type: config.TrackerRedmine, URL: server.URL}).State("7"); err == nil {
+			t.Errorf("State() should return error when the tracker rejects the request")
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		cfg := config.IssueTracker{Type: config.TrackerJira, URL: server.URL, Timeout: 50 * time.Millisecond}
+		if _, err := New(cfg).State("SLOW-1"); err == nil {
+			t.Errorf("State() should return error when the tracker is too slow")
+		}
+	})
+
+	t.Run("Cache file", func(t *testing.T) {
+		cfg := jira
+		cfg.CachePath = filepath.Join(t.TempDir(), "cache", "issues.json")
+		if _, err := New(cfg).State("PROJ-1"); err != nil {
+			t.Fatalf("State() returned error: %v", err)
+		}
+
+		before := requests
+		state, err := New(cfg).State("PROJ-1")
+		if err != nil {
+			t.Fatalf("State() returned error: %v", err)
+		}
+		if state != StateOpen || requests != before {
+			t.Errorf("State() = %s after %d requests, expected the cached open state", state, requests-before)
+		}
+
+		cfg.CacheTTL = time.Nanosecond
+		if _, err := New(cfg).State("PROJ-1"); err != nil {
+			t.Fatalf("State() returned error: %v", err)
+		}
+		if requests != before+1 {
+			t.Errorf("State() should look up expired cache entries again")
+		}
+	})
+
+	t.Run("Expired cache entries are pruned", func(t *testing.T) {
+		cfg := jira
+		cfg.CachePath = filepath.Join(t.TempDir(), "issues.json")
+		stale := `{"PROJ-9":{"state":"closed","time":"2000-01-01T00:00:00Z"}}`
+		if err := os.WriteFile(cfg.CachePath, []byte(stale), 0644); err != nil {
+			t.Fatalf("Failed to write cache: %v", err)
+		}
+		if _, err := New(cfg).State("PROJ-1"); err != nil {
+			t.Fatalf("State() returned error: %v", err)
+		}
+
+		data, err := os.ReadFile(cfg.CachePath)
+		if err != nil {
+			t.Fatalf("Failed to read cache: %v", err)
+		}
+		var entries map[string]cacheEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			t.Fatalf("Failed to decode cache %q: %v", data, err)
+		}
+		if _, exists := entries["PROJ-9"]; exists || len(entries) != 1 {
+			t.Errorf("cache holds %v, expected only PROJ-1", entries)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+// Package version holds the build information of the githookkit binaries
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+)
+
+// Build information, injected at build time with
+// -ldflags "-X github.com/bwinhwang/githookkit/cmd/internal/version.Version=..."
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version       string
+	Commit        string
+	BuildDate     string
+	ConfigVersion int // Config schema version supported by the build
+}
+
+// Get returns the build information, values not injected are taken from the build info embedded by the go tool
+func Get() Info {
+	info := Info{
+		Version:       Version,
+		Commit:        Commit,
+		BuildDate:     BuildDate,
+		ConfigVersion: config.CurrentConfigVersion,
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && buildInfo.Main.Version != "" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" && info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// String formats the build information on a single line, name is the binary name
+func String(name string) string {
+	info := Get()
+	return fmt.Sprintf("%s %s (commit %s, built %s, config schema version %d)", name, info.Version, info.Commit, info.BuildDate, info.ConfigVersion)
+}
@@ -0,0 +1,34 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	oldVersion, oldCommit, oldBuildDate := Version, Commit, BuildDate
+	defer func() { Version, Commit, BuildDate = oldVersion, oldCommit, oldBuildDate }()
+
+	Version, Commit, BuildDate = "v1.2.3", "abc123", "2024-01-02T03:04:05Z"
+	info := Get()
+	if info.Version != "v1.2.3" || info.Commit != "abc123" || info.BuildDate != "2024-01-02T03:04:05Z" {
+		t.Errorf("Get() = %+v, expected the injected values", info)
+	}
+	if info.ConfigVersion != 2 {
+		t.Errorf("ConfigVersion = %d, expected 2", info.ConfigVersion)
+	}
+
+	expected := "ref-update v1.2.3 (commit abc123, built 2024-01-02T03:04:05Z, config schema version 2)"
+	if result := String("ref-update"); result != expected {
+		t.Errorf("String() = %s, expected %s", result, expected)
+	}
+
+	Version, Commit, BuildDate = "", "", ""
+	info = Get()
+	if info.Version == "" || info.Commit == "" || info.BuildDate == "" {
+		t.Errorf("Get() = %+v, expected defaults for values not injected", info)
+	}
+	if !strings.HasPrefix(String("update"), "update ") {
+		t.Errorf("String() = %s, expected the binary name first", String("update"))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// patchset-created is the Gerrit hook called after a patch set has been created, it checks the patch set
+// and, with gerrit.review enabled, posts the violations as a review so that they show in the review UI.
+// The gerrit.actions add reviewers or vote labels on the changes violating their rules.
+// commit-received can't do it: a rejected commit never becomes a patch set. The patch set already exists
+// so errors are only logged.
+func main() {
+	// Define command line parameters
+	change := flag.String("change", "", "Change ID")
+	flag.String("kind", "", "Kind of patch set, e.g. REWORK or TRIVIAL_REBASE")
+	flag.String("change-url", "", "Change URL")
+	flag.String("change-owner", "", "Change owner")
+	flag.String("change-owner-username", "", "Change owner username")
+	project := flag.String("project", "", "Project name")
+	branch := flag.String("branch", "", "Target branch")
+	flag.String("topic", "", "Topic")
+	uploader := flag.String("uploader", "", "Uploader information")
+	uploaderUsername := flag.String("uploader-username", "", "Uploader username")
+	commit := flag.String("commit", "", "Commit of the patch set")
+	patchset := flag.String("patchset", "", "Patch set number")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters, parameters added by newer Gerrit versions are ignored
+	unknownArgs, _ := config.ParseArgs(flag.CommandLine, os.Args[1:])
+
+	if *showVersion {
+		fmt.Println(version.String("patchset-created"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
+	}
+	defer logger.Close()
+
+	if err := check.SetOutput("patchset-created", *format); err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+	// The patch set is already created, the document is written however the hook ends
+	defer check.WriteOutput(githookkit.ExitPass, "")
+
+	if cfgErr != nil {
+		logger.Errorf("Load config failed: %v", cfgErr)
+		return
+	}
+
+	if len(unknownArgs) > 0 {
+		logger.Warnf("Ignoring unknown parameters: %q", unknownArgs)
+	}
+
+	logger.Debugf("project=%s, branch=%s, change=%s, patchset=%s", *project, *branch, *change, *patchset)
+	logger.Debugf("uploader=%s, username=%s, commit=%s", *uploader, *uploaderUsername, *commit)
+
+	if *commit == "" {
+		logger.Errorf("no --commit given")
+		return
+	}
+
+	if config.IsProjectWhitelisted(cfg, *project) {
+		logger.Infof("%s", locale.T("Project %s is in the whitelist, exiting", *project))
+		check.RunChainedHooks(logger, cfg, "patchset-created", os.Args[1:], os.Stdin)
+		return
+	}
+
+	// A change is a single commit on top of its parent, the parent is either merged or checked as its own change
+	refName := *branch
+	if !strings.HasPrefix(refName, "refs/") {
+		refName = "refs/heads/" + refName
+	}
+	result, err := check.Check(cfg, logger, check.Update{
+		Project:          *project,
+		Uploader:         *uploader,
+		UploaderUsername: *uploaderUsername,
+		OldRev:           *commit + "^",
+		NewRev:           *commit,
+		RefName:          refName,
+	})
+	if err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+	check.Report(logger, cfg, []check.Result{result})
+
+	if err := check.PostReview(cfg, *change, *commit, result); err != nil {
+		logger.Errorf("%v", err)
+	}
+
+	check.RunChainedHooks(logger, cfg, "patchset-created", os.Args[1:], os.Stdin)
+}
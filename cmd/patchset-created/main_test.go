@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/gerrit"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestMain(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "patchset-created")
+
+	dir := testutil.InitRepo(t)
+	testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 4096, "small.txt": 100})
+
+	reviews := map[string]gerrit.Review{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review gerrit.Review
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reviews[r.URL.EscapedPath()] = review
+	}))
+	defer server.Close()
+
+	configYAML := fmt.Sprintf("gerrit:\n  url: %s\n  review:\n    enabled: true\n", server.URL)
+	args := []string{"--change", "app~master~I0123", "--kind", "REWORK", "--change-url", "https://review.example.com/123",
+		"--change-owner", "Alice <alice@example.com>", "--change-owner-username", "alice", "--project", "app", "--branch", "master",
+		"--topic", "", "--uploader", "Alice <alice@example.com>", "--uploader-username", "alice", "--commit", newRev, "--patchset", "1"}
+
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, []string{"GITHOOK_FILE_SIZE_MAX=1024"}, "", args...)
+	if err != nil {
+		t.Fatalf("patchset-created failed: %v\n%s", err, output)
+	}
+	review, ok := reviews["/a/changes/app~master~I0123/revisions/"+newRev+"/review"]
+	if !ok {
+		t.Fatalf("no review posted, got %v:\n%s", reviews, output)
+	}
+	if !strings.Contains(review.Message, "REJECTED: one or more files exceed maximum size of 1.00 KB") || review.Labels["Code-Review"] != -1 {
+		t.Errorf("review = %+v, expected the size violation with Code-Review -1", review)
+	}
+
+	// A clean patch set gets no review
+	reviews = map[string]gerrit.Review{}
+	output, err = testutil.RunBinary(t, execPath, dir, configYAML, nil, "", args...)
+	if err != nil || len(reviews) != 0 {
+		t.Errorf("patchset-created returned %v and posted %v, expected no review:\n%s", err, reviews, output)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/notify"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// post-receive runs after the push has been accepted: it reports files above the advisory threshold,
+// records statistics in the audit log and sends notifications. It never fails the push,
+// errors are only logged because the refs are already updated.
+func main() {
+	// Define command line parameters
+	project := flag.String("project", "", "Project name, defaults to the one provided by the server or the repository directory name")
+	uploaderUsername := flag.String("uploader-username", "", "Uploader username, defaults to the one provided by the server")
+	adapter := flag.String("adapter", "", "Hosting server conventions: git, bitbucket or gitea, detected from the environment when empty")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.String("post-receive"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
+	}
+	defer logger.Close()
+
+	if err := check.SetOutput("post-receive", *format); err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+	// The push is already accepted, the document is written however the hook ends
+	defer check.WriteOutput(githookkit.ExitPass, "")
+
+	if cfgErr != nil {
+		logger.Errorf("Load config failed: %v", cfgErr)
+		return
+	}
+
+	// Keep the raw input, chained hooks expect the same stdin
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		logger.Errorf("Read stdin failed: %v", err)
+		return
+	}
+
+	*project, *uploaderUsername, err = check.HookIdentity(*adapter, *project, *uploaderUsername)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+
+	updates, err := check.ReadUpdates(bytes.NewReader(input), *project, *uploaderUsername)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return
+	}
+	pushOptions := check.PushOptions()
+	for i := range updates {
+		updates[i].PushOptions = pushOptions
+	}
+
+	if !config.IsProjectWhitelisted(cfg, *project) {
+		for _, update := range updates {
+			if config.IsRefSkipped(cfg, update.RefName) {
+				continue
+			}
+			process(cfg, logger, update)
+		}
+	}
+
+	check.RunChainedHooks(logger, cfg, "post-receive", os.Args[1:], bytes.NewReader(input))
+}
+
+// process analyses one accepted ref update, warns the pusher, writes the audit record and sends the notification
+func process(cfg config.Config, logger *config.Logger, update check.Update) {
+	result, err := check.Check(cfg, logger, update)
+	if err != nil {
+		logger.Errorf("%s: %v", update.RefName, err)
+		return
+	}
+	check.AddOutput(result)
+
+	record := audit.Record{
+		Event:      audit.EventPush,
+		Hook:       "post-receive",
+		Project:    update.Project,
+		RefName:    update.RefName,
+		OldRev:     update.OldRev,
+		NewRev:     update.NewRev,
+		User:       update.UploaderUsername,
+		NewFiles:   len(result.NewFiles),
+		Warnings:   append(append(result.Violations, result.Bypassed...), result.Warnings...),
+		Violations: result.Findings,
+		Overrides:  result.Overrides,
+	}
+
+	advisoryLimit := config.GetAdvisorySizeLimit(cfg, update.Project)
+	var largest githookkit.FileInfo
+	for _, file := range result.NewFiles {
+		record.TotalSize += file.Size
+		if file.Size > largest.Size {
+			largest = file
+		}
+		if advisoryLimit > 0 && file.Size > advisoryLimit {
+			warning := locale.T("%s is %s, above the advisory size of %s, consider git lfs", file.Path, githookkit.FormatSize(file.Size), githookkit.FormatSize(advisoryLimit))
+			record.Warnings = append(record.Warnings, warning)
+		}
+	}
+	record.Largest = largest.Path
+	record.Duplicates = len(result.Duplicates)
+	for _, file := range result.Duplicates {
+		record.DuplicateSize += file.Size
+	}
+
+	for _, warning := range record.Warnings {
+		logger.Warnf("WARNING: %s: %s", update.RefName, warning)
+	}
+	logger.Infof("%s: %d new files, %s in total", update.RefName, record.NewFiles, githookkit.FormatSize(record.TotalSize))
+
+	if path := config.GetAuditLogPath(cfg); path != "" {
+		if err := audit.Write(path, record); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+
+	// Only pushes that deserve attention are sent to the webhook
+	if len(record.Warnings) > 0 {
+		if err := notify.Send(cfg.Notify, record); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/audit"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestMain(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "post-receive")
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192, "small.txt": 100})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf("advisory_size_limit: 4096\naudit_log: %s\n", auditPath)
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, newRev)
+
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, nil, stdin, "-project", "app", "-uploader-username", "alice")
+	if err != nil {
+		t.Fatalf("post-receive failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "WARNING: refs/heads/master: large.bin is 8.00 KB, above the advisory size of 4.00 KB") {
+		t.Errorf("output does not contain the advisory warning:\n%s", output)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	var record audit.Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("Failed to decode audit log %q: %v", data, err)
+	}
+	if record.Event != audit.EventPush || record.Project != "app" || record.User != "alice" {
+		t.Errorf("audit record is incorrect: %+v", record)
+	}
+	if record.NewFiles != 2 || record.TotalSize != 8292 || record.Largest != "large.bin" {
+		t.Errorf("audit record statistics are incorrect: %+v", record)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// pre-receive is the native git hook: ref updates are read from stdin as "<oldrev> <newrev> <refname>" lines,
+// every ref is checked and the whole push is rejected if any of them violates an enforced rule
+func main() {
+	// Define command line parameters
+	project := flag.String("project", "", "Project name, defaults to the one provided by the server or the repository directory name")
+	uploaderUsername := flag.String("uploader-username", "", "Uploader username, defaults to the one provided by the server")
+	adapter := flag.String("adapter", "", "Hosting server conventions: git, bitbucket or gitea, detected from the environment when empty")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.String("pre-receive"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
+	}
+
+	if err := check.SetOutput("pre-receive", *format); err != nil {
+		check.ConfigError(logger, cfg, "%v", err)
+	}
+
+	if cfgErr != nil {
+		check.ConfigError(logger, cfg, "Load config failed: %v", cfgErr)
+	}
+
+	// Keep the raw input, chained hooks expect the same stdin
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		check.InternalError(logger, cfg, "Read stdin failed: %v", err)
+	}
+
+	*project, *uploaderUsername, err = check.HookIdentity(*adapter, *project, *uploaderUsername)
+	if err != nil {
+		check.Fail(logger, cfg, err)
+	}
+
+	updates, err := check.ReadUpdates(bytes.NewReader(input), *project, *uploaderUsername)
+	if err != nil {
+		check.InternalError(logger, cfg, "%v", err)
+	}
+	pushOptions := check.PushOptions()
+	for i := range updates {
+		updates[i].PushOptions = pushOptions
+	}
+
+	logger.Debugf("project=%s, username=%s, %d ref updates", *project, *uploaderUsername, len(updates))
+
+	if config.IsProjectWhitelisted(cfg, *project) {
+		logger.Infof("%s", locale.T("Project %s is in the whitelist, exiting", *project))
+		check.RunChainedHooks(logger, cfg, "pre-receive", os.Args[1:], bytes.NewReader(input))
+		check.Exit(githookkit.ExitPass, "project is in the whitelist")
+	}
+
+	if check.EmergencyBypass(logger, cfg, "pre-receive", updates) {
+		check.RunChainedHooks(logger, cfg, "pre-receive", os.Args[1:], bytes.NewReader(input))
+		check.Exit(githookkit.ExitPass, "emergency bypass")
+	}
+
+	if check.AdminBypass(logger, cfg, "pre-receive", updates) {
+		check.RunChainedHooks(logger, cfg, "pre-receive", os.Args[1:], bytes.NewReader(input))
+		check.Exit(githookkit.ExitPass, "admin bypass")
+	}
+
+	if check.RateLimited(logger, cfg, updates) {
+		check.Exit(githookkit.ExitRejected, "rate limit exceeded")
+	}
+
+	results, err := daemon.CheckUpdates(cfg, logger, updates)
+	if err != nil {
+		check.Fail(logger, cfg, err)
+	}
+
+	if check.Report(logger, cfg, results) {
+		check.Exit(githookkit.ExitRejected, "")
+	}
+	check.AuditOverrides(logger, cfg, "pre-receive", results)
+	check.RecordRefs(logger, cfg, results)
+
+	check.RunChainedHooks(logger, cfg, "pre-receive", os.Args[1:], bytes.NewReader(input))
+	check.WriteOutput(githookkit.ExitPass, "")
+}
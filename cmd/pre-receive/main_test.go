@@ -0,0 +1,528 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// buildHook compiles the pre-receive binary into a temporary directory
+func buildHook(t *testing.T) string {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	return testutil.BuildBinary(t, wd, "pre-receive")
+}
+
+func TestMainStdin(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	smallRev := testutil.CommitFiles(t, dir, map[string]int{"small.txt": 100})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+
+	tests := []struct {
+		name     string
+		stdin    string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "all refs pass",
+			stdin:   fmt.Sprintf("%s %s refs/heads/master\n", oldRev, smallRev),
+			wantErr: false,
+		},
+		{
+			name: "one ref fails the whole push",
+			stdin: fmt.Sprintf("%s %s refs/heads/master\n%s %s refs/heads/large\n",
+				oldRev, smallRev, smallRev, largeRev),
+			wantErr:  true,
+			expected: "REJECTED: refs/heads/large: one or more files exceed maximum size of 4.00 KB",
+		},
+		{
+			name:     "malformed input",
+			stdin:    "garbage\n",
+			wantErr:  true,
+			expected: "invalid ref update line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := testutil.RunBinary(t, execPath, dir, "", []string{"GITHOOK_FILE_SIZE_MAX=4096"}, tt.stdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainAdapters(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	// Bitbucket and Gitea projects are matched as "<project key or owner>/<repository>"
+	configYAML := "projects_whitelist:\n  - LEGACY/\n"
+
+	tests := []struct {
+		name     string
+		env      []string
+		args     []string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:     "detected from the environment",
+			env:      []string{"BB_PROJECT_KEY=APP", "BB_REPO_SLUG=web", "BB_USER_NAME=alice"},
+			wantErr:  true,
+			expected: "REJECTED: one or more files exceed maximum size of 4.00 KB",
+		},
+		{
+			name:    "whitelisted Bitbucket project",
+			env:     []string{"BB_PROJECT_KEY=LEGACY", "BB_REPO_SLUG=web"},
+			wantErr: false,
+		},
+		{
+			name:     "flags take precedence",
+			env:      []string{"BB_PROJECT_KEY=LEGACY", "BB_REPO_SLUG=web"},
+			args:     []string{"-project", "app"},
+			wantErr:  true,
+			expected: "REJECTED",
+		},
+		{
+			name:    "whitelisted Gitea repository",
+			env:     []string{"GITEA_REPO_USER_NAME=LEGACY", "GITEA_REPO_NAME=web", "GITEA_PUSHER_NAME=carol"},
+			wantErr: false,
+		},
+		{
+			name:     "unknown adapter",
+			args:     []string{"-adapter", "svn"},
+			wantErr:  true,
+			expected: "unknown adapter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := append([]string{"GITHOOK_FILE_SIZE_MAX=4096"}, tt.env...)
+			output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, stdin, tt.args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainOverride(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf(`audit_log: %s
+overrides:
+  large-files-ok:
+    rules: [file_size]
+    users: [alice]
+`, auditPath)
+	pushOptions := []string{"GITHOOK_FILE_SIZE_MAX=4096", "GIT_PUSH_OPTION_COUNT=1", "GIT_PUSH_OPTION_0=large-files-ok=JIRA-42"}
+
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, append(pushOptions, "REMOTE_USER=bob"), stdin)
+	if err == nil || !strings.Contains(output, "not allowed to use override large-files-ok=JIRA-42") {
+		t.Errorf("override should be denied to bob, got %v:\n%s", err, output)
+	}
+
+	output, err = testutil.RunBinary(t, execPath, dir, configYAML, append(pushOptions, "REMOTE_USER=alice"), stdin)
+	if err != nil {
+		t.Fatalf("override should let alice push, got %v:\n%s", err, output)
+	}
+	if !strings.Contains(output, "OVERRIDDEN: one or more files exceed maximum size of 4.00 KB") {
+		t.Errorf("output does not report the bypassed violation:\n%s", output)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"event":"override"`) || !strings.Contains(string(data), `"reason":"JIRA-42"`) {
+		t.Errorf("audit log does not record the override: %s", data)
+	}
+}
+
+func TestMainJSONOutput(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	smallRev := testutil.CommitFiles(t, dir, map[string]int{"small.txt": 100})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n%s %s refs/heads/large\n", oldRev, smallRev, smallRev, largeRev)
+
+	// stdout is the decision document alone, the messages go to stderr
+	document := func(stdout, stderr string) check.Document {
+		t.Helper()
+		var document check.Document
+		if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+			t.Fatalf("stdout is not a decision document: %v\n%s\nstderr:\n%s", err, stdout, stderr)
+		}
+		return document
+	}
+
+	stdout, stderr, err := testutil.RunBinaryStreams(t, execPath, dir, "", []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_OUTPUT=json"}, stdin)
+	if err == nil || !strings.Contains(stderr, "REJECTED: refs/heads/large") {
+		t.Fatalf("push should be rejected, got %v:\n%s", err, stderr)
+	}
+	got := document(stdout, stderr)
+	if got.Hook != "pre-receive" || got.Decision != check.DecisionReject || len(got.Refs) != 2 {
+		t.Fatalf("document = %+v, expected the rejection of 2 refs by pre-receive", got)
+	}
+	if ref := got.Refs[0]; ref.Decision != check.DecisionAccept || len(ref.Violations) != 0 || len(ref.Files) != 1 || ref.Size != 100 {
+		t.Errorf("refs/heads/master = %+v, expected accepted with small.txt", ref)
+	}
+	if ref := got.Refs[1]; ref.Decision != check.DecisionReject || len(ref.Violations) == 0 || ref.Violations[0].RuleID != config.RuleFileSize || ref.Violations[0].Path != "large.bin" {
+		t.Errorf("refs/heads/large = %+v, expected rejected by %s for large.bin", ref, config.RuleFileSize)
+	}
+
+	stdout, stderr, err = testutil.RunBinaryStreams(t, execPath, dir, "", nil, stdin, "--format", "json")
+	if err != nil {
+		t.Fatalf("push should be accepted, got %v:\n%s", err, stderr)
+	}
+	if got := document(stdout, stderr); got.Decision != check.DecisionAccept || len(got.Refs) != 2 {
+		t.Errorf("document = %+v, expected the acceptance of 2 refs", got)
+	}
+
+	output, err := testutil.RunBinary(t, execPath, dir, "", nil, stdin)
+	if err != nil || strings.Contains(output, `{"hook"`) {
+		t.Errorf("text output should have no decision document, got %v:\n%s", err, output)
+	}
+
+	output, err = testutil.RunBinary(t, execPath, dir, "", []string{"GITHOOK_OUTPUT=xml"}, stdin)
+	if err == nil || !strings.Contains(output, `unknown output format "xml"`) {
+		t.Errorf("unknown output format should be an internal error, got %v:\n%s", err, output)
+	}
+}
+
+func TestMainSARIFOutput(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	// SARIF consumers read the whole of stdout, the messages go to stderr
+	stdout, stderr, err := testutil.RunBinaryStreams(t, execPath, dir, "", []string{"GITHOOK_FILE_SIZE_MAX=4096"}, stdin, "--format", "sarif")
+	if err == nil || !strings.Contains(stderr, "REJECTED: one or more files exceed maximum size of 4.00 KB") {
+		t.Fatalf("push should be rejected, got %v:\n%s", err, stderr)
+	}
+	var log check.SARIFLog
+	if err := json.Unmarshal([]byte(stdout), &log); err != nil {
+		t.Fatalf("stdout is not a SARIF log: %v\n%s", err, stdout)
+	}
+	if len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 || log.Runs[0].Results[0].RuleID != config.RuleFileSize {
+		t.Errorf("SARIF log = %+v, expected the %s violation of large.bin", log, config.RuleFileSize)
+	}
+}
+
+func TestMainExitCodes(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	tests := []struct {
+		name     string
+		config   string
+		env      []string
+		stdin    string
+		args     []string
+		expected int
+	}{
+		{"Pass", "", nil, stdin, nil, githookkit.ExitPass},
+		{"Rejected", "", []string{"GITHOOK_FILE_SIZE_MAX=4096"}, stdin, nil, githookkit.ExitRejected},
+		{"Invalid config", "max_push_size: [", nil, stdin, nil, githookkit.ExitConfigError},
+		{"Unknown adapter", "", nil, stdin, []string{"-adapter", "svn"}, githookkit.ExitConfigError},
+		{"Unknown language", "", []string{"GITHOOK_LANG=fr"}, stdin, nil, githookkit.ExitConfigError},
+		{"Unknown revision", "", nil, fmt.Sprintf("%s %s refs/heads/master\n", oldRev, strings.Repeat("1", 40)), nil, githookkit.ExitInternalError},
+		{"Internal error allowed", "on_internal_error: allow\n", nil, fmt.Sprintf("%s %s refs/heads/master\n", oldRev, strings.Repeat("1", 40)), nil, githookkit.ExitPass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := testutil.RunBinary(t, execPath, dir, tt.config, tt.env, tt.stdin, tt.args...)
+			code := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("pre-receive failed: %v", err)
+			}
+			if code != tt.expected {
+				t.Errorf("exit code = %d, expected %d\n%s", code, tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainLanguage(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	output, err := testutil.RunBinary(t, execPath, dir, "lang: zh\n", []string{"GITHOOK_FILE_SIZE_MAX=4096"}, stdin)
+	if err == nil || !strings.Contains(output, "已拒绝: 有文件超过了 4.00 KB 的大小上限") {
+		t.Errorf("push should be rejected in Chinese, got %v:\n%s", err, output)
+	}
+
+	// GITHOOK_LANG takes precedence over the config
+	output, err = testutil.RunBinary(t, execPath, dir, "lang: zh\n", []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_LANG=en"}, stdin)
+	if err == nil || !strings.Contains(output, "REJECTED: one or more files exceed maximum size of 4.00 KB") {
+		t.Errorf("push should be rejected in English, got %v:\n%s", err, output)
+	}
+}
+
+func TestMainEmergencyBypass(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf("audit_log: %s\nemergency_bypass:\n  users: [alice]\n", auditPath)
+	env := []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_EMERGENCY_BYPASS=INC-7 production down"}
+
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, append(env, "REMOTE_USER=bob"), stdin)
+	if err == nil || !strings.Contains(output, `User "bob" is not allowed to use the emergency bypass`) {
+		t.Errorf("emergency bypass should be denied to bob, got %v:\n%s", err, output)
+	}
+
+	// Without an audit log the bypass can't be recorded and is refused
+	output, err = testutil.RunBinary(t, execPath, dir, "emergency_bypass:\n  users: [alice]\n", append(env, "REMOTE_USER=alice"), stdin)
+	if err == nil || !strings.Contains(output, "Emergency bypass requires an audit log") {
+		t.Errorf("emergency bypass without audit log should be refused, got %v:\n%s", err, output)
+	}
+
+	output, err = testutil.RunBinary(t, execPath, dir, configYAML, append(env, "REMOTE_USER=alice"), stdin)
+	if err != nil {
+		t.Fatalf("emergency bypass should let alice push, got %v:\n%s", err, output)
+	}
+	if !strings.Contains(output, "EMERGENCY BYPASS by alice: INC-7 production down") {
+		t.Errorf("output does not report the emergency bypass:\n%s", output)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"event":"emergency_bypass"`) || !strings.Contains(string(data), `"reason":"INC-7 production down"`) || !strings.Contains(string(data), `"user":"alice"`) {
+		t.Errorf("audit log does not record the emergency bypass: %s", data)
+	}
+}
+
+func TestMainAdminBypass(t *testing.T) {
+	execPath := buildHook(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a/accounts/admin/capabilities":
+			w.Write([]byte(")]}'\n{\"administrateServer\":true}"))
+		case "/a/accounts/alice/capabilities":
+			w.Write([]byte(")]}'\n{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	largeRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	stdin := fmt.Sprintf("%s %s refs/heads/master\n", oldRev, largeRev)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf("audit_log: %s\ngerrit:\n  url: %s\nadmin_bypass:\n  capabilities: [administrateServer]\n", auditPath, server.URL)
+	env := []string{"GITHOOK_FILE_SIZE_MAX=4096"}
+
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, append(env, "REMOTE_USER=alice"), stdin)
+	if err == nil || strings.Contains(output, "ADMIN BYPASS") {
+		t.Errorf("push of alice should be checked, got %v:\n%s", err, output)
+	}
+
+	// A failed lookup checks the push
+	output, err = testutil.RunBinary(t, execPath, dir, configYAML, append(env, "REMOTE_USER=bob"), stdin)
+	if err == nil || !strings.Contains(output, "admin bypass not granted") {
+		t.Errorf("push of bob should be checked after the failed lookup, got %v:\n%s", err, output)
+	}
+
+	output, err = testutil.RunBinary(t, execPath, dir, configYAML, append(env, "REMOTE_USER=admin"), stdin)
+	if err != nil {
+		t.Fatalf("admin bypass should let admin push, got %v:\n%s", err, output)
+	}
+	if !strings.Contains(output, "ADMIN BYPASS by admin: gerrit capability administrateServer") {
+		t.Errorf("output does not report the admin bypass:\n%s", output)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"event":"admin_bypass"`) || !strings.Contains(string(data), `"user":"admin"`) {
+		t.Errorf("audit log does not record the admin bypass: %s", data)
+	}
+}
+
+func TestMainGitPush(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook installation is not supported on Windows")
+	}
+
+	execPath := buildHook(t)
+
+	// Bare repository with the hook installed, named like a project
+	remote := filepath.Join(t.TempDir(), "app.git")
+	if output, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create bare repository: %v\n%s", err, output)
+	}
+	hookPath := filepath.Join(remote, "hooks", "pre-receive")
+	if err := os.Rename(execPath, hookPath); err != nil {
+		t.Fatalf("Failed to install hook: %v", err)
+	}
+
+	homeDir := t.TempDir()
+	configYAML := "version: 2\nprojects:\n  app:\n    size_limit: 4096\n"
+	if err := os.WriteFile(filepath.Join(homeDir, ".githook_config"), []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	dir := testutil.InitRepo(t)
+	testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+
+	push := func() (string, error) {
+		cmd := exec.Command("git", "push", remote, "HEAD:refs/heads/master")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HOME="+homeDir, "GITHOOK_FILE_SIZE_MAX=")
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := push(); err != nil {
+		t.Fatalf("push of small files should be accepted: %v\n%s", err, output)
+	}
+
+	testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	output, err := push()
+	if err == nil {
+		t.Fatalf("push of a large file should be rejected\n%s", output)
+	}
+	if !strings.Contains(output, "one or more files exceed maximum size of 4.00 KB") {
+		t.Errorf("output does not contain the rejection message:\n%s", output)
+	}
+}
+
+func TestMainGitPushDaemon(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook installation is not supported on Windows")
+	}
+
+	execPath := buildHook(t)
+
+	remote := filepath.Join(t.TempDir(), "app.git")
+	if output, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create bare repository: %v\n%s", err, output)
+	}
+	hookPath := filepath.Join(remote, "hooks", "pre-receive")
+	if err := os.Rename(execPath, hookPath); err != nil {
+		t.Fatalf("Failed to install hook: %v", err)
+	}
+
+	// The daemon holds the limits, the hook only knows where to forward its checks
+	daemonConfig := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(daemonConfig, []byte("version: 2\nprojects:\n  app:\n    size_limit: 4096\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	logger, err := config.InitLogger(config.Config{})
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	address := "unix:" + filepath.Join(t.TempDir(), "githookkit.sock")
+	listener, err := daemon.Listen(address)
+	if err != nil {
+		t.Fatalf("Listen() returned error: %v", err)
+	}
+	checker := daemon.NewServer(config.NewCache(daemonConfig), logger)
+	server := &http.Server{Handler: checker}
+	go server.Serve(listener)
+	defer checker.Close()
+	defer server.Close()
+
+	homeDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(homeDir, ".githook_config"), []byte("daemon: "+address+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	dir := testutil.InitRepo(t)
+	testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+
+	push := func() (string, error) {
+		cmd := exec.Command("git", "push", remote, "HEAD:refs/heads/master")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HOME="+homeDir, "GITHOOK_FILE_SIZE_MAX=", "GITHOOK_DAEMON=")
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := push(); err != nil {
+		t.Fatalf("push of small files should be accepted: %v\n%s", err, output)
+	}
+
+	// The pushed objects are only in the quarantine of the push, the daemon has to read them from there
+	testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+	output, err := push()
+	if err == nil {
+		t.Fatalf("push of a large file should be rejected\n%s", output)
+	}
+	if !strings.Contains(output, "one or more files exceed maximum size of 4.00 KB") {
+		t.Errorf("output does not contain the rejection message of the daemon:\n%s", output)
+	}
+	if strings.Contains(output, "checking locally") {
+		t.Errorf("hook should not fall back to local checks:\n%s", output)
+	}
+}
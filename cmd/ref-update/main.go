@@ -1,12 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
 	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
 )
 
 func main() {
@@ -17,97 +23,111 @@ func main() {
 	oldRev := flag.String("oldrev", "", "Old commit hash")
 	newRev := flag.String("newrev", "", "New commit hash")
 	refName := flag.String("refname", "", "Reference name")
+	batch := flag.Bool("stdin", false, "Read a batch of \"<oldrev> <newrev> <refname>\" lines from stdin instead of -oldrev, -newrev and -refname")
+	var pushOptions config.StringList
+	flag.Var(&pushOptions, "push-option", "Push option given by the uploader, can be repeated")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters, parameters added by newer Gerrit versions are ignored
+	unknownArgs, _ := config.ParseArgs(flag.CommandLine, os.Args[1:])
+
+	if *showVersion {
+		fmt.Println(version.String("ref-update"))
+		return
+	}
 
-	// Parse command line parameters
-	flag.Parse()
-
-	cfg, _ := config.LoadConfig()
+	cfg, cfgErr := config.LoadConfig()
 
-	// 初始化日志
+	// Initialize logging
 	logger, err := config.InitLogger(cfg)
 	if err != nil {
-		fmt.Printf("初始化日志失败: %v", err)
-		os.Exit(1)
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
 	}
 
-	// Print parameters for logging
-	logger.Debugf("project=%s, ref=%s\n", *project, *refName)
-	logger.Debugf("uploader=%s, username=%s\n", *uploader, *uploaderUsername)
-	logger.Debugf("oldRev=%s\n", *oldRev)
-	logger.Debugf("newRev=%s\n", *newRev)
-
-	if config.IsProjectWhitelisted(cfg, *project) {
-		logger.Infof("Project %s is in the whitelist, exiting\n", *project)
-		os.Exit(0) // Exit normally, no error
+	if err := check.SetOutput("ref-update", *format); err != nil {
+		check.ConfigError(logger, cfg, "%v", err)
 	}
 
-	sizeLimit := config.GetSizeLimit(cfg, *project)
-
-	largeFiles, err := run(*oldRev, *newRev, func(size int64) bool {
-		return size > sizeLimit // Use environment variable or default value
-	})
-
-	if err != nil {
-		logger.Fatalf("Run failed: %v", err)
+	if cfgErr != nil {
+		check.ConfigError(logger, cfg, "Load config failed: %v", cfgErr)
 	}
 
-	var maxFileSize int64 = 0
-	if len(largeFiles) > 0 {
-		logger.Infof("Found %d large files:", len(largeFiles))
-		for _, file := range largeFiles {
-			if file.Size > maxFileSize {
-				maxFileSize = file.Size
-			}
+	if len(unknownArgs) > 0 {
+		logger.Warnf("Ignoring unknown parameters: %q", unknownArgs)
+	}
 
-			logger.Infof("  Path: %s, Size: %d bytes", file.Path, file.Size)
+	// Print parameters for logging
+	logger.Debugf("project=%s, ref=%s\n", *project, *refName)
+	logger.Debugf("uploader=%s, username=%s\n", *uploader, *uploaderUsername)
+	logger.Debugf("oldRev=%s\n", *oldRev)
+	logger.Debugf("newRev=%s\n", *newRev)
 
+	// In batch mode stdin is consumed here, keep it for the chained hooks
+	var stdin io.Reader = os.Stdin
+	updates := []check.Update{{
+		Project:          *project,
+		Uploader:         *uploader,
+		UploaderUsername: *uploaderUsername,
+		OldRev:           *oldRev,
+		NewRev:           *newRev,
+		RefName:          *refName,
+		PushOptions:      pushOptions,
+	}}
+	if *batch {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			check.InternalError(logger, cfg, "Read stdin failed: %v", err)
+		}
+		updates, err = check.ReadUpdates(bytes.NewReader(input), *project, *uploaderUsername)
+		if err != nil {
+			check.InternalError(logger, cfg, "%v", err)
+		}
+		for i := range updates {
+			updates[i].Uploader = *uploader
+			updates[i].PushOptions = pushOptions
 		}
-		logger.Fatalf("REJECTED: one or more files exceed maximum size of %s, the largest one is %s, use git lfs!", githookkit.FormatSize(sizeLimit), githookkit.FormatSize(maxFileSize))
+		stdin = bytes.NewReader(input)
+		logger.Debugf("%d ref updates read from stdin", len(updates))
 	}
-}
-
-func run(startCommit, endCommit string, sizeChecker func(int64) bool) ([]githookkit.FileInfo, error) {
-	// Get all objects
-	// Collect all matching file information
-	var results []githookkit.FileInfo
 
-	// branch deletion, return
-	if endCommit == "0000000000000000000000000000000000000000" {
-		return results, nil
+	if config.IsProjectWhitelisted(cfg, *project) {
+		logger.Infof("%s", locale.T("Project %s is in the whitelist, exiting", *project))
+		check.RunChainedHooks(logger, cfg, "ref-update", os.Args[1:], stdin)
+		check.Exit(githookkit.ExitPass, "project is in the whitelist")
 	}
 
-	count, err := githookkit.CountCommits(endCommit, startCommit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get count: %w", err)
+	if check.EmergencyBypass(logger, cfg, "ref-update", updates) {
+		check.RunChainedHooks(logger, cfg, "ref-update", os.Args[1:], stdin)
+		check.Exit(githookkit.ExitPass, "emergency bypass")
 	}
-	assuredStartCommit := fmt.Sprintf("%s~%d", endCommit, count)
-
-	var objectChan <-chan string
-	isOk := githookkit.VerifyCommit(assuredStartCommit)
 
-	if isOk {
-		objectChan, err = githookkit.GetSpanObjectList(assuredStartCommit, endCommit, true)
-
-	} else {
-		objectChan, err = githookkit.GetSingleCommitObjectList(endCommit, true)
+	if check.AdminBypass(logger, cfg, "ref-update", updates) {
+		check.RunChainedHooks(logger, cfg, "ref-update", os.Args[1:], stdin)
+		check.Exit(githookkit.ExitPass, "admin bypass")
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to get object list: %w", err)
+	if check.RateLimited(logger, cfg, updates) {
+		check.Exit(githookkit.ExitRejected, "rate limit exceeded")
 	}
 
-	// Use GetObjectDetails and size checker to filter objects
-	fileInfoChan, err := githookkit.GetObjectDetails(objectChan, sizeChecker)
+	results, err := daemon.CheckUpdates(cfg, logger, updates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object details: %w", err)
+		check.Fail(logger, cfg, err)
 	}
 
-	for fileInfo := range fileInfoChan {
-		// Ensure object has path and size information
-		if fileInfo.Path != "" {
-			results = append(results, fileInfo)
-		}
+	if check.Report(logger, cfg, results) {
+		check.Exit(githookkit.ExitRejected, "")
 	}
+	check.AuditOverrides(logger, cfg, "ref-update", results)
+	check.AuditPushes(logger, cfg, "ref-update", results)
+	check.RecordRefs(logger, cfg, results)
+
+	check.RunChainedHooks(logger, cfg, "ref-update", os.Args[1:], stdin)
+	check.WriteOutput(githookkit.ExitPass, "")
+}
 
-	return results, nil
+func run(startCommit, endCommit string, sizeChecker func(int64) bool) ([]githookkit.FileInfo, error) {
+	return check.NewFiles(startCommit, endCommit, sizeChecker)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,6 +11,9 @@ import (
 	"testing"
 
 	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
 )
 
 func TestRun(t *testing.T) {
@@ -396,3 +400,486 @@ func TestMainIntegration(t *testing.T) {
 		})
 	}
 }
+
+// Test repository helpers shared with the other hook binaries
+var (
+	initTestRepo = testutil.InitRepo
+	commitFiles  = testutil.CommitFiles
+)
+
+// buildHook compiles the ref-update binary into a temporary directory
+func buildHook(t *testing.T) string {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	return testutil.BuildBinary(t, wd, "ref-update")
+}
+
+// runHook runs the compiled hook inside dir with a fresh HOME holding configYAML and returns its combined output
+func runHook(t *testing.T, execPath, dir, configYAML string, env []string, args ...string) (string, error) {
+	t.Helper()
+
+	return testutil.RunBinary(t, execPath, dir, configYAML, env, "", args...)
+}
+
+func TestMainPushLimits(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{
+		"a.bin": 3000,
+		"b.bin": 3000,
+		"c.bin": 3000,
+	})
+
+	args := []string{
+		"-project", "test-project",
+		"-oldrev", oldRev,
+		"-newrev", newRev,
+		"-refname", "refs/heads/master",
+	}
+
+	tests := []struct {
+		name     string
+		env      []string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "no push size limit",
+			env:     []string{"GITHOOK_FILE_SIZE_MAX=4096"},
+			wantErr: false,
+		},
+		{
+			name:    "total below push size limit",
+			env:     []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_PUSH_SIZE_MAX=10000"},
+			wantErr: false,
+		},
+		{
+			name:     "total above push size limit",
+			env:      []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_PUSH_SIZE_MAX=8192"},
+			wantErr:  true,
+			expected: "exceeding the maximum push size of 8.00 KB",
+		},
+		{
+			name:    "new files within limit",
+			env:     []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_NEW_FILES_MAX=3"},
+			wantErr: false,
+		},
+		{
+			name:     "too many new files",
+			env:      []string{"GITHOOK_FILE_SIZE_MAX=4096", "GITHOOK_NEW_FILES_MAX=2"},
+			wantErr:  true,
+			expected: "push adds 3 new files, exceeding the maximum of 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runHook(t, execPath, dir, "", tt.env, args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainCommitLimit(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	commitFiles(t, dir, map[string]int{"a.txt": 10})
+	commitFiles(t, dir, map[string]int{"b.txt": 10})
+	newRev := commitFiles(t, dir, map[string]int{"c.txt": 10})
+
+	configYAML := `max_commits_per_push:
+  - ref: refs/heads/import/*
+    max: 0
+  - ref: refs/heads/*
+    max: 2
+`
+
+	tests := []struct {
+		name     string
+		refName  string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:     "too many commits",
+			refName:  "refs/heads/master",
+			wantErr:  true,
+			expected: "push contains 3 commits for refs/heads/master, exceeding the maximum of 2",
+		},
+		{
+			name:    "unlimited ref pattern",
+			refName: "refs/heads/import/history",
+			wantErr: false,
+		},
+		{
+			name:    "no matching ref pattern",
+			refName: "refs/tags/v1.0",
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runHook(t, execPath, dir, configYAML, nil,
+				"-project", "test-project",
+				"-oldrev", oldRev,
+				"-newrev", newRev,
+				"-refname", tt.refName,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainWarnMode(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{"large.bin": 8192})
+
+	configYAML := `version: 2
+projects:
+  rollout/:
+    mode: warn
+    ref_modes:
+      - ref: refs/heads/release/*
+        mode: enforce
+`
+
+	tests := []struct {
+		name     string
+		project  string
+		refName  string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:     "enforced project is rejected",
+			project:  "other",
+			refName:  "refs/heads/master",
+			wantErr:  true,
+			expected: "REJECTED: one or more files exceed maximum size of 4.00 KB",
+		},
+		{
+			name:     "warn mode project is allowed",
+			project:  "rollout/app",
+			refName:  "refs/heads/master",
+			wantErr:  false,
+			expected: "WARNING: one or more files exceed maximum size of 4.00 KB",
+		},
+		{
+			name:     "enforced ref in warn mode project is rejected",
+			project:  "rollout/app",
+			refName:  "refs/heads/release/1.0",
+			wantErr:  true,
+			expected: "REJECTED: one or more files exceed maximum size of 4.00 KB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runHook(t, execPath, dir, configYAML, []string{"GITHOOK_FILE_SIZE_MAX=4096"},
+				"-project", tt.project,
+				"-oldrev", oldRev,
+				"-newrev", newRev,
+				"-refname", tt.refName,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainOnInternalError(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	newRev := commitFiles(t, dir, map[string]int{"README": 10})
+	args := []string{
+		"-project", "test-project",
+		"-oldrev", "invalid-hash",
+		"-newrev", newRev,
+		"-refname", "refs/heads/master",
+	}
+
+	tests := []struct {
+		name       string
+		configYAML string
+		env        []string
+		wantErr    bool
+		expected   string
+	}{
+		{
+			name:     "git failure rejects by default",
+			wantErr:  true,
+			expected: "on_internal_error is reject, push rejected",
+		},
+		{
+			name:       "git failure allowed by config",
+			configYAML: "on_internal_error: allow\n",
+			wantErr:    false,
+			expected:   "on_internal_error is allow, push accepted without checks",
+		},
+		{
+			name:       "bad config allowed by env",
+			configYAML: "version: 99\n",
+			env:        []string{"GITHOOK_ON_INTERNAL_ERROR=allow"},
+			wantErr:    false,
+			expected:   "Load config failed",
+		},
+		{
+			name:       "bad config rejects by default",
+			configYAML: "on_internal_error: sometimes\n",
+			wantErr:    true,
+			expected:   "Load config failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runHook(t, execPath, dir, tt.configYAML, tt.env, args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainReportLimit(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{
+		"a.bin": 2048,
+		"b.bin": 2048,
+		"c.bin": 2048,
+		"d.bin": 2048,
+		"e.bin": 2048,
+	})
+
+	output, err := runHook(t, execPath, dir, "max_reported_files: 2\n", []string{"GITHOOK_FILE_SIZE_MAX=1024"},
+		"-project", "test-project",
+		"-oldrev", oldRev,
+		"-newrev", newRev,
+		"-refname", "refs/heads/master",
+	)
+	if err == nil {
+		t.Fatalf("expected push to be rejected\n%s", output)
+	}
+
+	if count := strings.Count(output, "  Path: "); count != 2 {
+		t.Errorf("output lists %d files, expected 2:\n%s", count, output)
+	}
+	for _, expected := range []string{"Found 5 large files:", "  ...and 3 more"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output does not contain %q:\n%s", expected, output)
+		}
+	}
+}
+
+func TestMainMessageTemplate(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{"assets/video.mp4": 4096})
+
+	configYAML := `contact_url: https://wiki.example.com/lfs
+messages:
+  file_size: "{{.Largest.Path}} in {{.Project}} is larger than {{.Limit}}, read {{.ContactURL}}"
+`
+	output, err := runHook(t, execPath, dir, configYAML, []string{"GITHOOK_FILE_SIZE_MAX=1024"},
+		"-project", "test-project",
+		"-oldrev", oldRev,
+		"-newrev", newRev,
+		"-refname", "refs/heads/master",
+	)
+	if err == nil {
+		t.Fatalf("expected push to be rejected\n%s", output)
+	}
+
+	expected := "REJECTED: assets/video.mp4 in test-project is larger than 1.00 KB, read https://wiki.example.com/lfs"
+	if !strings.Contains(output, expected) {
+		t.Errorf("output does not contain %q:\n%s", expected, output)
+	}
+}
+
+func TestMainDaemon(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{"a.bin": 100, "b.bin": 100})
+
+	// The daemon holds its own config, the hook's config has no limits
+	daemonConfig := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(daemonConfig, []byte("max_new_files: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	logger, err := config.InitLogger(config.Config{})
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	address := "unix:" + filepath.Join(t.TempDir(), "githookkit.sock")
+	listener, err := daemon.Listen(address)
+	if err != nil {
+		t.Fatalf("Listen() returned error: %v", err)
+	}
+	server := &http.Server{Handler: daemon.NewServer(config.NewCache(daemonConfig), logger)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	args := []string{"-project", "app", "-oldrev", oldRev, "-newrev", newRev, "-refname", "refs/heads/master"}
+
+	output, err := runHook(t, execPath, dir, "", []string{"GITHOOK_DAEMON=" + address}, args...)
+	if err == nil || !strings.Contains(output, "REJECTED") {
+		t.Errorf("hook should be rejected by the daemon config, got %v:\n%s", err, output)
+	}
+
+	missing := "unix:" + filepath.Join(t.TempDir(), "missing.sock")
+	output, err = runHook(t, execPath, dir, "", []string{"GITHOOK_DAEMON=" + missing}, args...)
+	if err != nil || !strings.Contains(output, "checking locally") {
+		t.Errorf("hook should fall back to local checks, got %v:\n%s", err, output)
+	}
+}
+
+func TestMainUnknownParameters(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{"small.txt": 100})
+
+	output, err := runHook(t, execPath, dir, "", nil,
+		"--project", "app", "--cmdref", "refs/for/master", "--oldrev", oldRev, "--newrev", newRev,
+		"--refname", "refs/heads/master", "--new-gerrit-flag", "value")
+	if err != nil {
+		t.Fatalf("hook should accept unknown parameters, got %v:\n%s", err, output)
+	}
+	if !strings.Contains(output, `Ignoring unknown parameters: ["--cmdref" "refs/for/master" "--new-gerrit-flag" "value"]`) {
+		t.Errorf("output does not log the unknown parameters:\n%s", output)
+	}
+}
+
+func TestMainProtectedDeletion(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	configYAML := "protected_refs:\n  - refs/heads/release/*\n"
+
+	output, err := runHook(t, execPath, dir, configYAML, nil,
+		"-project", "app", "-oldrev", oldRev, "-newrev", "0000000000000000000000000000000000000000", "-refname", "refs/heads/release/1.0")
+	if err == nil || !strings.Contains(output, "REJECTED: refs/heads/release/1.0 is protected and can't be deleted!") {
+		t.Errorf("deleting a protected branch should be rejected, got %v:\n%s", err, output)
+	}
+
+	output, err = runHook(t, execPath, dir, configYAML, nil,
+		"-project", "app", "-oldrev", oldRev, "-newrev", "0000000000000000000000000000000000000000", "-refname", "refs/heads/topic")
+	if err != nil {
+		t.Errorf("deleting an unprotected branch should be allowed, got %v:\n%s", err, output)
+	}
+}
+
+func TestMainStdin(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := initTestRepo(t)
+	oldRev := commitFiles(t, dir, map[string]int{"README": 10})
+	newRev := commitFiles(t, dir, map[string]int{"large.bin": 2048})
+	configYAML := "projects:\n  app:\n    size_limit: 1024\n"
+
+	input := fmt.Sprintf("%s %s refs/heads/master\n%s %s refs/heads/release\n", oldRev, newRev, oldRev, newRev)
+	output, err := testutil.RunBinary(t, execPath, dir, configYAML, nil, input, "-project", "app", "-stdin")
+	if err == nil {
+		t.Fatalf("a batch with a large file should be rejected:\n%s", output)
+	}
+	for _, refName := range []string{"refs/heads/master", "refs/heads/release"} {
+		if !strings.Contains(output, "REJECTED: "+refName+": one or more files exceed maximum size") {
+			t.Errorf("expected %s to be rejected, got:\n%s", refName, output)
+		}
+	}
+
+	input = fmt.Sprintf("%s %s refs/heads/master\n", newRev, oldRev)
+	if output, err := testutil.RunBinary(t, execPath, dir, configYAML, nil, input, "-project", "app", "-stdin"); err != nil {
+		t.Errorf("a batch without violations should be accepted, got %v:\n%s", err, output)
+	}
+}
+
+// On Gerrit the uploads of a user are recorded by the hooks accepting them, post-receive never runs
+func TestMainUploadQuota(t *testing.T) {
+	execPath := buildHook(t)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	mergedPath := testutil.BuildBinary(t, filepath.Join(wd, "..", "change-merged"), "change-merged")
+
+	dir := testutil.InitRepo(t)
+	baseRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	configYAML := fmt.Sprintf("audit_log: %s\nupload_quota:\n  daily: 5000\n", auditPath)
+	env := []string{"GITHOOK_FILE_SIZE_MAX="}
+
+	push := func(oldRev, newRev string) (string, error) {
+		return runHook(t, execPath, dir, configYAML, env, "-project", "app", "-uploader-username", "alice",
+			"-oldrev", oldRev, "-newrev", newRev, "-refname", "refs/heads/master")
+	}
+
+	firstRev := testutil.CommitFiles(t, dir, map[string]int{"first.bin": 3000})
+	if output, err := push(baseRev, firstRev); err != nil {
+		t.Fatalf("first push should be accepted: %v\n%s", err, output)
+	}
+
+	// A merge is not an upload of the user
+	output, err := testutil.RunBinary(t, mergedPath, dir, configYAML, env, "",
+		"--change", "I0123456789abcdef", "--project", "app", "--branch", "refs/heads/master",
+		"--submitter-username", "alice", "--commit", firstRev, "--newrev", firstRev)
+	if err != nil {
+		t.Fatalf("change-merged failed: %v\n%s", err, output)
+	}
+
+	secondRev := testutil.CommitFiles(t, dir, map[string]int{"second.bin": 1500})
+	if output, err := push(firstRev, secondRev); err != nil {
+		t.Fatalf("second push within the quota should be accepted: %v\n%s", err, output)
+	}
+
+	thirdRev := testutil.CommitFiles(t, dir, map[string]int{"third.bin": 1000})
+	output, err = push(secondRev, thirdRev)
+	if err == nil {
+		t.Fatalf("push above the upload quota should be rejected\n%s", output)
+	}
+	if !strings.Contains(output, "exceeding the daily upload quota") {
+		t.Errorf("output does not contain the upload quota violation:\n%s", output)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// outputCSV is the format with a row per blob, for spreadsheets
+const outputCSV = "csv"
+
+// Usage summarises the blobs sharing an extension or a directory
+type Usage struct {
+	Name  string
+	Files int
+	Size  int64
+}
+
+// Report is the result of scanning a repository
+type Report struct {
+	Project       string
+	Files         int
+	TotalSize     int64
+	Largest       []githookkit.FileInfo // Largest blobs, biggest first
+	Blobs         []githookkit.FileInfo // All blobs, biggest first
+	ByExtension   []Usage               // Sorted by size, biggest first
+	ByDirectory   []Usage               // Sorted by size, biggest first
+	SizeLimit     int64
+	SizeWarning   int64
+	OverSizeLimit []githookkit.FileInfo // Blobs the file size rule would reject
+	PushSizeLimit int64
+	NewFileLimit  int
+}
+
+// scan is the offline counterpart of the hooks: it reports the largest blobs of a repository
+// and which of the current rules they would violate, before enforcement is enabled
+func main() {
+	// Define command line parameters
+	project := flag.String("project", "", "Project name, defaults to the repository directory name")
+	ref := flag.String("ref", "", "Ref to scan, all refs when empty")
+	top := flag.Int("top", 20, "Number of largest blobs to list")
+	depth := flag.Int("depth", 1, "Number of path components used to group directories")
+	format := flag.String("format", check.OutputText, "Output format: text, sarif for a SARIF log of the rule violations, junit for a JUnit XML report or csv for a row per blob")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	// Parse command line parameters
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.String("scan"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v", err)
+		os.Exit(githookkit.ExitConfigError)
+	}
+
+	if cfgErr != nil {
+		logger.Errorf("Load config failed: %v", cfgErr)
+		os.Exit(githookkit.ExitConfigError)
+	}
+
+	switch *format {
+	case check.OutputText, check.OutputSARIF, check.OutputJUnit, outputCSV:
+	default:
+		logger.Errorf("unknown output format %q, expected %s, %s, %s or %s", *format, check.OutputText, check.OutputSARIF, check.OutputJUnit, outputCSV)
+		os.Exit(githookkit.ExitConfigError)
+	}
+
+	if *project == "" {
+		*project, err = check.RepositoryProject()
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(githookkit.ExitInternalError)
+		}
+	}
+
+	var refs []string
+	if *ref != "" {
+		refs = append(refs, *ref)
+	}
+
+	report, err := scan(cfg, *project, refs, *top, *depth)
+	if err != nil {
+		logger.Errorf("Scan failed: %v", err)
+		os.Exit(githookkit.ExitInternalError)
+	}
+
+	switch *format {
+	case check.OutputSARIF:
+		err = check.WriteSARIF(os.Stdout, "scan", []check.Result{violations(cfg, report, *ref)})
+	case check.OutputJUnit:
+		err = check.WriteJUnit(os.Stdout, "scan", []check.Result{violations(cfg, report, *ref)})
+	case outputCSV:
+		var commits map[blobPath]string
+		if commits, err = lastCommits(refs); err == nil {
+			err = writeCSV(os.Stdout, report, commits)
+		}
+	default:
+		printReport(os.Stdout, report)
+	}
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(githookkit.ExitInternalError)
+	}
+}
+
+// scan collects the blobs reachable from refs and evaluates them against the project's rules
+func scan(cfg config.Config, project string, refs []string, top, depth int) (Report, error) {
+	objectChan, err := githookkit.Repository{}.GetRefsObjectList(true, refs...)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get object list: %w", err)
+	}
+
+	fileInfoChan, err := githookkit.GetObjectDetails(objectChan, nil)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get object details: %w", err)
+	}
+
+	report := Report{
+		Project:       project,
+		SizeLimit:     config.GetSizeLimit(cfg, project),
+		SizeWarning:   config.GetSizeWarning(cfg, project, ""), // Like the size limit, ref size limits don't apply to a scan
+		PushSizeLimit: config.GetPushSizeLimit(cfg, project),
+		NewFileLimit:  config.GetNewFileLimit(cfg, project),
+	}
+
+	var files []githookkit.FileInfo
+	extensions := map[string]*Usage{}
+	directories := map[string]*Usage{}
+	for file := range fileInfoChan {
+		files = append(files, file)
+		report.Files++
+		report.TotalSize += file.Size
+		addUsage(extensions, extension(file.Path), file.Size)
+		addUsage(directories, directory(file.Path, depth), file.Size)
+		if file.Size > report.SizeLimit {
+			report.OverSizeLimit = append(report.OverSizeLimit, file)
+		}
+	}
+
+	sortBySize(files)
+	sortBySize(report.OverSizeLimit)
+	report.Blobs = files
+	if top >= 0 && len(files) > top {
+		files = files[:top]
+	}
+	report.Largest = files
+	report.ByExtension = sortedUsage(extensions)
+	report.ByDirectory = sortedUsage(directories)
+
+	return report, nil
+}
+
+// violations returns the rule violations of the report as the result of a check of ref
+func violations(cfg config.Config, report Report, ref string) check.Result {
+	result := check.Result{
+		Update: check.Update{Project: report.Project, RefName: ref},
+		Mode:   config.GetMode(cfg, report.Project, ref),
+	}
+	for _, file := range report.OverSizeLimit {
+		message := fmt.Sprintf("%s is %s, above the maximum size of %s", file.Path, githookkit.FormatSize(file.Size), githookkit.FormatSize(report.SizeLimit))
+		result.Findings = append(result.Findings, githookkit.Violation{RuleID: config.RuleFileSize, Severity: githookkit.SeverityError, Path: file.Path, Size: file.Size, Message: message})
+	}
+	if report.PushSizeLimit > 0 && report.TotalSize > report.PushSizeLimit {
+		message := fmt.Sprintf("history is %s in total, above the push size limit of %s", githookkit.FormatSize(report.TotalSize), githookkit.FormatSize(report.PushSizeLimit))
+		result.Findings = append(result.Findings, githookkit.Violation{RuleID: config.RulePushSize, Severity: githookkit.SeverityError, Size: report.TotalSize, Message: message})
+	}
+	if report.NewFileLimit > 0 && report.Files > report.NewFileLimit {
+		message := fmt.Sprintf("history has %d files, above the new file limit of %d", report.Files, report.NewFileLimit)
+		result.Findings = append(result.Findings, githookkit.Violation{RuleID: config.RuleNewFiles, Severity: githookkit.SeverityError, Message: message})
+	}
+	return result
+}
+
+// blobPath is a blob at a path, the same content can be at several paths
+type blobPath struct {
+	hash, path string
+}
+
+// lastCommits returns the newest commit reachable from refs that wrote each blob at its path
+func lastCommits(refs []string) (map[blobPath]string, error) {
+	changes, err := githookkit.Repository{}.GetRefsChanges(refs...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := map[blobPath]string{}
+	for _, change := range changes {
+		key := blobPath{change.NewHash, change.Path}
+		if _, seen := commits[key]; !seen && change.Status != "D" {
+			commits[key] = change.Commit
+		}
+	}
+	return commits, nil
+}
+
+// writeCSV writes a row per blob of the report with the verdict of the file size rule, biggest first
+// The commit is empty for blobs that only merges wrote
+func writeCSV(w io.Writer, report Report, commits map[blobPath]string) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"hash", "path", "size", "commit", "verdict", "rule"})
+	for _, file := range report.Blobs {
+		verdict, rule := "pass", ""
+		switch {
+		case file.Size > report.SizeLimit:
+			verdict, rule = "reject", config.RuleFileSize
+		case report.SizeWarning > 0 && file.Size > report.SizeWarning:
+			verdict, rule = "warn", config.RuleFileSize
+		}
+		writer.Write([]string{file.Hash, file.Path, strconv.FormatInt(file.Size, 10), commits[blobPath{file.Hash, file.Path}], verdict, rule})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func addUsage(usage map[string]*Usage, name string, size int64) {
+	if usage[name] == nil {
+		usage[name] = &Usage{Name: name}
+	}
+	usage[name].Files++
+	usage[name].Size += size
+}
+
+// sortBySize sorts files biggest first, files of the same size by path, so that reports are the same on every run
+func sortBySize(files []githookkit.FileInfo) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Size != files[j].Size {
+			return files[i].Size > files[j].Size
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+func sortedUsage(usage map[string]*Usage) []Usage {
+	var result []Usage
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// extension returns the lower-cased extension of a path, "(none)" when it has none
+func extension(p string) string {
+	if ext := strings.ToLower(path.Ext(p)); ext != "" {
+		return ext
+	}
+	return "(none)"
+}
+
+// directory returns the first depth components of the directory of a path, "." for the root
+func directory(p string, depth int) string {
+	dir := path.Dir(p)
+	if dir == "." || depth <= 0 {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/") + "/"
+}
+
+func printReport(w io.Writer, report Report) {
+	fmt.Fprintf(w, "Project %s: %d files, %s in total\n", report.Project, report.Files, githookkit.FormatSize(report.TotalSize))
+
+	fmt.Fprintf(w, "\nLargest files:\n")
+	for _, file := range report.Largest {
+		fmt.Fprintf(w, "  %10s  %s\n", githookkit.FormatSize(file.Size), file.Path)
+	}
+
+	fmt.Fprintf(w, "\nBy extension:\n")
+	for _, u := range report.ByExtension {
+		fmt.Fprintf(w, "  %10s  %6d files  %s\n", githookkit.FormatSize(u.Size), u.Files, u.Name)
+	}
+
+	fmt.Fprintf(w, "\nBy directory:\n")
+	for _, u := range report.ByDirectory {
+		fmt.Fprintf(w, "  %10s  %6d files  %s\n", githookkit.FormatSize(u.Size), u.Files, u.Name)
+	}
+
+	fmt.Fprintf(w, "\nRules:\n")
+	fmt.Fprintf(w, "  %s: %d files above %s\n", config.RuleFileSize, len(report.OverSizeLimit), githookkit.FormatSize(report.SizeLimit))
+	for _, file := range report.OverSizeLimit {
+		fmt.Fprintf(w, "    %10s  %s\n", githookkit.FormatSize(file.Size), file.Path)
+	}
+	// The aggregate rules apply per push, the whole history is shown as if it were pushed at once
+	if report.PushSizeLimit > 0 {
+		fmt.Fprintf(w, "  %s: %s in total, limit is %s\n", config.RulePushSize, githookkit.FormatSize(report.TotalSize), githookkit.FormatSize(report.PushSizeLimit))
+	}
+	if report.NewFileLimit > 0 {
+		fmt.Fprintf(w, "  %s: %d files in total, limit is %d\n", config.RuleNewFiles, report.Files, report.NewFileLimit)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+func TestMain(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	execPath := testutil.BuildBinary(t, wd, "scan")
+
+	dir := testutil.InitRepo(t)
+	mainRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10, "assets/video.mp4": 8192})
+	testutil.Git(t, dir, "checkout", "-q", "-b", "topic")
+	testutil.CommitFiles(t, dir, map[string]int{"assets/big/image.PNG": 4096})
+	testutil.Git(t, dir, "checkout", "-q", "-")
+
+	configYAML := "max_new_files: 2\n"
+	env := []string{"GITHOOK_FILE_SIZE_MAX=5000"}
+
+	t.Run("All refs", func(t *testing.T) {
+		output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, "", "-project", "app")
+		if err != nil {
+			t.Fatalf("scan failed: %v\n%s", err, output)
+		}
+		expected := []string{
+			"Project app: 3 files",
+			"8.00 KB  assets/video.mp4",
+			"4.00 KB  assets/big/image.PNG",
+			"1 files  .png",
+			"2 files  assets/",
+			"file_size: 1 files above 4.88 KB",
+			"new_files: 3 files in total, limit is 2",
+		}
+		for _, line := range expected {
+			if !strings.Contains(output, line) {
+				t.Errorf("output does not contain %q:\n%s", line, output)
+			}
+		}
+	})
+
+	t.Run("Single ref", func(t *testing.T) {
+		output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, "", "-project", "app", "-ref", "HEAD")
+		if err != nil {
+			t.Fatalf("scan failed: %v\n%s", err, output)
+		}
+		if !strings.Contains(output, "Project app: 2 files") || strings.Contains(output, "image.PNG") {
+			t.Errorf("scan of HEAD should not include the topic branch:\n%s", output)
+		}
+	})
+
+	t.Run("SARIF", func(t *testing.T) {
+		output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, "", "-project", "app", "-format", "sarif")
+		if err != nil {
+			t.Fatalf("scan failed: %v\n%s", err, output)
+		}
+		var log check.SARIFLog
+		if err := json.Unmarshal([]byte(output), &log); err != nil {
+			t.Fatalf("Failed to parse the SARIF log: %v\n%s", err, output)
+		}
+		results := log.Runs[0].Results
+		if len(results) != 2 || results[0].RuleID != config.RuleFileSize || results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != "assets/video.mp4" || results[1].RuleID != config.RuleNewFiles {
+			t.Errorf("SARIF results = %+v, expected video.mp4 above the size limit and too many files", results)
+		}
+	})
+
+	t.Run("JUnit", func(t *testing.T) {
+		output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, "", "-project", "app", "-format", "junit")
+		if err != nil {
+			t.Fatalf("scan failed: %v\n%s", err, output)
+		}
+		var report check.JUnitReport
+		if err := xml.Unmarshal([]byte(output), &report); err != nil {
+			t.Fatalf("Failed to parse the JUnit report: %v\n%s", err, output)
+		}
+		if len(report.Suites) != 1 || report.Suites[0].Name != "app" || report.Failures != 2 {
+			t.Errorf("JUnit report = %+v, expected the file_size and new_files failures of app", report)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, "", "-project", "app", "-format", "csv")
+		if err != nil {
+			t.Fatalf("scan failed: %v\n%s", err, output)
+		}
+		rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse the CSV: %v\n%s", err, output)
+		}
+		if len(rows) != 4 || strings.Join(rows[0], ",") != "hash,path,size,commit,verdict,rule" {
+			t.Fatalf("CSV = %q, expected a header and 3 blobs", rows)
+		}
+		if video := rows[1]; video[1] != "assets/video.mp4" || video[2] != "8192" || video[3] != mainRev || video[4] != "reject" || video[5] != config.RuleFileSize {
+			t.Errorf("first row = %q, expected video.mp4 rejected by %s, written by %s", video, config.RuleFileSize, mainRev)
+		}
+		if readme := rows[3]; readme[1] != "README" || readme[4] != "pass" || readme[5] != "" {
+			t.Errorf("last row = %q, expected README passing", readme)
+		}
+	})
+
+	t.Run("Invalid ref", func(t *testing.T) {
+		if output, err := testutil.RunBinary(t, execPath, dir, configYAML, env, "", "-ref", "missing"); err == nil {
+			t.Errorf("scan should fail for an invalid ref:\n%s", output)
+		}
+	})
+}
+
+func TestDirectory(t *testing.T) {
+	tests := []struct {
+		path     string
+		depth    int
+		expected string
+	}{
+		{"README", 1, "."},
+		{"a/b/c.txt", 1, "a/"},
+		{"a/b/c.txt", 2, "a/b/"},
+		{"a/b/c.txt", 5, "a/b/"},
+		{"a/b/c.txt", 0, "."},
+	}
+
+	for _, test := range tests {
+		if result := directory(test.path, test.depth); result != test.expected {
+			t.Errorf("directory(%s, %d) = %s, expected %s", test.path, test.depth, result, test.expected)
+		}
+	}
+}
+
+func TestExtension(t *testing.T) {
+	tests := map[string]string{
+		"a/b.TXT":    ".txt",
+		"Makefile":   "(none)",
+		"a.tar.gz":   ".gz",
+		"dir.d/file": "(none)",
+	}
+
+	for path, expected := range tests {
+		if result := extension(path); result != expected {
+			t.Errorf("extension(%s) = %s, expected %s", path, result, expected)
+		}
+	}
+}
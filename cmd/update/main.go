@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bwinhwang/githookkit"
+	"github.com/bwinhwang/githookkit/cmd/internal/check"
+	"github.com/bwinhwang/githookkit/cmd/internal/config"
+	"github.com/bwinhwang/githookkit/cmd/internal/daemon"
+	"github.com/bwinhwang/githookkit/cmd/internal/locale"
+	"github.com/bwinhwang/githookkit/cmd/internal/version"
+)
+
+// update is the native git hook called once per ref as "update <refname> <oldrev> <newrev>",
+// a non-zero exit rejects only that ref
+func main() {
+	// Define command line parameters
+	project := flag.String("project", "", "Project name, defaults to the one provided by the server or the repository directory name")
+	uploaderUsername := flag.String("uploader-username", "", "Uploader username, defaults to the one provided by the server")
+	adapter := flag.String("adapter", "", "Hosting server conventions: git, bitbucket or gitea, detected from the environment when empty")
+	format := flag.String("format", "", "Output format: text, json to also write a decision document to stdout or sarif a SARIF log of the violations, defaults to $GITHOOK_OUTPUT")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <refname> <oldrev> <newrev>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	// Parse command line parameters
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.String("update"))
+		return
+	}
+
+	cfg, cfgErr := config.LoadConfig()
+
+	logger, err := config.InitLogger(cfg)
+	if err != nil {
+		fmt.Print(locale.T("Failed to initialize logger: %v", err))
+		os.Exit(githookkit.ExitConfigError)
+	}
+
+	if err := check.SetOutput("update", *format); err != nil {
+		check.ConfigError(logger, cfg, "%v", err)
+	}
+
+	if cfgErr != nil {
+		check.ConfigError(logger, cfg, "Load config failed: %v", cfgErr)
+	}
+
+	if flag.NArg() != 3 {
+		flag.Usage()
+		check.ConfigError(logger, cfg, "expected 3 arguments <refname> <oldrev> <newrev>, got %d", flag.NArg())
+	}
+
+	*project, *uploaderUsername, err = check.HookIdentity(*adapter, *project, *uploaderUsername)
+	if err != nil {
+		check.Fail(logger, cfg, err)
+	}
+
+	update := check.Update{
+		Project:          *project,
+		UploaderUsername: *uploaderUsername,
+		RefName:          flag.Arg(0),
+		OldRev:           flag.Arg(1),
+		NewRev:           flag.Arg(2),
+		PushOptions:      check.PushOptions(),
+	}
+
+	logger.Debugf("project=%s, ref=%s, username=%s", update.Project, update.RefName, update.UploaderUsername)
+	logger.Debugf("oldRev=%s, newRev=%s", update.OldRev, update.NewRev)
+
+	if config.IsProjectWhitelisted(cfg, *project) {
+		logger.Infof("%s", locale.T("Project %s is in the whitelist, exiting", *project))
+		check.RunChainedHooks(logger, cfg, "update", os.Args[1:], os.Stdin)
+		check.Exit(githookkit.ExitPass, "project is in the whitelist")
+	}
+
+	updates := []check.Update{update}
+	if check.EmergencyBypass(logger, cfg, "update", updates) {
+		check.RunChainedHooks(logger, cfg, "update", os.Args[1:], os.Stdin)
+		check.Exit(githookkit.ExitPass, "emergency bypass")
+	}
+
+	if check.AdminBypass(logger, cfg, "update", updates) {
+		check.RunChainedHooks(logger, cfg, "update", os.Args[1:], os.Stdin)
+		check.Exit(githookkit.ExitPass, "admin bypass")
+	}
+
+	if check.RateLimited(logger, cfg, updates) {
+		check.Exit(githookkit.ExitRejected, "rate limit exceeded")
+	}
+
+	results, err := daemon.CheckUpdates(cfg, logger, updates)
+	if err != nil {
+		check.Fail(logger, cfg, err)
+	}
+
+	if check.Report(logger, cfg, results) {
+		check.Exit(githookkit.ExitRejected, "")
+	}
+	check.AuditOverrides(logger, cfg, "update", results)
+	check.RecordRefs(logger, cfg, results)
+
+	check.RunChainedHooks(logger, cfg, "update", os.Args[1:], os.Stdin)
+	check.WriteOutput(githookkit.ExitPass, "")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bwinhwang/githookkit/cmd/internal/testutil"
+)
+
+// buildHook compiles the update binary into a temporary directory
+func buildHook(t *testing.T) string {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	return testutil.BuildBinary(t, wd, "update")
+}
+
+func TestMainArguments(t *testing.T) {
+	execPath := buildHook(t)
+
+	dir := testutil.InitRepo(t)
+	oldRev := testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	newRev := testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:     "large file is rejected",
+			args:     []string{"refs/heads/master", oldRev, newRev},
+			wantErr:  true,
+			expected: "REJECTED: one or more files exceed maximum size of 4.00 KB",
+		},
+		{
+			name:    "whitelisted project",
+			args:    []string{"-project", "trusted", "refs/heads/master", oldRev, newRev},
+			wantErr: false,
+		},
+		{
+			name:     "missing arguments",
+			args:     []string{"refs/heads/master", oldRev},
+			wantErr:  true,
+			expected: "expected 3 arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := testutil.RunBinary(t, execPath, dir, "projects_whitelist:\n  - trusted\n", []string{"GITHOOK_FILE_SIZE_MAX=4096"}, "", tt.args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("output does not contain %q:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMainGitPush(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook installation is not supported on Windows")
+	}
+
+	execPath := buildHook(t)
+
+	remote := filepath.Join(t.TempDir(), "app.git")
+	if output, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create bare repository: %v\n%s", err, output)
+	}
+	if err := os.Rename(execPath, filepath.Join(remote, "hooks", "update")); err != nil {
+		t.Fatalf("Failed to install hook: %v", err)
+	}
+
+	dir := testutil.InitRepo(t)
+	testutil.CommitFiles(t, dir, map[string]int{"README": 10})
+	testutil.Git(t, dir, "branch", "small")
+	testutil.CommitFiles(t, dir, map[string]int{"large.bin": 8192})
+
+	// The update hook rejects refs individually, the small branch is accepted
+	cmd := exec.Command("git", "push", remote, "small:refs/heads/small", "HEAD:refs/heads/large")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HOME="+t.TempDir(), "GITHOOK_FILE_SIZE_MAX=4096")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("push of a large file should be rejected\n%s", output)
+	}
+
+	refs := testutil.Git(t, remote, "for-each-ref", "--format=%(refname)")
+	if refs != "refs/heads/small" {
+		t.Errorf("remote refs = %q, expected only refs/heads/small\n%s", refs, output)
+	}
+}
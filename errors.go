@@ -0,0 +1,72 @@
+package githookkit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes of the hooks, so that server wrappers and monitoring can tell a rejected push from a broken hook
+const (
+	ExitPass          = 0 // The push is accepted
+	ExitRejected      = 1 // The push violates the policy
+	ExitConfigError   = 2 // The configuration or the invocation of the hook is invalid
+	ExitInternalError = 3 // git or another dependency of the checks failed
+)
+
+// PolicyError is the rejection of a push that violates the policy
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	switch len(e.Violations) {
+	case 0:
+		return "push rejected"
+	case 1:
+		return "push rejected: " + e.Violations[0]
+	default:
+		return fmt.Sprintf("push rejected with %d violations, the first one is: %s", len(e.Violations), e.Violations[0])
+	}
+}
+
+// ConfigError is an invalid configuration or invocation of a hook
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// InternalError is a failure of git or another dependency of the checks, the push itself may be fine
+type InternalError struct {
+	Err error
+}
+
+func (e *InternalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of a hook ending with err, errors of no known type are internal errors
+func ExitCode(err error) int {
+	var policyErr *PolicyError
+	var configErr *ConfigError
+	switch {
+	case err == nil:
+		return ExitPass
+	case errors.As(err, &policyErr):
+		return ExitRejected
+	case errors.As(err, &configErr):
+		return ExitConfigError
+	default:
+		return ExitInternalError
+	}
+}
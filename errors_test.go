@@ -0,0 +1,39 @@
+package githookkit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Pass", nil, ExitPass},
+		{"Policy", &PolicyError{Violations: []string{"file too large"}}, ExitRejected},
+		{"Wrapped policy", fmt.Errorf("refs/heads/master: %w", &PolicyError{}), ExitRejected},
+		{"Config", &ConfigError{Err: errors.New("unknown adapter")}, ExitConfigError},
+		{"Wrapped config", fmt.Errorf("load: %w", &ConfigError{Err: errors.New("invalid yaml")}), ExitConfigError},
+		{"Internal", &InternalError{Err: errors.New("git failed")}, ExitInternalError},
+		{"Untyped", errors.New("git failed"), ExitInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(tt.err); code != tt.expected {
+				t.Errorf("ExitCode(%v) = %d, expected %d", tt.err, code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPolicyError(t *testing.T) {
+	err := &PolicyError{Violations: []string{"file too large", "too many files"}}
+	expected := "push rejected with 2 violations, the first one is: file too large"
+	if err.Error() != expected {
+		t.Errorf("Error() = %q, expected %q", err.Error(), expected)
+	}
+}
@@ -2,17 +2,22 @@ package githookkit
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // File information structure
 type FileInfo struct {
 	Size int64
 	Path string
+	Hash string `json:",omitempty"` // Blob object name
 }
 
 // Format file size to human-readable format
@@ -35,7 +40,7 @@ func FormatSize(size int64) string {
 		return fmt.Sprintf("%d B", size)
 	}
 }
-func CountCommits(newRev, oldRev string) (int, error) {
+func (r Repository) CountCommits(newRev, oldRev string) (int, error) {
 
 	var cmds []string
 	cmds = append(cmds, "git")
@@ -49,7 +54,7 @@ func CountCommits(newRev, oldRev string) (int, error) {
 	} else {
 		cmds = append(cmds, fmt.Sprintf("%s..%s", oldRev, newRev))
 	}
-	cmd := exec.Command(cmds[0], cmds[1:]...)
+	cmd := r.command(cmds[1:]...)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -64,18 +69,414 @@ func CountCommits(newRev, oldRev string) (int, error) {
 	return count, nil
 }
 
-func VerifyCommit(commit string) bool {
-	cmd := exec.Command("git", "rev-parse", "--verify", commit)
+// Commit describes a commit returned by GetCommits
+type Commit struct {
+	Hash           string
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+	AuthorDate     time.Time
+	CommitterDate  time.Time
+	Parents        []string
+	Message        string // Raw message, subject first
+}
+
+// GetCommits returns the commits between oldRev and newRev, newest first, like CountCommits an oldRev of
+// all zeros means the commits of newRev that are not on any ref yet
+func (r Repository) GetCommits(newRev, oldRev string) ([]Commit, error) {
+	// Fields are separated by US and commits by RS, neither shows up in names or messages
+	cmds := append([]string{"git", "log", "--format=%H%x1f%an%x1f%ae%x1f%cn%x1f%ce%x1f%at%x1f%ct%x1f%P%x1f%B%x1e"}, revisionRange(newRev, oldRev)...)
+
+	output, err := r.command(cmds[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute git log: %w", err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(strings.TrimPrefix(record, "\n"), "\x1f")
+		if len(fields) != 9 {
+			continue
+		}
+		authorDate, _ := strconv.ParseInt(fields[5], 10, 64)
+		committerDate, _ := strconv.ParseInt(fields[6], 10, 64)
+		commits = append(commits, Commit{
+			Hash:           fields[0],
+			AuthorName:     fields[1],
+			AuthorEmail:    fields[2],
+			CommitterName:  fields[3],
+			CommitterEmail: fields[4],
+			AuthorDate:     time.Unix(authorDate, 0),
+			CommitterDate:  time.Unix(committerDate, 0),
+			Parents:        strings.Fields(fields[7]),
+			Message:        fields[8],
+		})
+	}
+	return commits, nil
+}
+
+// revisionRange returns the git log arguments selecting the new commits of an update from oldRev to newRev
+func revisionRange(newRev, oldRev string) []string {
+	if oldRev == "0000000000000000000000000000000000000000" {
+		return []string{newRev, "--not", "--all"}
+	}
+	return []string{fmt.Sprintf("%s..%s", oldRev, newRev)}
+}
+
+// Signature is the result of verifying the signature of a commit
+type Signature struct {
+	Status string // %G? of git log: G good, B bad, U untrusted key, X expired signature, Y expired key, R revoked key, E can't be checked, N unsigned
+	Key    string // Key used for the signature, empty when unsigned
+}
+
+// SignatureKeys are the trusted keys signatures are verified against, empty fields use the git and gpg defaults
+type SignatureKeys struct {
+	GPGHome        string // GNUPGHOME holding the trusted GPG public keys
+	AllowedSigners string // SSH allowed signers file, see gpg.ssh.allowedSignersFile
+}
+
+// GetSignatures verifies the signatures of the commits GetCommits returns for the same revisions, keyed by commit hash
+func (r Repository) GetSignatures(newRev, oldRev string, keys SignatureKeys) (map[string]Signature, error) {
+	args := []string{"git"}
+	if keys.AllowedSigners != "" {
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+keys.AllowedSigners)
+	}
+	args = append(args, "log", "--format=%H %G? %GK")
+	args = append(args, revisionRange(newRev, oldRev)...)
+
+	cmd := r.command(args[1:]...)
+	if keys.GPGHome != "" {
+		cmd.Env = append(r.environ(), "GNUPGHOME="+keys.GPGHome)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify commit signatures: %w", err)
+	}
+
+	signatures := map[string]Signature{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		signature := Signature{Status: fields[1]}
+		if len(fields) > 2 {
+			signature.Key = fields[2]
+		}
+		signatures[fields[0]] = signature
+	}
+	return signatures, nil
+}
+
+// GetEmptyCommits returns the non-merge commits GetCommits returns for the same revisions whose tree is the one of their parent
+func (r Repository) GetEmptyCommits(newRev, oldRev string) (map[string]bool, error) {
+	// --raw lists the changed files after each commit, empty commits have none
+	args := append([]string{"log", "--no-merges", "--raw", "--format=%x1e%H"}, revisionRange(newRev, oldRev)...)
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list empty commits: %w", err)
+	}
+
+	empty := map[string]bool{}
+	for _, record := range strings.Split(string(output), "\x1e")[1:] {
+		hash, changes, _ := strings.Cut(strings.TrimSpace(record), "\n")
+		if strings.TrimSpace(changes) == "" {
+			empty[hash] = true
+		}
+	}
+	return empty, nil
+}
+
+// GetChangedPaths returns the paths added, modified or deleted by the non-merge commits GetCommits returns for the same revisions
+func (r Repository) GetChangedPaths(newRev, oldRev string) ([]string, error) {
+	return r.logPaths(newRev, oldRev)
+}
+
+// GetAddedPaths returns the paths added by the non-merge commits GetCommits returns for the same revisions
+func (r Repository) GetAddedPaths(newRev, oldRev string) ([]string, error) {
+	return r.logPaths(newRev, oldRev, "--diff-filter=A")
+}
+
+// logPaths returns the distinct paths git log --name-only lists for the new commits of an update
+func (r Repository) logPaths(newRev, oldRev string, options ...string) ([]string, error) {
+	// -z keeps paths with special characters unquoted
+	args := append([]string{"log", "-z", "--no-renames", "--name-only", "--format="}, options...)
+	args = append(args, revisionRange(newRev, oldRev)...)
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed paths: %w", err)
+	}
+
+	var paths []string
+	seen := map[string]bool{}
+	for _, path := range strings.Split(string(output), "\x00") {
+		path = strings.TrimPrefix(path, "\n")
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// File modes of tree entries
+const (
+	ModeFile       = "100644"
+	ModeExecutable = "100755"
+	ModeSymlink    = "120000"
+	ModeGitlink    = "160000" // Submodule commit
+	ModeNone       = "000000" // Old mode of added entries, new mode of deleted entries
+)
+
+// Change is a tree entry added, modified or deleted by a commit
+type Change struct {
+	Commit  string
+	Status  string // A, M, D or T (type change)
+	OldMode string
+	NewMode string
+	OldHash string
+	NewHash string
+	Path    string
+}
+
+// GetChanges returns the changes of the non-merge commits GetCommits returns for the same revisions, in git log order
+func (r Repository) GetChanges(newRev, oldRev string) ([]Change, error) {
+	return r.logChanges(revisionRange(newRev, oldRev)...)
+}
+
+// GetRefsChanges returns the changes of the non-merge commits reachable from refs, all refs when none is given,
+// newest first
+func (r Repository) GetRefsChanges(refs ...string) ([]Change, error) {
+	if len(refs) == 0 {
+		return r.logChanges("--all")
+	}
+	return r.logChanges(refs...)
+}
+
+// logChanges returns the changes of the non-merge commits git log lists for the revisions, in git log order
+func (r Repository) logChanges(revisions ...string) ([]Change, error) {
+	// -z keeps paths with special characters unquoted: "\x1e<commit>\x00\n:<old mode> <new mode> <old hash> <new hash> <status>\x00<path>\x00..."
+	args := append([]string{"log", "-z", "--no-merges", "--no-renames", "--raw", "--no-abbrev", "--format=%x1e%H"}, revisions...)
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	var changes []Change
+	for _, record := range strings.Split(string(output), "\x1e")[1:] {
+		commit, raw, _ := strings.Cut(record, "\x00")
+		fields := strings.Split(strings.TrimPrefix(raw, "\n"), "\x00")
+		for i := 0; i+1 < len(fields); i += 2 {
+			meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+			if len(meta) != 5 {
+				return nil, fmt.Errorf("failed to parse change %q of %s", fields[i], commit)
+			}
+			changes = append(changes, Change{Commit: commit, OldMode: meta[0], NewMode: meta[1], OldHash: meta[2], NewHash: meta[3], Status: meta[4], Path: fields[i+1]})
+		}
+	}
+	return changes, nil
+}
+
+// ListTree returns the paths of the files in the tree of rev
+func (r Repository) ListTree(rev string) ([]string, error) {
+	output, err := r.command("ls-tree", "-r", "-z", "--name-only", rev).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree of %s: %w", rev, err)
+	}
+	return strings.FieldsFunc(string(output), func(r rune) bool { return r == 0 }), nil
+}
+
+// TreeEntry is a file in a tree
+type TreeEntry struct {
+	Mode string
+	Hash string
+	Path string
+}
+
+// ListTreeEntries returns the files in the tree of rev with their modes and object hashes
+func (r Repository) ListTreeEntries(rev string) ([]TreeEntry, error) {
+	// -z keeps paths with special characters unquoted: "<mode> <type> <hash>\t<path>\x00"
+	output, err := r.command("ls-tree", "-r", "-z", rev).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree of %s: %w", rev, err)
+	}
+
+	var entries []TreeEntry
+	for _, line := range strings.FieldsFunc(string(output), func(r rune) bool { return r == 0 }) {
+		meta, path, found := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !found || len(fields) != 3 {
+			return nil, fmt.Errorf("failed to parse tree entry %q of %s", line, rev)
+		}
+		entries = append(entries, TreeEntry{Mode: fields[0], Hash: fields[2], Path: path})
+	}
+	return entries, nil
+}
+
+// quarantineEnv are the variables git receive-pack sets so that hooks see the pushed objects before they are
+// moved into the object store of the repository
+var quarantineEnv = []string{"GIT_QUARANTINE_PATH", "GIT_OBJECT_DIRECTORY", "GIT_ALTERNATE_OBJECT_DIRECTORIES"}
+
+// ExistingObjects returns which of the objects the repository already had before the push, by looking them up
+// in its object store without the quarantine of the push. Outside of a quarantine the pushed objects can't be told
+// apart from the existing ones and nil is returned.
+func (r Repository) ExistingObjects(objects []string) (map[string]bool, error) {
+	if quarantine, _ := r.LookupEnv("GIT_QUARANTINE_PATH"); quarantine == "" || len(objects) == 0 {
+		return nil, nil
+	}
+
+	cmd := r.command("cat-file", "--batch-check=%(objectname) %(objecttype)")
+	cmd.Env = r.environ(quarantineEnv...)
+	cmd.Stdin = strings.NewReader(strings.Join(objects, "\n") + "\n")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing objects: %w", err)
+	}
+
+	// Missing objects are reported as "<object> missing"
+	existing := map[string]bool{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if object, objectType, found := strings.Cut(line, " "); found && objectType != "missing" {
+			existing[object] = true
+		}
+	}
+	return existing, nil
+}
+
+func (r Repository) VerifyCommit(commit string) bool {
+	cmd := r.command("rev-parse", "--verify", commit)
 	if err := cmd.Run(); err != nil {
 		return false
 	}
 	return true
 }
 
+// IsFastForward reports whether newRev contains oldRev, i.e. updating a ref from oldRev to newRev is not a forced update
+func (r Repository) IsFastForward(oldRev, newRev string) (bool, error) {
+	err := r.command("merge-base", "--is-ancestor", oldRev, newRev).Run()
+	if err == nil {
+		return true, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to execute git merge-base: %w", err)
+}
+
+// GetObjectType returns the type of an object: commit, tree, blob or tag
+func (r Repository) GetObjectType(object string) (string, error) {
+	output, err := r.command("cat-file", "-t", object).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get type of %s: %w", object, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IsSignedTag reports whether the annotated tag object carries a PGP, SSH or X.509 signature
+func (r Repository) IsSignedTag(tag string) (bool, error) {
+	output, err := r.command("cat-file", "tag", tag).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to read tag %s: %w", tag, err)
+	}
+	for _, marker := range []string{"-----BEGIN PGP SIGNATURE-----", "-----BEGIN SSH SIGNATURE-----", "-----BEGIN SIGNED MESSAGE-----"} {
+		if strings.Contains(string(output), marker) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReadFile returns the content of the file at path in rev, found is false when rev has no such file
+func (r Repository) ReadFile(rev, path string) (content []byte, found bool, err error) {
+	object := rev + ":" + path
+	if r.command("cat-file", "-e", object).Run() != nil {
+		if !r.VerifyCommit(rev) {
+			return nil, false, fmt.Errorf("invalid commit hash: %s", rev)
+		}
+		return nil, false, nil
+	}
+
+	content, err = r.command("cat-file", "blob", object).Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", object, err)
+	}
+	return content, true, nil
+}
+
+// Submodule is an entry of a .gitmodules file
+type Submodule struct {
+	Name string
+	Path string
+	URL  string
+}
+
+// GetSubmodules returns the submodules declared in the .gitmodules file of rev, none when rev has no such file
+func (r Repository) GetSubmodules(rev string) ([]Submodule, error) {
+	content, found, err := r.ReadFile(rev, ".gitmodules")
+	if err != nil || !found || len(content) == 0 {
+		return nil, err
+	}
+
+	// git config parses the file like git submodule does, -z prints "<key>\n<value>\x00" for each entry
+	output, err := r.command("config", "-z", "--blob", rev+":.gitmodules", "--list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .gitmodules of %s: %w", rev, err)
+	}
+
+	var submodules []Submodule
+	index := map[string]int{}
+	for _, entry := range strings.Split(string(output), "\x00") {
+		key, value, _ := strings.Cut(entry, "\n")
+		// The name between submodule. and the variable may contain dots
+		section, rest, _ := strings.Cut(key, ".")
+		dot := strings.LastIndex(rest, ".")
+		if section != "submodule" || dot < 0 {
+			continue
+		}
+		name, variable := rest[:dot], rest[dot+1:]
+
+		i, exists := index[name]
+		if !exists {
+			i = len(submodules)
+			index[name] = i
+			submodules = append(submodules, Submodule{Name: name})
+		}
+		switch variable {
+		case "path":
+			submodules[i].Path = value
+		case "url":
+			submodules[i].URL = value
+		}
+	}
+	return submodules, nil
+}
+
+// GetGitDir returns the absolute path of the git directory of the current repository
+func (r Repository) GetGitDir() (string, error) {
+	output, err := r.command("rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git directory: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRefs returns the names of the existing refs, limited to the given for-each-ref patterns when any are given
+func (r Repository) GetRefs(patterns ...string) ([]string, error) {
+	args := append([]string{"for-each-ref", "--format=%(refname)"}, patterns...)
+	output, err := r.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
 // GetObjectList returns a channel of object hashes in the specified commit range
-func GetSingleCommitObjectList(commit string, includePath bool) (<-chan string, error) {
+func (r Repository) GetSingleCommitObjectList(commit string, includePath bool) (<-chan string, error) {
 	// First verify if the commit is valid
-	if !VerifyCommit(commit) {
+	if !r.VerifyCommit(commit) {
 		return nil, fmt.Errorf("invalid commit hash: %s", commit)
 	}
 
@@ -86,8 +487,7 @@ func GetSingleCommitObjectList(commit string, includePath bool) (<-chan string,
 	cmds = append(cmds, "--all")
 	cmds = append(cmds, commit)
 
-	fmt.Printf("%s\n", strings.Join(cmds, " "))
-	cmd := exec.Command(cmds[0], cmds[1:]...)
+	cmd := r.command(cmds[1:]...)
 	output, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
@@ -126,12 +526,12 @@ func GetSingleCommitObjectList(commit string, includePath bool) (<-chan string,
 }
 
 // GetObjectList returns a channel of object hashes in the specified commit range
-func GetSpanObjectList(startCommit, endCommit string, includePath bool) (<-chan string, error) {
+func (r Repository) GetSpanObjectList(startCommit, endCommit string, includePath bool) (<-chan string, error) {
 	// Verify if both commits are valid
-	if !VerifyCommit(startCommit) {
+	if !r.VerifyCommit(startCommit) {
 		return nil, fmt.Errorf("invalid start commit hash: %s", startCommit)
 	}
-	if !VerifyCommit(endCommit) {
+	if !r.VerifyCommit(endCommit) {
 		return nil, fmt.Errorf("invalid end commit hash: %s", endCommit)
 	}
 
@@ -141,8 +541,7 @@ func GetSpanObjectList(startCommit, endCommit string, includePath bool) (<-chan
 	cmds = append(cmds, "--objects")
 	cmds = append(cmds, fmt.Sprintf("%s..%s", startCommit, endCommit))
 
-	fmt.Printf("%s\n", strings.Join(cmds, " "))
-	cmd := exec.Command(cmds[0], cmds[1:]...)
+	cmd := r.command(cmds[1:]...)
 	output, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
@@ -180,9 +579,59 @@ func GetSpanObjectList(startCommit, endCommit string, includePath bool) (<-chan
 	return objectChan, nil
 }
 
+// GetRefsObjectList returns a channel of the objects reachable from the given refs, all refs when none are given
+func (r Repository) GetRefsObjectList(includePath bool, refs ...string) (<-chan string, error) {
+	for _, ref := range refs {
+		if !r.VerifyCommit(ref) {
+			return nil, fmt.Errorf("invalid ref: %s", ref)
+		}
+	}
+
+	cmds := []string{"git", "rev-list", "--objects"}
+	if len(refs) == 0 {
+		cmds = append(cmds, "--all")
+	}
+	cmds = append(cmds, refs...)
+
+	cmd := r.command(cmds[1:]...)
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	objectChan := make(chan string)
+
+	if err := cmd.Start(); err != nil {
+		output.Close()
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	go func() {
+		defer close(objectChan)
+		defer output.Close()
+
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if includePath {
+				objectChan <- line
+			} else {
+				parts := strings.Fields(line)
+				if len(parts) > 0 {
+					objectChan <- parts[0]
+				}
+			}
+		}
+
+		cmd.Wait()
+	}()
+
+	return objectChan, nil
+}
+
 // GetObjectDetails processes objects in batches and returns a channel of FileInfo
 // sizeFilter is an optional function that returns true if the object should be included based on its size
-func GetObjectDetails(objectChan <-chan string, sizeFilter func(int64) bool) (<-chan FileInfo, error) {
+func (r Repository) GetObjectDetails(objectChan <-chan string, sizeFilter func(int64) bool) (<-chan FileInfo, error) {
 	const batchSize = 1000
 	resultChan := make(chan FileInfo)
 
@@ -194,14 +643,14 @@ func GetObjectDetails(objectChan <-chan string, sizeFilter func(int64) bool) (<-
 			batch = append(batch, line)
 
 			if len(batch) >= batchSize {
-				processObjectBatch(batch, resultChan, sizeFilter)
+				r.processObjectBatch(batch, resultChan, sizeFilter)
 				batch = nil
 			}
 		}
 
 		// Process remaining objects
 		if len(batch) > 0 {
-			processObjectBatch(batch, resultChan, sizeFilter)
+			r.processObjectBatch(batch, resultChan, sizeFilter)
 		}
 	}()
 
@@ -210,30 +659,29 @@ func GetObjectDetails(objectChan <-chan string, sizeFilter func(int64) bool) (<-
 
 // Helper function to process a batch of objects
 // sizeFilter is an optional function that returns true if the object should be included based on its size
-func processObjectBatch(objects []string, resultChan chan<- FileInfo, sizeFilter func(int64) bool) {
+func (r Repository) processObjectBatch(objects []string, resultChan chan<- FileInfo, sizeFilter func(int64) bool) {
 	if len(objects) == 0 {
 		return
 	}
 
-	input := strings.Join(objects, "\n")
-	cmd := exec.Command("git", "cat-file", "--batch-check=%(objectname) %(objectsize) %(objecttype) %(rest)")
-	cmd.Stdin = strings.NewReader(input)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return
+	store := r.Objects
+	if store == nil {
+		store = NewObjectStore(r)
+		defer store.Close()
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-
 	re := regexp.MustCompile(`^([a-f0-9]+) (\d+) (blob|tree)(?: (.+))?$`)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		//fmt.Printf("Debug: Processing line: %s\n", line)
+	// The results are sent once the store is free again, the reader of resultChan may use it meanwhile
+	var results []FileInfo
+	err := store.query(&store.batchCheck, []string{"--batch-check=" + objectDetailsFormat}, objects, func(_ string, output *bufio.Reader) error {
+		line, err := output.ReadString('\n')
+		if err != nil {
+			return err
+		}
 
-		matches := re.FindStringSubmatch(line)
+		matches := re.FindStringSubmatch(strings.TrimSuffix(line, "\n"))
 		if len(matches) >= 4 {
-			//hash := matches[1]
 			size, _ := strconv.ParseInt(matches[2], 10, 64)
 			objType := matches[3]
 			var path string
@@ -241,19 +689,87 @@ func processObjectBatch(objects []string, resultChan chan<- FileInfo, sizeFilter
 				path = matches[4]
 			}
 
-			//fmt.Printf("Debug: Parsed: size=%d, type=%s, path=%s\n", size, objType, path)
-
 			// 应用大小过滤条件（如果提供）
 			if objType == "blob" && path != "" && (sizeFilter == nil || sizeFilter(size)) {
-				resultChan <- FileInfo{
+				results = append(results, FileInfo{
 					Size: size,
 					Path: path,
-				}
+					Hash: matches[1],
+				})
 			}
 		}
+		return nil
+	})
+	for _, result := range results {
+		resultChan <- result
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Debug: Error scanning output: %v\n", err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading git cat-file output: %v\n", err)
 	}
 }
+
+// GroupBlobs returns the blobs of files in order and the files of each blob, the same blob can be added at several
+// paths and is read once. Files without blob and the files skip returns true for are left out, skip may be nil.
+func GroupBlobs(files []FileInfo, skip func(FileInfo) bool) ([]string, map[string][]FileInfo) {
+	var objects []string
+	paths := map[string][]FileInfo{}
+	for _, file := range files {
+		if file.Hash == "" || (skip != nil && skip(file)) {
+			continue
+		}
+		if _, exists := paths[file.Hash]; !exists {
+			objects = append(objects, file.Hash)
+		}
+		paths[file.Hash] = append(paths[file.Hash], file)
+	}
+	return objects, paths
+}
+
+// ReadBlobs streams the content of objects through the git cat-file --batch process of r.Objects, or one started for
+// the call, calling fn for each object in order. Only the first limit bytes of an object are passed to fn, 0 means
+// the whole content. An error returned by fn stops the reading and is returned. fn must not read objects itself.
+func (r Repository) ReadBlobs(objects []string, limit int64, fn func(object string, content []byte) error) error {
+	return r.ReadBlobSamples(objects, func(string) int64 { return limit }, fn)
+}
+
+// ReadBlobSamples is ReadBlobs with a limit per object, limit returns the number of bytes of object passed to fn
+func (r Repository) ReadBlobSamples(objects []string, limit func(object string) int64, fn func(object string, content []byte) error) error {
+	store := r.Objects
+	if store == nil {
+		store = NewObjectStore(r)
+		defer store.Close()
+	}
+
+	return store.query(&store.batch, []string{"--batch"}, objects, func(object string, reader *bufio.Reader) error {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read object %s: %w", object, err)
+		}
+
+		// "<object> <type> <size>" or "<object> missing"
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return fmt.Errorf("failed to read object %s: %s", object, strings.TrimSpace(header))
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse size of object %s: %w", object, err)
+		}
+
+		readSize := size
+		if limit := limit(object); limit > 0 && limit < size {
+			readSize = limit
+		}
+		content := make([]byte, readSize)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return fmt.Errorf("failed to read object %s: %w", object, err)
+		}
+		// Skip the rest of the content and the newline following it
+		if _, err := io.CopyN(io.Discard, reader, size-readSize+1); err != nil {
+			return fmt.Errorf("failed to read object %s: %w", object, err)
+		}
+
+		return fn(object, content)
+	})
+}
@@ -0,0 +1,190 @@
+package githookkit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Repository runs git for one repository. The zero value runs git in the working directory with the environment
+// of the process, like the hooks do; a server checking the pushes of several repositories sets Dir and the variables
+// git receive-pack gave the hook of each push.
+type Repository struct {
+	Dir     string       // Directory git runs in, empty = the working directory
+	Env     []string     // Variables set for git over the environment of the process, e.g. the quarantine of a push
+	Objects *ObjectStore // Running cat-file processes reading the objects, nil = a process for each call
+}
+
+// command returns a git command run in the repository
+func (r Repository) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	if len(r.Env) > 0 {
+		cmd.Env = r.environ()
+	}
+	return cmd
+}
+
+// environ returns the environment of git without the variables of drop
+func (r Repository) environ(drop ...string) []string {
+	env := []string{} // nil would pass on the whole environment
+	for _, variable := range append(os.Environ(), r.Env...) {
+		name, _, _ := strings.Cut(variable, "=")
+		if !slices.Contains(drop, name) {
+			env = append(env, variable)
+		}
+	}
+	return env
+}
+
+// LookupEnv returns the value of a variable in the environment of git, Env overrides the environment of the process
+func (r Repository) LookupEnv(name string) (string, bool) {
+	for i := len(r.Env) - 1; i >= 0; i-- {
+		if value, found := strings.CutPrefix(r.Env[i], name+"="); found {
+			return value, true
+		}
+	}
+	return os.LookupEnv(name)
+}
+
+// ObjectStore keeps git cat-file processes running to read the objects of a repository, so that a long-running
+// server doesn't start them for every check. git looks for new packs when an object is missing, objects pushed
+// after the processes started are found as well. It is safe for concurrent use, the calls are served in turn.
+type ObjectStore struct {
+	repo Repository
+
+	mu         sync.Mutex
+	batch      *catFile // git cat-file --batch
+	batchCheck *catFile // git cat-file --batch-check=<objectDetailsFormat>
+}
+
+// objectDetailsFormat is the --batch-check format GetObjectDetails parses
+const objectDetailsFormat = "%(objectname) %(objectsize) %(objecttype) %(rest)"
+
+// NewObjectStore returns an object store for repo, the processes start on first use
+func NewObjectStore(repo Repository) *ObjectStore {
+	repo.Objects = nil
+	return &ObjectStore{repo: repo}
+}
+
+// Close stops the processes, a later call starts them again
+func (s *ObjectStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batch.close()
+	s.batchCheck.close()
+	s.batch, s.batchCheck = nil, nil
+}
+
+// catFile is a running git cat-file process
+type catFile struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// close lets the process finish, a nil process is ignored
+func (c *catFile) close() {
+	if c != nil {
+		c.stdin.Close()
+		c.cmd.Wait()
+	}
+}
+
+// kill stops the process without waiting for pending answers
+func (c *catFile) kill() {
+	c.cmd.Process.Kill()
+	c.stdin.Close()
+	c.cmd.Wait()
+}
+
+// query writes lines to the cat-file process in *process, started with args when there is none, and calls read for
+// the answer to each line in order. The process is stopped when the query fails, its output is out of step after that.
+func (s *ObjectStore) query(process **catFile, args []string, lines []string, read func(line string, output *bufio.Reader) error) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if *process == nil {
+		cmd := s.repo.command(append([]string{"cat-file"}, args...)...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("failed to create stdin pipe: %w", err)
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("failed to create stdout pipe: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to start git cat-file: %w", err)
+		}
+		*process = &catFile{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}
+	}
+	c := *process
+
+	// The lines are written while the answers are read, git would block on a full pipe otherwise
+	written := make(chan error, 1)
+	go func() {
+		writer := bufio.NewWriter(c.stdin)
+		for _, line := range lines {
+			writer.WriteString(line)
+			writer.WriteByte('\n')
+		}
+		written <- writer.Flush()
+	}()
+
+	var err error
+	for _, line := range lines {
+		if err = read(line, c.stdout); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		c.kill()
+		*process = nil
+	}
+	if writeErr := <-written; err == nil && writeErr != nil {
+		err = fmt.Errorf("failed to write to git cat-file: %w", writeErr)
+		c.kill()
+		*process = nil
+	}
+	return err
+}
+
+// CountCommits is Repository.CountCommits in the working directory
+func CountCommits(newRev, oldRev string) (int, error) {
+	return Repository{}.CountCommits(newRev, oldRev)
+}
+
+// VerifyCommit is Repository.VerifyCommit in the working directory
+func VerifyCommit(commit string) bool {
+	return Repository{}.VerifyCommit(commit)
+}
+
+// GetSingleCommitObjectList is Repository.GetSingleCommitObjectList in the working directory
+func GetSingleCommitObjectList(commit string, includePath bool) (<-chan string, error) {
+	return Repository{}.GetSingleCommitObjectList(commit, includePath)
+}
+
+// GetSpanObjectList is Repository.GetSpanObjectList in the working directory
+func GetSpanObjectList(startCommit, endCommit string, includePath bool) (<-chan string, error) {
+	return Repository{}.GetSpanObjectList(startCommit, endCommit, includePath)
+}
+
+// GetObjectDetails is Repository.GetObjectDetails in the working directory
+func GetObjectDetails(objectChan <-chan string, sizeFilter func(int64) bool) (<-chan FileInfo, error) {
+	return Repository{}.GetObjectDetails(objectChan, sizeFilter)
+}
+
+// processObjectBatch is Repository.processObjectBatch in the working directory
+func processObjectBatch(objects []string, resultChan chan<- FileInfo, sizeFilter func(int64) bool) {
+	Repository{}.processObjectBatch(objects, resultChan, sizeFilter)
+}
@@ -0,0 +1,18 @@
+package githookkit
+
+// Severities of violations
+const (
+	SeverityError   = "error"   // The violation rejects the push
+	SeverityWarning = "warning" // The violation is reported, the push is accepted
+)
+
+// Violation is a breach of a rule by a push, at a file or a commit when the rule is about one of them
+type Violation struct {
+	RuleID   string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Message  string `json:"message"`
+	Bypassed bool   `json:"bypassed,omitempty"` // An override granted for the push accepted the violation
+}